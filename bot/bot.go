@@ -0,0 +1,219 @@
+// bot/bot.go
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+	"arbitrage-bot/services"
+)
+
+// ErrShutdownIncomplete is returned by Run when the shutdown grace period
+// expired with an execution still open, so the wallet may be holding an
+// intermediate token
+var ErrShutdownIncomplete = errors.New("shutdown grace period expired with an execution open")
+
+// Bot is the persistent arbitrage scanner: the services it trades through and
+// the loop that drives them. Build one with New, optionally adjust its
+// services (e.g. ArbitrageService.ConfirmTrade), then call Run.
+type Bot struct {
+	Config           *config.Config
+	Client           *services.EthClient
+	TokenService     *services.TokenService
+	RouterService    *services.RouterService
+	ArbitrageService *services.ArbitrageService
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// New initializes the contract ABIs, connects to the configured RPCs and
+// builds the services. cfg must already be validated. Close releases what it
+// opened.
+func New(cfg *config.Config) (*Bot, error) {
+	if err := contracts.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize contract ABIs: %v", err)
+	}
+
+	client, err := services.NewEthClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to BSC network: %v", err)
+	}
+
+	tokenService := services.NewTokenService(client)
+	routerService := services.NewRouterService(client, tokenService, cfg)
+	arbitrageService := services.NewArbitrageService(client, tokenService, routerService, cfg)
+
+	if cfg.OpportunityLog != "" {
+		opportunityLog, err := services.OpenOpportunityLog(cfg.OpportunityLog)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+		arbitrageService.OpportunityLog = opportunityLog
+	}
+
+	return &Bot{
+		Config:           cfg,
+		Client:           client,
+		TokenService:     tokenService,
+		RouterService:    routerService,
+		ArbitrageService: arbitrageService,
+		stop:             make(chan struct{}),
+	}, nil
+}
+
+// VerifyPairs checks every configured pool against the factories, retrying
+// across RPCs on connection errors
+func (b *Bot) VerifyPairs() error {
+	return b.Client.WithRetry("VerifyPairs", func() error {
+		return b.ArbitrageService.VerifyAndUpdatePairs()
+	})
+}
+
+// Run scans until ctx is done or Stop is called, then waits up to
+// SHUTDOWN_GRACE_PERIOD for an execution in flight to finish. It returns
+// ErrShutdownIncomplete if one was still open when the grace period expired.
+func (b *Bot) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-b.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Decide before the first scan whether the wallet can execute at all
+	b.checkWallet(ctx)
+
+	// RPC health monitoring, wallet checks and /healthz run alongside the loop
+	go b.monitorRPCHealth(ctx)
+	go b.monitorWallet(ctx)
+	if healthServer := b.startHealthServer(); healthServer != nil {
+		defer healthServer.Close()
+	}
+
+	stats := b.runLoop(ctx)
+
+	slog.Info("🛑 Shutdown signal received...")
+
+	var err error
+	slog.Info("⏳ Waiting for in-flight executions to finish...", "grace_period", b.Config.ShutdownGracePeriod)
+	if b.ArbitrageService.Shutdown(b.Config.ShutdownGracePeriod) {
+		slog.Info("✅ No executions in progress")
+	} else {
+		slog.Error("🚨 GRACE PERIOD EXPIRED WITH A TRADE STILL OPEN - wallet may be holding an intermediate token, check balances and unwind manually!",
+			"grace_period", b.Config.ShutdownGracePeriod)
+		err = ErrShutdownIncomplete
+	}
+
+	b.printFinalStats(stats)
+	return err
+}
+
+// Stop asks a running Run to shut down. It is safe to call more than once.
+func (b *Bot) Stop() {
+	b.stopOnce.Do(func() { close(b.stop) })
+}
+
+// Close flushes the opportunity log and closes the RPC connections
+func (b *Bot) Close() {
+	if b.ArbitrageService.OpportunityLog != nil {
+		b.ArbitrageService.OpportunityLog.Close()
+	}
+	b.Client.Close()
+}
+
+// monitorRPCHealth switches RPC whenever the periodic health check fails
+func (b *Bot) monitorRPCHealth(ctx context.Context) {
+	ticker := time.NewTicker(b.Config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	slog.Info("🔍 Starting RPC health monitoring...", "interval", b.Config.HealthCheckInterval)
+
+	for {
+		select {
+		case <-ticker.C:
+			if !b.Client.HealthCheck() {
+				slog.Warn("⚠️ RPC health check failed, attempting recovery...")
+				if err := b.Client.SwitchRPC(); err != nil {
+					slog.Error("❌ RPC recovery failed", "err", err)
+				} else {
+					slog.Info("✅ RPC recovery successful")
+				}
+			}
+
+		case <-ctx.Done():
+			slog.Info("🛑 Stopping RPC health monitoring")
+			return
+		}
+	}
+}
+
+// checkWallet switches the bot to monitor-only while the wallet can't trade
+func (b *Bot) checkWallet(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, b.Config.HealthCheckTimeout)
+	defer cancel()
+	if err := b.ArbitrageService.CheckTradeable(ctx); err != nil {
+		slog.Warn("⚠️ Wallet tradeability check failed", "err", err)
+	}
+}
+
+// monitorWallet rechecks the wallet every WALLET_CHECK_INTERVAL_SECONDS, so an
+// unfunded wallet only scans and reports until it is funded
+func (b *Bot) monitorWallet(ctx context.Context) {
+	if b.Config.WalletCheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(b.Config.WalletCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.checkWallet(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// startHealthServer serves /healthz on HEALTH_ADDR, reporting 503 when the RPC
+// is down, the node lags more than MAX_BLOCK_LAG_SECONDS, or the wallet is
+// below the gas reserve. It returns nil when no address is configured.
+func (b *Bot) startHealthServer() *http.Server {
+	cfg, client := b.Config, b.Client
+	if cfg.HealthAddr == "" {
+		return nil
+	}
+
+	check := func(ctx context.Context) *services.HealthReport {
+		ctx, cancel := context.WithTimeout(ctx, cfg.HealthCheckTimeout)
+		defer cancel()
+		report := services.CheckHealth(ctx, client, client.ActiveAddress(), cfg.MaxBlockLag, cfg.GasReserveBNB, time.Now())
+		report.MissedOpportunities = b.ArbitrageService.TradeSummary().MissedOpportunities
+		return report
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", services.HealthHandler(check))
+	server := &http.Server{Addr: cfg.HealthAddr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		slog.Info("🩺 Health endpoint listening", "addr", cfg.HealthAddr, "path", "/healthz")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("❌ Health endpoint stopped", "err", err)
+		}
+	}()
+	return server
+}
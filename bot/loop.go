@@ -0,0 +1,509 @@
+// bot/loop.go
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/models"
+	"arbitrage-bot/services"
+	"arbitrage-bot/utils"
+)
+
+// Bounds every scan interval is kept within, jitter included
+const (
+	minScanInterval = 10 * time.Second  // Minimum 10 seconds
+	maxScanInterval = 120 * time.Second // FIXED: Maximum 2 minutes (not hours!)
+)
+
+// loopStats are the scan loop's running totals
+type loopStats struct {
+	totalScans      int
+	successfulScans int
+	errorCount      int
+	startTime       time.Time
+
+	// Every failover, including those made by retries and the health
+	// monitor, not just the ones the loop forces
+	rpcSwitches atomic.Int64
+}
+
+// runLoop scans on an adaptive interval until ctx is done and returns the
+// loop's totals. It never stops on scan errors, only slows down.
+func (b *Bot) runLoop(ctx context.Context) *loopStats {
+	cfg, client, arbitrageService := b.Config, b.Client, b.ArbitrageService
+
+	// FIXED: Start with reasonable base interval dan cap maksimum
+	baseScanInterval := time.Duration(cfg.CooldownPeriod) * time.Second
+	if baseScanInterval < 15*time.Second {
+		baseScanInterval = 15 * time.Second // Minimum 15 detik
+	}
+	if baseScanInterval > 60*time.Second {
+		baseScanInterval = 60 * time.Second // Maximum 1 minute base
+	}
+
+	// Statistics
+	stats := &loopStats{startTime: time.Now()}
+	var consecutiveErrors int          // Slows the scan interval
+	var consecutiveConnErrors int      // Triggers RPC health checks and switches
+	var consecutiveNoOpportunities int // FIXED: Track this separately
+
+	client.OnRPCSwitch(func(from, to string) {
+		stats.rpcSwitches.Add(1)
+	})
+
+	// Large pending swaps trigger targeted scans between interval scans
+	triggers := b.startMempoolWatcher(ctx)
+	b.startReserveWatcher(ctx)
+	refresh := b.startPairRefresh(ctx)
+
+	slog.Info("🔄 Starting persistent monitoring until Ctrl+C",
+		"interval", baseScanInterval, "min_interval", 15*time.Second, "max_interval", maxScanInterval)
+
+	// Run initial scan
+	slog.Info("🔍 Running initial enhanced scan...")
+	if foundCount, err := b.performScan(ctx, "initial"); err != nil {
+		category := services.ClassifyError(err)
+		if category == services.ErrorRevert {
+			slog.Debug("Initial scan hit a contract revert", "err", err)
+		} else {
+			slog.Error("❌ Initial scan error", "category", category, "err", err)
+			stats.errorCount++
+			consecutiveErrors++
+			if category == services.ErrorConnection {
+				consecutiveConnErrors++
+			}
+		}
+	} else {
+		stats.successfulScans++
+		consecutiveErrors = 0
+		if foundCount == 0 {
+			consecutiveNoOpportunities++
+		}
+	}
+	stats.totalScans++
+
+	// FIXED: Main loop yang tidak akan berhenti
+	for {
+		// CRITICAL: Selalu sleep dulu sebelum scan berikutnya
+		if !b.waitForNextScan(ctx, jitteredInterval(cfg, baseScanInterval), triggers, refresh) {
+			slog.Info("🛑 Received stop signal, exiting scan loop...")
+			return stats
+		}
+
+		// Log RPC status periodically
+		if stats.totalScans%10 == 0 {
+			client.LogConnectionStatus()
+		}
+
+		// Determine scan type based on current time
+		scanType := arbitrageService.ScanPeriod()
+
+		// FIXED: Perform scan dengan error recovery yang proper
+		slog.Info("🔍 Scan", "scan", stats.totalScans+1, "period", scanType, "interval", baseScanInterval)
+
+		foundCount, err := b.performScan(ctx, scanType)
+		category := services.ClassifyError(err)
+		if ctx.Err() != nil {
+			// Stopped mid-scan; the wait above returns straight away
+			continue
+		} else if err != nil && category == services.ErrorRevert {
+			// A revert is a market condition (e.g. drained pool), not a
+			// failing node: don't penalize the interval or RPC health
+			slog.Debug("Scan hit a contract revert", "scan", stats.totalScans+1, "err", err)
+		} else if err != nil {
+			slog.Error("❌ Scan error", "scan", stats.totalScans+1, "category", category, "err", err)
+			stats.errorCount++
+			consecutiveErrors++
+			consecutiveNoOpportunities = 0 // Reset this counter
+
+			// Enhanced error recovery
+			if category == services.ErrorConnection {
+				consecutiveConnErrors++
+				slog.Warn("🔄 RPC connection error", "in_a_row", consecutiveConnErrors)
+			} else {
+				consecutiveConnErrors = 0
+			}
+
+			// Only connection errors point at the RPC, so only they
+			// trigger a health check and forced switch
+			if consecutiveConnErrors >= 3 {
+				slog.Warn("⚠️ Multiple consecutive connection errors, checking RPC health...",
+					"in_a_row", consecutiveConnErrors)
+				if !client.HealthCheck() {
+					slog.Warn("🔄 RPC unhealthy, forcing switch...")
+					if switchErr := client.SwitchRPC(); switchErr != nil {
+						slog.Error("❌ Manual RPC switch failed", "err", switchErr)
+					} else {
+						slog.Info("✅ Manual RPC switch successful")
+						consecutiveErrors = 0
+						consecutiveConnErrors = 0
+					}
+				}
+			}
+
+			// FIXED: Jangan berhenti meskipun ada error, cuma tambah delay
+			if consecutiveErrors >= 5 {
+				slog.Warn("⚠️ Too many errors, adding extra delay...", "errors", consecutiveErrors,
+					"delay", time.Duration(consecutiveErrors)*10*time.Second)
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Duration(consecutiveErrors) * 10 * time.Second):
+				}
+			}
+		} else {
+			slog.Info("✅ Scan completed successfully", "scan", stats.totalScans+1)
+			stats.successfulScans++
+			consecutiveErrors = 0
+			consecutiveConnErrors = 0
+
+			// FIXED: Track consecutive "no opportunities" separately
+			// This is normal and shouldn't increase error count
+			if foundCount == 0 {
+				consecutiveNoOpportunities++
+			} else {
+				consecutiveNoOpportunities = 0
+			}
+		}
+		stats.totalScans++
+
+		// Print statistics every 5 scans
+		if stats.totalScans%5 == 0 {
+			b.printStats(stats)
+		}
+
+		// FIXED: Only use real errors for adaptive interval, not "no opportunities"
+		realErrorsForAdaptive := consecutiveErrors
+		if consecutiveNoOpportunities > 5 && consecutiveErrors == 0 {
+			// If many scans with no opportunities but no real errors,
+			// slow down slightly but not dramatically
+			realErrorsForAdaptive = 1
+		}
+
+		// FIXED: Calculate new interval with better logic
+		newInterval := calculateAdaptiveIntervalWithCap(cfg, baseScanInterval, realErrorsForAdaptive, arbitrageService.Clock.Now())
+
+		// FIXED: Only change interval if significantly different
+		if newInterval != baseScanInterval {
+			percentChange := float64(newInterval-baseScanInterval) / float64(baseScanInterval) * 100
+			if math.Abs(percentChange) > 20 { // Only log if >20% change
+				slog.Info("⚡ Adjusting scan interval", "from", baseScanInterval, "to", newInterval,
+					"change_percent", percentChange)
+				baseScanInterval = newInterval
+			}
+		}
+
+		// FIXED: Regular status update
+		if stats.totalScans%10 == 0 {
+			slog.Info("🔄 Bot status", "scans", stats.totalScans, "successful", stats.successfulScans,
+				"interval", baseScanInterval)
+		}
+	}
+}
+
+// startMempoolWatcher starts watching pending swaps when MEMPOOL_WS_URL is set
+// and returns the channel of pairs to scan. It returns nil when the watcher is
+// disabled, which leaves the loop on interval scanning alone. A dropped
+// subscription is retried; the interval keeps running meanwhile.
+func (b *Bot) startMempoolWatcher(ctx context.Context) <-chan string {
+	if b.Config.MempoolWSURL == "" {
+		slog.Info("👀 No MEMPOOL_WS_URL configured, using interval scanning only")
+		return nil
+	}
+
+	watcher := services.NewMempoolWatcher(b.Config, b.ArbitrageService.DEXes, b.ArbitrageService.TokenPairs)
+	triggers := make(chan string, 16)
+
+	go func() {
+		for {
+			err := watcher.Watch(ctx, triggers)
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("⚠️ Mempool watcher stopped, falling back to interval scanning", "err", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(30 * time.Second):
+				slog.Info("👀 Reconnecting mempool watcher...")
+			}
+		}
+	}()
+
+	return triggers
+}
+
+// startReserveWatcher drops cached quotes of a pool whenever its reserves
+// change when WATCH_RESERVES is set. A dropped subscription is retried;
+// quotes fall back to per-block caching meanwhile.
+func (b *Bot) startReserveWatcher(ctx context.Context) {
+	if !b.Config.WatchReserves {
+		return
+	}
+
+	go func() {
+		for {
+			err := b.ArbitrageService.WatchReserves(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("⚠️ Reserve watcher stopped, falling back to per-block quote caching", "err", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(30 * time.Second):
+				slog.Info("🔔 Resubscribing reserve watcher...")
+			}
+		}
+	}()
+}
+
+// startPairRefresh signals every PAIR_REFRESH_INTERVAL_SECONDS that the pool
+// addresses are due to be re-verified. The loop runs the refresh itself
+// between scans, since scans read the pair map. It returns nil when the
+// refresh is disabled.
+func (b *Bot) startPairRefresh(ctx context.Context) <-chan struct{} {
+	if b.Config.PairRefreshInterval <= 0 {
+		return nil
+	}
+
+	refresh := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(b.Config.PairRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				select {
+				case refresh <- struct{}{}:
+				default: // a refresh is already pending
+				}
+			}
+		}
+	}()
+	return refresh
+}
+
+// jitteredInterval randomly spreads interval by SCAN_JITTER_PERCENT, keeping
+// the result within the scan interval bounds
+func jitteredInterval(cfg *config.Config, interval time.Duration) time.Duration {
+	if cfg.ScanJitterPercent == 0 {
+		return interval
+	}
+
+	jittered := utils.Jitter(interval, cfg.ScanJitterPercent, rand.Float64())
+	if jittered < minScanInterval {
+		return minScanInterval
+	}
+	if jittered > maxScanInterval {
+		return maxScanInterval
+	}
+	return jittered
+}
+
+// waitForNextScan waits out the scan interval, running a targeted scan for
+// each pair the mempool watcher flags once the large swap that moved it is
+// mined, and re-verifying the pairs whenever a
+// refresh is due in the meantime. A nil triggers or refresh channel never
+// fires, so this is a plain sleep without them. It returns false once ctx is
+// done.
+func (b *Bot) waitForNextScan(ctx context.Context, interval time.Duration, triggers <-chan string, refresh <-chan struct{}) bool {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return true
+		case <-refresh:
+			slog.Info("🔁 Re-verifying pair addresses...")
+			if err := b.VerifyPairs(); err != nil {
+				slog.Warn("⚠️ Pair re-verification failed", "err", err)
+			}
+		case pairName := <-triggers:
+			slog.Info("⚡ Mempool-triggered scan after a large swap", "pair", pairName)
+			scanCtx, cancel := context.WithTimeout(ctx, b.Config.ScanTimeout)
+			opportunities, err := b.ArbitrageService.ScanPair(scanCtx, pairName)
+			foundCount := 0
+			if err == nil {
+				foundCount, err = b.executeBest(scanCtx, opportunities)
+			}
+			cancel()
+			if err != nil {
+				slog.Error("❌ Triggered scan failed", "pair", pairName, "category", services.ClassifyError(err), "err", err)
+			} else if foundCount > 0 {
+				slog.Info("✅ Triggered scan executed", "pair", pairName, "executed", foundCount)
+			}
+		}
+	}
+}
+
+// performScan runs one enhanced scan, cancelled past SCAN_TIMEOUT_SECONDS or
+// when ctx is done. Returns the number of opportunities executed; an empty
+// scan is not an error.
+func (b *Bot) performScan(ctx context.Context, scanType string) (int, error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("❌ Panic recovered in scan", "period", scanType, "panic", r)
+		}
+	}()
+
+	startTime := time.Now()
+	timeout := b.Config.ScanTimeout
+
+	type scanOutcome struct {
+		foundCount int
+		err        error
+	}
+
+	// FIXED: Wrapper dengan timeout untuk mencegah hanging
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan scanOutcome, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- scanOutcome{err: fmt.Errorf("panic in scan: %v", r)}
+			}
+		}()
+
+		slog.Info("🎯 Performing enhanced scan...", "period", scanType)
+
+		// FIXED: Don't use WithRetry for this - it's not a connection error
+		opportunities, err := b.ArbitrageService.FindEnhancedArbitrageOpportunities(ctx)
+
+		// FIXED: "No opportunities found" is NOT an error - it's normal
+		if errors.Is(err, services.ErrNoOpportunity) {
+			slog.Info("📊 No opportunities found (normal during off-peak)", "period", scanType)
+			done <- scanOutcome{} // Return success, not error
+			return
+		}
+		if err != nil {
+			done <- scanOutcome{err: err}
+			return
+		}
+
+		foundCount, err := b.executeBest(ctx, opportunities)
+		done <- scanOutcome{foundCount: foundCount, err: err}
+	}()
+
+	// Past SCAN_TIMEOUT_SECONDS the scan's reads are cancelled, so it returns
+	// promptly instead of being left running; only a trade already being sent
+	// is waited for
+	var outcome scanOutcome
+	select {
+	case outcome = <-done:
+	case <-ctx.Done():
+		slog.Warn("⏰ Scan timed out or stopped, cancelling...", "period", scanType,
+			"elapsed", time.Since(startTime).Round(time.Millisecond))
+		outcome = <-done
+	}
+
+	scanDuration := time.Since(startTime)
+	if outcome.err != nil {
+		if services.ClassifyError(outcome.err) != services.ErrorRevert {
+			slog.Error("❌ Scan failed", "period", scanType, "duration", scanDuration.Round(time.Millisecond), "err", outcome.err)
+		}
+		return 0, outcome.err
+	}
+	slog.Info("✅ Scan completed", "period", scanType, "duration", scanDuration.Round(time.Millisecond),
+		"executed", outcome.foundCount)
+	return outcome.foundCount, nil
+}
+
+// executeBest executes the top-ranked of a scan's opportunities and returns
+// the number of trades made. A trade already being sent runs to completion even
+// if ctx is cancelled meanwhile.
+func (b *Bot) executeBest(ctx context.Context, opportunities []models.Opportunity) (int, error) {
+	if len(opportunities) == 0 {
+		return 0, nil
+	}
+
+	execution, err := b.ArbitrageService.Execute(ctx, opportunities[0])
+	if err != nil || execution == nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// calculateAdaptiveInterval scales baseInterval by recent errors and the
+// trading window now falls in. Errors take precedence, so a failing RPC slows
+// the bot down even at peak hours. The result always stays within the scan
+// interval bounds and never drops below 15 seconds.
+func calculateAdaptiveInterval(cfg *config.Config, baseInterval time.Duration, consecutiveErrors int, now time.Time) time.Duration {
+	const baseMinimum = 15 * time.Second // Base minimum for any time
+
+	period := cfg.ScanPeriodFor(now)
+	var newInterval time.Duration
+
+	// FIXED: More conservative multipliers
+	switch {
+	case consecutiveErrors >= 5:
+		// Many errors - back off as far as allowed
+		newInterval = maxScanInterval
+
+	case consecutiveErrors >= 3:
+		// Multiple errors - slow down a bit
+		newInterval = time.Duration(float64(baseInterval) * 1.5) // 50% slower
+
+	case period == config.ScanPeriodPeak:
+		// Peak hours - scan faster
+		newInterval = time.Duration(float64(baseInterval) * 0.8) // 20% faster
+
+	case period == config.ScanPeriodLow:
+		// Low activity hours - but not crazy slow
+		newInterval = time.Duration(float64(baseInterval) * 1.3) // Only 30% slower
+
+	default:
+		// Normal hours - keep base interval
+		newInterval = baseInterval
+	}
+
+	// CRITICAL: Always enforce bounds
+	if newInterval > maxScanInterval {
+		return maxScanInterval
+	}
+
+	// FIXED: Never go below reasonable base minimum, which is above minScanInterval
+	if newInterval < baseMinimum {
+		return baseMinimum
+	}
+
+	return newInterval
+}
+
+// ABSOLUTE SAFE version with emergency caps
+func calculateAdaptiveIntervalWithCap(cfg *config.Config, baseInterval time.Duration, consecutiveErrors int, now time.Time) time.Duration {
+	// Call existing function
+	newInterval := calculateAdaptiveInterval(cfg, baseInterval, consecutiveErrors, now)
+
+	// EMERGENCY CAP - double protection
+	const emergencyCap = 120 * time.Second
+	if newInterval > emergencyCap {
+		slog.Warn("🚨 EMERGENCY: Interval capped", "interval", newInterval, "cap", emergencyCap)
+		return emergencyCap
+	}
+
+	// Additional safety check
+	if newInterval.Minutes() > 2 {
+		slog.Warn("🛑 SAFETY: Forcing interval cap", "interval", newInterval, "cap", emergencyCap)
+		return emergencyCap
+	}
+
+	return newInterval
+}
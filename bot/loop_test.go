@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"arbitrage-bot/config"
+)
+
+func TestCalculateAdaptiveInterval(t *testing.T) {
+	cfg := &config.Config{
+		PeakHours: []config.HourRange{{Start: 13, End: 16}},
+		LowHours:  []config.HourRange{{Start: 2, End: 6}},
+	}
+
+	peak := time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)
+	low := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)
+	standard := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		base   time.Duration
+		errors int
+		now    time.Time
+		want   time.Duration
+	}{
+		{"standard hours keep base", 30 * time.Second, 0, standard, 30 * time.Second},
+		{"peak hours scan faster", 30 * time.Second, 0, peak, 24 * time.Second},
+		{"low hours scan slower", 30 * time.Second, 0, low, 39 * time.Second},
+		{"few errors are ignored", 30 * time.Second, 2, standard, 30 * time.Second},
+		{"three errors slow down", 30 * time.Second, 3, standard, 45 * time.Second},
+		{"errors override peak hours", 30 * time.Second, 3, peak, 45 * time.Second},
+		{"five errors hit the maximum", 30 * time.Second, 5, standard, maxScanInterval},
+		{"many errors hit the maximum at peak", 30 * time.Second, 50, peak, maxScanInterval},
+		{"floored at 15 seconds", 15 * time.Second, 0, peak, 15 * time.Second},
+		{"zero base is floored", 0, 0, standard, 15 * time.Second},
+		{"negative base is floored", -time.Minute, 0, standard, 15 * time.Second},
+		{"capped at the maximum", 100 * time.Second, 0, low, maxScanInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateAdaptiveInterval(cfg, tt.base, tt.errors, tt.now); got != tt.want {
+				t.Errorf("calculateAdaptiveInterval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateAdaptiveIntervalBoundsAndMonotonicity(t *testing.T) {
+	cfg := &config.Config{
+		PeakHours: []config.HourRange{{Start: 13, End: 16}, {Start: 21, End: 23}},
+		LowHours:  []config.HourRange{{Start: 2, End: 6}},
+	}
+
+	bases := []time.Duration{0, time.Second, 10 * time.Second, 30 * time.Second, time.Minute, 2 * time.Minute, time.Hour}
+	for hour := 0; hour < 24; hour++ {
+		now := time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)
+		for _, base := range bases {
+			previous := time.Duration(0)
+			for errors := 0; errors <= 10; errors++ {
+				got := calculateAdaptiveInterval(cfg, base, errors, now)
+				if got < minScanInterval || got > maxScanInterval {
+					t.Fatalf("hour %d, base %v, %d errors: interval %v outside [%v, %v]",
+						hour, base, errors, got, minScanInterval, maxScanInterval)
+				}
+				if got < previous {
+					t.Fatalf("hour %d, base %v: %d errors gave %v, shorter than %v for fewer errors",
+						hour, base, errors, got, previous)
+				}
+				previous = got
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// bot/stats.go
+package bot
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/services"
+)
+
+// printStats logs the loop's running statistics, RPC status and per-pair spreads
+func (b *Bot) printStats(stats *loopStats) {
+	arbitrageService := b.ArbitrageService
+	uptime := time.Since(stats.startTime)
+	successRate := float64(stats.successfulScans) / float64(stats.totalScans) * 100
+
+	slog.Info("📊 Enhanced statistics",
+		"uptime", uptime.Round(time.Second),
+		"total_scans", stats.totalScans,
+		"successful", stats.successfulScans,
+		"success_rate_percent", successRate,
+		"errors", stats.errorCount,
+		"rpc_switches", stats.rpcSwitches.Load(),
+		"avg_scan_seconds", uptime.Seconds()/float64(stats.totalScans))
+
+	// RPC status
+	b.Client.LogConnectionStatus()
+
+	logTradeSummary(arbitrageService.TradeSummary())
+	logPendingTransactions(arbitrageService)
+
+	// A spread that never turns profitable marks a poor candidate; one that
+	// keeps shrinking means others are arbitraging it
+	spreads := arbitrageService.AverageSpreads()
+	pairNames := make([]string, 0, len(spreads))
+	for name := range spreads {
+		pairNames = append(pairNames, name)
+	}
+	sort.Strings(pairNames)
+	for _, name := range pairNames {
+		slog.Info("📏 Average spread", "pair", name, "spread_percent", spreads[name])
+	}
+
+	// Time-based insights
+	switch period := arbitrageService.ScanPeriod(); period {
+	case config.ScanPeriodPeak:
+		slog.Info("🔥 PEAK HOURS - Prime time for volatility!", "period", period)
+	case config.ScanPeriodLow:
+		slog.Info("😴 Low activity - reduced opportunities", "period", period)
+	default:
+		slog.Info("📈 Standard hours - moderate activity", "period", period)
+	}
+
+	slog.Info("🔄 Bot will continue scanning...")
+}
+
+// printFinalStats logs the loop's totals once it has stopped
+func (b *Bot) printFinalStats(stats *loopStats) {
+	uptime := time.Since(stats.startTime)
+
+	attrs := []any{
+		"uptime", uptime.Round(time.Second),
+		"total_scans", stats.totalScans,
+		"successful", stats.successfulScans,
+		"failed", stats.errorCount,
+		"rpc_switches", stats.rpcSwitches.Load(),
+	}
+	if stats.totalScans > 0 {
+		attrs = append(attrs,
+			"success_rate_percent", float64(stats.successfulScans)/float64(stats.totalScans)*100,
+			"avg_scan_seconds", uptime.Seconds()/float64(stats.totalScans))
+	}
+	slog.Info("📋 Final enhanced statistics", attrs...)
+
+	// Final RPC status
+	b.Client.LogConnectionStatus()
+
+	logTradeSummary(b.ArbitrageService.TradeSummary())
+	logPendingTransactions(b.ArbitrageService)
+}
+
+// logPendingTransactions prints how many sent transactions are still
+// unconfirmed against MAX_PENDING_TX
+func logPendingTransactions(arbitrageService *services.ArbitrageService) {
+	pending := arbitrageService.PendingTransactions()
+	if limit := arbitrageService.Config.MaxPendingTx; limit > 0 {
+		slog.Info("⏳ Pending transactions", "pending", pending, "limit", limit)
+	} else {
+		slog.Info("⏳ Pending transactions", "pending", pending)
+	}
+}
+
+// logTradeSummary prints expected against realized profit, so detected
+// opportunities can be told apart from money actually made
+func logTradeSummary(summary services.TradeSummary) {
+	if summary.MissedOpportunities > 0 {
+		slog.Info("🏁 Missed opportunities, reverted at minimum output and taken by a faster trade",
+			"missed", summary.MissedOpportunities)
+	}
+	if summary.Trades == 0 {
+		slog.Info("💰 Trades executed", "trades", 0)
+		return
+	}
+
+	// Realized profit is net of gas for manual trades, before gas for flash
+	slog.Info("💰 Trades executed",
+		"trades", summary.Trades,
+		"profitable_percent", summary.WinRate(),
+		"expected_profit_wbnb", summary.ExpectedProfit,
+		"realized_profit_wbnb", summary.RealizedProfit)
+}
@@ -0,0 +1,535 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+	"arbitrage-bot/services"
+	"arbitrage-bot/utils"
+)
+
+// commandServices holds the services shared by the one-shot subcommands
+type commandServices struct {
+	cfg              *config.Config
+	client           *services.EthClient
+	tokenService     *services.TokenService
+	routerService    *services.RouterService
+	arbitrageService *services.ArbitrageService
+}
+
+// runCommand executes a one-shot subcommand and returns the process exit code
+func runCommand(name string, args []string) int {
+	commands := map[string]func(*commandServices, []string) error{
+		"quote":      runQuoteCommand,
+		"balances":   runBalancesCommand,
+		"approve":    runApproveCommand,
+		"allowances": runAllowancesCommand,
+		"scan-once":  runScanOnceCommand,
+		"wrap":       runWrapCommand,
+		"unwrap":     runUnwrapCommand,
+		"backtest":   runBacktestCommand,
+		"unwind":     runUnwindCommand,
+	}
+
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return 0
+	}
+
+	command, exists := commands[name]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage()
+		return 2
+	}
+
+	svc, err := setupCommandServices()
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 1
+	}
+	defer svc.client.Close()
+
+	if err := command(svc, args); err != nil {
+		log.Printf("❌ %s: %v", name, err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  arbi                                        run the persistent arbitrage loop")
+	fmt.Fprintln(os.Stderr, "  arbi quote --pair WBNB-CAKE-USDT --amount 0.5  quote both routes for a pair")
+	fmt.Fprintln(os.Stderr, "  arbi balances                               show wallet balances for configured tokens")
+	fmt.Fprintln(os.Stderr, "  arbi approve --router pancake [--token CAKE]  approve router spending")
+	fmt.Fprintln(os.Stderr, "  arbi allowances [--router pancake] [--revoke]  list router allowances, optionally revoke them")
+	fmt.Fprintln(os.Stderr, "  arbi scan-once [--execute]                  run one scan, exit 1 if nothing found")
+	fmt.Fprintln(os.Stderr, "  arbi wrap --amount 0.5                      wrap native BNB into WBNB")
+	fmt.Fprintln(os.Stderr, "  arbi unwrap --amount 0.5                    unwrap WBNB into native BNB")
+	fmt.Fprintln(os.Stderr, "  arbi backtest --from N --to M [--step K]    replay scans at historical blocks")
+	fmt.Fprintln(os.Stderr, "  arbi unwind --token 0x... [--wallet 0x...]   swap a stranded token's balance back to WBNB")
+}
+
+// setupCommandServices loads the configuration and connects the services
+func setupCommandServices() (*commandServices, error) {
+	cfg := config.LoadConfig()
+	if err := cfg.ValidateConfig(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+	if err := setupLogger(cfg); err != nil {
+		return nil, fmt.Errorf("failed to set up logging: %v", err)
+	}
+
+	if err := contracts.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize contract ABIs: %v", err)
+	}
+
+	client, err := services.NewEthClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to BSC network: %v", err)
+	}
+
+	tokenService := services.NewTokenService(client)
+	routerService := services.NewRouterService(client, tokenService, cfg)
+	arbitrageService := services.NewArbitrageService(client, tokenService, routerService, cfg)
+	if cfg.ConfirmTrades {
+		arbitrageService.ConfirmTrade = promptTradeConfirmation(cfg.ConfirmTimeout)
+	}
+
+	return &commandServices{
+		cfg:              cfg,
+		client:           client,
+		tokenService:     tokenService,
+		routerService:    routerService,
+		arbitrageService: arbitrageService,
+	}, nil
+}
+
+// runQuoteCommand quotes both routes of a pair without executing
+func runQuoteCommand(svc *commandServices, args []string) error {
+	flags := flag.NewFlagSet("quote", flag.ContinueOnError)
+	pairName := flags.String("pair", "", "pair name, e.g. WBNB-CAKE-USDT")
+	amountStr := flags.String("amount", "0.1", "WBNB amount to quote")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *pairName == "" {
+		return fmt.Errorf("--pair is required")
+	}
+
+	amountWei, err := utils.ParseTokenAmount(*amountStr, 18)
+	if err != nil {
+		return err
+	}
+	if amountWei.Sign() <= 0 {
+		return fmt.Errorf("amount must be a positive number")
+	}
+	amount := svc.tokenService.ConvertToReadable(amountWei, 18)
+
+	pair, err := svc.arbitrageService.FindTokenPair(*pairName)
+	if err != nil {
+		return err
+	}
+
+	routes, err := svc.arbitrageService.Routes(pair)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range routes {
+		result, err := svc.arbitrageService.CheckTriangularArbitrage(pair, amount, route)
+		if errors.Is(err, services.ErrNoOpportunity) {
+			log.Printf("🚫 %s: a pool on this route is not listed", route)
+			continue
+		}
+		if err != nil {
+			log.Printf("❌ %s quote failed: %v", route, err)
+			continue
+		}
+
+		log.Printf("📊 %s from %s: %.4f%% user profit, net %.6f WBNB after %.6f WBNB gas",
+			route, route.Hops[0].SymbolIn, svc.arbitrageService.UserProfitPercent(result)*100, result.NetProfitWBNB, result.GasCostWBNB)
+	}
+
+	// The mixed V2/V3 route is quote-only, so it is shown here rather than
+	// quoted on every scan
+	if svc.cfg.EnableV3 {
+		result, err := svc.arbitrageService.CheckBestVenueArbitrage(pair, amount)
+		if err != nil {
+			log.Printf("❌ Best-venue quote failed: %v", err)
+		} else {
+			log.Printf("🧪 %s (quote-only): %.4f%% user profit, net %.6f WBNB after %.6f WBNB gas",
+				strings.Join(result.Venues, "→"), svc.arbitrageService.UserProfitPercent(result)*100,
+				result.NetProfitWBNB, result.GasCostWBNB)
+		}
+	}
+
+	return nil
+}
+
+// runBalancesCommand prints BNB and token balances for every configured token
+func runBalancesCommand(svc *commandServices, args []string) error {
+	flags := flag.NewFlagSet("balances", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	log.Printf("📍 Address: %s", svc.client.Address.Hex())
+
+	tokens := configuredTokens(svc.arbitrageService)
+	symbols := sortedKeys(tokens)
+	addresses := make([]common.Address, len(symbols))
+	for i, symbol := range symbols {
+		addresses[i] = tokens[symbol]
+	}
+
+	balances, err := svc.client.GetBalances(addresses, svc.client.Address)
+	if err != nil {
+		return fmt.Errorf("error getting balances: %v", err)
+	}
+	log.Printf("🪙 BNB: %.6f", svc.tokenService.ConvertToReadable(balances[services.NativeBalance], 18))
+
+	for _, symbol := range symbols {
+		address := tokens[symbol]
+
+		balance, fetched := balances[address]
+		if !fetched {
+			log.Printf("❌ %s: balanceOf failed", symbol)
+			continue
+		}
+
+		decimals, err := svc.tokenService.GetTokenDecimals(address)
+		if err != nil {
+			log.Printf("❌ %s decimals: %v", symbol, err)
+			continue
+		}
+
+		log.Printf("💰 %s: %.6f", symbol, svc.tokenService.ConvertToReadable(balance, decimals))
+	}
+
+	return nil
+}
+
+// runApproveCommand approves a router to spend the configured tokens
+func runApproveCommand(svc *commandServices, args []string) error {
+	flags := flag.NewFlagSet("approve", flag.ContinueOnError)
+	routerName := flags.String("router", "", "exchange router to approve, e.g. pancake or biswap")
+	tokenSymbol := flags.String("token", "", "only approve this token symbol (default: all configured tokens)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	dex, err := findDEXByPrefix(svc.arbitrageService, *routerName)
+	if err != nil {
+		return err
+	}
+	router := dex.Router()
+
+	tokens := configuredTokens(svc.arbitrageService)
+	if *tokenSymbol != "" {
+		address, exists := tokens[strings.ToUpper(*tokenSymbol)]
+		if !exists {
+			return fmt.Errorf("token %s is not in any configured pair", *tokenSymbol)
+		}
+		tokens = map[string]common.Address{strings.ToUpper(*tokenSymbol): address}
+	}
+
+	maxAmount := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	for _, symbol := range sortedKeys(tokens) {
+		hash, err := svc.tokenService.ApproveToken(tokens[symbol], router, maxAmount)
+		if err != nil {
+			return fmt.Errorf("error approving %s: %v", symbol, err)
+		}
+		log.Printf("✅ Approved %s for %s (%s): %s", symbol, dex.Name(), router.Hex(), hash.Hex())
+	}
+
+	return nil
+}
+
+// runAllowancesCommand lists the wallet's non-zero allowances of every
+// configured token to the exchange routers, and with --revoke resets them to
+// zero after confirmation
+func runAllowancesCommand(svc *commandServices, args []string) error {
+	flags := flag.NewFlagSet("allowances", flag.ContinueOnError)
+	routerName := flags.String("router", "", "only check this exchange router (default: all)")
+	revoke := flags.Bool("revoke", false, "revoke the listed allowances")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	spenders := make(map[string]common.Address)
+	if *routerName != "" {
+		dex, err := findDEXByPrefix(svc.arbitrageService, *routerName)
+		if err != nil {
+			return err
+		}
+		spenders[dex.Name()] = dex.Router()
+	} else {
+		for _, dex := range svc.arbitrageService.DEXes {
+			spenders[dex.Name()] = dex.Router()
+		}
+	}
+
+	allowances, err := svc.tokenService.Allowances(configuredTokens(svc.arbitrageService), spenders)
+	if err != nil {
+		return err
+	}
+	if len(allowances) == 0 {
+		log.Println("✅ No outstanding allowances")
+		return nil
+	}
+
+	unlimited := new(big.Int).Lsh(big.NewInt(1), 255)
+	for _, allowance := range allowances {
+		if allowance.Amount.Cmp(unlimited) >= 0 {
+			log.Printf("🔓 %s → %s: unlimited", allowance.Symbol, allowance.Spender)
+			continue
+		}
+
+		decimals, err := svc.tokenService.GetTokenDecimals(allowance.Token)
+		if err != nil {
+			log.Printf("🔓 %s → %s: %s (raw)", allowance.Symbol, allowance.Spender, allowance.Amount)
+			continue
+		}
+		log.Printf("🔓 %s → %s: %.6f", allowance.Symbol, allowance.Spender,
+			svc.tokenService.ConvertToReadable(allowance.Amount, decimals))
+	}
+
+	if !*revoke {
+		return nil
+	}
+	if !utils.WaitForConfirmation(fmt.Sprintf("Revoke %d allowance(s)?", len(allowances))) {
+		log.Println("🚫 Revocation cancelled")
+		return nil
+	}
+
+	for _, allowance := range allowances {
+		hash, err := svc.tokenService.RevokeApproval(allowance.Token, allowance.SpenderAddress)
+		if err != nil {
+			return fmt.Errorf("error revoking %s for %s: %v", allowance.Symbol, allowance.Spender, err)
+		}
+		log.Printf("✅ Revoked %s for %s (%s): %s", allowance.Symbol, allowance.Spender, allowance.SpenderAddress.Hex(), hash.Hex())
+	}
+
+	return nil
+}
+
+// runWrapCommand wraps native BNB into WBNB
+func runWrapCommand(svc *commandServices, args []string) error {
+	amount, err := parseWrapAmount(svc, "wrap", args)
+	if err != nil {
+		return err
+	}
+
+	hash, err := svc.tokenService.WrapBNB(amount)
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Wrapped %.6f BNB: %s", svc.tokenService.ConvertToReadable(amount, 18), hash.Hex())
+	return nil
+}
+
+// runUnwrapCommand unwraps WBNB into native BNB
+func runUnwrapCommand(svc *commandServices, args []string) error {
+	amount, err := parseWrapAmount(svc, "unwrap", args)
+	if err != nil {
+		return err
+	}
+
+	hash, err := svc.tokenService.UnwrapBNB(amount)
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Unwrapped %.6f WBNB: %s", svc.tokenService.ConvertToReadable(amount, 18), hash.Hex())
+	return nil
+}
+
+// runUnwindCommand swaps the wallet's whole balance of a stranded token back
+// to WBNB on the exchange quoting the most for it
+func runUnwindCommand(svc *commandServices, args []string) error {
+	flags := flag.NewFlagSet("unwind", flag.ContinueOnError)
+	token := flags.String("token", "", "address of the token to sell back to WBNB")
+	wallet := flags.String("wallet", "", "wallet holding the token (default: the first configured wallet)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !common.IsHexAddress(*token) {
+		return fmt.Errorf("--token must be a token address")
+	}
+	if *wallet != "" {
+		if !common.IsHexAddress(*wallet) || !svc.client.UseWallet(common.HexToAddress(*wallet)) {
+			return fmt.Errorf("wallet %s is not configured", *wallet)
+		}
+	}
+
+	received, err := svc.arbitrageService.UnwindPosition(common.HexToAddress(*token))
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Unwound to %.6f WBNB", svc.tokenService.ConvertToReadable(received, 18))
+	return nil
+}
+
+// parseWrapAmount reads the --amount flag shared by wrap and unwrap, in BNB
+func parseWrapAmount(svc *commandServices, name string, args []string) (*big.Int, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	amount := flags.Float64("amount", 0, "amount in BNB")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *amount <= 0 {
+		return nil, fmt.Errorf("--amount must be positive")
+	}
+	return svc.tokenService.FormatTokenAmount(*amount, 18), nil
+}
+
+// runScanOnceCommand performs exactly one enhanced scan and reports what it
+// found, executing the best opportunity only when --execute is set
+func runScanOnceCommand(svc *commandServices, args []string) error {
+	flags := flag.NewFlagSet("scan-once", flag.ContinueOnError)
+	execute := flags.Bool("execute", false, "execute the best opportunity instead of only reporting it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := svc.arbitrageService.VerifyAndUpdatePairs(); err != nil {
+		log.Printf("⚠️ Warning: Error verifying pairs: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), svc.cfg.ScanTimeout)
+	defer cancel()
+
+	opportunities, err := svc.arbitrageService.ScanEnhancedOpportunities(ctx)
+	if err != nil {
+		return err
+	}
+	if len(opportunities) == 0 {
+		return services.ErrNoOpportunity
+	}
+
+	for _, opportunity := range opportunities {
+		log.Printf("💰 %s via %s (%s): %.4f WBNB, gross %.3f%%, net %.6f WBNB, gas %.6f WBNB",
+			opportunity.PairName, opportunity.Route(), opportunity.Strategy, opportunity.AmountWBNB,
+			opportunity.GrossPercent, opportunity.NetProfitWBNB, opportunity.GasCostWBNB)
+	}
+	if !*execute {
+		return nil
+	}
+
+	if err := svc.arbitrageService.CheckTradeable(ctx); err != nil {
+		log.Printf("⚠️ Wallet tradeability check failed: %v", err)
+	}
+
+	execution, err := svc.arbitrageService.Execute(ctx, opportunities[0])
+	if err != nil {
+		return err
+	}
+	if execution == nil {
+		return fmt.Errorf("opportunity on %s was not executed", opportunities[0].PairName)
+	}
+	log.Printf("✅ Executed %s via %s", execution.PairName, execution.Route)
+	return nil
+}
+
+// runBacktestCommand replays the enhanced scanner over a range of historical
+// blocks and prints what the current thresholds would have caught
+func runBacktestCommand(svc *commandServices, args []string) error {
+	flags := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	fromBlock := flags.Uint64("from", 0, "first block to replay")
+	toBlock := flags.Uint64("to", 0, "last block to replay")
+	step := flags.Uint64("step", 1, "replay every step-th block")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *fromBlock == 0 || *toBlock == 0 {
+		return fmt.Errorf("--from and --to are required")
+	}
+
+	// Historical state needs an archive node; most public RPCs prune it
+	arbitrageService := svc.arbitrageService
+	if svc.cfg.ArchiveRPCURL != "" {
+		archive, err := ethclient.Dial(svc.cfg.ArchiveRPCURL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to archive RPC: %v", err)
+		}
+		defer archive.Close()
+		arbitrageService = services.NewBacktestService(archive, svc.cfg)
+	} else {
+		log.Println("⚠️ ARCHIVE_RPC_URL not set, replaying against the regular RPC")
+	}
+
+	if err := arbitrageService.VerifyAndUpdatePairs(); err != nil {
+		log.Printf("⚠️ Warning: Error verifying pairs: %v", err)
+	}
+
+	log.Printf("⏪ Backtesting blocks %d-%d every %d block(s)...", *fromBlock, *toBlock, *step)
+	report, err := arbitrageService.Backtest(*fromBlock, *toBlock, *step)
+	if err != nil {
+		return err
+	}
+
+	log.Println("======================================")
+	log.Printf("📊 Blocks evaluated: %d (%d failed evaluations)", report.Blocks, report.Errors)
+	log.Printf("💰 Opportunities: %d, total net profit %.6f WBNB", report.Opportunities, report.TotalNetProfitWBNB)
+	if report.Opportunities > 0 {
+		log.Printf("🏆 Best: %.6f WBNB on %s at block %d", report.BestNetProfitWBNB, report.BestPair, report.BestBlock)
+	}
+	for _, name := range report.PairNames() {
+		stats := report.Pairs[name]
+		log.Printf("   %s: %d opportunity(ies), %.6f WBNB net, best %.4f%%",
+			name, stats.Opportunities, stats.NetProfitWBNB, stats.BestProfitPercent*100)
+	}
+	log.Println("======================================")
+	return nil
+}
+
+// configuredTokens collects every token used by the configured pairs
+func configuredTokens(arbitrageService *services.ArbitrageService) map[string]common.Address {
+	tokens := make(map[string]common.Address)
+	for _, pair := range arbitrageService.TokenPairs {
+		for symbol, address := range pair.Tokens {
+			tokens[symbol] = common.HexToAddress(address)
+		}
+	}
+	return tokens
+}
+
+// findDEXByPrefix matches an exchange by a case-insensitive name prefix, so
+// "pancake" finds PancakeSwap
+func findDEXByPrefix(arbitrageService *services.ArbitrageService, name string) (services.DEX, error) {
+	var names []string
+	for _, dex := range arbitrageService.DEXes {
+		names = append(names, dex.Name())
+		if name != "" && strings.HasPrefix(strings.ToLower(dex.Name()), strings.ToLower(name)) {
+			return dex, nil
+		}
+	}
+	return nil, fmt.Errorf("--router must be one of: %s", strings.Join(names, ", "))
+}
+
+func sortedKeys(m map[string]common.Address) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
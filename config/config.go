@@ -4,17 +4,31 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration values
 type Config struct {
-	// Wallet
-	PrivateKey string
+	// Wallet: a raw private key, an encrypted keystore file, or an external signer
+	PrivateKey           string
+	KeystorePath         string
+	KeystorePassword     string
+	KeystorePasswordFile string
+	SignerURL            string
+	SignerAddress        string
+	SignerMethod         string
+
+	// Extra wallets from PRIVATE_KEY_1..N; executions rotate through
+	// PRIVATE_KEY and these in order
+	PrivateKeys []string
 
 	// RPC URLs (multiple for failover)
 	BSCRPCURL  string
@@ -30,16 +44,203 @@ type Config struct {
 	// Contracts
 	FlashArbContract string
 
+	// Manual arbitrage trades the wallet's own balance leg by leg and can
+	// strand funds mid-route, so it only runs when explicitly allowed
+	AllowManualArbitrage bool
+
+	// Ask on the terminal before each live trade, answering no after
+	// ConfirmTimeout so an unattended bot keeps scanning
+	ConfirmTrades  bool
+	ConfirmTimeout time.Duration
+
 	// Gas settings
 	GasLimit uint64
 	GasPrice int64
 
+	// Executions are skipped while the network gas price is above this; 0
+	// disables the ceiling
+	MaxGasPriceGwei float64
+	// Trades are sent at the node's suggested gas price plus this percentage
+	GasPriceBufferPercent int
+	// Gas estimates are raised by this percentage to leave headroom
+	GasLimitBufferPercent int
+	// A transaction the node rejects as underpriced is re-signed at the same
+	// nonce with its gas price raised by GasBumpPercent, up to GasBumpRetries times
+	GasBumpPercent int
+	GasBumpRetries int
+
+	// New trades are refused while this many of the wallet's transactions are
+	// still unconfirmed; 0 disables the limit
+	MaxPendingTx int
+
+	// Trades are skipped when paying their gas would leave less native BNB
+	// than this, so there is always enough to unwind a position or replace a
+	// stuck transaction; 0 disables the check
+	MinNativeReserveBNB float64
+
+	// A pair whose execution reverts or fails is skipped by the scanner for
+	// this long; 0 disables the cooldown
+	PairFailureCooldown time.Duration
+
+	// How often the wallet is rechecked for the BNB and WBNB it needs to
+	// trade; while it can't, the bot only scans and reports. 0 checks once
+	// at startup.
+	WalletCheckInterval time.Duration
+
+	// How often pool addresses are re-verified against the factories while
+	// the bot runs, picking up new and migrated pools; 0 verifies only at
+	// startup
+	PairRefreshInterval time.Duration
+
+	// Strategies the scanner runs on every pair, e.g. triangular and direct
+	Strategies []string
+
+	// Tokens triangular cycles are anchored on, e.g. WBNB, BUSD and USDT. A
+	// pair holding several of them is scanned once per base token.
+	BaseTokens []string
+
+	// Most pairs evaluated per scan, in priority order with the rest taking
+	// turns across scans; 0 scans every pair
+	MaxPairsPerScan int
+
 	// Trading parameters
 	MinProfit      float64
 	MaxSlippage    float64
 	CooldownPeriod int
+	MaxPriceImpact float64
+	MinReserveWBNB float64
+
+	// Floor on each pool's total value locked in USD, valued from its USDT
+	// reserve or its WBNB value at the WBNB-USDT price. 0 disables it.
+	MinLiquidityUSD float64
+
+	// Largest share of a pool's input-side reserve a trade may swap into any
+	// hop, in basis points. Larger trades are sized down and re-quoted; 0
+	// disables the cap.
+	MaxPoolFractionBps int64
+
+	// Absolute floor on net profit (after platform fee and gas) in WBNB
+	MinNetProfitWBNB float64
+	SwapDeadline     time.Duration
+	ReceiptTimeout   time.Duration
+
+	// A scan still running after this long is cancelled
+	ScanTimeout time.Duration
+
+	// Each wait between scans is randomly lengthened or shortened by up to
+	// this percentage so scans don't line up with other bots'. 0 disables it.
+	ScanJitterPercent int
+
+	// Per-category thresholds for the enhanced scanner
+	CategoryMinProfit     map[string]float64
+	CategoryGasAdjustment map[string]float64
+
+	// Per-category overrides of MaxSlippage for flash trade minimum outputs;
+	// categories without one use MaxSlippage
+	CategoryMaxSlippage map[string]float64
+
+	// Per-pair thresholds that take precedence over the pair's category
+	PairOverrides map[string]PairOverride
+
+	// Smallest quote output, in whole tokens, that CheckLiquidity accepts as
+	// a real route rather than a drained pool. MinOutputAmounts overrides it
+	// per token symbol, e.g. a lower floor for BTCB.
+	MinOutputAmount  float64
+	MinOutputAmounts map[string]float64
+
+	// Platform fee taken by the flash contract, in basis points
+	PlatformFeeBps int
+
+	// Trading activity windows (UTC hours)
+	PeakHours []HourRange
+	LowHours  []HourRange
+
+	// Optional private relay used to submit trades away from the public mempool
+	PrivateTxURL    string
+	PrivateTxMethod string
+
+	// Optional websocket endpoint for watching pending swaps. Large swaps on
+	// tracked pairs trigger an immediate scan of that pair; without it the
+	// bot only scans on its interval.
+	MempoolWSURL        string
+	MempoolMinSwapWBNB  float64
+	MempoolPairCooldown time.Duration
+
+	// Subscribe to Sync events of tracked pools and drop cached quotes of a
+	// pool as soon as its reserves change. Needs a ws:// or wss:// RPC.
+	WatchReserves bool
+
+	// Archive node used by the backtest command to read historical state
+	ArchiveRPCURL string
+
+	// Where detected opportunities are streamed as JSON lines: a file path,
+	// "stdout", or empty to disable
+	OpportunityLog string
+
+	// Where a manual arbitrage persists its position between legs
+	ExecutionStateFile string
+
+	// Where failed RPC timestamps are persisted across restarts
+	FailedRPCFile string
+
+	// RPC health checking and retry behaviour
+	HealthCheckInterval time.Duration
+	HealthCheckTimeout  time.Duration
+	MaxRetries          int
+	RetryBaseDelay      time.Duration
+	RetryMaxDelay       time.Duration
+
+	// Address of the /healthz endpoint (empty disables it) and how old the
+	// node's latest block may get before the bot reports itself unhealthy
+	HealthAddr  string
+	MaxBlockLag time.Duration
 
-	// Debug mode
+	// Rate-limited RPCs are backed off rather than switched away from until
+	// they rate-limit this many times in a row
+	RateLimitBackoff         time.Duration
+	RateLimitSwitchThreshold int
+
+	// Calls per second the bot allows itself on each RPC endpoint, so it
+	// stays under the provider's limit instead of collecting 429s; 0 is
+	// unlimited. RPCCallLimits overrides it for endpoints whose URL contains
+	// the key, e.g. "ankr.com".
+	RPCCallsPerSecond float64
+	RPCCallLimits     map[string]float64
+
+	// How long shutdown waits for an in-flight execution to finish its legs
+	ShutdownGracePeriod time.Duration
+
+	// PancakeSwap V3 quoting: fee tiers (in hundredths of a bip) to try per leg
+	EnableV3   bool
+	V3FeeTiers []uint32
+
+	// Quote the quote-only mixed V2/V3 route every scan, just to log when it
+	// would beat the V2 routes. It costs a quoter call per fee tier per leg.
+	LogV3Routes bool
+
+	// Estimate each route locally from pool reserves and only quote it on-chain
+	// when the estimate is within PrefilterTolerance of the pair's min profit
+	ReservePrefilter   bool
+	PrefilterTolerance float64
+
+	// Swap fee of each V2 exchange in basis points, used by the local reserve
+	// math. BiSwap pools that report their own fee through swapFee() use it.
+	PancakeswapFeeBps int64
+	BiswapFeeBps      int64
+
+	// Before each scan, wrap native BNB when WBNB falls below the threshold
+	// (0 disables) until it reaches the target, always leaving GasReserveBNB
+	// unwrapped for gas
+	AutoWrapThresholdWBNB float64
+	AutoWrapTargetWBNB    float64
+	GasReserveBNB         float64
+
+	// Logging: LOG_LEVEL is debug, info, warn or error; LOG_FORMAT is
+	// console or json
+	LogLevel  string
+	LogFormat string
+
+	// Debug mode (forces the debug log level)
 	Debug bool
 }
 
@@ -51,6 +252,15 @@ const (
 	CAKE = "0x0E09FaBB73Bd3Ade0a17ECC321fD13a19e81cE82"
 	DOGE = "0xbA2aE424d960c26247Dd6c32edC70B295c744C43"
 	SHIB = "0x2859e4544C4bB03966803b044A93563Bd2D0DD4D"
+	BSW  = "0x965F527D9159dCe6288a2219DB51fc6Eef120dD1"
+	BTCB = "0x7130d2A12B9BCbFAe4f2634d864A1Ee1Ce3Ead9c"
+	ETH  = "0x2170Ed0880ac9A755fd29B2688956BD959F933F8"
+	ADA  = "0x3EE2200Efb3400fAbB9AacF31297cBdD1d435D47"
+	DOT  = "0x7083609fCE4d1d8Dc0C979AAb8c869Ea2C873402"
+	XRP  = "0x1D2F0da169ceB9fC7B3144628dB156f3F6c60dBE"
+	LINK = "0xF8A0BF9cF54Bb92F17374d9e9A321E6a111a51bD"
+
+	MATIC = "0xCC42724C6683B7E57334c4E856f4c9965ED682bD"
 
 	// DEX Routers
 	PancakeswapRouter = "0x10ED43C718714eb63d5aA57B78B54704E256024E"
@@ -59,8 +269,50 @@ const (
 	// DEX Factories
 	PancakeswapFactory = "0xcA143Ce32Fe78f1f7019d7d551a6402fC5350c73"
 	BiswapFactory      = "0x858E3312ed3A876947EA49d572A7C42DE08af7EE"
+
+	// PancakeSwap V3 QuoterV2
+	PancakeswapV3Quoter = "0xB048Bbc1Ee6b733FFfCFb9e9CeF7375518e25997"
+
+	// Multicall3, deployed at the same address on every chain
+	Multicall3 = "0xcA11bde05977b3631167028862bE2a173976CA11"
+)
+
+// Scan periods returned by ScanPeriodFor
+const (
+	ScanPeriodPeak     = "peak_hours"
+	ScanPeriodLow      = "low_activity"
+	ScanPeriodStandard = "standard"
+)
+
+// Scan strategies selectable with STRATEGIES
+const (
+	StrategyTriangular = "triangular"
+	StrategyDirect     = "direct"
 )
 
+// HourRange is an inclusive range of UTC hours. A range whose start is after
+// its end wraps around midnight, e.g. 22-2.
+type HourRange struct {
+	Start int
+	End   int
+}
+
+// Contains reports whether the hour falls inside the range
+func (r HourRange) Contains(hour int) bool {
+	if r.Start <= r.End {
+		return hour >= r.Start && hour <= r.End
+	}
+	return hour >= r.Start || hour <= r.End
+}
+
+// String formats the range the same way it is configured
+func (r HourRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// PairCategories lists the pair categories used by the enhanced scanner
+var PairCategories = []string{"meme", "volatile", "established", "stable", "unknown"}
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
 	// Load .env file if it exists
@@ -71,16 +323,109 @@ func LoadConfig() *Config {
 	// Create config with defaults
 	cfg := &Config{
 		// Default values
-		GasLimit:       600000,
-		GasPrice:       5000000000, // 5 Gwei
-		MinProfit:      0.005,      // 0.5%
-		MaxSlippage:    0.02,       // 2%
-		CooldownPeriod: 30,         // 30 seconds
-		Debug:          false,
+		GasLimit:        600000,
+		GasPrice:        5000000000,        // 5 Gwei
+		MinProfit:       0.005,             // 0.5%
+		MaxSlippage:     0.02,              // 2%
+		CooldownPeriod:  30,                // 30 seconds
+		MaxPriceImpact:  0.03,              // 3%
+		MinReserveWBNB:  10,                // 10 WBNB per pool side
+		MinLiquidityUSD: 50000,             // $50k TVL per pool
+		SwapDeadline:    300 * time.Second, // 5 minutes
+		ReceiptTimeout:  90 * time.Second,  // 1.5 minutes
+		PlatformFeeBps:  1000,              // 10%
+		Debug:           false,
+
+		ConfirmTimeout: 30 * time.Second,
+		ScanTimeout:    60 * time.Second,
+
+		MaxGasPriceGwei:       20,
+		GasPriceBufferPercent: 20,
+		GasLimitBufferPercent: 20,
+		GasBumpPercent:        10, // the minimum most nodes accept to replace a transaction
+		GasBumpRetries:        3,
+		MaxPendingTx:          1,
+		PairFailureCooldown:   5 * time.Minute,
+		WalletCheckInterval:   5 * time.Minute,
+		PairRefreshInterval:   time.Hour,
+		MinNativeReserveBNB:   0.01,
+		MaxPoolFractionBps:    100, // 1% of a pool's reserve
+		Strategies:            []string{StrategyTriangular},
+		BaseTokens:            []string{"WBNB"},
+
+		PrefilterTolerance: 0.005, // 0.5%
+		PancakeswapFeeBps:  25,    // 0.25%
+		BiswapFeeBps:       20,    // 0.2%, 0.1% on reduced-fee pools
+
+		MinNetProfitWBNB:    0.001,
+		ShutdownGracePeriod: 120 * time.Second,
+		ExecutionStateFile:  getEnv("EXECUTION_STATE_FILE", "execution_state.json"),
+		FailedRPCFile:       getEnv("FAILED_RPC_FILE", "failed_rpcs.json"),
+		PrivateTxURL:        getEnv("PRIVATE_TX_URL", ""),
+		PrivateTxMethod:     getEnv("PRIVATE_TX_METHOD", "eth_sendRawTransaction"),
+
+		MempoolWSURL:        getEnv("MEMPOOL_WS_URL", ""),
+		MempoolMinSwapWBNB:  5,
+		MempoolPairCooldown: 10 * time.Second,
+
+		ArchiveRPCURL: getEnv("ARCHIVE_RPC_URL", ""),
+
+		OpportunityLog: getEnv("OPPORTUNITY_LOG", ""),
+
+		HealthCheckInterval: 60 * time.Second,
+		HealthCheckTimeout:  5 * time.Second,
+		MaxRetries:          3,
+		RetryBaseDelay:      2 * time.Second,
+		RetryMaxDelay:       30 * time.Second,
+
+		HealthAddr:  getEnv("HEALTH_ADDR", ""),
+		MaxBlockLag: 30 * time.Second,
+
+		RateLimitBackoff:         10 * time.Second,
+		RateLimitSwitchThreshold: 3,
+
+		V3FeeTiers: []uint32{100, 500, 2500, 10000}, // 0.01%, 0.05%, 0.25%, 1%
+
+		GasReserveBNB: 0.01,
+
+		CategoryMinProfit: map[string]float64{
+			"meme":        0.005, // 0.5% for meme coins (higher volatility expected)
+			"volatile":    0.003, // 0.3% for volatile tokens
+			"established": 0.002, // 0.2% for established tokens
+			"stable":      0.001, // 0.1% for stable pairs
+			"unknown":     0.002,
+		},
+		CategoryGasAdjustment: map[string]float64{
+			"meme":        0.0015, // 0.15% - meme coins may have higher gas costs
+			"volatile":    0.0012, // 0.12%
+			"established": 0.0010, // 0.10%
+			"stable":      0.0008, // 0.08%
+			"unknown":     0.0010,
+		},
+		CategoryMaxSlippage: make(map[string]float64),
+
+		MinOutputAmount:  0.000001,
+		MinOutputAmounts: make(map[string]float64),
+
+		PeakHours: []HourRange{{13, 16}, {21, 23}}, // Asia and US sessions
+		LowHours:  []HourRange{{2, 8}},
 	}
 
-	// Load required values
-	cfg.PrivateKey = getEnvRequired("PRIVATE_KEY")
+	// Load wallet credentials (validated in ValidateConfig)
+	cfg.PrivateKey = getEnv("PRIVATE_KEY", "")
+	cfg.KeystorePath = getEnv("KEYSTORE_PATH", "")
+	cfg.KeystorePassword = getEnv("KEYSTORE_PASSWORD", "")
+	cfg.KeystorePasswordFile = getEnv("KEYSTORE_PASSWORD_FILE", "")
+	cfg.SignerURL = getEnv("SIGNER_URL", "")
+	cfg.SignerAddress = getEnv("SIGNER_ADDRESS", "")
+	cfg.SignerMethod = getEnv("SIGNER_METHOD", "account_signTransaction")
+	for i := 1; ; i++ {
+		key := getEnv(fmt.Sprintf("PRIVATE_KEY_%d", i), "")
+		if key == "" {
+			break
+		}
+		cfg.PrivateKeys = append(cfg.PrivateKeys, key)
+	}
 
 	// Load RPC URLs - check both BSC_RPC_URL and BSCRPCURL for compatibility
 	cfg.BSCRPCURL = getEnv("BSC_RPC_URL", getEnv("BSCRPCURL", ""))
@@ -99,6 +444,15 @@ func LoadConfig() *Config {
 
 	// Load optional contract
 	cfg.FlashArbContract = getEnv("FLASH_ARB_CONTRACT", "")
+	cfg.AllowManualArbitrage = strings.ToLower(getEnv("ALLOW_MANUAL_ARBITRAGE", "false")) == "true"
+
+	// Load trade confirmation, e.g. CONFIRM_TRADES=true CONFIRM_TIMEOUT_SECONDS=20
+	cfg.ConfirmTrades = strings.ToLower(getEnv("CONFIRM_TRADES", "false")) == "true"
+	if timeout := getEnv("CONFIRM_TIMEOUT_SECONDS", ""); timeout != "" {
+		if parsed, err := strconv.Atoi(timeout); err == nil {
+			cfg.ConfirmTimeout = time.Duration(parsed) * time.Second
+		}
+	}
 
 	// Load gas settings
 	if gasLimit := getEnv("GAS_LIMIT", ""); gasLimit != "" {
@@ -113,6 +467,85 @@ func LoadConfig() *Config {
 		}
 	}
 
+	if maxGasPrice := getEnv("MAX_GAS_PRICE_GWEI", ""); maxGasPrice != "" {
+		if parsed, err := strconv.ParseFloat(maxGasPrice, 64); err == nil {
+			cfg.MaxGasPriceGwei = parsed
+		}
+	}
+
+	if buffer := getEnv("GAS_PRICE_BUFFER_PERCENT", ""); buffer != "" {
+		if parsed, err := strconv.Atoi(buffer); err == nil {
+			cfg.GasPriceBufferPercent = parsed
+		}
+	}
+
+	if buffer := getEnv("GAS_LIMIT_BUFFER_PERCENT", ""); buffer != "" {
+		if parsed, err := strconv.Atoi(buffer); err == nil {
+			cfg.GasLimitBufferPercent = parsed
+		}
+	}
+
+	if bump := getEnv("GAS_BUMP_PERCENT", ""); bump != "" {
+		if parsed, err := strconv.Atoi(bump); err == nil {
+			cfg.GasBumpPercent = parsed
+		}
+	}
+
+	if retries := getEnv("GAS_BUMP_RETRIES", ""); retries != "" {
+		if parsed, err := strconv.Atoi(retries); err == nil {
+			cfg.GasBumpRetries = parsed
+		}
+	}
+
+	if maxPending := getEnv("MAX_PENDING_TX", ""); maxPending != "" {
+		if parsed, err := strconv.Atoi(maxPending); err == nil {
+			cfg.MaxPendingTx = parsed
+		}
+	}
+
+	if reserve := getEnv("MIN_NATIVE_RESERVE_BNB", ""); reserve != "" {
+		if parsed, err := strconv.ParseFloat(reserve, 64); err == nil {
+			cfg.MinNativeReserveBNB = parsed
+		}
+	}
+
+	// Load the failed-pair cooldown, e.g. PAIR_FAILURE_COOLDOWN_SECONDS=600
+	if cooldown := getEnv("PAIR_FAILURE_COOLDOWN_SECONDS", ""); cooldown != "" {
+		if parsed, err := strconv.Atoi(cooldown); err == nil {
+			cfg.PairFailureCooldown = time.Duration(parsed) * time.Second
+		}
+	}
+
+	// Load the wallet recheck interval, e.g. WALLET_CHECK_INTERVAL_SECONDS=60
+	if interval := getEnv("WALLET_CHECK_INTERVAL_SECONDS", ""); interval != "" {
+		if parsed, err := strconv.Atoi(interval); err == nil {
+			cfg.WalletCheckInterval = time.Duration(parsed) * time.Second
+		}
+	}
+
+	// Load the pair re-verification interval, e.g. PAIR_REFRESH_INTERVAL_SECONDS=1800
+	if interval := getEnv("PAIR_REFRESH_INTERVAL_SECONDS", ""); interval != "" {
+		if parsed, err := strconv.Atoi(interval); err == nil {
+			cfg.PairRefreshInterval = time.Duration(parsed) * time.Second
+		}
+	}
+
+	// Load the scan strategies, e.g. STRATEGIES=triangular,direct
+	if strategies := getEnv("STRATEGIES", ""); strategies != "" {
+		cfg.Strategies = parseStrategies(strategies)
+	}
+
+	// Load the base tokens, e.g. BASE_TOKENS=WBNB,BUSD,USDT
+	if baseTokens := getEnv("BASE_TOKENS", ""); baseTokens != "" {
+		cfg.BaseTokens = parseBaseTokens(baseTokens)
+	}
+
+	if maxPairs := getEnv("MAX_PAIRS_PER_SCAN", ""); maxPairs != "" {
+		if parsed, err := strconv.Atoi(maxPairs); err == nil {
+			cfg.MaxPairsPerScan = parsed
+		}
+	}
+
 	// Load trading parameters
 	if minProfit := getEnv("MIN_PROFIT", ""); minProfit != "" {
 		if parsed, err := strconv.ParseFloat(minProfit, 64); err == nil {
@@ -132,122 +565,1159 @@ func LoadConfig() *Config {
 		}
 	}
 
-	// Load debug flag
-	if debug := getEnv("DEBUG", ""); debug != "" {
-		cfg.Debug = strings.ToLower(debug) == "true"
+	if maxImpact := getEnv("MAX_PRICE_IMPACT", ""); maxImpact != "" {
+		if parsed, err := strconv.ParseFloat(maxImpact, 64); err == nil {
+			cfg.MaxPriceImpact = parsed
+		}
 	}
 
-	return cfg
-}
+	if minReserve := getEnv("MIN_RESERVE_WBNB", ""); minReserve != "" {
+		if parsed, err := strconv.ParseFloat(minReserve, 64); err == nil {
+			cfg.MinReserveWBNB = parsed
+		}
+	}
 
-// ValidateConfig validates the configuration
-func (c *Config) ValidateConfig() error {
-	var errors []string
+	if minLiquidity := getEnv("MIN_LIQUIDITY_USD", ""); minLiquidity != "" {
+		if parsed, err := strconv.ParseFloat(minLiquidity, 64); err == nil {
+			cfg.MinLiquidityUSD = parsed
+		}
+	}
 
-	// Validate private key
-	if c.PrivateKey == "" {
-		errors = append(errors, "PRIVATE_KEY is required")
-	} else if len(c.PrivateKey) != 64 {
-		errors = append(errors, "PRIVATE_KEY must be 64 characters (without 0x prefix)")
+	if poolFraction := getEnv("MAX_POOL_FRACTION_BPS", ""); poolFraction != "" {
+		if parsed, err := strconv.ParseInt(poolFraction, 10, 64); err == nil {
+			cfg.MaxPoolFractionBps = parsed
+		}
 	}
 
-	// Validate at least one RPC URL
-	if c.countConfiguredRPCs() == 0 {
-		errors = append(errors, "at least one BSC_RPC_URL must be configured")
+	if minNet := getEnv("MIN_NET_PROFIT_WBNB", ""); minNet != "" {
+		if parsed, err := strconv.ParseFloat(minNet, 64); err == nil {
+			cfg.MinNetProfitWBNB = parsed
+		}
 	}
 
-	// Validate gas settings
-	if c.GasLimit < 21000 {
-		errors = append(errors, "GAS_LIMIT must be at least 21000")
+	if deadline := getEnv("SWAP_DEADLINE_SECONDS", ""); deadline != "" {
+		if parsed, err := strconv.Atoi(deadline); err == nil {
+			cfg.SwapDeadline = time.Duration(parsed) * time.Second
+		}
 	}
 
-	if c.GasPrice < 1000000000 { // 1 Gwei minimum
-		errors = append(errors, "GAS_PRICE must be at least 1 Gwei (1000000000)")
+	if grace := getEnv("SHUTDOWN_GRACE_SECONDS", ""); grace != "" {
+		if parsed, err := strconv.Atoi(grace); err == nil {
+			cfg.ShutdownGracePeriod = time.Duration(parsed) * time.Second
+		}
 	}
 
-	// Validate trading parameters
-	if c.MinProfit < 0.001 || c.MinProfit > 0.1 {
-		errors = append(errors, "MIN_PROFIT must be between 0.001 (0.1%) and 0.1 (10%)")
+	if receiptTimeout := getEnv("RECEIPT_TIMEOUT_SECONDS", ""); receiptTimeout != "" {
+		if parsed, err := strconv.Atoi(receiptTimeout); err == nil {
+			cfg.ReceiptTimeout = time.Duration(parsed) * time.Second
+		}
 	}
 
-	if c.MaxSlippage < 0.005 || c.MaxSlippage > 0.1 {
-		errors = append(errors, "MAX_SLIPPAGE must be between 0.005 (0.5%) and 0.1 (10%)")
+	if scanTimeout := getEnv("SCAN_TIMEOUT_SECONDS", ""); scanTimeout != "" {
+		if parsed, err := strconv.Atoi(scanTimeout); err == nil {
+			cfg.ScanTimeout = time.Duration(parsed) * time.Second
+		}
 	}
 
-	if c.CooldownPeriod < 5 || c.CooldownPeriod > 300 {
-		errors = append(errors, "COOLDOWN_PERIOD must be between 5 and 300 seconds")
+	if jitter := getEnv("SCAN_JITTER_PERCENT", ""); jitter != "" {
+		if parsed, err := strconv.Atoi(jitter); err == nil {
+			cfg.ScanJitterPercent = parsed
+		}
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("configuration errors: %s", strings.Join(errors, "; "))
+	if interval := getEnv("HEALTH_CHECK_INTERVAL_SECONDS", ""); interval != "" {
+		if parsed, err := strconv.Atoi(interval); err == nil {
+			cfg.HealthCheckInterval = time.Duration(parsed) * time.Second
+		}
 	}
 
-	return nil
-}
+	if timeout := getEnv("HEALTH_CHECK_TIMEOUT_SECONDS", ""); timeout != "" {
+		if parsed, err := strconv.Atoi(timeout); err == nil {
+			cfg.HealthCheckTimeout = time.Duration(parsed) * time.Second
+		}
+	}
 
-// countConfiguredRPCs counts how many RPC URLs are configured
-func (c *Config) countConfiguredRPCs() int {
-	count := 0
-	rpcs := []string{
-		c.BSCRPCURL, c.BSCRPCURL1, c.BSCRPCURL2, c.BSCRPCURL3,
-		c.BSCRPCURL4, c.BSCRPCURL5, c.BSCRPCURL6, c.BSCRPCURL7, c.BSCRPCURL8,
+	if lag := getEnv("MAX_BLOCK_LAG_SECONDS", ""); lag != "" {
+		if parsed, err := strconv.Atoi(lag); err == nil {
+			cfg.MaxBlockLag = time.Duration(parsed) * time.Second
+		}
 	}
 
-	for _, rpc := range rpcs {
-		if rpc != "" {
-			count++
+	if retries := getEnv("MAX_RETRIES", ""); retries != "" {
+		if parsed, err := strconv.Atoi(retries); err == nil {
+			cfg.MaxRetries = parsed
 		}
 	}
 
-	return count
-}
+	if delay := getEnv("RETRY_BASE_DELAY_MS", ""); delay != "" {
+		if parsed, err := strconv.Atoi(delay); err == nil {
+			cfg.RetryBaseDelay = time.Duration(parsed) * time.Millisecond
+		}
+	}
 
-// GetAllRPCURLs returns all configured RPC URLs
-func (c *Config) GetAllRPCURLs() []string {
-	var urls []string
-	rpcs := []string{
-		c.BSCRPCURL, c.BSCRPCURL1, c.BSCRPCURL2, c.BSCRPCURL3,
-		c.BSCRPCURL4, c.BSCRPCURL5, c.BSCRPCURL6, c.BSCRPCURL7, c.BSCRPCURL8,
+	if maxDelay := getEnv("RETRY_MAX_DELAY_MS", ""); maxDelay != "" {
+		if parsed, err := strconv.Atoi(maxDelay); err == nil {
+			cfg.RetryMaxDelay = time.Duration(parsed) * time.Millisecond
+		}
 	}
 
-	for _, rpc := range rpcs {
-		if rpc != "" {
-			urls = append(urls, rpc)
+	if backoff := getEnv("RATE_LIMIT_BACKOFF_SECONDS", ""); backoff != "" {
+		if parsed, err := strconv.Atoi(backoff); err == nil {
+			cfg.RateLimitBackoff = time.Duration(parsed) * time.Second
 		}
 	}
 
-	return urls
-}
+	if threshold := getEnv("RATE_LIMIT_SWITCH_THRESHOLD", ""); threshold != "" {
+		if parsed, err := strconv.Atoi(threshold); err == nil {
+			cfg.RateLimitSwitchThreshold = parsed
+		}
+	}
 
-// LogConfiguration prints the current configuration (without sensitive data)
-func (c *Config) LogConfiguration() {
-	log.Println("======================================")
-	log.Println("⚙️ Configuration Summary")
-	log.Println("======================================")
-	log.Printf("🌐 RPC endpoints: %d configured", c.countConfiguredRPCs())
-	log.Printf("⛽ Gas limit: %d", c.GasLimit)
-	log.Printf("💰 Gas price: %.2f Gwei", float64(c.GasPrice)/1e9)
-	log.Printf("📊 Min profit: %.2f%%", c.MinProfit*100)
-	log.Printf("🎯 Max slippage: %.2f%%", c.MaxSlippage*100)
-	log.Printf("⏰ Scan interval: %d seconds", c.CooldownPeriod)
-	log.Printf("🔍 Debug mode: %v", c.Debug)
+	// Load RPC call budgets, e.g. RPC_CALLS_PER_SECOND=8 RPC_CALL_LIMITS=ankr.com=25
+	if perSecond := getEnv("RPC_CALLS_PER_SECOND", ""); perSecond != "" {
+		if parsed, err := strconv.ParseFloat(perSecond, 64); err == nil {
+			cfg.RPCCallsPerSecond = parsed
+		}
+	}
 
-	if c.FlashArbContract != "" {
-		log.Printf("⚡ Flash contract: %s", c.FlashArbContract)
-	} else {
-		log.Printf("⚡ Flash contract: Not configured (manual arbitrage only)")
+	if limits := getEnv("RPC_CALL_LIMITS", ""); limits != "" {
+		if parsed, err := parseRPCCallLimits(limits); err == nil {
+			cfg.RPCCallLimits = parsed
+		} else {
+			log.Printf("⚠️ Ignoring invalid RPC_CALL_LIMITS: %v", err)
+		}
 	}
 
-	log.Println("======================================")
-}
+	if platformFee := getEnv("PLATFORM_FEE_BPS", ""); platformFee != "" {
+		if parsed, err := strconv.Atoi(platformFee); err == nil {
+			cfg.PlatformFeeBps = parsed
+		}
+	}
 
-// Helper functions
+	// Load per-category thresholds, e.g. MIN_PROFIT_MEME, GAS_ADJ_MEME and
+	// MAX_SLIPPAGE_MEME
+	for _, category := range PairCategories {
+		suffix := strings.ToUpper(category)
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+		if minProfit := getEnv("MIN_PROFIT_"+suffix, ""); minProfit != "" {
+			if parsed, err := strconv.ParseFloat(minProfit, 64); err == nil {
+				cfg.CategoryMinProfit[category] = parsed
+			}
+		}
+
+		if gasAdj := getEnv("GAS_ADJ_"+suffix, ""); gasAdj != "" {
+			if parsed, err := strconv.ParseFloat(gasAdj, 64); err == nil {
+				cfg.CategoryGasAdjustment[category] = parsed
+			}
+		}
+
+		if slippage := getEnv("MAX_SLIPPAGE_"+suffix, ""); slippage != "" {
+			if parsed, err := strconv.ParseFloat(slippage, 64); err == nil {
+				cfg.CategoryMaxSlippage[category] = parsed
+			}
+		}
 	}
-	return defaultValue
+
+	// Load the quote output floors, e.g. MIN_OUTPUT_AMOUNT=0.000001 MIN_OUTPUT_BTCB=0.0000001
+	if minOutput := getEnv("MIN_OUTPUT_AMOUNT", ""); minOutput != "" {
+		if parsed, err := strconv.ParseFloat(minOutput, 64); err == nil {
+			cfg.MinOutputAmount = parsed
+		}
+	}
+	for symbol := range Tokens {
+		if minOutput := getEnv("MIN_OUTPUT_"+symbol, ""); minOutput != "" {
+			if parsed, err := strconv.ParseFloat(minOutput, 64); err == nil {
+				cfg.MinOutputAmounts[symbol] = parsed
+			}
+		}
+	}
+
+	// Load per-pair thresholds, e.g. PAIR_OVERRIDES=WBNB-SHIB-USDT:0.004:0.002
+	if overrides := getEnv("PAIR_OVERRIDES", ""); overrides != "" {
+		if parsed, err := parsePairOverrides(overrides); err == nil {
+			cfg.PairOverrides = parsed
+		} else {
+			log.Printf("⚠️ Ignoring invalid PAIR_OVERRIDES: %v", err)
+		}
+	}
+
+	// Load activity windows, e.g. PEAK_HOURS=13-16,21-23
+	if peakHours := getEnv("PEAK_HOURS", ""); peakHours != "" {
+		if parsed, err := parseHourRanges(peakHours); err == nil {
+			cfg.PeakHours = parsed
+		} else {
+			log.Printf("⚠️ Ignoring invalid PEAK_HOURS: %v", err)
+		}
+	}
+
+	if lowHours := getEnv("LOW_HOURS", ""); lowHours != "" {
+		if parsed, err := parseHourRanges(lowHours); err == nil {
+			cfg.LowHours = parsed
+		} else {
+			log.Printf("⚠️ Ignoring invalid LOW_HOURS: %v", err)
+		}
+	}
+
+	// Load V3 quoting, e.g. ENABLE_V3=true V3_FEE_TIERS=500,2500 LOG_V3_ROUTES=true
+	if enableV3 := getEnv("ENABLE_V3", ""); enableV3 != "" {
+		cfg.EnableV3 = strings.ToLower(enableV3) == "true"
+	}
+
+	if feeTiers := getEnv("V3_FEE_TIERS", ""); feeTiers != "" {
+		if parsed, err := parseFeeTiers(feeTiers); err == nil {
+			cfg.V3FeeTiers = parsed
+		} else {
+			log.Printf("⚠️ Ignoring invalid V3_FEE_TIERS: %v", err)
+		}
+	}
+
+	if logV3Routes := getEnv("LOG_V3_ROUTES", ""); logV3Routes != "" {
+		cfg.LogV3Routes = strings.ToLower(logV3Routes) == "true"
+	}
+
+	// Load the reserve pre-filter, e.g. RESERVE_PREFILTER=true PREFILTER_TOLERANCE=0.005
+	if prefilter := getEnv("RESERVE_PREFILTER", ""); prefilter != "" {
+		cfg.ReservePrefilter = strings.ToLower(prefilter) == "true"
+	}
+
+	if tolerance := getEnv("PREFILTER_TOLERANCE", ""); tolerance != "" {
+		if parsed, err := strconv.ParseFloat(tolerance, 64); err == nil {
+			cfg.PrefilterTolerance = parsed
+		}
+	}
+
+	// Load exchange swap fees, e.g. PANCAKESWAP_FEE_BPS=25 BISWAP_FEE_BPS=20
+	if fee := getEnv("PANCAKESWAP_FEE_BPS", ""); fee != "" {
+		if parsed, err := strconv.ParseInt(fee, 10, 64); err == nil {
+			cfg.PancakeswapFeeBps = parsed
+		}
+	}
+
+	if fee := getEnv("BISWAP_FEE_BPS", ""); fee != "" {
+		if parsed, err := strconv.ParseInt(fee, 10, 64); err == nil {
+			cfg.BiswapFeeBps = parsed
+		}
+	}
+
+	// Load mempool trigger, e.g. MEMPOOL_MIN_SWAP_WBNB=10 MEMPOOL_PAIR_COOLDOWN_SECONDS=5
+	if minSwap := getEnv("MEMPOOL_MIN_SWAP_WBNB", ""); minSwap != "" {
+		if parsed, err := strconv.ParseFloat(minSwap, 64); err == nil {
+			cfg.MempoolMinSwapWBNB = parsed
+		}
+	}
+
+	if cooldown := getEnv("MEMPOOL_PAIR_COOLDOWN_SECONDS", ""); cooldown != "" {
+		if parsed, err := strconv.Atoi(cooldown); err == nil {
+			cfg.MempoolPairCooldown = time.Duration(parsed) * time.Second
+		}
+	}
+
+	// Load reserve watching, e.g. WATCH_RESERVES=true
+	if watch := getEnv("WATCH_RESERVES", ""); watch != "" {
+		cfg.WatchReserves = strings.ToLower(watch) == "true"
+	}
+
+	// Load auto-wrap, e.g. AUTO_WRAP_THRESHOLD_WBNB=0.1 AUTO_WRAP_TARGET_WBNB=0.5
+	if threshold := getEnv("AUTO_WRAP_THRESHOLD_WBNB", ""); threshold != "" {
+		if parsed, err := strconv.ParseFloat(threshold, 64); err == nil {
+			cfg.AutoWrapThresholdWBNB = parsed
+		}
+	}
+
+	if target := getEnv("AUTO_WRAP_TARGET_WBNB", ""); target != "" {
+		if parsed, err := strconv.ParseFloat(target, 64); err == nil {
+			cfg.AutoWrapTargetWBNB = parsed
+		}
+	}
+
+	if gasReserve := getEnv("GAS_RESERVE_BNB", ""); gasReserve != "" {
+		if parsed, err := strconv.ParseFloat(gasReserve, 64); err == nil {
+			cfg.GasReserveBNB = parsed
+		}
+	}
+
+	// Load logging, e.g. LOG_LEVEL=warn LOG_FORMAT=json
+	cfg.LogLevel = strings.ToLower(getEnv("LOG_LEVEL", "info"))
+	cfg.LogFormat = strings.ToLower(getEnv("LOG_FORMAT", "console"))
+
+	// Load debug flag
+	if debug := getEnv("DEBUG", ""); debug != "" {
+		cfg.Debug = strings.ToLower(debug) == "true"
+	}
+
+	return cfg
+}
+
+// EffectiveLogLevel returns the configured log level, or debug when DEBUG is set
+func (c *Config) EffectiveLogLevel() string {
+	if c.Debug {
+		return "debug"
+	}
+	return c.LogLevel
+}
+
+// LocalPrivateKeys returns PRIVATE_KEY, if set, followed by PRIVATE_KEY_1..N
+func (c *Config) LocalPrivateKeys() []string {
+	var keys []string
+	if c.PrivateKey != "" {
+		keys = append(keys, c.PrivateKey)
+	}
+	return append(keys, c.PrivateKeys...)
+}
+
+// ValidateConfig validates the configuration
+func (c *Config) ValidateConfig() error {
+	var errors []string
+
+	// Validate wallet credentials: exactly one of PRIVATE_KEY (with or without
+	// PRIVATE_KEY_1..N), KEYSTORE_PATH or SIGNER_URL
+	localKeys := c.PrivateKey
+	if len(c.PrivateKeys) > 0 {
+		localKeys = "set"
+	}
+	credentialSources := 0
+	for _, source := range []string{localKeys, c.KeystorePath, c.SignerURL} {
+		if source != "" {
+			credentialSources++
+		}
+	}
+
+	switch {
+	case credentialSources == 0:
+		errors = append(errors, "one of PRIVATE_KEY, KEYSTORE_PATH or SIGNER_URL is required")
+	case credentialSources > 1:
+		errors = append(errors, "set only one of PRIVATE_KEY (or PRIVATE_KEY_1..N), KEYSTORE_PATH or SIGNER_URL")
+	case c.SignerURL != "" && !common.IsHexAddress(c.SignerAddress):
+		errors = append(errors, "SIGNER_URL requires SIGNER_ADDRESS to be a valid address")
+	case c.PrivateKey != "" && len(c.PrivateKey) != 64:
+		errors = append(errors, "PRIVATE_KEY must be 64 characters (without 0x prefix)")
+	case c.KeystorePath != "" && (c.KeystorePassword == "") == (c.KeystorePasswordFile == ""):
+		errors = append(errors, "KEYSTORE_PATH requires exactly one of KEYSTORE_PASSWORD or KEYSTORE_PASSWORD_FILE")
+	}
+	for i, key := range c.PrivateKeys {
+		if len(key) != 64 {
+			errors = append(errors, fmt.Sprintf("PRIVATE_KEY_%d must be 64 characters (without 0x prefix)", i+1))
+		}
+	}
+
+	// A malformed contract would parse as the zero address and silently
+	// disable the flash path
+	if c.FlashArbContract != "" {
+		if !common.IsHexAddress(c.FlashArbContract) {
+			errors = append(errors, fmt.Sprintf("FLASH_ARB_CONTRACT %q is not a valid address", c.FlashArbContract))
+		} else if common.HexToAddress(c.FlashArbContract) == (common.Address{}) {
+			errors = append(errors, "FLASH_ARB_CONTRACT cannot be the zero address")
+		}
+	}
+
+	// Validate at least one RPC URL
+	if c.countConfiguredRPCs() == 0 {
+		errors = append(errors, "at least one BSC_RPC_URL must be configured")
+	}
+
+	// Validate RPC URL format before the bot tries to dial them
+	rpcFields := []struct {
+		name  string
+		value string
+	}{
+		{"BSC_RPC_URL", c.BSCRPCURL}, {"BSC_RPC_URL1", c.BSCRPCURL1}, {"BSC_RPC_URL2", c.BSCRPCURL2},
+		{"BSC_RPC_URL3", c.BSCRPCURL3}, {"BSC_RPC_URL4", c.BSCRPCURL4}, {"BSC_RPC_URL5", c.BSCRPCURL5},
+		{"BSC_RPC_URL6", c.BSCRPCURL6}, {"BSC_RPC_URL7", c.BSCRPCURL7}, {"BSC_RPC_URL8", c.BSCRPCURL8},
+	}
+	for _, field := range rpcFields {
+		if field.value == "" {
+			continue
+		}
+		if err := validateRPCURL(field.value); err != nil {
+			errors = append(errors, fmt.Sprintf("%s %q is invalid: %v", field.name, field.value, err))
+		}
+	}
+
+	if c.PrivateTxURL != "" {
+		if err := validateRPCURL(c.PrivateTxURL); err != nil {
+			errors = append(errors, fmt.Sprintf("PRIVATE_TX_URL is invalid: %v", err))
+		}
+	}
+
+	if c.ArchiveRPCURL != "" {
+		if err := validateRPCURL(c.ArchiveRPCURL); err != nil {
+			errors = append(errors, fmt.Sprintf("ARCHIVE_RPC_URL is invalid: %v", err))
+		}
+	}
+
+	if c.MempoolWSURL != "" {
+		if err := validateRPCURL(c.MempoolWSURL); err != nil {
+			errors = append(errors, fmt.Sprintf("MEMPOOL_WS_URL is invalid: %v", err))
+		} else if !strings.HasPrefix(c.MempoolWSURL, "ws") {
+			errors = append(errors, "MEMPOOL_WS_URL must be a ws:// or wss:// endpoint")
+		}
+	}
+
+	if c.WatchReserves && !hasWebsocketRPC(c.GetAllRPCURLs()) {
+		errors = append(errors, "WATCH_RESERVES needs at least one ws:// or wss:// RPC endpoint")
+	}
+
+	if c.MempoolMinSwapWBNB < 0 {
+		errors = append(errors, "MEMPOOL_MIN_SWAP_WBNB cannot be negative")
+	}
+
+	if c.MempoolPairCooldown < 0 {
+		errors = append(errors, "MEMPOOL_PAIR_COOLDOWN_SECONDS cannot be negative")
+	}
+
+	// Validate gas settings
+	if c.GasLimit < 21000 {
+		errors = append(errors, "GAS_LIMIT must be at least 21000")
+	}
+
+	if c.GasPrice < 1000000000 { // 1 Gwei minimum
+		errors = append(errors, "GAS_PRICE must be at least 1 Gwei (1000000000)")
+	}
+
+	if c.MaxGasPriceGwei < 0 {
+		errors = append(errors, "MAX_GAS_PRICE_GWEI cannot be negative")
+	} else if c.MaxGasPriceGwei > 0 && c.MaxGasPriceGwei*1e9 < float64(c.GasPrice) {
+		errors = append(errors, "MAX_GAS_PRICE_GWEI must be at least GAS_PRICE, or 0 to disable")
+	}
+
+	if c.ConfirmTrades && c.ConfirmTimeout <= 0 {
+		errors = append(errors, "CONFIRM_TIMEOUT_SECONDS must be positive when CONFIRM_TRADES is set")
+	}
+
+	if c.GasPriceBufferPercent < 0 || c.GasPriceBufferPercent > 100 {
+		errors = append(errors, "GAS_PRICE_BUFFER_PERCENT must be between 0 and 100")
+	}
+
+	if c.GasLimitBufferPercent < 0 || c.GasLimitBufferPercent > 100 {
+		errors = append(errors, "GAS_LIMIT_BUFFER_PERCENT must be between 0 and 100")
+	}
+
+	if c.GasBumpRetries > 0 && (c.GasBumpPercent < 10 || c.GasBumpPercent > 100) {
+		errors = append(errors, "GAS_BUMP_PERCENT must be between 10 and 100 (nodes reject smaller replacements)")
+	}
+
+	if c.GasBumpRetries < 0 || c.GasBumpRetries > 10 {
+		errors = append(errors, "GAS_BUMP_RETRIES must be between 0 and 10")
+	}
+
+	if c.MaxPendingTx < 0 {
+		errors = append(errors, "MAX_PENDING_TX cannot be negative")
+	}
+
+	if c.MinNativeReserveBNB < 0 {
+		errors = append(errors, "MIN_NATIVE_RESERVE_BNB cannot be negative")
+	}
+
+	if c.PairFailureCooldown < 0 {
+		errors = append(errors, "PAIR_FAILURE_COOLDOWN_SECONDS cannot be negative")
+	}
+
+	if c.WalletCheckInterval < 0 {
+		errors = append(errors, "WALLET_CHECK_INTERVAL_SECONDS cannot be negative")
+	}
+
+	if c.PairRefreshInterval < 0 {
+		errors = append(errors, "PAIR_REFRESH_INTERVAL_SECONDS cannot be negative")
+	}
+
+	if c.MaxPairsPerScan < 0 {
+		errors = append(errors, "MAX_PAIRS_PER_SCAN cannot be negative")
+	}
+
+	if len(c.Strategies) == 0 {
+		errors = append(errors, "STRATEGIES must name at least one strategy")
+	}
+	for _, strategy := range c.Strategies {
+		switch strategy {
+		case StrategyTriangular, StrategyDirect:
+		default:
+			errors = append(errors, fmt.Sprintf("STRATEGIES entry %q must be %s or %s",
+				strategy, StrategyTriangular, StrategyDirect))
+		}
+	}
+
+	if len(c.BaseTokens) == 0 {
+		errors = append(errors, "BASE_TOKENS must name at least one token")
+	}
+	for _, symbol := range c.BaseTokens {
+		if _, known := Tokens[symbol]; !known {
+			errors = append(errors, fmt.Sprintf("BASE_TOKENS entry %q is not a known token", symbol))
+		}
+	}
+
+	// Validate trading parameters
+	if c.MinProfit < 0.001 || c.MinProfit > 0.1 {
+		errors = append(errors, "MIN_PROFIT must be between 0.001 (0.1%) and 0.1 (10%)")
+	}
+
+	if c.MaxSlippage < 0.005 || c.MaxSlippage > 0.1 {
+		errors = append(errors, "MAX_SLIPPAGE must be between 0.005 (0.5%) and 0.1 (10%)")
+	}
+
+	if c.CooldownPeriod < 5 || c.CooldownPeriod > 300 {
+		errors = append(errors, "COOLDOWN_PERIOD must be between 5 and 300 seconds")
+	}
+
+	if c.MaxPriceImpact <= 0 || c.MaxPriceImpact > 0.5 {
+		errors = append(errors, "MAX_PRICE_IMPACT must be between 0 and 0.5 (50%)")
+	}
+
+	if c.MinReserveWBNB < 0 {
+		errors = append(errors, "MIN_RESERVE_WBNB must not be negative")
+	}
+
+	if c.MaxPoolFractionBps < 0 || c.MaxPoolFractionBps > 10000 {
+		errors = append(errors, "MAX_POOL_FRACTION_BPS must be between 0 and 10000")
+	}
+
+	if c.MinLiquidityUSD < 0 {
+		errors = append(errors, "MIN_LIQUIDITY_USD must not be negative")
+	}
+
+	if c.MinNetProfitWBNB < 0 {
+		errors = append(errors, "MIN_NET_PROFIT_WBNB must not be negative")
+	}
+
+	if c.SwapDeadline < 10*time.Second || c.SwapDeadline > 600*time.Second {
+		errors = append(errors, "SWAP_DEADLINE_SECONDS must be between 10 and 600 seconds")
+	}
+
+	if c.ReceiptTimeout < 5*time.Second || c.ReceiptTimeout > 600*time.Second {
+		errors = append(errors, "RECEIPT_TIMEOUT_SECONDS must be between 5 and 600 seconds")
+	}
+
+	if c.ScanTimeout < 5*time.Second || c.ScanTimeout > 600*time.Second {
+		errors = append(errors, "SCAN_TIMEOUT_SECONDS must be between 5 and 600 seconds")
+	}
+
+	if c.ScanJitterPercent < 0 || c.ScanJitterPercent > 50 {
+		errors = append(errors, "SCAN_JITTER_PERCENT must be between 0 and 50")
+	}
+
+	for _, category := range PairCategories {
+		suffix := strings.ToUpper(category)
+
+		if minProfit := c.CategoryMinProfit[category]; minProfit < 0 || minProfit > 0.1 {
+			errors = append(errors, fmt.Sprintf("MIN_PROFIT_%s must be between 0 and 0.1 (10%%)", suffix))
+		}
+
+		if gasAdj := c.CategoryGasAdjustment[category]; gasAdj < 0 || gasAdj > 0.05 {
+			errors = append(errors, fmt.Sprintf("GAS_ADJ_%s must be between 0 and 0.05 (5%%)", suffix))
+		}
+
+		if slippage, set := c.CategoryMaxSlippage[category]; set && (slippage < 0.005 || slippage > 0.1) {
+			errors = append(errors, fmt.Sprintf("MAX_SLIPPAGE_%s must be between 0.005 (0.5%%) and 0.1 (10%%)", suffix))
+		}
+	}
+
+	if c.MinOutputAmount < 0 {
+		errors = append(errors, "MIN_OUTPUT_AMOUNT cannot be negative")
+	}
+	for symbol, minOutput := range c.MinOutputAmounts {
+		if minOutput < 0 {
+			errors = append(errors, fmt.Sprintf("MIN_OUTPUT_%s cannot be negative", symbol))
+		}
+	}
+
+	for name, override := range c.PairOverrides {
+		if override.MinProfit < 0 || override.MinProfit > 0.1 {
+			errors = append(errors, fmt.Sprintf("PAIR_OVERRIDES min profit for %s must be between 0 and 0.1 (10%%)", name))
+		}
+
+		if override.GasAdjustment < 0 || override.GasAdjustment > 0.05 {
+			errors = append(errors, fmt.Sprintf("PAIR_OVERRIDES gas adjustment for %s must be between 0 and 0.05 (5%%)", name))
+		}
+	}
+
+	for _, r := range append(append([]HourRange{}, c.PeakHours...), c.LowHours...) {
+		if r.Start < 0 || r.Start > 23 || r.End < 0 || r.End > 23 {
+			errors = append(errors, fmt.Sprintf("hour range %s must use hours between 0 and 23", r))
+		}
+	}
+
+	if c.PlatformFeeBps < 0 || c.PlatformFeeBps > 10000 {
+		errors = append(errors, "PLATFORM_FEE_BPS must be between 0 and 10000")
+	}
+
+	if c.HealthCheckInterval < 10*time.Second || c.HealthCheckInterval > 10*time.Minute {
+		errors = append(errors, "HEALTH_CHECK_INTERVAL_SECONDS must be between 10 and 600 seconds")
+	}
+
+	if c.HealthCheckTimeout < time.Second || c.HealthCheckTimeout > 60*time.Second {
+		errors = append(errors, "HEALTH_CHECK_TIMEOUT_SECONDS must be between 1 and 60 seconds")
+	}
+
+	if c.MaxBlockLag < 3*time.Second {
+		errors = append(errors, "MAX_BLOCK_LAG_SECONDS must be at least 3 seconds (one block)")
+	}
+
+	if c.MaxRetries < 1 || c.MaxRetries > 10 {
+		errors = append(errors, "MAX_RETRIES must be between 1 and 10")
+	}
+
+	if c.RetryBaseDelay < 100*time.Millisecond || c.RetryBaseDelay > 30*time.Second {
+		errors = append(errors, "RETRY_BASE_DELAY_MS must be between 100 and 30000 milliseconds")
+	}
+
+	if c.RetryMaxDelay < c.RetryBaseDelay || c.RetryMaxDelay > 5*time.Minute {
+		errors = append(errors, "RETRY_MAX_DELAY_MS must be at least RETRY_BASE_DELAY_MS and at most 300000 milliseconds")
+	}
+
+	if c.RateLimitBackoff < time.Second || c.RateLimitBackoff > 5*time.Minute {
+		errors = append(errors, "RATE_LIMIT_BACKOFF_SECONDS must be between 1 and 300 seconds")
+	}
+
+	if c.RateLimitSwitchThreshold < 1 || c.RateLimitSwitchThreshold > 20 {
+		errors = append(errors, "RATE_LIMIT_SWITCH_THRESHOLD must be between 1 and 20")
+	}
+
+	if c.RPCCallsPerSecond < 0 {
+		errors = append(errors, "RPC_CALLS_PER_SECOND cannot be negative")
+	}
+	for endpoint, perSecond := range c.RPCCallLimits {
+		if perSecond < 0 {
+			errors = append(errors, fmt.Sprintf("RPC_CALL_LIMITS for %s cannot be negative", endpoint))
+		}
+	}
+
+	if c.ShutdownGracePeriod < 0 || c.ShutdownGracePeriod > 30*time.Minute {
+		errors = append(errors, "SHUTDOWN_GRACE_SECONDS must be between 0 and 1800 seconds")
+	}
+
+	if c.PrefilterTolerance < 0 || c.PrefilterTolerance > 0.1 {
+		errors = append(errors, "PREFILTER_TOLERANCE must be between 0 and 0.1 (10%)")
+	}
+
+	if c.PancakeswapFeeBps < 0 || c.PancakeswapFeeBps > 1000 {
+		errors = append(errors, "PANCAKESWAP_FEE_BPS must be between 0 and 1000")
+	}
+
+	if c.BiswapFeeBps < 0 || c.BiswapFeeBps > 1000 {
+		errors = append(errors, "BISWAP_FEE_BPS must be between 0 and 1000")
+	}
+
+	if c.EnableV3 && len(c.V3FeeTiers) == 0 {
+		errors = append(errors, "V3_FEE_TIERS must list at least one fee tier when ENABLE_V3 is set")
+	}
+	if c.LogV3Routes && !c.EnableV3 {
+		errors = append(errors, "LOG_V3_ROUTES requires ENABLE_V3")
+	}
+	for _, fee := range c.V3FeeTiers {
+		if fee == 0 || fee >= 1000000 {
+			errors = append(errors, fmt.Sprintf("V3 fee tier %d must be between 1 and 999999", fee))
+		}
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		errors = append(errors, fmt.Sprintf("LOG_LEVEL %q must be debug, info, warn or error", c.LogLevel))
+	}
+
+	if c.LogFormat != "console" && c.LogFormat != "json" {
+		errors = append(errors, fmt.Sprintf("LOG_FORMAT %q must be console or json", c.LogFormat))
+	}
+
+	if c.AutoWrapThresholdWBNB < 0 {
+		errors = append(errors, "AUTO_WRAP_THRESHOLD_WBNB cannot be negative")
+	}
+
+	if c.AutoWrapThresholdWBNB > 0 && c.AutoWrapTargetWBNB < c.AutoWrapThresholdWBNB {
+		errors = append(errors, "AUTO_WRAP_TARGET_WBNB must be at least AUTO_WRAP_THRESHOLD_WBNB")
+	}
+
+	if c.GasReserveBNB < 0 {
+		errors = append(errors, "GAS_RESERVE_BNB cannot be negative")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("configuration errors: %s", strings.Join(errors, "; "))
+	}
+
+	return nil
+}
+
+// SlippageFor returns the maximum slippage for a pair category: its
+// MAX_SLIPPAGE_<CATEGORY> override when set, otherwise MAX_SLIPPAGE
+func (c *Config) SlippageFor(category string) float64 {
+	if slippage, set := c.CategoryMaxSlippage[category]; set {
+		return slippage
+	}
+	return c.MaxSlippage
+}
+
+// MinOutputFor returns the smallest quote output, in whole tokens, accepted
+// for a token symbol: its MIN_OUTPUT_<SYMBOL> override when set, otherwise
+// MIN_OUTPUT_AMOUNT
+func (c *Config) MinOutputFor(symbol string) float64 {
+	if minOutput, set := c.MinOutputAmounts[strings.ToUpper(symbol)]; set {
+		return minOutput
+	}
+	return c.MinOutputAmount
+}
+
+// ScanPeriodFor classifies a time as peak, low activity, or standard hours.
+// Peak windows take precedence when ranges overlap.
+func (c *Config) ScanPeriodFor(t time.Time) string {
+	hour := t.UTC().Hour()
+
+	for _, r := range c.PeakHours {
+		if r.Contains(hour) {
+			return ScanPeriodPeak
+		}
+	}
+
+	for _, r := range c.LowHours {
+		if r.Contains(hour) {
+			return ScanPeriodLow
+		}
+	}
+
+	return ScanPeriodStandard
+}
+
+// FormatHourRanges formats hour ranges for logging, e.g. "13-16, 21-23"
+func FormatHourRanges(ranges []HourRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RPCCallLimit returns the calls per second allowed on an RPC endpoint: the
+// RPC_CALL_LIMITS entry with the longest key its URL contains, or
+// RPC_CALLS_PER_SECOND. 0 is unlimited.
+func (c *Config) RPCCallLimit(rpcURL string) float64 {
+	limit, matched := c.RPCCallsPerSecond, ""
+	for endpoint, perSecond := range c.RPCCallLimits {
+		if strings.Contains(rpcURL, endpoint) && len(endpoint) > len(matched) {
+			limit, matched = perSecond, endpoint
+		}
+	}
+	return limit
+}
+
+// FormatRPCCallLimits describes the RPC call limits for the startup banner,
+// e.g. "8 calls/s per endpoint, ankr.com 25 calls/s"
+func (c *Config) FormatRPCCallLimits() string {
+	parts := []string{"unlimited per endpoint"}
+	if c.RPCCallsPerSecond > 0 {
+		parts[0] = fmt.Sprintf("%g calls/s per endpoint", c.RPCCallsPerSecond)
+	}
+
+	endpoints := make([]string, 0, len(c.RPCCallLimits))
+	for endpoint := range c.RPCCallLimits {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	for _, endpoint := range endpoints {
+		if perSecond := c.RPCCallLimits[endpoint]; perSecond > 0 {
+			parts = append(parts, fmt.Sprintf("%s %g calls/s", endpoint, perSecond))
+		} else {
+			parts = append(parts, endpoint+" unlimited")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// countConfiguredRPCs counts how many RPC URLs are configured
+func (c *Config) countConfiguredRPCs() int {
+	count := 0
+	rpcs := []string{
+		c.BSCRPCURL, c.BSCRPCURL1, c.BSCRPCURL2, c.BSCRPCURL3,
+		c.BSCRPCURL4, c.BSCRPCURL5, c.BSCRPCURL6, c.BSCRPCURL7, c.BSCRPCURL8,
+	}
+
+	for _, rpc := range rpcs {
+		if rpc != "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+// validateRPCURL checks that an RPC URL parses and uses an http(s) or ws(s) scheme
+func validateRPCURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	switch parsed.Scheme {
+	case "http", "https", "ws", "wss":
+	default:
+		return fmt.Errorf("scheme must be http, https, ws or wss")
+	}
+
+	if parsed.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	return nil
+}
+
+// hasWebsocketRPC reports whether any of urls can carry subscriptions
+func hasWebsocketRPC(urls []string) bool {
+	for _, url := range urls {
+		if strings.HasPrefix(url, "ws") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAllRPCURLs returns all configured RPC URLs
+func (c *Config) GetAllRPCURLs() []string {
+	var urls []string
+	rpcs := []string{
+		c.BSCRPCURL, c.BSCRPCURL1, c.BSCRPCURL2, c.BSCRPCURL3,
+		c.BSCRPCURL4, c.BSCRPCURL5, c.BSCRPCURL6, c.BSCRPCURL7, c.BSCRPCURL8,
+	}
+
+	for _, rpc := range rpcs {
+		if rpc != "" {
+			urls = append(urls, rpc)
+		}
+	}
+
+	return urls
+}
+
+// LogConfiguration prints the current configuration (without sensitive data)
+func (c *Config) LogConfiguration() {
+	log.Println("======================================")
+	log.Println("⚙️ Configuration Summary")
+	log.Println("======================================")
+	log.Printf("🌐 RPC endpoints: %d configured", c.countConfiguredRPCs())
+	switch {
+	case c.SignerURL != "":
+		log.Printf("🔑 Signer: external (%s)", c.SignerMethod)
+	case c.KeystorePath != "":
+		log.Printf("🔑 Signer: keystore %s", c.KeystorePath)
+	case len(c.PrivateKeys) > 0:
+		log.Printf("🔑 Signer: %d private keys from environment, rotated per execution", len(c.LocalPrivateKeys()))
+	default:
+		log.Println("🔑 Signer: private key from environment")
+	}
+	log.Printf("⛽ Gas limit: %d", c.GasLimit)
+	log.Printf("💰 Gas price: %.2f Gwei", float64(c.GasPrice)/1e9)
+	if c.MaxGasPriceGwei > 0 {
+		log.Printf("🚧 Max gas price: %.2f Gwei", c.MaxGasPriceGwei)
+	} else {
+		log.Println("🚧 Max gas price: disabled")
+	}
+	log.Printf("📈 Gas price buffer: +%d%%", c.GasPriceBufferPercent)
+	log.Printf("📈 Gas limit buffer: +%d%%", c.GasLimitBufferPercent)
+	log.Printf("📈 Underpriced resubmits: up to %d, +%d%% each", c.GasBumpRetries, c.GasBumpPercent)
+	if c.MaxPendingTx > 0 {
+		log.Printf("⏳ Max pending transactions: %d", c.MaxPendingTx)
+	} else {
+		log.Println("⏳ Max pending transactions: unlimited")
+	}
+	if c.MinNativeReserveBNB > 0 {
+		log.Printf("🪙 Native BNB reserve: %.4f BNB kept after gas", c.MinNativeReserveBNB)
+	} else {
+		log.Println("🪙 Native BNB reserve: disabled")
+	}
+	if c.PairFailureCooldown > 0 {
+		log.Printf("🧊 Failed pair cooldown: %v", c.PairFailureCooldown)
+	} else {
+		log.Println("🧊 Failed pair cooldown: disabled")
+	}
+	if c.WalletCheckInterval > 0 {
+		log.Printf("👛 Wallet tradeability check: every %v", c.WalletCheckInterval)
+	} else {
+		log.Println("👛 Wallet tradeability check: startup only")
+	}
+	if c.PairRefreshInterval > 0 {
+		log.Printf("🔁 Pair re-verification: every %v", c.PairRefreshInterval)
+	} else {
+		log.Println("🔁 Pair re-verification: startup only")
+	}
+	log.Printf("🧭 Strategies: %s", strings.Join(c.Strategies, ", "))
+	log.Printf("⚓ Base tokens: %s", strings.Join(c.BaseTokens, ", "))
+	if c.MaxPairsPerScan > 0 {
+		log.Printf("📋 Pairs per scan: %d, by priority", c.MaxPairsPerScan)
+	} else {
+		log.Println("📋 Pairs per scan: all, by priority")
+	}
+	log.Printf("📊 Min profit: %.2f%%", c.MinProfit*100)
+	log.Printf("🎯 Max slippage: %.2f%%", c.MaxSlippage*100)
+	log.Printf("⏰ Scan interval: %d seconds", c.CooldownPeriod)
+	log.Printf("🌊 Max price impact: %.2f%%", c.MaxPriceImpact*100)
+	log.Printf("🧪 Min quote output: %g tokens, %d token override(s)", c.MinOutputAmount, len(c.MinOutputAmounts))
+	log.Printf("💧 Min pool reserve: %.2f WBNB", c.MinReserveWBNB)
+	if c.MinLiquidityUSD > 0 {
+		log.Printf("💧 Min pool liquidity: $%.0f", c.MinLiquidityUSD)
+	} else {
+		log.Println("💧 Min pool liquidity: disabled")
+	}
+	if c.MaxPoolFractionBps > 0 {
+		log.Printf("✂️ Max trade size: %.2f%% of a pool's reserve", float64(c.MaxPoolFractionBps)/100)
+	} else {
+		log.Println("✂️ Max trade size: no pool cap")
+	}
+	log.Printf("💵 Min net profit: %.6f WBNB", c.MinNetProfitWBNB)
+	log.Printf("⌛ Swap deadline: %v", c.SwapDeadline)
+	log.Printf("🧾 Receipt timeout: %v", c.ReceiptTimeout)
+	log.Printf("⏱️ Scan timeout: %v", c.ScanTimeout)
+	if c.ScanJitterPercent > 0 {
+		log.Printf("🎲 Scan interval jitter: ±%d%%", c.ScanJitterPercent)
+	} else {
+		log.Println("🎲 Scan interval jitter: disabled")
+	}
+	log.Printf("🏦 Platform fee: %.2f%%", float64(c.PlatformFeeBps)/100)
+	for _, category := range PairCategories {
+		log.Printf("🎯 %s: min profit %.2f%%, gas adjustment %.2f%%, max slippage %.2f%%", category,
+			c.CategoryMinProfit[category]*100, c.CategoryGasAdjustment[category]*100, c.SlippageFor(category)*100)
+	}
+	for name, override := range c.PairOverrides {
+		log.Printf("🎯 %s override: min profit %.2f%%, gas adjustment %.2f%%", name,
+			override.MinProfit*100, override.GasAdjustment*100)
+	}
+	log.Printf("🔥 Peak hours: %s UTC", FormatHourRanges(c.PeakHours))
+	log.Printf("😴 Low activity hours: %s UTC", FormatHourRanges(c.LowHours))
+	log.Printf("🛑 Shutdown grace period: %v", c.ShutdownGracePeriod)
+	log.Printf("💾 Execution state file: %s", c.ExecutionStateFile)
+	log.Printf("💾 Failed RPC state file: %s", c.FailedRPCFile)
+	if c.PrivateTxURL != "" {
+		log.Printf("🛡️ Private relay: enabled (%s)", c.PrivateTxMethod)
+	} else {
+		log.Println("🛡️ Private relay: disabled (public mempool)")
+	}
+	if c.MempoolWSURL != "" {
+		log.Printf("👀 Mempool trigger: swaps >= %.2f WBNB, %v cooldown per pair", c.MempoolMinSwapWBNB, c.MempoolPairCooldown)
+	} else {
+		log.Println("👀 Mempool trigger: disabled (interval scanning only)")
+	}
+	if c.WatchReserves {
+		log.Println("🔔 Reserve watcher: enabled (quotes dropped per pool on Sync)")
+	} else {
+		log.Println("🔔 Reserve watcher: disabled (quotes cached per block)")
+	}
+	if c.ArchiveRPCURL != "" {
+		log.Println("🗄️ Archive RPC: configured for backtests")
+	}
+	if c.OpportunityLog != "" {
+		log.Printf("📝 Opportunity log: %s", c.OpportunityLog)
+	}
+	log.Printf("🩺 Health check: every %v, timeout %v", c.HealthCheckInterval, c.HealthCheckTimeout)
+	if c.HealthAddr != "" {
+		log.Printf("🩺 Health endpoint: http://%s/healthz (max block lag %v)", c.HealthAddr, c.MaxBlockLag)
+	}
+	log.Printf("🔁 Retries: %d, base delay %v, max delay %v", c.MaxRetries, c.RetryBaseDelay, c.RetryMaxDelay)
+	log.Printf("🚦 Rate limit: back off %v, switch after %d hits", c.RateLimitBackoff, c.RateLimitSwitchThreshold)
+	log.Printf("🚦 RPC call limit: %s", c.FormatRPCCallLimits())
+	if c.EnableV3 {
+		log.Printf("🧪 PancakeSwap V3 quotes: enabled (fee tiers %v)", c.V3FeeTiers)
+		if c.LogV3Routes {
+			log.Println("🧪 Mixed V2/V3 route logging: every scan (quote-only, extra quoter calls)")
+		} else {
+			log.Println("🧪 Mixed V2/V3 route logging: off, quote command only")
+		}
+	} else {
+		log.Println("🧪 PancakeSwap V3 quotes: disabled")
+	}
+	if c.ReservePrefilter {
+		log.Printf("🧮 Reserve pre-filter: enabled (tolerance %.2f%%)", c.PrefilterTolerance*100)
+	} else {
+		log.Println("🧮 Reserve pre-filter: disabled")
+	}
+	log.Printf("💱 Swap fees: PancakeSwap %.2f%%, BiSwap %.2f%% unless the pool sets its own",
+		float64(c.PancakeswapFeeBps)/100, float64(c.BiswapFeeBps)/100)
+	if c.AutoWrapThresholdWBNB > 0 {
+		log.Printf("🔄 Auto-wrap: below %.4f WBNB wrap up to %.4f WBNB, keep %.4f BNB for gas",
+			c.AutoWrapThresholdWBNB, c.AutoWrapTargetWBNB, c.GasReserveBNB)
+	} else {
+		log.Println("🔄 Auto-wrap: disabled")
+	}
+	log.Printf("🔍 Debug mode: %v", c.Debug)
+	log.Printf("📝 Logging: level %s, format %s", c.EffectiveLogLevel(), c.LogFormat)
+
+	if c.FlashArbContract != "" {
+		log.Printf("⚡ Flash contract: %s (flash arbitrage active)", common.HexToAddress(c.FlashArbContract).Hex())
+	} else {
+		log.Printf("⚠️ Flash contract: Not configured")
+	}
+	if c.AllowManualArbitrage {
+		log.Println("⚠️ Manual arbitrage: enabled, routes without a flash path run as three separate swaps")
+	} else {
+		log.Println("🛡️ Manual arbitrage: disabled, only flash arbitrage is executed")
+	}
+	if c.ConfirmTrades {
+		log.Printf("🙋 Trade confirmation: enabled, no after %v", c.ConfirmTimeout)
+	}
+
+	log.Println("======================================")
+}
+
+// Helper functions
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// parseHourRanges parses a comma-separated list of hour ranges like "13-16,21-23"
+func parseHourRanges(value string) ([]HourRange, error) {
+	var ranges []HourRange
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.Split(part, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid hour range %q, expected start-end", part)
+		}
+
+		start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid start hour in %q: %v", part, err)
+		}
+
+		end, err := strconv.Atoi(strings.TrimSpace(bounds[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid end hour in %q: %v", part, err)
+		}
+
+		ranges = append(ranges, HourRange{Start: start, End: end})
+	}
+
+	return ranges, nil
+}
+
+// PairOverride replaces a pair's category thresholds; a zero field keeps the
+// category value
+type PairOverride struct {
+	MinProfit     float64
+	GasAdjustment float64
+}
+
+// parseRPCCallLimits parses a comma-separated list of per-endpoint call
+// limits like "ankr.com=25,defibit.io=10", keyed by part of the RPC URL
+func parseRPCCallLimits(value string) (map[string]float64, error) {
+	limits := make(map[string]float64)
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		separator := strings.LastIndex(part, "=")
+		if separator <= 0 {
+			return nil, fmt.Errorf("invalid RPC call limit %q, expected endpoint=callsPerSecond", part)
+		}
+
+		perSecond, err := strconv.ParseFloat(strings.TrimSpace(part[separator+1:]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calls per second in %q: %v", part, err)
+		}
+
+		limits[strings.TrimSpace(part[:separator])] = perSecond
+	}
+
+	return limits, nil
+}
+
+// parsePairOverrides parses a comma-separated list of per-pair thresholds like
+// "WBNB-SHIB-USDT:0.004:0.002,WBNB-CAKE-USDT:0.0015", where each entry is
+// name:minProfit with an optional :gasAdjustment
+func parsePairOverrides(value string) (map[string]PairOverride, error) {
+	overrides := make(map[string]PairOverride)
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Split(part, ":")
+		if len(fields) < 2 || len(fields) > 3 || strings.TrimSpace(fields[0]) == "" {
+			return nil, fmt.Errorf("invalid pair override %q, expected name:minProfit[:gasAdjustment]", part)
+		}
+
+		var override PairOverride
+		var err error
+		if minProfit := strings.TrimSpace(fields[1]); minProfit != "" {
+			if override.MinProfit, err = strconv.ParseFloat(minProfit, 64); err != nil {
+				return nil, fmt.Errorf("invalid min profit in %q: %v", part, err)
+			}
+		}
+		if len(fields) == 3 {
+			if override.GasAdjustment, err = strconv.ParseFloat(strings.TrimSpace(fields[2]), 64); err != nil {
+				return nil, fmt.Errorf("invalid gas adjustment in %q: %v", part, err)
+			}
+		}
+
+		overrides[strings.TrimSpace(fields[0])] = override
+	}
+
+	return overrides, nil
+}
+
+// parseStrategies parses a comma-separated list of strategy names like
+// "triangular,direct"
+func parseStrategies(value string) []string {
+	var strategies []string
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+
+		strategies = append(strategies, part)
+	}
+
+	return strategies
+}
+
+// parseBaseTokens parses a comma-separated list of token symbols like
+// "WBNB,BUSD", dropping repeats
+func parseBaseTokens(value string) []string {
+	var symbols []string
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		if part == "" || seen[part] {
+			continue
+		}
+
+		seen[part] = true
+		symbols = append(symbols, part)
+	}
+
+	return symbols
+}
+
+// parseFeeTiers parses a comma-separated list of V3 fee tiers like "500,2500"
+func parseFeeTiers(value string) ([]uint32, error) {
+	var tiers []uint32
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fee, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee tier %q: %v", part, err)
+		}
+
+		tiers = append(tiers, uint32(fee))
+	}
+
+	return tiers, nil
 }
 
 func getEnvRequired(key string) string {
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParsePairOverrides(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    map[string]PairOverride
+		wantErr bool
+	}{
+		{
+			input: "WBNB-SHIB-USDT:0.004:0.002, WBNB-CAKE-USDT:0.0015",
+			want: map[string]PairOverride{
+				"WBNB-SHIB-USDT": {MinProfit: 0.004, GasAdjustment: 0.002},
+				"WBNB-CAKE-USDT": {MinProfit: 0.0015},
+			},
+		},
+		{
+			input: "WBNB-DOGE-USDT::0.003",
+			want:  map[string]PairOverride{"WBNB-DOGE-USDT": {GasAdjustment: 0.003}},
+		},
+		{input: "WBNB-SHIB-USDT", wantErr: true},
+		{input: ":0.004", wantErr: true},
+		{input: "WBNB-SHIB-USDT:high", wantErr: true},
+		{input: "WBNB-SHIB-USDT:0.004:0.002:1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePairOverrides(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePairOverrides(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parsePairOverrides(%q) = %v, want %v", tt.input, got, tt.want)
+			continue
+		}
+		for name, override := range tt.want {
+			if got[name] != override {
+				t.Errorf("parsePairOverrides(%q)[%s] = %+v, want %+v", tt.input, name, got[name], override)
+			}
+		}
+	}
+}
+
+func TestValidateConfigRejectsPairOverridesOutOfRange(t *testing.T) {
+	cfg := &Config{
+		PairOverrides: map[string]PairOverride{
+			"WBNB-SHIB-USDT": {MinProfit: 0.2},
+			"WBNB-CAKE-USDT": {MinProfit: 0.002, GasAdjustment: -0.001},
+			"WBNB-USDT-BUSD": {MinProfit: 0.002, GasAdjustment: 0.001},
+		},
+	}
+
+	err := cfg.ValidateConfig()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"PAIR_OVERRIDES min profit for WBNB-SHIB-USDT",
+		"PAIR_OVERRIDES gas adjustment for WBNB-CAKE-USDT",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validation error missing %q:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "WBNB-USDT-BUSD") {
+		t.Errorf("valid override rejected:\n%v", err)
+	}
+}
+
+func TestValidateConfigRejectsGasBuffersOutOfRange(t *testing.T) {
+	cfg := &Config{
+		GasPriceBufferPercent: 150,
+		GasLimitBufferPercent: -5,
+	}
+
+	err := cfg.ValidateConfig()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"GAS_PRICE_BUFFER_PERCENT", "GAS_LIMIT_BUFFER_PERCENT"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validation error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateConfigRejectsUnknownStrategies(t *testing.T) {
+	cfg := &Config{Strategies: parseStrategies(" Triangular, ,sandwich")}
+
+	if len(cfg.Strategies) != 2 || cfg.Strategies[0] != StrategyTriangular {
+		t.Fatalf("parseStrategies = %v, want [triangular sandwich]", cfg.Strategies)
+	}
+
+	err := cfg.ValidateConfig()
+	if err == nil || !strings.Contains(err.Error(), `STRATEGIES entry "sandwich"`) {
+		t.Errorf("validation error missing the unknown strategy:\n%v", err)
+	}
+	if strings.Contains(err.Error(), `"triangular"`) {
+		t.Errorf("validation rejected a known strategy:\n%v", err)
+	}
+}
+
+func TestValidateConfigRejectsUnknownBaseTokens(t *testing.T) {
+	cfg := &Config{BaseTokens: parseBaseTokens(" wbnb,BUSD, ,busd,FOO")}
+
+	if got := strings.Join(cfg.BaseTokens, ","); got != "WBNB,BUSD,FOO" {
+		t.Fatalf("parseBaseTokens = %s, want WBNB,BUSD,FOO", got)
+	}
+
+	err := cfg.ValidateConfig()
+	if err == nil || !strings.Contains(err.Error(), `BASE_TOKENS entry "FOO"`) {
+		t.Errorf("validation error missing the unknown token:\n%v", err)
+	}
+	if strings.Contains(err.Error(), `"BUSD"`) {
+		t.Errorf("validation rejected a known token:\n%v", err)
+	}
+}
+
+func TestRPCCallLimitMatchesLongestEndpoint(t *testing.T) {
+	limits, err := parseRPCCallLimits(" ankr.com=25, bsc.ankr.com=5,defibit.io=0 ")
+	if err != nil {
+		t.Fatalf("parseRPCCallLimits returned error: %v", err)
+	}
+	cfg := &Config{RPCCallsPerSecond: 8, RPCCallLimits: limits}
+
+	tests := []struct {
+		url  string
+		want float64
+	}{
+		{"https://rpc.ankr.com/bsc", 25},
+		{"https://bsc.ankr.com/key", 5},
+		{"https://bsc-dataseed1.defibit.io/", 0},
+		{"https://bsc-dataseed.binance.org/", 8},
+	}
+	for _, tt := range tests {
+		if got := cfg.RPCCallLimit(tt.url); got != tt.want {
+			t.Errorf("RPCCallLimit(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	if _, err := parseRPCCallLimits("ankr.com"); err == nil {
+		t.Error("parseRPCCallLimits accepted an entry without a limit")
+	}
+}
+
+func TestSlippageForCategory(t *testing.T) {
+	cfg := &Config{
+		MaxSlippage:         0.02,
+		CategoryMaxSlippage: map[string]float64{"meme": 0.05, "stable": 0.2},
+	}
+
+	if got := cfg.SlippageFor("meme"); got != 0.05 {
+		t.Errorf("SlippageFor(meme) = %v, want the 0.05 override", got)
+	}
+	if got := cfg.SlippageFor("volatile"); got != 0.02 {
+		t.Errorf("SlippageFor(volatile) = %v, want MAX_SLIPPAGE 0.02", got)
+	}
+
+	err := cfg.ValidateConfig()
+	if err == nil || !strings.Contains(err.Error(), "MAX_SLIPPAGE_STABLE") {
+		t.Errorf("out of range MAX_SLIPPAGE_STABLE not rejected: %v", err)
+	}
+	if strings.Contains(err.Error(), "MAX_SLIPPAGE_MEME") {
+		t.Errorf("valid MAX_SLIPPAGE_MEME rejected:\n%v", err)
+	}
+}
+
+func TestValidateConfigFlashArbContract(t *testing.T) {
+	tests := []struct {
+		contract string
+		wantErr  bool
+	}{
+		{"", false},
+		{"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", false},
+		{"0x5B38Da6a701c568545dCfcB03FcB875f56beddC", true}, // one digit short
+		{"5B38Da6a701c568545dCfcB03FcB875f56beddC4x", true},
+		{"0x0000000000000000000000000000000000000000", true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{FlashArbContract: tt.contract}
+		err := cfg.ValidateConfig()
+		gotErr := err != nil && strings.Contains(err.Error(), "FLASH_ARB_CONTRACT")
+		if gotErr != tt.wantErr {
+			t.Errorf("FLASH_ARB_CONTRACT=%q: rejected = %v, want %v (%v)", tt.contract, gotErr, tt.wantErr, err)
+		}
+	}
+}
+
+func TestTokenRegistryResolve(t *testing.T) {
+	address, err := Tokens.Resolve("cake")
+	if err != nil {
+		t.Fatalf("Resolve(cake) returned error: %v", err)
+	}
+	if address != common.HexToAddress(CAKE) {
+		t.Errorf("Resolve(cake) = %s, want %s", address.Hex(), CAKE)
+	}
+
+	if _, err := Tokens.Resolve("NOPE"); err == nil {
+		t.Error("expected error for an unknown symbol")
+	}
+
+	doge, err := Tokens.Lookup("DOGE")
+	if err != nil || doge.Decimals != 8 {
+		t.Errorf("Lookup(DOGE) = %+v, %v, want 8 decimals", doge, err)
+	}
+}
+
+func TestValidateConfigPrivateKeys(t *testing.T) {
+	key := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string // substring of the credential error, empty when valid
+	}{
+		{"numbered keys alone", Config{PrivateKeys: []string{key, key}}, ""},
+		{"numbered keys after PRIVATE_KEY", Config{PrivateKey: key, PrivateKeys: []string{key}}, ""},
+		{"numbered keys with a signer", Config{PrivateKeys: []string{key}, SignerURL: "http://localhost:8550"}, "set only one of"},
+		{"short numbered key", Config{PrivateKeys: []string{key, "abc"}}, "PRIVATE_KEY_2 must be 64 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.ValidateConfig()
+			if tt.want == "" {
+				if err != nil && strings.Contains(err.Error(), "PRIVATE_KEY") {
+					t.Errorf("valid keys rejected:\n%v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validation error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	cfg := Config{PrivateKey: "k0", PrivateKeys: []string{"k1", "k2"}}
+	if got := cfg.LocalPrivateKeys(); len(got) != 3 || got[0] != "k0" || got[2] != "k2" {
+		t.Errorf("LocalPrivateKeys = %v, want PRIVATE_KEY then PRIVATE_KEY_1..N", got)
+	}
+}
@@ -0,0 +1,66 @@
+// config/tokens.go
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TokenInfo is a token the bot knows by symbol
+type TokenInfo struct {
+	Symbol   string
+	Address  common.Address
+	Decimals uint8
+}
+
+// TokenRegistry maps token symbols to their BSC addresses. Pair definitions
+// list symbols and resolve them here, so each address is written down once.
+type TokenRegistry map[string]TokenInfo
+
+// Tokens is every token the built-in pairs trade. All of them use 18
+// decimals on BSC, including USDT and BUSD.
+var Tokens = NewTokenRegistry(
+	TokenInfo{Symbol: "WBNB", Address: common.HexToAddress(WBNB), Decimals: 18},
+	TokenInfo{Symbol: "USDT", Address: common.HexToAddress(USDT), Decimals: 18},
+	TokenInfo{Symbol: "BUSD", Address: common.HexToAddress(BUSD), Decimals: 18},
+	TokenInfo{Symbol: "CAKE", Address: common.HexToAddress(CAKE), Decimals: 18},
+	TokenInfo{Symbol: "DOGE", Address: common.HexToAddress(DOGE), Decimals: 8},
+	TokenInfo{Symbol: "SHIB", Address: common.HexToAddress(SHIB), Decimals: 18},
+	TokenInfo{Symbol: "BSW", Address: common.HexToAddress(BSW), Decimals: 18},
+	TokenInfo{Symbol: "BTCB", Address: common.HexToAddress(BTCB), Decimals: 18},
+	TokenInfo{Symbol: "ETH", Address: common.HexToAddress(ETH), Decimals: 18},
+	TokenInfo{Symbol: "ADA", Address: common.HexToAddress(ADA), Decimals: 18},
+	TokenInfo{Symbol: "DOT", Address: common.HexToAddress(DOT), Decimals: 18},
+	TokenInfo{Symbol: "XRP", Address: common.HexToAddress(XRP), Decimals: 18},
+	TokenInfo{Symbol: "LINK", Address: common.HexToAddress(LINK), Decimals: 18},
+	TokenInfo{Symbol: "MATIC", Address: common.HexToAddress(MATIC), Decimals: 18},
+)
+
+// NewTokenRegistry indexes tokens by upper-case symbol
+func NewTokenRegistry(tokens ...TokenInfo) TokenRegistry {
+	registry := make(TokenRegistry, len(tokens))
+	for _, token := range tokens {
+		registry[strings.ToUpper(token.Symbol)] = token
+	}
+	return registry
+}
+
+// Lookup returns the token registered under symbol, case-insensitively
+func (r TokenRegistry) Lookup(symbol string) (TokenInfo, error) {
+	token, exists := r[strings.ToUpper(symbol)]
+	if !exists {
+		return TokenInfo{}, fmt.Errorf("unknown token symbol %q", symbol)
+	}
+	return token, nil
+}
+
+// Resolve returns the address registered under symbol
+func (r TokenRegistry) Resolve(symbol string) (common.Address, error) {
+	token, err := r.Lookup(symbol)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return token.Address, nil
+}
@@ -12,6 +12,12 @@ var (
 	ERC20ABI  abi.ABI
 	PairABI   abi.ABI
 	FlashABI  abi.ABI
+
+	QuoterV2ABI abi.ABI
+
+	WBNBABI abi.ABI
+
+	Multicall3ABI abi.ABI
 )
 
 // Initialize loads all the required ABIs
@@ -28,6 +34,7 @@ func Initialize() error {
 	erc20AbiJson := `[
 		{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
 		{"inputs":[{"internalType":"address","name":"account","type":"address"}],"name":"balanceOf","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+		{"inputs":[{"internalType":"address","name":"owner","type":"address"},{"internalType":"address","name":"spender","type":"address"}],"name":"allowance","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
 		{"inputs":[{"internalType":"address","name":"spender","type":"address"},{"internalType":"uint256","name":"amount","type":"uint256"}],"name":"approve","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"}
 	]`
 	
@@ -35,13 +42,34 @@ func Initialize() error {
 	pairAbiJson := `[
 		{"inputs":[],"name":"token0","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},
 		{"inputs":[],"name":"token1","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},
-		{"inputs":[],"name":"getReserves","outputs":[{"internalType":"uint112","name":"reserve0","type":"uint112"},{"internalType":"uint112","name":"reserve1","type":"uint112"},{"internalType":"uint32","name":"blockTimestampLast","type":"uint32"}],"stateMutability":"view","type":"function"}
+		{"inputs":[],"name":"getReserves","outputs":[{"internalType":"uint112","name":"reserve0","type":"uint112"},{"internalType":"uint112","name":"reserve1","type":"uint112"},{"internalType":"uint32","name":"blockTimestampLast","type":"uint32"}],"stateMutability":"view","type":"function"},
+		{"inputs":[],"name":"swapFee","outputs":[{"internalType":"uint32","name":"","type":"uint32"}],"stateMutability":"view","type":"function"},
+		{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint112","name":"reserve0","type":"uint112"},{"indexed":false,"internalType":"uint112","name":"reserve1","type":"uint112"}],"name":"Sync","type":"event"}
 	]`
 	
 	// Flash arbitrage contract ABI (key functions only)
 	flashAbiJson := `[
 		{"inputs":[{"components":[{"internalType":"address[]","name":"path1","type":"address[]"},{"internalType":"address[]","name":"path2","type":"address[]"},{"internalType":"address[]","name":"path3","type":"address[]"},{"internalType":"uint256[]","name":"minAmountsOut","type":"uint256[]"},{"internalType":"bool","name":"direction","type":"bool"}],"internalType":"struct FlashTriangularArbitrage.ArbitrageData","name":"data","type":"tuple"},{"internalType":"uint256","name":"loanAmount","type":"uint256"},{"internalType":"bool","name":"fromPancake","type":"bool"}],"name":"checkArbitrageProfitability","outputs":[{"internalType":"uint256","name":"expectedProfit","type":"uint256"},{"internalType":"uint256","name":"expectedPlatformFee","type":"uint256"},{"internalType":"uint256","name":"expectedUserProfit","type":"uint256"}],"stateMutability":"view","type":"function"},
-		{"inputs":[{"internalType":"address","name":"pairAddress","type":"address"},{"internalType":"uint256","name":"borrowAmount","type":"uint256"},{"components":[{"internalType":"address[]","name":"path1","type":"address[]"},{"internalType":"address[]","name":"path2","type":"address[]"},{"internalType":"address[]","name":"path3","type":"address[]"},{"internalType":"uint256[]","name":"minAmountsOut","type":"uint256[]"},{"internalType":"bool","name":"direction","type":"bool"}],"internalType":"struct FlashTriangularArbitrage.ArbitrageData","name":"data","type":"tuple"},{"internalType":"bool","name":"fromPancake","type":"bool"}],"name":"executeFlashLoan","outputs":[],"stateMutability":"nonpayable","type":"function"}
+		{"inputs":[{"internalType":"address","name":"pairAddress","type":"address"},{"internalType":"uint256","name":"borrowAmount","type":"uint256"},{"components":[{"internalType":"address[]","name":"path1","type":"address[]"},{"internalType":"address[]","name":"path2","type":"address[]"},{"internalType":"address[]","name":"path3","type":"address[]"},{"internalType":"uint256[]","name":"minAmountsOut","type":"uint256[]"},{"internalType":"bool","name":"direction","type":"bool"}],"internalType":"struct FlashTriangularArbitrage.ArbitrageData","name":"data","type":"tuple"},{"internalType":"bool","name":"fromPancake","type":"bool"}],"name":"executeFlashLoan","outputs":[],"stateMutability":"nonpayable","type":"function"},
+		{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"user","type":"address"},{"indexed":true,"internalType":"address","name":"tokenBorrowed","type":"address"},{"indexed":false,"internalType":"uint256","name":"loanAmount","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"profit","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"platformFee","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"userProfit","type":"uint256"}],"name":"ArbitrageExecuted","type":"event"}
+	]`
+	
+	// PancakeSwap V3 QuoterV2 ABI (quotes are eth_call'd, not sent)
+	quoterV2AbiJson := `[
+		{"inputs":[{"components":[{"internalType":"address","name":"tokenIn","type":"address"},{"internalType":"address","name":"tokenOut","type":"address"},{"internalType":"uint256","name":"amountIn","type":"uint256"},{"internalType":"uint24","name":"fee","type":"uint24"},{"internalType":"uint160","name":"sqrtPriceLimitX96","type":"uint160"}],"internalType":"struct IQuoterV2.QuoteExactInputSingleParams","name":"params","type":"tuple"}],"name":"quoteExactInputSingle","outputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"},{"internalType":"uint160","name":"sqrtPriceX96After","type":"uint160"},{"internalType":"uint32","name":"initializedTicksCrossed","type":"uint32"},{"internalType":"uint256","name":"gasEstimate","type":"uint256"}],"stateMutability":"nonpayable","type":"function"},
+		{"inputs":[{"internalType":"bytes","name":"path","type":"bytes"},{"internalType":"uint256","name":"amountIn","type":"uint256"}],"name":"quoteExactInput","outputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"},{"internalType":"uint160[]","name":"sqrtPriceX96AfterList","type":"uint160[]"},{"internalType":"uint32[]","name":"initializedTicksCrossedList","type":"uint32[]"},{"internalType":"uint256","name":"gasEstimate","type":"uint256"}],"stateMutability":"nonpayable","type":"function"}
+	]`
+	
+	// WBNB ABI (wrap/unwrap only; balances use the ERC20 ABI)
+	wbnbAbiJson := `[
+		{"inputs":[],"name":"deposit","outputs":[],"stateMutability":"payable","type":"function"},
+		{"inputs":[{"internalType":"uint256","name":"wad","type":"uint256"}],"name":"withdraw","outputs":[],"stateMutability":"nonpayable","type":"function"}
+	]`
+	
+	// Multicall3 ABI (batched reads only)
+	multicall3AbiJson := `[
+		{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"},
+		{"inputs":[{"internalType":"address","name":"addr","type":"address"}],"name":"getEthBalance","outputs":[{"internalType":"uint256","name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"}
 	]`
 	
 	RouterABI, err = abi.JSON(strings.NewReader(routerAbiJson))
@@ -64,5 +92,20 @@ func Initialize() error {
 		return err
 	}
 	
+	QuoterV2ABI, err = abi.JSON(strings.NewReader(quoterV2AbiJson))
+	if err != nil {
+		return err
+	}
+	
+	WBNBABI, err = abi.JSON(strings.NewReader(wbnbAbiJson))
+	if err != nil {
+		return err
+	}
+	
+	Multicall3ABI, err = abi.JSON(strings.NewReader(multicall3AbiJson))
+	if err != nil {
+		return err
+	}
+	
 	return nil
 }
\ No newline at end of file
@@ -1,8 +1,22 @@
 module arbitrage-bot
 
-go 1.16
+go 1.21
 
 require (
 	github.com/ethereum/go-ethereum v1.10.17
 	github.com/joho/godotenv v1.4.0
+	golang.org/x/time v0.5.0
+)
+
+require (
+	github.com/deckarep/golang-set v1.8.0 // indirect
+	github.com/go-stack/stack v1.8.0 // indirect
+	github.com/google/uuid v1.2.0 // indirect
+	github.com/gorilla/websocket v1.4.2 // indirect
+	github.com/rjeczalik/notify v0.9.1 // indirect
+	github.com/shirou/gopsutil v3.21.4-0.20210419000835-c7a38de76ee5+incompatible // indirect
+	github.com/tklauser/go-sysconf v0.3.5 // indirect
+	github.com/tklauser/numcpus v0.2.2 // indirect
+	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2 // indirect
+	golang.org/x/sys v0.0.0-20210816183151-1e6c022a8912 // indirect
 )
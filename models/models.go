@@ -3,8 +3,12 @@ package models
 
 import (
 	"math/big"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/config"
 )
 
 // TokenPair represents a token pair for arbitrage
@@ -15,6 +19,13 @@ type TokenPair struct {
 	BiswapPair      map[string]string
 	Priority        int
 	TestAmounts     []float64
+
+	// Pool addresses on any other exchange, keyed by exchange name
+	DEXPairs map[string]map[string]string
+
+	// Optional per-pair overrides of the category thresholds (0 = use category)
+	MinProfitOverride     float64
+	GasAdjustmentOverride float64
 }
 
 // ArbitrageData represents the data structure for arbitrage execution
@@ -23,18 +34,129 @@ type ArbitrageData struct {
 	Path2         []common.Address
 	Path3         []common.Address
 	MinAmountsOut []*big.Int
-	Direction     bool
+	Direction     bool // flash contract's fromPancake flag, derived from the route
 }
 
-// ArbitrageResult represents the result of an arbitrage operation
+// QuoteRequest asks a router what amountIn returns along path
+type QuoteRequest struct {
+	Router   common.Address
+	AmountIn *big.Int
+	Path     []common.Address
+}
+
+// Quote is a router's answer to a QuoteRequest
+type Quote struct {
+	Request   QuoteRequest
+	Amounts   []*big.Int // one per path token, starting with AmountIn
+	AmountOut *big.Int   // the last entry of Amounts
+}
+
+// ArbitrageResult represents the result of an arbitrage operation. Amounts
+// are in the cycle's base token, the *WBNB figures in WBNB.
 type ArbitrageResult struct {
 	Profit        *big.Int
 	PlatformFee   *big.Int
 	UserProfit    *big.Int
 	TargetAmount  *big.Int
+	AmountWBNB    float64 // what TargetAmount is worth
 	ProfitPercent float64
-	Direction     bool
-	Path          []string
+	GasCostWBNB   float64
+	NetProfitWBNB float64
+
+	// Checksummed token addresses of the whole cycle, starting and ending
+	// with the base token, e.g. [WBNB, USDT, BUSD, WBNB]
+	Path []string
+
+	// Exchange (or V3 fee tier) used for each leg: Venues[i] swaps Path[i]
+	// into Path[i+1]
+	Venues []string
+}
+
+// Opportunity is an arbitrage a scan found worth executing. It holds what is
+// needed to execute it later: the route is rebuilt from the pair's tokens and
+// the symbol and exchange of each hop.
+type Opportunity struct {
+	PairName string
+	Strategy string
+	Category string
+
+	// Token symbols of the cycle, starting and ending with its base token, and
+	// the exchange of each hop: Venues[i] swaps Symbols[i] into Symbols[i+1]
+	Symbols []string
+	Venues  []string
+
+	AmountWBNB     float64
+	GrossPercent   float64 // profit before platform fee and gas, in percent
+	AdjustedProfit float64 // user's share after gas, as a ratio of AmountWBNB
+	NetProfitWBNB  float64
+	GasCostWBNB    float64
+
+	Result *ArbitrageResult
+}
+
+// Route names the exchange order, e.g. "PancakeSwap→BiSwap→PancakeSwap"
+func (o Opportunity) Route() string {
+	return strings.Join(o.Venues, "→")
+}
+
+// ExecutionState records a manual arbitrage that is between legs, so a restart
+// can detect funds left sitting in an intermediate token
+type ExecutionState struct {
+	Wallet         common.Address `json:"wallet"` // zero in states saved before multiple wallets
+	PairName       string         `json:"pair_name"`
+	Route          []string       `json:"route"`
+	Base           string         `json:"base,omitempty"` // the cycle's start token; empty means WBNB
+	InitialAmount  *big.Int       `json:"initial_amount"`
+	InitialBalance *big.Int       `json:"initial_balance"`
+	CompletedLegs  int            `json:"completed_legs"`
+	HeldToken      common.Address `json:"held_token"`
+	HeldSymbol     string         `json:"held_symbol"`
+	HeldAmount     *big.Int       `json:"held_amount"`
+	TxHashes       []common.Hash  `json:"tx_hashes"`
+	GasUsed        []uint64       `json:"gas_used"`
+	GasCost        *big.Int       `json:"gas_cost"`          // wei paid for gas by every mined leg, reverted ones included
+	PendingTx      common.Hash    `json:"pending_tx"`        // leg CompletedLegs+1, sent but not seen mined; zero if none
+	PendingPrice   *big.Int       `json:"pending_gas_price"` // gas price PendingTx was signed at
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// ExecutionResult describes the realized outcome of an executed arbitrage
+type ExecutionResult struct {
+	PairName string
+	Route    string
+	Flash    bool
+
+	// Per-transaction details, one entry per leg (a single entry for flash)
+	TxHashes []common.Hash
+	GasUsed  []uint64
+
+	// Wei paid for gas by the execution's transactions, reverted legs included
+	GasCost *big.Int
+
+	// WBNB wallet balance around the execution
+	InitialBalance *big.Int
+	FinalBalance   *big.Int
+
+	// RealizedProfit is the WBNB balance change less GasCost for manual
+	// trades; flash trades report the contract's user profit, before gas
+	AmountIn       *big.Int
+	RealizedProfit *big.Int
+	ProfitPercent  float64
+
+	// Split reported by the flash contract's ArbitrageExecuted event; nil for
+	// manual trades or when the receipt has no such event
+	Profit      *big.Int
+	PlatformFee *big.Int
+	UserProfit  *big.Int
+}
+
+// TotalGasUsed sums the gas used across all transactions
+func (r *ExecutionResult) TotalGasUsed() uint64 {
+	var total uint64
+	for _, gas := range r.GasUsed {
+		total += gas
+	}
+	return total
 }
 
 // PairReserves represents the reserves of a token pair
@@ -45,17 +167,27 @@ type PairReserves struct {
 	Token1   common.Address
 }
 
+// pairTokens resolves a pair's token symbols through config.Tokens. The pair
+// table is static, so an unknown symbol is a programming error and panics.
+func pairTokens(symbols ...string) map[string]string {
+	tokens := make(map[string]string, len(symbols))
+	for _, symbol := range symbols {
+		address, err := config.Tokens.Resolve(symbol)
+		if err != nil {
+			panic(err)
+		}
+		tokens[symbol] = address.Hex()
+	}
+	return tokens
+}
+
 // Initialize token pairs with HIGH VOLUME FOCUS - coins with consistent trading activity
 func InitializeTokenPairs() []TokenPair {
 	return []TokenPair{
 		// PRIORITY 1: HIGH VOLUME STABLE PAIRS - Most liquid and active
 		{
-			Name: "WBNB-USDT-BUSD",
-			Tokens: map[string]string{
-				"WBNB": "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"USDT": "0x55d398326f99059fF775485246999027B3197955",
-				"BUSD": "0xe9e7CEA3DedcA5984780Bafc599bD69ADd087D56",
-			},
+			Name:   "WBNB-USDT-BUSD",
+			Tokens: pairTokens("WBNB", "USDT", "BUSD"),
 			PancakeswapPair: map[string]string{
 				"WBNB-USDT": "0x16b9a82891338f9bA80E2D6970FddA79D1eb0daE",
 				"USDT-BUSD": "0x7EFaEf62fDdCCa950418312c6C91Aef321375A00",
@@ -72,12 +204,8 @@ func InitializeTokenPairs() []TokenPair {
 
 		// PRIORITY 2: BSW - BiSwap native token with high trading volume
 		{
-			Name: "WBNB-BSW-USDT",
-			Tokens: map[string]string{
-				"WBNB": "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"BSW":  "0x965F527D9159dCe6288a2219DB51fc6Eef120dD1", // BiSwap Token - high volume
-				"USDT": "0x55d398326f99059fF775485246999027B3197955",
-			},
+			Name:   "WBNB-BSW-USDT",
+			Tokens: pairTokens("WBNB", "BSW", "USDT"), // BiSwap Token - high volume
 			PancakeswapPair: map[string]string{
 				"WBNB-BSW":  "0x8CA3fF14A52b080C54A6d1a405eecA02959d39fE",
 				"BSW-USDT":  "0x4344b51cf44f4f182a8e25239cf4d81b315331c3",
@@ -94,12 +222,8 @@ func InitializeTokenPairs() []TokenPair {
 
 		// PRIORITY 3: CAKE - PancakeSwap native token, very high volume
 		{
-			Name: "WBNB-CAKE-USDT",
-			Tokens: map[string]string{
-				"WBNB": "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"CAKE": "0x0E09FaBB73Bd3Ade0a17ECC321fD13a19e81cE82", // PancakeSwap token - huge volume
-				"USDT": "0x55d398326f99059fF775485246999027B3197955",
-			},
+			Name:   "WBNB-CAKE-USDT",
+			Tokens: pairTokens("WBNB", "CAKE", "USDT"), // PancakeSwap token - huge volume
 			PancakeswapPair: map[string]string{
 				"WBNB-CAKE": "0x0eD7e52944161450477ee417DE9Cd3a859b14fD0",
 				"CAKE-USDT": "0xA39Af17CE4a8eb807E076805Da1e2B8EA7D0755b", // High volume pair
@@ -116,12 +240,8 @@ func InitializeTokenPairs() []TokenPair {
 
 		// PRIORITY 4: BTC - Wrapped Bitcoin, extremely high volume and liquidity
 		{
-			Name: "WBNB-BTCB-USDT",
-			Tokens: map[string]string{
-				"WBNB": "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"BTCB": "0x7130d2A12B9BCbFAe4f2634d864A1Ee1Ce3Ead9c", // Bitcoin BEP20 - massive volume
-				"USDT": "0x55d398326f99059fF775485246999027B3197955",
-			},
+			Name:   "WBNB-BTCB-USDT",
+			Tokens: pairTokens("WBNB", "BTCB", "USDT"), // Bitcoin BEP20 - massive volume
 			PancakeswapPair: map[string]string{
 				"WBNB-BTCB": "0x61EB789d75A95CAa3fF50ed7E47b96c132fEc082",
 				"BTCB-USDT": "0xD171B26E4484402de70e3944CC6B9301014B0C1e",
@@ -138,12 +258,8 @@ func InitializeTokenPairs() []TokenPair {
 
 		// PRIORITY 5: ETH - Wrapped Ethereum, very high volume
 		{
-			Name: "WBNB-ETH-USDT",
-			Tokens: map[string]string{
-				"WBNB": "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"ETH":  "0x2170Ed0880ac9A755fd29B2688956BD959F933F8", // Ethereum BEP20 - high volume
-				"USDT": "0x55d398326f99059fF775485246999027B3197955",
-			},
+			Name:   "WBNB-ETH-USDT",
+			Tokens: pairTokens("WBNB", "ETH", "USDT"), // Ethereum BEP20 - high volume
 			PancakeswapPair: map[string]string{
 				"WBNB-ETH":  "0x74E4716E431f45807DCF19f284c7aA99F18a4fbc",
 				"ETH-USDT":  "0x531FEbA4f95504c4B9883Eb2b59C30Bd7a10aBfB",
@@ -160,12 +276,8 @@ func InitializeTokenPairs() []TokenPair {
 
 		// PRIORITY 6: ADA - Cardano, good trading volume
 		{
-			Name: "WBNB-ADA-USDT",
-			Tokens: map[string]string{
-				"WBNB": "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"ADA":  "0x3EE2200Efb3400fAbB9AacF31297cBdD1d435D47", // Cardano BEP20 - good volume
-				"USDT": "0x55d398326f99059fF775485246999027B3197955",
-			},
+			Name:   "WBNB-ADA-USDT",
+			Tokens: pairTokens("WBNB", "ADA", "USDT"), // Cardano BEP20 - good volume
 			PancakeswapPair: map[string]string{
 				"WBNB-ADA":  "0x28415ff2C35b65B9E5c7de82126b4015ab9d031F",
 				"ADA-USDT":  "0x8CEd2C5F5FFFd7d6a27ac0c7b5c0Ed84E3e8b19A",
@@ -182,12 +294,8 @@ func InitializeTokenPairs() []TokenPair {
 
 		// PRIORITY 7: DOT - Polkadot, consistent trading volume
 		{
-			Name: "WBNB-DOT-USDT",
-			Tokens: map[string]string{
-				"WBNB": "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"DOT":  "0x7083609fCE4d1d8Dc0C979AAb8c869Ea2C873402", // Polkadot BEP20 - steady volume
-				"USDT": "0x55d398326f99059fF775485246999027B3197955",
-			},
+			Name:   "WBNB-DOT-USDT",
+			Tokens: pairTokens("WBNB", "DOT", "USDT"), // Polkadot BEP20 - steady volume
 			PancakeswapPair: map[string]string{
 				"WBNB-DOT":  "0xDd5bAd8f8b360d76d12FdA230F8BAF42fe0022CF",
 				"DOT-USDT":  "0x54aFF400858Dcac39797a81894D9920f16972D1D",
@@ -204,12 +312,8 @@ func InitializeTokenPairs() []TokenPair {
 
 		// PRIORITY 8: MATIC - Polygon, active trading
 		{
-			Name: "WBNB-MATIC-USDT",
-			Tokens: map[string]string{
-				"WBNB":  "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"MATIC": "0xCC42724C6683B7E57334c4E856f4c9965ED682bD", // Polygon BEP20 - active trading
-				"USDT":  "0x55d398326f99059fF775485246999027B3197955",
-			},
+			Name:   "WBNB-MATIC-USDT",
+			Tokens: pairTokens("WBNB", "MATIC", "USDT"), // Polygon BEP20 - active trading
 			PancakeswapPair: map[string]string{
 				"WBNB-MATIC": "0x7Eb5D86FD78f3852a3e0e064f2842d45a3dB6EA2",
 				"MATIC-USDT": "0x3578B7cc2aFD09bE5bFAaED8c1d89C40dFbf4b3A",
@@ -226,12 +330,8 @@ func InitializeTokenPairs() []TokenPair {
 
 		// PRIORITY 9: LINK - Chainlink, reliable volume
 		{
-			Name: "WBNB-LINK-USDT",
-			Tokens: map[string]string{
-				"WBNB": "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"LINK": "0xF8A0BF9cF54Bb92F17374d9e9A321E6a111a51bD", // Chainlink BEP20 - reliable volume
-				"USDT": "0x55d398326f99059fF775485246999027B3197955",
-			},
+			Name:   "WBNB-LINK-USDT",
+			Tokens: pairTokens("WBNB", "LINK", "USDT"), // Chainlink BEP20 - reliable volume
 			PancakeswapPair: map[string]string{
 				"WBNB-LINK": "0x824eb9faDFb377394430d2744fa7C42916DE3eCe",
 				"LINK-USDT": "0x88f4BdE4a94cbE8B9318B2CAc59Fe55c68b7ff97",
@@ -248,12 +348,8 @@ func InitializeTokenPairs() []TokenPair {
 
 		// PRIORITY 10: XRP - Ripple, consistent volume
 		{
-			Name: "WBNB-XRP-USDT",
-			Tokens: map[string]string{
-				"WBNB": "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-				"XRP":  "0x1D2F0da169ceB9fC7B3144628dB156f3F6c60dBE", // XRP BEP20 - consistent volume
-				"USDT": "0x55d398326f99059fF775485246999027B3197955",
-			},
+			Name:   "WBNB-XRP-USDT",
+			Tokens: pairTokens("WBNB", "XRP", "USDT"), // XRP BEP20 - consistent volume
 			PancakeswapPair: map[string]string{
 				"WBNB-XRP":  "0x03F18135c44C64ebFdCBad8297fe5bDafdBbdd86",
 				"XRP-USDT":  "0x8b303d5BbfBbf46F1a4d9741E491e06986894e18",
@@ -3,35 +3,150 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"math/big"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 
 	"arbitrage-bot/config"
 	"arbitrage-bot/contracts"
 	"arbitrage-bot/models"
+	"arbitrage-bot/utils"
 )
 
+// ErrNoOpportunity is returned by a scan that completed normally but found
+// nothing worth executing
+var ErrNoOpportunity = errors.New("no arbitrage opportunities found")
+
+// ErrPoolTooThin is returned by CheckPairLiquidity when a pool's reserves are
+// below MIN_RESERVE_WBNB or its value below MIN_LIQUIDITY_USD; the pair is
+// skipped rather than failing the scan
+var ErrPoolTooThin = errors.New("pool too thin")
+
+// ErrPreflightReverted is returned by PreflightArbitrage when the gas estimate
+// of the execution reverts, i.e. the transaction would fail on-chain
+var ErrPreflightReverted = errors.New("execution preflight reverted")
+
+// ErrManualArbitrageDisabled is returned when a route can't use the flash
+// contract and ALLOW_MANUAL_ARBITRAGE is off
+var ErrManualArbitrageDisabled = errors.New("no flash contract for route and manual arbitrage is disabled")
+
+// ErrGasPriceTooHigh is returned by ExecuteArbitrage when the network gas
+// price is above MAX_GAS_PRICE_GWEI
+var ErrGasPriceTooHigh = errors.New("gas price above ceiling")
+
+// ErrTooManyPending is returned by ExecuteArbitrage while MAX_PENDING_TX of
+// the wallet's transactions are still unconfirmed
+var ErrTooManyPending = errors.New("too many pending transactions")
+
+// ErrNativeReserve is returned by ExecuteArbitrage when paying the trade's gas
+// would leave less native BNB than MIN_NATIVE_RESERVE_BNB
+var ErrNativeReserve = errors.New("native balance below reserve")
+
+// ErrLostRace is returned by ExecuteArbitrage when the flash contract or the
+// first manual leg reverted because the trade no longer met its minimum
+// outputs, usually because someone else took the opportunity first. Nothing
+// but gas was lost.
+var ErrLostRace = errors.New("lost race")
+
+// ErrFlashMisconfigured is returned by ExecuteFlashArbitrage, before anything
+// is sent, when the flash contract or the pool it borrows from can't work:
+// nothing deployed at the address, or a pool without the borrowed liquidity
+var ErrFlashMisconfigured = errors.New("flash execution misconfigured")
+
+// ErrNotMined is returned when a sent transaction has no receipt within
+// RECEIPT_TIMEOUT. It may still be mined later.
+var ErrNotMined = errors.New("transaction not mined")
+
+// ErrScanCancelled is returned by a scan, and the reads within it, once the
+// scan's context is cancelled or times out
+var ErrScanCancelled = errors.New("scan cancelled")
+
+// scanCancelled returns ErrScanCancelled wrapping ctx's error once ctx is
+// done, or nil. It is never classified as a connection error, so abandoned
+// reads aren't retried and don't count against the RPC.
+func scanCancelled(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrScanCancelled, err)
+	}
+	return nil
+}
+
 // ArbitrageService handles arbitrage operations
 type ArbitrageService struct {
 	Client        *EthClient
+	Backend       ContractCaller
 	TokenService  *TokenService
 	RouterService *RouterService
+	V3Router      *V3RouterService
 	Config        *config.Config
 	TokenPairs    []models.TokenPair
 
-	PancakeRouter common.Address
-	BiswapRouter  common.Address
+	// Clock decides which trading window a scan falls in
+	Clock utils.Clock
+
+	// Exchanges routes are built from and positions are unwound through
+	DEXes         []DEX
+	Strategies    []Strategy
 	FlashContract common.Address
+
+	// Result of checking for code at FlashContract at startup
+	flashContractErr error
+
+	// Pools resolved by VerifyAndUpdatePairs: true if the factory listed the
+	// pool, false if it doesn't exist on that exchange. Unchecked pools are
+	// absent and still get quoted.
+	poolStatus map[string]bool
+
+	// Gas price for route cost estimates, fetched once per scan
+	gasPriceMu   sync.Mutex
+	scanGasPrice *big.Int
+
+	enhancedStats EnhancedStats
+	spreadMu      sync.Mutex // guards enhancedStats.PairSpreads
+	tradeMu       sync.Mutex // guards enhancedStats trade totals
+
+	// ConfirmTrade, when set, is asked before the scanner executes a trade
+	// and the trade is skipped unless it returns true
+	ConfirmTrade func(pairName string, route Route, result *models.ArbitrageResult) bool
+
+	// OpportunityLog, when set, receives every opportunity the scanner finds
+	OpportunityLog *OpportunityLogger
+
+	// Sent transactions not yet seen mined, for MAX_PENDING_TX, and the
+	// auto-wrap among them, so another isn't stacked on top of it
+	pendingMu   sync.Mutex
+	pendingTxs  map[common.Hash]bool
+	pendingWrap common.Hash
+
+	// Pairs skipped until the given time after a failed execution
+	cooldownMu    sync.Mutex
+	pairCooldowns map[string]time.Time
+
+	// Where the next budgeted scan resumes among the pairs that take turns
+	cursorMu   sync.Mutex
+	pairCursor int
+
+	// Why the wallet can't trade, empty while it can. Execute skips every
+	// opportunity while this is set.
+	monitorMu     sync.Mutex
+	monitorReason string
+
+	// In-flight execution tracking for graceful shutdown
+	execMu       sync.Mutex
+	executions   sync.WaitGroup
+	shuttingDown bool
 }
 
 // NewArbitrageService creates a new ArbitrageService
@@ -41,108 +156,113 @@ func NewArbitrageService(
 	routerService *RouterService,
 	cfg *config.Config,
 ) *ArbitrageService {
-	return &ArbitrageService{
+	service := &ArbitrageService{
 		Client:        client,
+		Backend:       client,
 		TokenService:  tokenService,
 		RouterService: routerService,
+		V3Router:      NewV3RouterService(client, cfg),
 		Config:        cfg,
-		TokenPairs:    models.InitializeTokenPairs(),
+		TokenPairs:    applyPairOverrides(models.InitializeTokenPairs(), cfg.PairOverrides),
+		Clock:         utils.SystemClock{},
 
-		PancakeRouter: common.HexToAddress(config.PancakeswapRouter),
-		BiswapRouter:  common.HexToAddress(config.BiswapRouter),
+		DEXes:         DefaultDEXes(routerService),
 		FlashContract: common.HexToAddress(cfg.FlashArbContract),
-	}
-}
-
-// FindArbitrageOpportunities scans all token pairs for arbitrage opportunities
-func (s *ArbitrageService) FindArbitrageOpportunities() error {
-	log.Println("Scanning for arbitrage opportunities...")
-
-	// Loop through all token pairs
-	for _, pair := range s.TokenPairs {
-		log.Printf("Checking pair: %s", pair.Name)
-
-		// Verify tokens and pairs before trying arbitrage
-		if err := s.VerifyPairTokens(pair); err != nil {
-			log.Printf("Warning: Pair %s has issues: %v", pair.Name, err)
-			continue // Skip pairs with issues
+		poolStatus:    make(map[string]bool),
+
+		enhancedStats: EnhancedStats{
+			CategoryStats: make(map[string]int),
+		},
+	}
+	service.Strategies = NewStrategies(service, cfg.Strategies)
+
+	// An EOA or wrong-chain address would only show up mid-trade otherwise
+	if service.FlashContract != (common.Address{}) {
+		service.flashContractErr = service.VerifyFlashContract()
+		if errors.Is(service.flashContractErr, ErrFlashMisconfigured) {
+			slog.Error("🚨 No contract deployed at FLASH_ARB_CONTRACT, every flash execution will fail",
+				"address", service.FlashContract.Hex(), "err", service.flashContractErr)
+		} else if service.flashContractErr != nil {
+			slog.Warn("⚠️ Could not verify the flash contract", "address", service.FlashContract.Hex(),
+				"err", service.flashContractErr)
 		}
+	}
 
-		// Try different test amounts
-		for _, amount := range pair.TestAmounts {
-			// Check PancakeSwap -> BiSwap -> PancakeSwap route
-			resultPancakeFirst, err := s.CheckTriangularArbitrage(pair, amount, true)
-			if err != nil {
-				log.Printf("Error checking Pancake->BiSwap route: %v", err)
-				continue
-			}
-
-			// Check BiSwap -> PancakeSwap -> BiSwap route
-			resultBiswapFirst, err := s.CheckTriangularArbitrage(pair, amount, false)
-			if err != nil {
-				log.Printf("Error checking BiSwap->Pancake route: %v", err)
-				continue
-			}
-
-			// Log the results with proper formatting
-			log.Printf("Pancake->BiSwap route profit: %.4f%%", resultPancakeFirst.ProfitPercent*100)
-			log.Printf("BiSwap->Pancake route profit: %.4f%%", resultBiswapFirst.ProfitPercent*100)
-
-			// Check if either route is profitable enough
-			if resultPancakeFirst.ProfitPercent > s.Config.MinProfit {
-				log.Printf("Found profitable opportunity (Pancake->BiSwap): %.4f%%", resultPancakeFirst.ProfitPercent*100)
+	return service
+}
 
-				// Double-check profitability with a second calculation
-				confirmProfit, err := s.ConfirmProfitability(pair, amount, true)
-				if err != nil || confirmProfit < s.Config.MinProfit {
-					log.Printf("Profit confirmation failed: %.4f%% (below threshold or error: %v)",
-						confirmProfit*100, err)
-					continue
-				}
+// VerifyFlashContract checks there is code at FLASH_ARB_CONTRACT, returning
+// an error wrapping ErrFlashMisconfigured when there is none
+func (s *ArbitrageService) VerifyFlashContract() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-				// Execute the arbitrage if we have a flash arbitrage contract
-				if s.FlashContract != (common.Address{}) {
-					err = s.ExecuteArbitrage(pair, resultPancakeFirst.TargetAmount, true)
-					if err != nil {
-						log.Printf("Error executing arbitrage: %v", err)
-					}
-				} else {
-					log.Println("Flash arbitrage contract not set. Skipping execution.")
-				}
+	code, err := s.Backend.CodeAt(ctx, s.FlashContract, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read flash contract code: %v", err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("%w: no contract at FLASH_ARB_CONTRACT %s on this chain", ErrFlashMisconfigured, s.FlashContract.Hex())
+	}
+	return nil
+}
 
-				return nil
-			} else if resultBiswapFirst.ProfitPercent > s.Config.MinProfit {
-				log.Printf("Found profitable opportunity (BiSwap->Pancake): %.4f%%", resultBiswapFirst.ProfitPercent*100)
+// FlashContractStatus returns what the startup check of FLASH_ARB_CONTRACT
+// found: nil when code was found or no contract is configured
+func (s *ArbitrageService) FlashContractStatus() error {
+	return s.flashContractErr
+}
 
-				// Double-check profitability with a second calculation
-				confirmProfit, err := s.ConfirmProfitability(pair, amount, false)
-				if err != nil || confirmProfit < s.Config.MinProfit {
-					log.Printf("Profit confirmation failed: %.4f%% (below threshold or error: %v)",
-						confirmProfit*100, err)
-					continue
-				}
+// applyPairOverrides sets each pair's threshold overrides from PAIR_OVERRIDES
+func applyPairOverrides(pairs []models.TokenPair, overrides map[string]config.PairOverride) []models.TokenPair {
+	known := make(map[string]bool, len(pairs))
+	for i := range pairs {
+		known[pairs[i].Name] = true
+		if override, exists := overrides[pairs[i].Name]; exists {
+			pairs[i].MinProfitOverride = override.MinProfit
+			pairs[i].GasAdjustmentOverride = override.GasAdjustment
+		}
+	}
 
-				// Execute the arbitrage if we have a flash arbitrage contract
-				if s.FlashContract != (common.Address{}) {
-					err = s.ExecuteArbitrage(pair, resultBiswapFirst.TargetAmount, false)
-					if err != nil {
-						log.Printf("Error executing arbitrage: %v", err)
-					}
-				} else {
-					log.Println("Flash arbitrage contract not set. Skipping execution.")
-				}
+	for name := range overrides {
+		if !known[name] {
+			slog.Warn("⚠️ PAIR_OVERRIDES names an unknown pair, ignoring it", "pair", name)
+		}
+	}
+	return pairs
+}
 
-				return nil
+// minPairTokens is the fewest tokens a triangular cycle can walk
+const minPairTokens = 3
+
+// checkPairTokenKeys reports a pair that can't form a cycle before any of its
+// addresses reach a router: one without a WBNB token, which every cycle is
+// sized and valued in, or with too few tokens
+func checkPairTokenKeys(pair models.TokenPair) error {
+	if _, exists := pair.Tokens["WBNB"]; !exists {
+		// Point at the likely typo, e.g. "wbnb" or WBNB's address under another symbol
+		for name, addr := range pair.Tokens {
+			if strings.EqualFold(name, "WBNB") || strings.EqualFold(addr, config.WBNB) {
+				return fmt.Errorf("pair %s has no WBNB token, but lists %s (%s): token keys must be exactly \"WBNB\"",
+					pair.Name, name, addr)
 			}
 		}
+		return fmt.Errorf("pair %s has no WBNB token", pair.Name)
 	}
 
-	log.Println("No profitable arbitrage opportunities found in this round.")
+	if len(pair.Tokens) < minPairTokens {
+		return fmt.Errorf("pair %s has %d tokens, triangular arbitrage needs at least %d",
+			pair.Name, len(pair.Tokens), minPairTokens)
+	}
 	return nil
 }
 
 // VerifyPairTokens checks if all tokens and pairs are valid
 func (s *ArbitrageService) VerifyPairTokens(pair models.TokenPair) error {
+	if err := checkPairTokenKeys(pair); err != nil {
+		return err
+	}
+
 	var errors []string
 
 	// Verify tokens
@@ -165,150 +285,255 @@ func (s *ArbitrageService) VerifyPairTokens(pair models.TokenPair) error {
 	}
 
 	// Quick validation of pair addresses
-	for name, addr := range pair.PancakeswapPair {
-		if addr != "" && !common.IsHexAddress(addr) {
-			errors = append(errors, fmt.Sprintf("Invalid PancakeSwap pair address %s: %s", name, addr))
-		}
-	}
-
-	for name, addr := range pair.BiswapPair {
-		if addr != "" && !common.IsHexAddress(addr) {
-			errors = append(errors, fmt.Sprintf("Invalid BiSwap pair address %s: %s", name, addr))
+	for _, dex := range s.DEXes {
+		for name, addr := range dex.PairAddresses(&pair) {
+			if addr != "" && !common.IsHexAddress(addr) {
+				errors = append(errors, fmt.Sprintf("Invalid %s pair address %s: %s", dex.Name(), name, addr))
+			}
 		}
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("pair verification issues: %s", strings.Join(errors, "; "))
+		return fmt.Errorf("pair %s verification issues: %s", pair.Name, strings.Join(errors, "; "))
 	}
 
 	return nil
 }
 
-// CheckTriangularArbitrage checks if a triangular arbitrage opportunity exists
-func (s *ArbitrageService) CheckTriangularArbitrage(
-	pair models.TokenPair,
-	testAmount float64,
-	pancakeFirst bool,
-) (*models.ArbitrageResult, error) {
-	// Get token addresses safely
-	tokenA := common.HexToAddress(pair.Tokens["WBNB"])
+// CheckPairLiquidity verifies that every configured pool of a token pair holds
+// at least MinReserveWBNB worth of liquidity and, when MinLiquidityUSD is set,
+// is worth at least that much in total. Thin pools give misleading quotes.
+func (s *ArbitrageService) CheckPairLiquidity(pair models.TokenPair) error {
+	wbnb := common.HexToAddress(pair.Tokens["WBNB"])
+	var wbnbPrice float64 // USD, quoted once the first pool needs it
 
-	// Get the other two tokens (not WBNB)
-	otherTokens := getOtherTokens(pair.Tokens)
-	if len(otherTokens) < 2 {
-		return nil, fmt.Errorf("need at least 3 tokens for triangular arbitrage, got %d", len(otherTokens)+1)
-	}
+	for _, dex := range s.DEXes {
+		for key, addr := range dex.PairAddresses(&pair) {
+			if addr == "" {
+				continue
+			}
+
+			symbols := strings.Split(key, "-")
+			if len(symbols) != 2 {
+				return fmt.Errorf("invalid %s pair key %s", dex.Name(), key)
+			}
+
+			tokenA := common.HexToAddress(pair.Tokens[symbols[0]])
+			tokenB := common.HexToAddress(pair.Tokens[symbols[1]])
+			if s.poolUnlisted(dex, tokenA, tokenB) {
+				continue // routes through it are skipped too
+			}
+
+			// Measure liquidity on the WBNB side when the pool has one
+			if tokenB == wbnb {
+				tokenA, tokenB = tokenB, tokenA
+			}
+
+			reserveA, reserveB, err := s.RouterService.cachedPairReserves(common.HexToAddress(addr), tokenA, tokenB)
+			if err != nil {
+				return fmt.Errorf("failed to get %s reserves for %s: %v", dex.Name(), key, err)
+			}
+
+			liquidity, err := s.reserveValueInWBNB(dex, tokenA, reserveA, wbnb)
+			if err != nil {
+				return fmt.Errorf("failed to value %s reserves for %s: %v", dex.Name(), key, err)
+			}
+
+			if liquidity < s.Config.MinReserveWBNB {
+				return fmt.Errorf("%w: %s pool %s has %.4f WBNB < %.4f WBNB minimum",
+					ErrPoolTooThin, dex.Name(), key, liquidity, s.Config.MinReserveWBNB)
+			}
+
+			if s.Config.MinLiquidityUSD <= 0 {
+				continue
+			}
 
-	tokenB := common.HexToAddress(pair.Tokens[otherTokens[0]])
-	tokenC := common.HexToAddress(pair.Tokens[otherTokens[1]])
+			value, err := s.poolValueUSD(tokenA, reserveA, tokenB, reserveB, liquidity, &wbnbPrice)
+			if err != nil {
+				return fmt.Errorf("failed to value %s pool %s in USD: %v", dex.Name(), key, err)
+			}
 
-	// Verify the addresses are different
-	if tokenA == tokenB || tokenB == tokenC || tokenA == tokenC {
-		return nil, fmt.Errorf("token addresses must be different for arbitrage")
+			if value < s.Config.MinLiquidityUSD {
+				return fmt.Errorf("%w: %s pool %s is worth $%.0f < $%.0f minimum",
+					ErrPoolTooThin, dex.Name(), key, value, s.Config.MinLiquidityUSD)
+			}
+		}
 	}
 
-	// Log token addresses for debugging
-	log.Printf("Token A (WBNB): %s", tokenA.Hex())
-	log.Printf("Token B (%s): %s", otherTokens[0], tokenB.Hex())
-	log.Printf("Token C (%s): %s", otherTokens[1], tokenC.Hex())
+	return nil
+}
 
-	// Get token decimals
-	tokenADecimals, err := s.TokenService.GetTokenDecimals(tokenA)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get decimals for WBNB: %v", err)
+// poolValueUSD values a pool at twice one side's reserve: its USDT reserve
+// when it has one, otherwise reserveA's WBNB value at the WBNB-USDT price.
+// The price is quoted the first time it's needed and kept in wbnbPrice.
+func (s *ArbitrageService) poolValueUSD(
+	tokenA common.Address, reserveA *big.Int,
+	tokenB common.Address, reserveB *big.Int,
+	valueWBNB float64, wbnbPrice *float64,
+) (float64, error) {
+	usdt := common.HexToAddress(config.USDT)
+
+	var usdtReserve *big.Int
+	switch usdt {
+	case tokenA:
+		usdtReserve = reserveA
+	case tokenB:
+		usdtReserve = reserveB
+	}
+	if usdtReserve != nil {
+		decimals, err := s.TokenService.GetTokenDecimals(usdt)
+		if err != nil {
+			return 0, err
+		}
+		return 2 * s.TokenService.ConvertToReadable(usdtReserve, decimals), nil
 	}
 
-	// Convert test amount to token amount with decimals
-	tokenAmount := s.TokenService.FormatTokenAmount(testAmount, tokenADecimals)
-	log.Printf("Test amount: %.6f WBNB (%s wei)", testAmount, tokenAmount.String())
-
-	// Prepare paths for both routes
-	path1 := []common.Address{tokenA, tokenB}
-	path2 := []common.Address{tokenB, tokenC}
-	path3 := []common.Address{tokenC, tokenA}
-
-	var route1Router, route2Router, route3Router common.Address
-	var routeDescription string
-
-	if pancakeFirst {
-		// PancakeSwap -> BiSwap -> PancakeSwap
-		route1Router = s.PancakeRouter
-		route2Router = s.BiswapRouter
-		route3Router = s.PancakeRouter
-		routeDescription = "PancakeSwap -> BiSwap -> PancakeSwap"
-	} else {
-		// BiSwap -> PancakeSwap -> BiSwap
-		route1Router = s.BiswapRouter
-		route2Router = s.PancakeRouter
-		route3Router = s.BiswapRouter
-		routeDescription = "BiSwap -> PancakeSwap -> BiSwap"
+	if *wbnbPrice == 0 {
+		price, err := s.wbnbPriceUSD()
+		if err != nil {
+			return 0, err
+		}
+		*wbnbPrice = price
 	}
+	return 2 * valueWBNB * *wbnbPrice, nil
+}
 
-	log.Printf("Route: %s", routeDescription)
+// wbnbPriceUSD quotes one WBNB in USDT on the first exchange
+func (s *ArbitrageService) wbnbPriceUSD() (float64, error) {
+	wbnb, usdt := common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT)
 
-	// Calculate amounts out for each step in the route
-	// Step 1: WBNB -> TokenB
-	amounts1, err := s.RouterService.GetAmountsOut(route1Router, tokenAmount, path1)
+	decimals, err := s.TokenService.GetTokenDecimals(usdt)
 	if err != nil {
-		return nil, fmt.Errorf("error in step 1 (WBNB -> %s): %v", otherTokens[0], err)
+		return 0, err
 	}
 
-	if len(amounts1) < 2 {
-		return nil, fmt.Errorf("invalid amounts1 length: %d", len(amounts1))
+	amounts, err := s.DEXes[0].GetAmountsOut(big.NewInt(1e18), []common.Address{wbnb, usdt})
+	if err != nil {
+		return 0, fmt.Errorf("failed to quote WBNB price: %v", err)
 	}
+	return s.TokenService.ConvertToReadable(amounts[len(amounts)-1], decimals), nil
+}
 
-	var dex1 string
-	if pancakeFirst {
-		dex1 = "PancakeSwap"
-	} else {
-		dex1 = "BiSwap"
+// reserveValueInWBNB converts a token reserve into its WBNB equivalent using
+// the spot price of one whole token on the given exchange
+func (s *ArbitrageService) reserveValueInWBNB(dex DEX, token common.Address, reserve *big.Int, wbnb common.Address) (float64, error) {
+	decimals, err := s.TokenService.GetTokenDecimals(token)
+	if err != nil {
+		return 0, err
 	}
 
-	log.Printf("Step 1 (WBNB -> %s via %s): In: %s, Out: %s",
-		otherTokens[0], dex1, tokenAmount.String(), amounts1[1].String())
+	readable := s.TokenService.ConvertToReadable(reserve, decimals)
+	if token == wbnb {
+		return readable, nil
+	}
 
-	// Step 2: TokenB -> TokenC
-	amounts2, err := s.RouterService.GetAmountsOut(route2Router, amounts1[1], path2)
+	oneToken := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	amounts, err := dex.GetAmountsOut(oneToken, []common.Address{token, wbnb})
 	if err != nil {
-		return nil, fmt.Errorf("error in step 2 (%s -> %s): %v", otherTokens[0], otherTokens[1], err)
+		return 0, err
+	}
+
+	return readable * s.TokenService.ConvertToReadable(amounts[len(amounts)-1], 18), nil
+}
+
+// CheckTriangularArbitrage checks if a triangular arbitrage opportunity exists
+// on a route. The cycle starts and ends in the route's base token; testAmount
+// is in WBNB and sizes cycles on other base tokens at the same value, so the
+// result's WBNB figures compare across bases.
+func (s *ArbitrageService) CheckTriangularArbitrage(
+	pair models.TokenPair,
+	testAmount float64,
+	route Route,
+) (*models.ArbitrageResult, error) {
+	if len(route.Hops) == 0 {
+		return nil, fmt.Errorf("route has no hops")
 	}
 
-	if len(amounts2) < 2 {
-		return nil, fmt.Errorf("invalid amounts2 length: %d", len(amounts2))
+	// A pool that isn't listed would only revert, so don't spend RPC calls on it
+	for _, hop := range route.Hops {
+		if s.poolMissing(hop) {
+			slog.Debug("Skipping route: pool not listed", "route", route.String(),
+				"dex", hop.DEX.Name(), "from", hop.SymbolIn, "to", hop.SymbolOut)
+			return nil, ErrNoOpportunity
+		}
 	}
 
-	var dex2 string
-	if pancakeFirst {
-		dex2 = "BiSwap"
-	} else {
-		dex2 = "PancakeSwap"
+	// Log token addresses for debugging
+	for _, hop := range route.Hops[:len(route.Hops)-1] {
+		slog.Debug("Token", "symbol", hop.SymbolOut, "address", hop.TokenOut.Hex())
 	}
 
-	log.Printf("Step 2 (%s -> %s via %s): In: %s, Out: %s",
-		otherTokens[0], otherTokens[1], dex2, amounts1[1].String(), amounts2[1].String())
+	// Get token decimals
+	base := route.Hops[0]
+	tokenADecimals, err := s.TokenService.GetTokenDecimals(base.TokenIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decimals for %s: %v", base.SymbolIn, err)
+	}
 
-	// Step 3: TokenC -> WBNB
-	amounts3, err := s.RouterService.GetAmountsOut(route3Router, amounts2[1], path3)
+	// Convert test amount to token amount with decimals
+	tokenAmount, err := s.baseAmount(base, testAmount, tokenADecimals)
 	if err != nil {
-		return nil, fmt.Errorf("error in step 3 (%s -> WBNB): %v", otherTokens[1], err)
+		return nil, err
+	}
+	slog.Debug("Quoting route", "route", route.String(), "base", base.SymbolIn,
+		"amount_wbnb", testAmount, "amount_wei", tokenAmount.String())
+
+	// Calculate amounts out for each hop in the route
+	legIn := tokenAmount
+
+	for i, hop := range route.Hops {
+		amounts, err := hop.DEX.GetAmountsOut(legIn, hop.Path())
+		if err != nil {
+			return nil, fmt.Errorf("error in step %d (%s -> %s): %v", i+1, hop.SymbolIn, hop.SymbolOut, err)
+		}
+
+		if len(amounts) < 2 {
+			return nil, fmt.Errorf("invalid amounts%d length: %d", i+1, len(amounts))
+		}
+
+		slog.Debug("Quoted step", "step", i+1, "from", hop.SymbolIn, "to", hop.SymbolOut,
+			"dex", hop.DEX.Name(), "in", legIn.String(), "out", amounts[1].String())
+		s.logSpotPriceCheck(pair, hop, legIn, amounts[1])
+
+		legIn = amounts[1]
 	}
 
-	if len(amounts3) < 2 {
-		return nil, fmt.Errorf("invalid amounts3 length: %d", len(amounts3))
+	amountWBNB := s.TokenService.ConvertToReadable(tokenAmount, tokenADecimals)
+	if base.SymbolIn != "WBNB" {
+		amountWBNB = testAmount
 	}
 
-	var dex3 string
-	if pancakeFirst {
-		dex3 = "PancakeSwap"
-	} else {
-		dex3 = "BiSwap"
+	result := s.buildArbitrageResult(tokenAmount, legIn, tokenADecimals, amountWBNB)
+	result.Venues = route.DEXNames()
+	for _, hop := range route.Hops {
+		result.Path = append(result.Path, hop.TokenIn.Hex())
+	}
+	result.Path = append(result.Path, route.Hops[len(route.Hops)-1].TokenOut.Hex())
+
+	return result, nil
+}
+
+// baseAmount converts a WBNB test amount into the hop's input token: as is for
+// WBNB, otherwise at the hop exchange's WBNB price
+func (s *ArbitrageService) baseAmount(hop Hop, amountWBNB float64, decimals uint8) (*big.Int, error) {
+	if hop.SymbolIn == "WBNB" {
+		return s.TokenService.FormatTokenAmount(amountWBNB, decimals), nil
 	}
 
-	log.Printf("Step 3 (%s -> WBNB via %s): In: %s, Out: %s",
-		otherTokens[1], dex3, amounts2[1].String(), amounts3[1].String())
+	wbnb := common.HexToAddress(config.WBNB)
+	amounts, err := hop.DEX.GetAmountsOut(s.TokenService.FormatTokenAmount(amountWBNB, 18),
+		[]common.Address{wbnb, hop.TokenIn})
+	if err != nil {
+		return nil, fmt.Errorf("failed to price %.4f WBNB in %s: %v", amountWBNB, hop.SymbolIn, err)
+	}
+	return amounts[len(amounts)-1], nil
+}
 
+// buildArbitrageResult computes profit, platform fee and gas-adjusted figures
+// for a round trip that turned tokenAmount of the base token into
+// finalAmount, where tokenAmount is worth amountWBNB
+func (s *ArbitrageService) buildArbitrageResult(tokenAmount, finalAmount *big.Int, tokenADecimals uint8, amountWBNB float64) *models.ArbitrageResult {
 	// Calculate profit (or loss)
-	finalAmount := amounts3[1]
 	profit := new(big.Int).Sub(finalAmount, tokenAmount)
 
 	// Calculate profit percentage
@@ -321,204 +546,668 @@ func (s *ArbitrageService) CheckTriangularArbitrage(
 		profitPercent, _ = percentFloat.Float64()
 	}
 
-	// Estimate gas costs (~0.1% for BSC)
-	gasAdjustedProfitPercent := profitPercent - 0.001
+	// Split profit between the platform and the user
+	platformFee := s.CalculatePlatformFee(profit)
+	userProfit := new(big.Int).Sub(profit, platformFee)
+
+	// WBNB value of one base token, exactly 1 for WBNB cycles
+	wbnbPerToken := 0.0
+	if initial := s.TokenService.ConvertToReadable(tokenAmount, tokenADecimals); initial > 0 {
+		wbnbPerToken = amountWBNB / initial
+	}
+	inWBNB := func(amount *big.Int) float64 {
+		return s.TokenService.ConvertToReadable(amount, tokenADecimals) * wbnbPerToken
+	}
+
+	// Absolute gas cost in WBNB (BNB and WBNB are 1:1)
+	profitWBNB := inWBNB(profit)
+	gasCostWBNB := s.EstimateGasCostWBNB()
+	gasAdjustedProfitPercent := profitPercent - gasCostPercent(gasCostWBNB, amountWBNB)
+	netProfitWBNB := inWBNB(userProfit) - gasCostWBNB
 
 	// Log results with proper formatting
-	log.Printf("Initial: %.6f WBNB, Final: %.6f WBNB",
-		s.TokenService.ConvertToReadable(tokenAmount, tokenADecimals),
-		s.TokenService.ConvertToReadable(finalAmount, tokenADecimals))
-	log.Printf("Profit: %.6f WBNB (%.4f%%), Gas adjusted profit: %.4f%%",
-		s.TokenService.ConvertToReadable(profit, tokenADecimals),
-		profitPercent*100, gasAdjustedProfitPercent*100)
-
-	// Prepare the result
-	result := &models.ArbitrageResult{
+	slog.Debug("Round trip quoted",
+		"initial_wbnb", amountWBNB,
+		"final_wbnb", inWBNB(finalAmount),
+		"profit_wbnb", profitWBNB,
+		"profit_pct", profitPercent*100,
+		"gas_adjusted_pct", gasAdjustedProfitPercent*100,
+		"platform_fee_wbnb", inWBNB(platformFee),
+		"gas_cost_wbnb", gasCostWBNB,
+		"net_profit_wbnb", netProfitWBNB)
+
+	return &models.ArbitrageResult{
 		Profit:        profit,
-		PlatformFee:   new(big.Int).Div(profit, big.NewInt(10)), // 10% platform fee
-		UserProfit:    new(big.Int).Sub(profit, new(big.Int).Div(profit, big.NewInt(10))),
+		PlatformFee:   platformFee,
+		UserProfit:    userProfit,
 		TargetAmount:  tokenAmount,
+		AmountWBNB:    amountWBNB,
 		ProfitPercent: gasAdjustedProfitPercent,
-		Direction:     pancakeFirst,
-		Path:          []string{pair.Tokens["WBNB"], pair.Tokens[otherTokens[0]], pair.Tokens[otherTokens[1]]},
+		GasCostWBNB:   gasCostWBNB,
+		NetProfitWBNB: netProfitWBNB,
 	}
-
-	return result, nil
 }
 
-// ConfirmProfitability does a second profit calculation to verify results
-func (s *ArbitrageService) ConfirmProfitability(
-	pair models.TokenPair,
-	testAmount float64,
-	pancakeFirst bool,
-) (float64, error) {
-	// Get token addresses safely
-	tokenA := common.HexToAddress(pair.Tokens["WBNB"])
-
-	otherTokens := getOtherTokens(pair.Tokens)
+// CheckBestVenueArbitrage quotes the WBNB -> B -> C -> WBNB cycle picking the
+// best venue for every leg independently: PancakeSwap V2, BiSwap, and (when
+// enabled) each PancakeSwap V3 fee tier. Since each leg's output only grows
+// with its input, taking the best quote per leg gives the best whole route.
+func (s *ArbitrageService) CheckBestVenueArbitrage(pair models.TokenPair, testAmount float64) (*models.ArbitrageResult, error) {
+	otherTokens := getOtherTokens(pair.Tokens, "WBNB")
 	if len(otherTokens) < 2 {
-		return 0, fmt.Errorf("need at least 3 tokens for triangular arbitrage")
+		return nil, fmt.Errorf("need at least 3 tokens for triangular arbitrage, got %d", len(otherTokens)+1)
 	}
 
-	tokenB := common.HexToAddress(pair.Tokens[otherTokens[0]])
-	tokenC := common.HexToAddress(pair.Tokens[otherTokens[1]])
+	symbols := []string{"WBNB", otherTokens[0], otherTokens[1], "WBNB"}
 
-	// Get token decimals
-	decimalsA, err := s.TokenService.GetTokenDecimals(tokenA)
+	tokenA := common.HexToAddress(pair.Tokens["WBNB"])
+	tokenADecimals, err := s.TokenService.GetTokenDecimals(tokenA)
 	if err != nil {
-		return 0, err
+		return nil, fmt.Errorf("failed to get decimals for WBNB: %v", err)
 	}
 
-	// Convert to wei
-	amountInWei := s.TokenService.FormatTokenAmount(testAmount, decimalsA)
+	tokenAmount := s.TokenService.FormatTokenAmount(testAmount, tokenADecimals)
+	legIn := tokenAmount
+	venues := make([]string, 0, 3)
+	path := []string{tokenA.Hex()}
 
-	// Prepare routes
-	var route1Router, route2Router, route3Router common.Address
-	if pancakeFirst {
-		route1Router = s.PancakeRouter
-		route2Router = s.BiswapRouter
-		route3Router = s.PancakeRouter
-	} else {
-		route1Router = s.BiswapRouter
-		route2Router = s.PancakeRouter
-		route3Router = s.BiswapRouter
-	}
+	for i := 0; i < 3; i++ {
+		tokenIn := common.HexToAddress(pair.Tokens[symbols[i]])
+		tokenOut := common.HexToAddress(pair.Tokens[symbols[i+1]])
 
-	// Calculate each swap
-	path1 := []common.Address{tokenA, tokenB}
-	amountOut1, err := s.RouterService.GetAmountOutSingle(route1Router, amountInWei, path1)
-	if err != nil {
-		return 0, err
-	}
+		legOut, venue, err := s.bestLegQuote(tokenIn, tokenOut, legIn)
+		if err != nil {
+			return nil, fmt.Errorf("error in step %d (%s -> %s): %v", i+1, symbols[i], symbols[i+1], err)
+		}
 
-	path2 := []common.Address{tokenB, tokenC}
-	amountOut2, err := s.RouterService.GetAmountOutSingle(route2Router, amountOut1, path2)
-	if err != nil {
-		return 0, err
-	}
+		slog.Debug("Quoted step", "step", i+1, "from", symbols[i], "to", symbols[i+1],
+			"dex", venue, "in", legIn.String(), "out", legOut.String())
 
-	path3 := []common.Address{tokenC, tokenA}
-	amountOut3, err := s.RouterService.GetAmountOutSingle(route3Router, amountOut2, path3)
-	if err != nil {
-		return 0, err
+		venues = append(venues, venue)
+		path = append(path, tokenOut.Hex())
+		legIn = legOut
 	}
 
-	// Calculate profit
-	profit := new(big.Float).SetInt(new(big.Int).Sub(amountOut3, amountInWei))
-	initial := new(big.Float).SetInt(amountInWei)
+	result := s.buildArbitrageResult(tokenAmount, legIn, tokenADecimals,
+		s.TokenService.ConvertToReadable(tokenAmount, tokenADecimals))
+	result.Path = path
+	result.Venues = venues
 
-	var profitPercent float64
-	if initial.Cmp(big.NewFloat(0)) > 0 {
-		percentFloat := new(big.Float).Quo(profit, initial)
-		profitPercent, _ = percentFloat.Float64()
+	return result, nil
+}
+
+// bestLegQuote returns the largest output for one hop across all venues
+func (s *ArbitrageService) bestLegQuote(tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, string, error) {
+	var bestOut *big.Int
+	var bestVenue string
+	var lastErr error
+
+	consider := func(venue string, amountOut *big.Int, err error) {
+		if err != nil {
+			lastErr = err
+			return
+		}
+		if bestOut == nil || amountOut.Cmp(bestOut) > 0 {
+			bestOut = amountOut
+			bestVenue = venue
+		}
 	}
 
-	// Subtract gas costs (approximately 0.1%)
-	profitPercent -= 0.001
+	path := []common.Address{tokenIn, tokenOut}
 
-	return profitPercent, nil
-}
+	for _, dex := range s.DEXes {
+		amounts, err := dex.GetAmountsOut(amountIn, path)
+		if err != nil {
+			consider(dex.Name(), nil, err)
+			continue
+		}
+		consider(dex.Name(), amounts[len(amounts)-1], nil)
+	}
 
-// ExecuteArbitrage executes a triangular arbitrage trade
-func (s *ArbitrageService) ExecuteArbitrage(
-	pair models.TokenPair,
-	amount *big.Int,
-	pancakeFirst bool,
-) error {
-	log.Printf("Executing arbitrage on pair %s, amount: %s, pancakeFirst: %v",
-		pair.Name, amount.String(), pancakeFirst)
+	if s.Config.EnableV3 && s.V3Router != nil {
+		amountOut, fee, err := s.V3Router.BestQuote(tokenIn, tokenOut, amountIn)
+		consider(fmt.Sprintf("PancakeSwap V3 %.2f%%", FeeTierPercent(fee)), amountOut, err)
+	}
 
-	// If we have a flash arbitrage contract, use it
-	if s.FlashContract != (common.Address{}) {
-		return s.ExecuteFlashArbitrage(pair, amount, pancakeFirst)
+	if bestOut == nil {
+		return nil, "", lastErr
 	}
+	return bestOut, bestVenue, nil
+}
 
-	// Otherwise execute manually (not recommended without flash loans)
-	return s.ExecuteManualArbitrage(pair, amount, pancakeFirst)
+// usesV3 reports whether any leg of a best-venue route goes through V3
+func usesV3(result *models.ArbitrageResult) bool {
+	for _, venue := range result.Venues {
+		if strings.HasPrefix(venue, "PancakeSwap V3") {
+			return true
+		}
+	}
+	return false
 }
 
-// ExecuteFlashArbitrage executes a triangular arbitrage using the flash arbitrage contract
-func (s *ArbitrageService) ExecuteFlashArbitrage(
+// logSpotPriceCheck logs the reserve-based spot price next to the price
+// implied by a getAmountsOut quote. A wide divergence usually means the
+// configured pair address doesn't belong to the router being quoted.
+func (s *ArbitrageService) logSpotPriceCheck(
 	pair models.TokenPair,
-	amount *big.Int,
-	pancakeFirst bool,
-) error {
-	log.Println("Executing flash arbitrage...")
-
-	// Get token addresses safely
-	tokenA := common.HexToAddress(pair.Tokens["WBNB"])
+	hop Hop,
+	amountIn, amountOut *big.Int,
+) {
+	// The check costs extra RPC calls, so only make them when they'll be logged
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
 
-	otherTokens := getOtherTokens(pair.Tokens)
-	if len(otherTokens) < 2 {
-		return fmt.Errorf("need at least 3 tokens for triangular arbitrage")
+	dexName := hop.DEX.Name()
+	symbolIn, symbolOut := hop.SymbolIn, hop.SymbolOut
+	pairAddr := findPairAddress(hop.DEX.PairAddresses(&pair), symbolIn, symbolOut)
+	if pairAddr == "" {
+		slog.Debug("🔍 No pair configured, skipping spot check", "dex", dexName, "from", symbolIn, "to", symbolOut)
+		return
 	}
 
-	tokenB := common.HexToAddress(pair.Tokens[otherTokens[0]])
-	tokenC := common.HexToAddress(pair.Tokens[otherTokens[1]])
+	tokenIn, tokenOut := hop.TokenIn, hop.TokenOut
 
-	// Prepare paths
-	path1 := []common.Address{tokenA, tokenB}
-	path2 := []common.Address{tokenB, tokenC}
-	path3 := []common.Address{tokenC, tokenA}
+	spotPrice, err := s.RouterService.GetSpotPrice(common.HexToAddress(pairAddr), tokenIn)
+	if err != nil {
+		slog.Debug("🔍 Spot price check failed", "dex", dexName, "from", symbolIn, "to", symbolOut, "err", err)
+		return
+	}
 
-	// Calculate min amounts out with 1% slippage tolerance
-	minOutA := new(big.Int).Div(new(big.Int).Mul(amount, big.NewInt(99)), big.NewInt(100))
-	minOutB := new(big.Int).Div(new(big.Int).Mul(amount, big.NewInt(99)), big.NewInt(100))
-	minOutC := new(big.Int).Div(new(big.Int).Mul(amount, big.NewInt(100)), big.NewInt(99))
+	decimalsIn, err := s.TokenService.GetTokenDecimals(tokenIn)
+	if err != nil {
+		return
+	}
+	decimalsOut, err := s.TokenService.GetTokenDecimals(tokenOut)
+	if err != nil {
+		return
+	}
 
-	minAmountsOut := []*big.Int{minOutA, minOutB, minOutC}
+	readableIn := s.TokenService.ConvertToReadable(amountIn, decimalsIn)
+	if readableIn == 0 {
+		return
+	}
+	quotedPrice := s.TokenService.ConvertToReadable(amountOut, decimalsOut) / readableIn
 
-	// Define pair address to borrow from
-	var pairAddress common.Address
-	pairKey := fmt.Sprintf("WBNB-%s", otherTokens[0])
+	divergence := 0.0
+	if spotPrice > 0 {
+		divergence = (quotedPrice - spotPrice) / spotPrice * 100
+	}
 
-	if pancakeFirst {
-		if addr, exists := pair.PancakeswapPair[pairKey]; exists && addr != "" {
-			pairAddress = common.HexToAddress(addr)
-		}
-	} else {
-		if addr, exists := pair.BiswapPair[pairKey]; exists && addr != "" {
-			pairAddress = common.HexToAddress(addr)
-		}
+	slog.Debug("🔍 Spot price check", "dex", dexName, "from", symbolIn, "to", symbolOut,
+		"spot", spotPrice, "quoted", quotedPrice, "divergence_pct", divergence)
+}
+
+// CalculatePlatformFee returns the flash contract's share of a profit based on
+// the configured fee in basis points. Losses carry no fee.
+func (s *ArbitrageService) CalculatePlatformFee(profit *big.Int) *big.Int {
+	if profit.Sign() <= 0 {
+		return big.NewInt(0)
 	}
 
-	if pairAddress == (common.Address{}) {
-		return fmt.Errorf("pair address not found for flash loan")
+	fee := new(big.Int).Mul(profit, big.NewInt(int64(s.Config.PlatformFeeBps)))
+	return fee.Div(fee, big.NewInt(10000))
+}
+
+// UserProfitPercent returns the user's share of the profit relative to the
+// input amount, less the result's gas cost like ProfitPercent
+func (s *ArbitrageService) UserProfitPercent(result *models.ArbitrageResult) float64 {
+	if result.TargetAmount == nil || result.TargetAmount.Sign() <= 0 {
+		return 0
 	}
 
-	log.Printf("Using pair address for flash loan: %s", pairAddress.Hex())
+	percentFloat := new(big.Float).Quo(
+		new(big.Float).SetInt(result.UserProfit),
+		new(big.Float).SetInt(result.TargetAmount),
+	)
+	percent, _ := percentFloat.Float64()
 
-	// Prepare arbitrage data
-	arbData := models.ArbitrageData{
-		Path1:         path1,
-		Path2:         path2,
-		Path3:         path3,
-		MinAmountsOut: minAmountsOut,
-		Direction:     pancakeFirst,
+	amountWBNB := result.AmountWBNB
+	if amountWBNB == 0 {
+		amountWBNB = s.TokenService.ConvertToReadable(result.TargetAmount, 18)
 	}
+	return percent - gasCostPercent(result.GasCostWBNB, amountWBNB)
+}
 
-	// Get nonce
-	nonce, err := s.Client.Client.PendingNonceAt(context.Background(), s.Client.Address)
-	if err != nil {
-		return err
+// gasCostPercent expresses a gas cost as a fraction of the WBNB traded, so it
+// can be subtracted from a profit percentage
+func gasCostPercent(gasCostWBNB, amountWBNB float64) float64 {
+	if amountWBNB <= 0 {
+		return 0
 	}
+	return gasCostWBNB / amountWBNB
+}
 
-	// Get gas price
-	gasPrice, err := s.Client.Client.SuggestGasPrice(context.Background())
+// RefreshGasPrice fetches the network gas price used by EstimateGasCostWBNB.
+// Call it once at the start of each scan so every route quoted in the scan
+// shares one gas price instead of making an RPC call per route.
+func (s *ArbitrageService) RefreshGasPrice() *big.Int {
+	gasPrice, err := s.Backend.SuggestGasPrice(context.Background())
 	if err != nil {
-		return err
+		slog.Warn("Failed to get gas price, using configured value", "err", err)
+		gasPrice = big.NewInt(s.Config.GasPrice)
 	}
 
-	// Pack function call
-	callData, err := contracts.FlashABI.Pack(
-		"executeFlashLoan",
-		pairAddress,
-		amount,
-		arbData,
-		pancakeFirst,
-	)
-	if err != nil {
-		return err
+	s.gasPriceMu.Lock()
+	s.scanGasPrice = gasPrice
+	s.gasPriceMu.Unlock()
+	return gasPrice
+}
+
+// PinBlock pins every quote and reserve read to a block, or back to the latest
+// block when nil
+func (s *ArbitrageService) PinBlock(block *big.Int) {
+	s.RouterService.SetQuoteBlock(block)
+	if s.V3Router != nil {
+		s.V3Router.SetQuoteBlock(block)
+	}
+}
+
+// bindQuoteContext ties quote and reserve reads to a scan's context, or
+// detaches them when nil
+func (s *ArbitrageService) bindQuoteContext(ctx context.Context) {
+	s.RouterService.SetQuoteContext(ctx)
+	if s.V3Router != nil {
+		s.V3Router.SetQuoteContext(ctx)
+	}
+}
+
+// pinLatestBlock pins quotes to the current block number, falling back to
+// unpinned "latest" reads if the node can't report it
+func (s *ArbitrageService) pinLatestBlock(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	block, err := s.Backend.BlockNumber(ctx)
+	if err != nil {
+		slog.Warn("⚠️ Failed to get block number, quoting against latest", "err", err)
+		s.PinBlock(nil)
+		return
+	}
+	s.PinBlock(new(big.Int).SetUint64(block))
+}
+
+// EstimateGasCostWBNB estimates the cost of one arbitrage transaction in WBNB
+// using the configured gas limit and the scan's gas price, fetching it first
+// when quoting outside a scan. The price is buffered the same way trades are
+// sent so the profit gate charges what execution will pay.
+func (s *ArbitrageService) EstimateGasCostWBNB() float64 {
+	// Native BNB has 18 decimals, same as WBNB
+	return s.TokenService.ConvertToReadable(s.estimateGasCost(), 18)
+}
+
+// estimateGasCost is EstimateGasCostWBNB in wei
+func (s *ArbitrageService) estimateGasCost() *big.Int {
+	s.gasPriceMu.Lock()
+	gasPrice := s.scanGasPrice
+	s.gasPriceMu.Unlock()
+	if gasPrice == nil {
+		gasPrice = s.RefreshGasPrice()
+	}
+
+	gasPrice = applyGasPriceBuffer(gasPrice, s.Config.GasPriceBufferPercent)
+	return new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(s.Config.GasLimit))
+}
+
+// GetRoutePriceImpact calculates the aggregate price impact of trading amount
+// through every hop of the route. Hops are measured on their pool's reserves,
+// read once per quote block; a hop whose pool the pair doesn't list is
+// measured against its router instead.
+func (s *ArbitrageService) GetRoutePriceImpact(pair models.TokenPair, route Route, amount *big.Int) (float64, error) {
+	// Impacts compound across legs: each leg keeps (1 - impact) of the price
+	remaining := 1.0
+	legIn := amount
+
+	for i, hop := range route.Hops {
+		pool := findPairAddress(hop.DEX.PairAddresses(&pair), hop.SymbolIn, hop.SymbolOut)
+		if pool == "" {
+			impact, err := s.RouterService.GetPriceImpact(hop.DEX.Router(), legIn, hop.Path())
+			if err != nil {
+				return 0, fmt.Errorf("error calculating price impact for leg %d: %v", i+1, err)
+			}
+			remaining *= 1 - impact/100
+
+			legIn, err = s.RouterService.GetAmountOutSingle(hop.DEX.Router(), legIn, hop.Path())
+			if err != nil {
+				return 0, fmt.Errorf("error quoting leg %d: %v", i+1, err)
+			}
+			continue
+		}
+
+		reserveIn, _, err := s.RouterService.cachedOrientedReserves(common.HexToAddress(pool), hop.TokenIn)
+		if err != nil {
+			return 0, fmt.Errorf("error getting leg %d reserves: %v", i+1, err)
+		}
+
+		// A constant-product swap of x moves the price by x / (reserveIn + x)
+		impact, _ := new(big.Float).Quo(
+			new(big.Float).SetInt(legIn),
+			new(big.Float).SetInt(new(big.Int).Add(reserveIn, legIn)),
+		).Float64()
+		remaining *= 1 - impact
+
+		legIn, err = hop.DEX.EstimateAmountOut(common.HexToAddress(pool), hop.TokenIn, legIn)
+		if err != nil {
+			return 0, fmt.Errorf("error estimating leg %d: %v", i+1, err)
+		}
+	}
+
+	return (1 - remaining) * 100, nil
+}
+
+// EstimateRouteProfit estimates a route's gross profit ratio locally from the
+// reserves and fees of its pools, without quoting any router. It fails when a
+// hop's pool address isn't known.
+func (s *ArbitrageService) EstimateRouteProfit(pair models.TokenPair, route Route, amount *big.Int) (float64, error) {
+	legIn := amount
+	for i, hop := range route.Hops {
+		pool := findPairAddress(hop.DEX.PairAddresses(&pair), hop.SymbolIn, hop.SymbolOut)
+		if pool == "" {
+			return 0, fmt.Errorf("no %s pool configured for %s-%s", hop.DEX.Name(), hop.SymbolIn, hop.SymbolOut)
+		}
+
+		legOut, err := hop.DEX.EstimateAmountOut(common.HexToAddress(pool), hop.TokenIn, legIn)
+		if err != nil {
+			return 0, fmt.Errorf("error estimating leg %d: %v", i+1, err)
+		}
+		legIn = legOut
+	}
+
+	return profitRatio(new(big.Int).Sub(legIn, amount), amount), nil
+}
+
+// poolFractionCap returns the largest input amount of a route that keeps every
+// hop's input within MAX_POOL_FRACTION_BPS of its pool's input-side reserve,
+// or amount itself when it already does. Hop inputs are estimated from the
+// reserves and shrink roughly in proportion to the route's input.
+func (s *ArbitrageService) poolFractionCap(pair models.TokenPair, route Route, amount *big.Int) (*big.Int, error) {
+	capped := new(big.Int).Set(amount)
+	legIn := amount
+
+	for i, hop := range route.Hops {
+		pool := findPairAddress(hop.DEX.PairAddresses(&pair), hop.SymbolIn, hop.SymbolOut)
+		if pool == "" {
+			return nil, fmt.Errorf("no %s pool configured for %s-%s", hop.DEX.Name(), hop.SymbolIn, hop.SymbolOut)
+		}
+
+		reserveIn, _, err := s.RouterService.cachedOrientedReserves(common.HexToAddress(pool), hop.TokenIn)
+		if err != nil {
+			return nil, fmt.Errorf("error getting leg %d reserves: %v", i+1, err)
+		}
+
+		maxIn := new(big.Int).Mul(reserveIn, big.NewInt(s.Config.MaxPoolFractionBps))
+		maxIn.Div(maxIn, big.NewInt(10000))
+		if legIn.Sign() > 0 && legIn.Cmp(maxIn) > 0 {
+			// Scale the route's input by how far this leg is over
+			limit := new(big.Int).Mul(amount, maxIn)
+			limit.Div(limit, legIn)
+			if limit.Cmp(capped) < 0 {
+				capped = limit
+			}
+		}
+
+		legIn, err = hop.DEX.EstimateAmountOut(common.HexToAddress(pool), hop.TokenIn, legIn)
+		if err != nil {
+			return nil, fmt.Errorf("error estimating leg %d: %v", i+1, err)
+		}
+	}
+
+	return capped, nil
+}
+
+// capToPools re-quotes a route at a smaller size when result's input would
+// take more than MAX_POOL_FRACTION_BPS of a pool, returning the WBNB amount
+// and result to trade. They are unchanged when the cap is off or not hit.
+func (s *ArbitrageService) capToPools(
+	pair models.TokenPair,
+	route Route,
+	amount float64,
+	result *models.ArbitrageResult,
+) (float64, *models.ArbitrageResult, error) {
+	if s.Config.MaxPoolFractionBps <= 0 {
+		return amount, result, nil
+	}
+
+	capped, err := s.poolFractionCap(pair, route, result.TargetAmount)
+	if err != nil {
+		return 0, nil, err
+	}
+	if capped.Cmp(result.TargetAmount) >= 0 {
+		return amount, result, nil
+	}
+
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(capped), new(big.Float).SetInt(result.TargetAmount)).Float64()
+	cappedWBNB := amount * ratio
+	slog.Info("✂️ Capping trade size to pool liquidity", "pair", pair.Name, "route", route.String(),
+		"amount_wbnb", amount, "capped_wbnb", cappedWBNB, "max_pool_fraction_bps", s.Config.MaxPoolFractionBps)
+
+	cappedResult, err := s.CheckTriangularArbitrage(pair, cappedWBNB, route)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to re-quote capped trade: %v", err)
+	}
+	return cappedWBNB, cappedResult, nil
+}
+
+// prefiltered reports whether a route's reserve-based estimate falls so far
+// below minProfit that quoting it on-chain isn't worth the RPC calls. Routes
+// that can't be estimated are left to the on-chain quote.
+func (s *ArbitrageService) prefiltered(pair models.TokenPair, route Route, amount float64, minProfit float64) bool {
+	estimate, err := s.EstimateRouteProfit(pair, route, s.TokenService.FormatTokenAmount(amount, 18))
+	if err != nil {
+		slog.Debug("🧮 Reserve estimate failed, quoting on-chain", "route", route.String(), "err", err)
+		return false
+	}
+	if estimate >= minProfit-s.Config.PrefilterTolerance {
+		return false
+	}
+
+	slog.Debug("🧮 Route pre-filtered", "pair", pair.Name, "route", route.String(),
+		"amount_wbnb", amount, "estimate_pct", estimate*100)
+	return true
+}
+
+// ExecuteArbitrage executes a triangular arbitrage trade
+func (s *ArbitrageService) ExecuteArbitrage(
+	pair models.TokenPair,
+	amount *big.Int,
+	route Route,
+) (*models.ExecutionResult, error) {
+	if !s.beginExecution() {
+		return nil, fmt.Errorf("shutdown in progress, not starting new arbitrage on %s", pair.Name)
+	}
+	defer s.executions.Done()
+
+	// Spread executions across the configured wallets
+	if s.Client != nil && len(s.Client.Wallets()) > 1 {
+		slog.Info("👛 Executing from wallet", "address", s.Client.NextWallet().Hex())
+	}
+
+	flash, err := s.executionMode(route)
+	if err != nil {
+		return nil, err
+	}
+
+	// A spike can turn a small edge into a loss once gas is paid
+	if err := s.checkGasCeiling(); err != nil {
+		return nil, err
+	}
+
+	// Don't pile more transactions onto a chain that isn't confirming ours
+	if err := s.checkPendingLimit(); err != nil {
+		return nil, err
+	}
+
+	// Keep enough BNB to unwind a position or replace a stuck transaction
+	if err := s.checkNativeReserve(flash, route); err != nil {
+		return nil, err
+	}
+
+	// Quote every leg fresh rather than from the scan cache
+	s.RouterService.ResetQuoteCache()
+
+	slog.Info("Executing arbitrage", "pair", pair.Name, "amount", amount.String(), "route", route.String())
+
+	if flash {
+		return s.ExecuteFlashArbitrage(pair, amount, route)
+	}
+
+	// Otherwise execute manually (not recommended without flash loans)
+	slog.Warn("⚠️ No flash path for route, executing manually", "route", route.String())
+	return s.ExecuteManualArbitrage(pair, amount, route)
+}
+
+// executionMode reports whether a route executes through the flash contract.
+// Routes it doesn't support fall back to manual arbitrage only when
+// ALLOW_MANUAL_ARBITRAGE is set.
+func (s *ArbitrageService) executionMode(route Route) (bool, error) {
+	if s.FlashContract != (common.Address{}) {
+		if _, ok := flashDirection(route); ok {
+			return true, nil
+		}
+	}
+	if !s.Config.AllowManualArbitrage {
+		return false, ErrManualArbitrageDisabled
+	}
+	return false, nil
+}
+
+// checkGasCeiling fetches the live gas price and fails with
+// ErrGasPriceTooHigh if it is above MAX_GAS_PRICE_GWEI
+func (s *ArbitrageService) checkGasCeiling() error {
+	if s.Config.MaxGasPriceGwei <= 0 {
+		return nil
+	}
+
+	gasPrice, err := s.Backend.SuggestGasPrice(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get gas price: %v", err)
+	}
+
+	gasPriceGwei := weiToGwei(gasPrice)
+	if gasPriceGwei > s.Config.MaxGasPriceGwei {
+		return fmt.Errorf("%w: %.2f Gwei > %.2f Gwei", ErrGasPriceTooHigh, gasPriceGwei, s.Config.MaxGasPriceGwei)
+	}
+	return nil
+}
+
+// PreflightArbitrage estimates gas for the first transaction ExecuteArbitrage
+// would send: the flash-loan call, or the first leg of a manual route. A
+// revert means the trade would fail on-chain, and is returned wrapping
+// ErrPreflightReverted with the decoded reason.
+func (s *ArbitrageService) PreflightArbitrage(
+	pair models.TokenPair,
+	amount *big.Int,
+	route Route,
+) (uint64, error) {
+	flash, err := s.executionMode(route)
+	if err != nil {
+		return 0, err
+	}
+
+	var gas uint64
+	if flash {
+		callData, _, packErr := s.packFlashCall(pair, amount, route)
+		if packErr != nil {
+			return 0, packErr
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		gas, err = s.Backend.EstimateGas(ctx, ethereum.CallMsg{
+			From: s.Client.Address,
+			To:   &s.FlashContract,
+			Data: callData,
+		})
+	} else {
+		first := route.Hops[0]
+		minOut, quoteErr := minAmountOut(first, amount, s.slippageFor(pair))
+		if quoteErr != nil {
+			return 0, quoteErr
+		}
+		gas, err = first.DEX.EstimateSwap(amount, minOut, first.Path())
+	}
+
+	if err != nil {
+		if IsRevertError(err) {
+			return 0, fmt.Errorf("%w: %s", ErrPreflightReverted, RevertReason(err))
+		}
+		return 0, fmt.Errorf("failed to estimate gas: %v", err)
+	}
+	return gas, nil
+}
+
+// beginExecution registers an in-flight execution unless shutdown has started
+func (s *ArbitrageService) beginExecution() bool {
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+
+	if s.shuttingDown {
+		return false
+	}
+	s.executions.Add(1)
+	return true
+}
+
+// Shutdown stops new executions from starting and waits up to gracePeriod for
+// in-flight ones to finish their remaining legs. It returns false if an
+// execution was still open when the grace period expired.
+func (s *ArbitrageService) Shutdown(gracePeriod time.Duration) bool {
+	s.execMu.Lock()
+	s.shuttingDown = true
+	s.execMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.executions.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(gracePeriod):
+		return false
+	}
+}
+
+// ExecuteFlashArbitrage executes a triangular arbitrage using the flash arbitrage contract
+func (s *ArbitrageService) ExecuteFlashArbitrage(
+	pair models.TokenPair,
+	amount *big.Int,
+	route Route,
+) (*models.ExecutionResult, error) {
+	slog.Info("Executing flash arbitrage...")
+
+	callData, pairAddress, err := s.packFlashCall(pair, amount, route)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Using pair address for flash loan", "pair_address", pairAddress.Hex())
+
+	// A wrong address or chain would otherwise burn gas on a certain failure
+	tokenA := route.Hops[0].TokenIn
+	if err := s.checkFlashTargets(pairAddress, tokenA, route.Hops[0].TokenOut, amount); err != nil {
+		return nil, err
+	}
+
+	// Record WBNB balance so the realized profit can be measured
+	initialBalance, err := s.TokenService.GetTokenBalance(tokenA, s.Client.Address)
+	if err != nil {
+		return nil, fmt.Errorf("error getting initial WBNB balance: %v", err)
+	}
+
+	// Get nonce
+	nonce, err := s.Backend.PendingNonceAt(context.Background(), s.Client.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get gas price, buffered like every trade
+	gasPrice, err := suggestGasPrice(context.Background(), s.Backend, s.Config.GasPriceBufferPercent)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create transaction
@@ -531,473 +1220,1813 @@ func (s *ArbitrageService) ExecuteFlashArbitrage(
 		callData,
 	)
 
-	// Sign the transaction
-	chainID := big.NewInt(56) // BSC chain ID
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), s.Client.PrivateKey)
+	// Sign and send, bumping the gas price if the node rejects it as underpriced
+	signedTx, err := sendWithGasBump(context.Background(), s.Backend, s.Client.Signer, s.Config, tx, sendProtectedTransaction)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	slog.Info("Arbitrage transaction sent", "tx", signedTx.Hash().Hex())
+
+	// Wait for transaction to be mined
+	receipt, err := s.waitMined(signedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	if receipt.Status == 0 {
+		return nil, s.flashRevertError(receipt, signedTx)
+	}
+
+	finalBalance, err := s.TokenService.GetTokenBalance(tokenA, s.Client.Address)
+	if err != nil {
+		return nil, fmt.Errorf("error getting final WBNB balance: %v", err)
+	}
+
+	result := &models.ExecutionResult{
+		PairName:       pair.Name,
+		Route:          route.String(),
+		Flash:          true,
+		TxHashes:       []common.Hash{signedTx.Hash()},
+		GasUsed:        []uint64{receipt.GasUsed},
+		GasCost:        addGasCost(nil, receipt.GasUsed, signedTx.GasPrice()),
+		InitialBalance: initialBalance,
+		FinalBalance:   finalBalance,
+		AmountIn:       amount,
+		RealizedProfit: new(big.Int).Sub(finalBalance, initialBalance),
+	}
+
+	// The profit passes through the flash contract, so its event is the
+	// accurate figure; the balance change is only a fallback
+	if profit, ok := decodeArbitrageExecuted(receipt.Logs, s.FlashContract); ok {
+		result.Profit = profit.Profit
+		result.PlatformFee = profit.PlatformFee
+		result.UserProfit = profit.UserProfit
+		result.RealizedProfit = profit.UserProfit
+	} else {
+		slog.Warn("⚠️ No ArbitrageExecuted event in receipt, using WBNB balance change as profit",
+			"tx", signedTx.Hash().Hex())
+	}
+	result.ProfitPercent = profitRatio(result.RealizedProfit, amount)
+
+	s.logExecutionResult(result)
+	return result, nil
+}
+
+// flashRevertError classifies a reverted flash transaction by replaying it at
+// the block it was mined in. Only a revert at a minimum output is a lost race;
+// any other reason, like a bad route or a fault in the contract, is a failure
+// that must not be retried as if the market had just moved.
+func (s *ArbitrageService) flashRevertError(receipt *types.Receipt, tx *types.Transaction) error {
+	if receipt.GasUsed >= tx.Gas() {
+		return fmt.Errorf("flash transaction %s ran out of gas (limit %d)", tx.Hash().Hex(), tx.Gas())
+	}
+
+	replayErr := s.replayTransaction(receipt, tx)
+	switch {
+	case replayErr == nil:
+		return fmt.Errorf("flash transaction %s reverted, but not when replayed at block %s",
+			tx.Hash().Hex(), receipt.BlockNumber)
+	case IsSlippageRevert(replayErr):
+		return fmt.Errorf("%w: flash transaction %s reverted: %s", ErrLostRace, tx.Hash().Hex(), RevertReason(replayErr))
+	default:
+		return fmt.Errorf("flash transaction %s reverted: %s", tx.Hash().Hex(), RevertReason(replayErr))
+	}
+}
+
+// replayTransaction re-runs a mined transaction with eth_call at the block it
+// was mined in and returns the error it hits there, so a revert's reason can
+// be read; receipts don't carry it
+func (s *ArbitrageService) replayTransaction(receipt *types.Receipt, tx *types.Transaction) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := s.Backend.CallContract(ctx, ethereum.CallMsg{
+		From:     s.Client.Address,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}, receipt.BlockNumber)
+	return err
+}
+
+// flashProfit is the profit split reported by an ArbitrageExecuted event
+type flashProfit struct {
+	Profit      *big.Int
+	PlatformFee *big.Int
+	UserProfit  *big.Int
+}
+
+// decodeArbitrageExecuted finds the flash contract's ArbitrageExecuted event
+// among a receipt's logs and returns the profit split it reports
+func decodeArbitrageExecuted(logs []*types.Log, flashContract common.Address) (*flashProfit, bool) {
+	event := contracts.FlashABI.Events["ArbitrageExecuted"]
+
+	for _, entry := range logs {
+		if entry.Address != flashContract || len(entry.Topics) == 0 || entry.Topics[0] != event.ID {
+			continue
+		}
+
+		var decoded struct {
+			LoanAmount  *big.Int
+			Profit      *big.Int
+			PlatformFee *big.Int
+			UserProfit  *big.Int
+		}
+		if err := contracts.FlashABI.UnpackIntoInterface(&decoded, event.Name, entry.Data); err != nil {
+			slog.Warn("⚠️ Failed to decode ArbitrageExecuted event", "tx", entry.TxHash.Hex(), "err", err)
+			continue
+		}
+
+		return &flashProfit{
+			Profit:      decoded.Profit,
+			PlatformFee: decoded.PlatformFee,
+			UserProfit:  decoded.UserProfit,
+		}, true
+	}
+	return nil, false
+}
+
+// flashMinAmountsOut quotes each leg of route and returns the minimum output
+// the flash contract must get from it: the quote less maxSlippage, and for the
+// last leg at least breakEven. The contract reverts when a minimum isn't met,
+// so it never completes a losing trade.
+func flashMinAmountsOut(route Route, amount *big.Int, maxSlippage float64, breakEven *big.Int) ([]*big.Int, error) {
+	minAmountsOut := make([]*big.Int, len(route.Hops))
+	legIn := amount
+	for i, hop := range route.Hops {
+		amounts, err := hop.DEX.GetAmountsOut(legIn, hop.Path())
+		if err != nil {
+			return nil, fmt.Errorf("failed to quote %s leg %s -> %s: %v", hop.DEX.Name(), hop.SymbolIn, hop.SymbolOut, err)
+		}
+		legIn = amounts[len(amounts)-1]
+
+		minAmountsOut[i] = applySlippage(legIn, maxSlippage)
+	}
+
+	if last := len(minAmountsOut) - 1; minAmountsOut[last].Cmp(breakEven) < 0 {
+		minAmountsOut[last] = new(big.Int).Set(breakEven)
+	}
+	return minAmountsOut, nil
+}
+
+// flashBreakEven is the least the final leg of a flash trade must return to
+// come out ahead: what repaying the loan costs after the borrowed pool's swap
+// fee, plus the trade's gas
+func (s *ArbitrageService) flashBreakEven(route Route, amount *big.Int) *big.Int {
+	// V2 pools take amount * 10000 / (10000 - fee) back for a flash swap
+	feeBps := route.Hops[0].DEX.FeeBps()
+	repay := new(big.Int).Mul(amount, big.NewInt(10000))
+	repay.Div(repay, big.NewInt(10000-feeBps))
+	repay.Add(repay, big.NewInt(1))
+
+	return repay.Add(repay, s.estimateGasCost())
+}
+
+// packFlashCall builds the executeFlashLoan call for a route and returns it
+// with the pool the loan is borrowed from
+func (s *ArbitrageService) packFlashCall(
+	pair models.TokenPair,
+	amount *big.Int,
+	route Route,
+) ([]byte, common.Address, error) {
+	fromPancake, ok := flashDirection(route)
+	if !ok {
+		return nil, common.Address{}, fmt.Errorf("flash contract does not support route %s", route)
+	}
+
+	// Prepare paths
+	hops := route.Hops
+	path1, path2, path3 := hops[0].Path(), hops[1].Path(), hops[2].Path()
+
+	minAmountsOut, err := flashMinAmountsOut(route, amount, s.slippageFor(pair), s.flashBreakEven(route, amount))
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+
+	// Define pair address to borrow from
+	var pairAddress common.Address
+	pairKey := fmt.Sprintf("%s-%s", hops[0].SymbolIn, hops[0].SymbolOut)
+
+	if addr, exists := hops[0].DEX.PairAddresses(&pair)[pairKey]; exists && addr != "" {
+		pairAddress = common.HexToAddress(addr)
+	}
+
+	if pairAddress == (common.Address{}) {
+		return nil, common.Address{}, fmt.Errorf("pair address not found for flash loan")
+	}
+
+	// Prepare arbitrage data
+	arbData := models.ArbitrageData{
+		Path1:         path1,
+		Path2:         path2,
+		Path3:         path3,
+		MinAmountsOut: minAmountsOut,
+		Direction:     fromPancake,
 	}
 
-	// Send the transaction
-	err = s.Client.Client.SendTransaction(context.Background(), signedTx)
+	// Pack function call
+	callData, err := contracts.FlashABI.Pack(
+		"executeFlashLoan",
+		pairAddress,
+		amount,
+		arbData,
+		fromPancake,
+	)
 	if err != nil {
+		return nil, common.Address{}, err
+	}
+
+	return callData, pairAddress, nil
+}
+
+// checkFlashTargets confirms a contract is deployed at FLASH_ARB_CONTRACT and
+// at the pool the loan is taken from, that the pool pairs the borrowed token
+// with paired, and that it holds more of the borrowed token than amount. The contract borrows from the pool rather than
+// pulling funds from the wallet, so there is no allowance to check.
+func (s *ArbitrageService) checkFlashTargets(pool, borrowed, paired common.Address, amount *big.Int) error {
+	if err := s.VerifyFlashContract(); err != nil {
 		return err
 	}
 
-	log.Printf("Arbitrage transaction sent: %s", signedTx.Hash().Hex())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	code, err := s.Backend.CodeAt(ctx, pool, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read flash pool code: %v", err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("%w: no pool deployed at %s", ErrFlashMisconfigured, pool.Hex())
+	}
+
+	reserve, _, err := s.RouterService.GetOrientedReserves(pool, borrowed, paired)
+	if err != nil {
+		return fmt.Errorf("%w: can't borrow from pool %s: %v", ErrFlashMisconfigured, pool.Hex(), err)
+	}
+	if reserve.Cmp(amount) <= 0 {
+		return fmt.Errorf("%w: pool %s holds %s of %s, not enough to borrow %s",
+			ErrFlashMisconfigured, pool.Hex(), reserve, borrowed.Hex(), amount)
+	}
+	return nil
+}
+
+// ExecuteManualArbitrage executes a triangular arbitrage manually (without flash loans)
+func (s *ArbitrageService) ExecuteManualArbitrage(
+	pair models.TokenPair,
+	amount *big.Int,
+	route Route,
+) (*models.ExecutionResult, error) {
+	slog.Warn("Executing manual arbitrage (not using flash loans)...")
+
+	// Never start a new trade on top of an unresolved one
+	if open, err := LoadExecutionState(s.Config.ExecutionStateFile); err != nil {
+		return nil, err
+	} else if open != nil {
+		return nil, fmt.Errorf("unresolved execution on %s is holding %s, resume or unwind it first",
+			open.PairName, open.HeldSymbol)
+	}
+
+	slog.Info("Executing route", "route", route.String())
+
+	first := route.Hops[0]
+	initialBalance, err := s.TokenService.GetTokenBalance(first.TokenIn, s.Client.Address)
+	if err != nil {
+		return nil, fmt.Errorf("error getting initial WBNB balance: %v", err)
+	}
+
+	state := &models.ExecutionState{
+		Wallet:         s.Client.Address,
+		PairName:       pair.Name,
+		Route:          route.DEXNames(),
+		Base:           first.SymbolIn,
+		InitialAmount:  amount,
+		InitialBalance: initialBalance,
+		HeldToken:      first.TokenIn,
+		HeldSymbol:     first.SymbolIn,
+		HeldAmount:     amount,
+	}
+
+	return s.runManualLegs(pair, route, state)
+}
+
+// ResumeManualArbitrage completes the remaining legs of a persisted execution
+func (s *ArbitrageService) ResumeManualArbitrage(state *models.ExecutionState) (*models.ExecutionResult, error) {
+	pair, err := s.FindTokenPair(state.PairName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.useStateWallet(state); err != nil {
+		return nil, err
+	}
+
+	base := state.Base
+	if base == "" {
+		base = "WBNB"
+	}
+
+	route, err := s.RouteFromNames(pair, base, state.Route)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resume execution on %s: %v", state.PairName, err)
+	}
+
+	if err := s.settlePendingLeg(state, route); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Resuming route", "route", route.String(), "pair", pair.Name, "completed_legs", state.CompletedLegs)
+
+	return s.runManualLegs(pair, route, state)
+}
+
+// useStateWallet activates the wallet holding a persisted position, so it is
+// finished or unwound from the wallet that owns the tokens
+func (s *ArbitrageService) useStateWallet(state *models.ExecutionState) error {
+	if state.Wallet == (common.Address{}) {
+		return nil
+	}
+	if !s.Client.UseWallet(state.Wallet) {
+		return fmt.Errorf("wallet %s holding the position is no longer configured", state.Wallet.Hex())
+	}
+	return nil
+}
+
+// settlePendingLeg resolves a leg that was sent but not seen mined when the
+// execution stopped, so it is neither sent again nor forgotten. A mined leg
+// counts as completed with the output its receipt transferred to the wallet; a
+// reverted one is left to be sent again. It fails with ErrNotMined while the
+// transaction is still unmined.
+func (s *ArbitrageService) settlePendingLeg(state *models.ExecutionState, route Route) error {
+	if state.PendingTx == (common.Hash{}) {
+		return nil
+	}
+	if state.CompletedLegs >= len(route.Hops) {
+		return fmt.Errorf("pending transaction %s is past the last leg of %s", state.PendingTx.Hex(), state.PairName)
+	}
+
+	step := state.CompletedLegs + 1
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	receipt, err := s.Backend.TransactionReceipt(ctx, state.PendingTx)
+	cancel()
+	if errors.Is(err, ethereum.NotFound) {
+		return fmt.Errorf("%w: step %d transaction %s; retry once it confirms, or remove %s if it was dropped",
+			ErrNotMined, step, state.PendingTx.Hex(), s.Config.ExecutionStateFile)
+	}
+	if err != nil {
+		return fmt.Errorf("error checking step %d transaction %s: %v", step, state.PendingTx.Hex(), err)
+	}
+
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		leg := route.Hops[state.CompletedLegs]
+		received := transferredTo(receipt, leg.TokenOut, s.Client.Address)
+		if received.Sign() <= 0 {
+			return fmt.Errorf("no %s received from %s", leg.SymbolOut, state.PendingTx.Hex())
+		}
+
+		slog.Info("Pending leg confirmed", "step", step, "tx", state.PendingTx.Hex(),
+			"received", s.readableAmount(leg.TokenOut, received), "token", leg.SymbolOut)
+		state.CompletedLegs = step
+		state.TxHashes = append(state.TxHashes, receipt.TxHash)
+		state.GasUsed = append(state.GasUsed, receipt.GasUsed)
+		state.HeldToken = leg.TokenOut
+		state.HeldSymbol = leg.SymbolOut
+		state.HeldAmount = received
+	} else {
+		slog.Warn("⚠️ Pending leg reverted", "step", step, "tx", state.PendingTx.Hex())
+	}
+
+	state.GasCost = addGasCost(state.GasCost, receipt.GasUsed, state.PendingPrice)
+	state.PendingTx = common.Hash{}
+	state.PendingPrice = nil
+	state.UpdatedAt = time.Now()
+	return saveExecutionState(s.Config.ExecutionStateFile, state)
+}
+
+// transferTopic is the ERC20 Transfer(address,address,uint256) event ID
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// transferredTo sums the ERC20 Transfer events of token to wallet in a receipt
+func transferredTo(receipt *types.Receipt, token, wallet common.Address) *big.Int {
+	total := new(big.Int)
+	for _, entry := range receipt.Logs {
+		if entry.Address != token || len(entry.Topics) != 3 || entry.Topics[0] != transferTopic {
+			continue
+		}
+		if common.BytesToAddress(entry.Topics[2].Bytes()) == wallet {
+			total.Add(total, new(big.Int).SetBytes(entry.Data))
+		}
+	}
+	return total
+}
+
+// UnwindStrandedPosition swaps a held intermediate token straight back to WBNB
+func (s *ArbitrageService) UnwindStrandedPosition(state *models.ExecutionState) error {
+	pair, err := s.FindTokenPair(state.PairName)
+	if err != nil {
+		return err
+	}
+	if err := s.useStateWallet(state); err != nil {
+		return err
+	}
+
+	if state.PendingTx != (common.Hash{}) {
+		base := state.Base
+		if base == "" {
+			base = "WBNB"
+		}
+		route, err := s.RouteFromNames(pair, base, state.Route)
+		if err != nil {
+			return fmt.Errorf("cannot unwind execution on %s: %v", state.PairName, err)
+		}
+		if err := s.settlePendingLeg(state, route); err != nil {
+			return err
+		}
+	}
+
+	hop, err := s.bestUnwindHop(state.HeldToken, state.HeldSymbol, state.HeldAmount)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Unwinding back to WBNB", "amount", s.readableAmount(state.HeldToken, state.HeldAmount),
+		"token", state.HeldSymbol, "dex", hop.DEX.Name())
+
+	amountOut, receipt, err := s.executeManualLeg(hop, state.HeldAmount, s.slippageFor(pair), nil)
+	if err != nil {
+		return fmt.Errorf("error unwinding %s: %v", state.HeldSymbol, err)
+	}
+
+	slog.Info("Unwind complete", "tx", receipt.TxHash.Hex(), "received_wbnb", s.readableAmount(hop.TokenOut, amountOut))
+
+	return clearExecutionState(s.Config.ExecutionStateFile)
+}
+
+// UnwindPosition swaps the active wallet's whole balance of token back to
+// WBNB through the exchange that quotes the most for it, e.g. to recover a
+// position stranded by a failed manual leg. It returns the WBNB received.
+func (s *ArbitrageService) UnwindPosition(token common.Address) (*big.Int, error) {
+	symbol := tokenSymbol(token)
+	if token == common.HexToAddress(config.WBNB) {
+		return nil, fmt.Errorf("%s is already WBNB", symbol)
+	}
+
+	balance, err := s.TokenService.GetTokenBalance(token, s.Client.Address)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s balance: %v", symbol, err)
+	}
+	if balance.Sign() == 0 {
+		return nil, fmt.Errorf("wallet %s holds no %s", s.Client.Address.Hex(), symbol)
+	}
+
+	hop, err := s.bestUnwindHop(token, symbol, balance)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Unwinding back to WBNB", "amount", s.readableAmount(token, balance),
+		"token", symbol, "dex", hop.DEX.Name())
+
+	amountOut, receipt, err := s.executeManualLeg(hop, balance, s.Config.MaxSlippage, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error unwinding %s: %v", symbol, err)
+	}
+
+	slog.Info("Unwind complete", "tx", receipt.TxHash.Hex(), "received_wbnb", s.readableAmount(hop.TokenOut, amountOut))
+
+	// A saved position in this token has just been closed
+	state, err := LoadExecutionState(s.Config.ExecutionStateFile)
+	if err == nil && state != nil && state.HeldToken == token &&
+		(state.Wallet == (common.Address{}) || state.Wallet == s.Client.Address) {
+		if err := clearExecutionState(s.Config.ExecutionStateFile); err != nil {
+			slog.Warn("⚠️ Failed to clear execution state", "err", err)
+		}
+	}
+	return amountOut, nil
+}
+
+// bestUnwindHop quotes selling amount of token for WBNB on every exchange and
+// returns the swap on the one paying the most
+func (s *ArbitrageService) bestUnwindHop(token common.Address, symbol string, amount *big.Int) (Hop, error) {
+	if len(s.DEXes) == 0 {
+		return Hop{}, fmt.Errorf("no exchanges configured to unwind %s", symbol)
+	}
+
+	wbnb := common.HexToAddress(config.WBNB)
+	path := []common.Address{token, wbnb}
+
+	var best Hop
+	var bestOut *big.Int
+	var lastErr error
+	for _, dex := range s.DEXes {
+		amounts, err := dex.GetAmountsOut(amount, path)
+		if err != nil {
+			slog.Debug("Unwind quote failed", "dex", dex.Name(), "token", symbol, "err", err)
+			lastErr = err
+			continue
+		}
+
+		amountOut := amounts[len(amounts)-1]
+		slog.Debug("Unwind quote", "dex", dex.Name(), "token", symbol, "wbnb_out", s.readableAmount(wbnb, amountOut))
+		if bestOut == nil || amountOut.Cmp(bestOut) > 0 {
+			bestOut = amountOut
+			best = Hop{TokenIn: token, TokenOut: wbnb, SymbolIn: symbol, SymbolOut: "WBNB", DEX: dex}
+		}
+	}
+
+	if bestOut == nil {
+		return Hop{}, fmt.Errorf("no exchange quotes %s to WBNB: %v", symbol, lastErr)
+	}
+	return best, nil
+}
+
+// tokenSymbol returns the registered symbol of a token, or its address when
+// the token isn't one the bot knows
+func tokenSymbol(token common.Address) string {
+	for symbol, info := range config.Tokens {
+		if info.Address == token {
+			return symbol
+		}
+	}
+	return token.Hex()
+}
+
+// runManualLegs executes the hops after state.CompletedLegs, persisting the
+// held position after each confirmed leg so a restart can pick it up
+func (s *ArbitrageService) runManualLegs(
+	pair models.TokenPair,
+	route Route,
+	state *models.ExecutionState,
+) (*models.ExecutionResult, error) {
+	legs := route.Hops
+	amountIn := state.HeldAmount
+
+	for i := state.CompletedLegs; i < len(legs); i++ {
+		leg := legs[i]
+		slog.Info("Swapping", "step", i+1, "amount", s.readableAmount(leg.TokenIn, amountIn),
+			"from", leg.SymbolIn, "to", leg.SymbolOut)
+
+		// Persist the leg before it is submitted, so a crash before its
+		// receipt still leaves the transaction on record
+		beforeSend := func(tx *types.Transaction) error {
+			state.PendingTx = tx.Hash()
+			state.PendingPrice = tx.GasPrice()
+			state.UpdatedAt = time.Now()
+			return saveExecutionState(s.Config.ExecutionStateFile, state)
+		}
+
+		amountOut, receipt, err := s.executeManualLeg(leg, amountIn, s.slippageFor(pair), beforeSend)
+		if errors.Is(err, ErrNotMined) {
+			// Check the receipt once more before reporting what the wallet
+			// holds; the leg may have been mined since the wait gave up
+			pending := state.PendingTx
+			if settleErr := s.settlePendingLeg(state, route); settleErr == nil {
+				if state.CompletedLegs > i {
+					amountIn = state.HeldAmount
+					continue
+				}
+				err = fmt.Errorf("transaction %s reverted", pending.Hex())
+			}
+		}
+		if err != nil {
+			// A leg that reverted was still mined, and paid for its gas
+			if receipt != nil {
+				state.GasCost = addGasCost(state.GasCost, receipt.GasUsed, state.PendingPrice)
+			}
+			// Only an unmined leg is still in flight
+			if !errors.Is(err, ErrNotMined) {
+				state.PendingTx = common.Hash{}
+				state.PendingPrice = nil
+			}
+			if i == 0 && state.PendingTx == (common.Hash{}) {
+				if err := clearExecutionState(s.Config.ExecutionStateFile); err != nil {
+					slog.Warn("⚠️ Failed to clear execution state", "err", err)
+				}
+			} else if err := saveExecutionState(s.Config.ExecutionStateFile, state); err != nil {
+				slog.Warn("⚠️ Failed to save execution state", "err", err)
+			}
+
+			if i == 0 && IsSlippageRevert(err) {
+				return nil, fmt.Errorf("%w: step 1 swap missed its minimum output: %v", ErrLostRace, err)
+			}
+			err = fmt.Errorf("error executing step %d swap: %v", i+1, err)
+			if i == 0 && state.PendingTx == (common.Hash{}) {
+				return nil, err
+			}
+
+			if state.PendingTx != (common.Hash{}) {
+				slog.Error("🚨 Aborting remaining legs with a leg unconfirmed", "step", i+1, "pending_tx", state.PendingTx.Hex(),
+					"held_amount", s.readableAmount(leg.TokenIn, amountIn), "held_token", leg.SymbolIn, "completed_legs", i)
+			} else {
+				slog.Error("🚨 Aborting remaining legs", "held_amount", s.readableAmount(leg.TokenIn, amountIn),
+					"held_token", leg.SymbolIn, "completed_legs", i)
+			}
+			return nil, &StrandedPositionError{
+				Pair:          pair.Name,
+				CompletedLegs: i,
+				Token:         leg.TokenIn,
+				Symbol:        leg.SymbolIn,
+				Amount:        amountIn,
+				PendingTx:     state.PendingTx,
+				PendingSymbol: leg.SymbolOut,
+				Err:           err,
+			}
+		}
+
+		state.CompletedLegs = i + 1
+		state.TxHashes = append(state.TxHashes, receipt.TxHash)
+		state.GasUsed = append(state.GasUsed, receipt.GasUsed)
+		state.HeldToken = leg.TokenOut
+		state.HeldSymbol = leg.SymbolOut
+		state.HeldAmount = amountOut
+		state.GasCost = addGasCost(state.GasCost, receipt.GasUsed, state.PendingPrice)
+		state.PendingTx = common.Hash{}
+		state.PendingPrice = nil
+		state.UpdatedAt = time.Now()
+
+		if state.CompletedLegs < len(legs) {
+			if err := saveExecutionState(s.Config.ExecutionStateFile, state); err != nil {
+				slog.Warn("⚠️ Failed to save execution state", "err", err)
+			}
+		}
+
+		amountIn = amountOut
+	}
+
+	if err := clearExecutionState(s.Config.ExecutionStateFile); err != nil {
+		slog.Warn("⚠️ Failed to clear execution state", "err", err)
+	}
+
+	finalBalance, err := s.TokenService.GetTokenBalance(legs[0].TokenIn, s.Client.Address)
+	if err != nil {
+		return nil, fmt.Errorf("error getting final WBNB balance: %v", err)
+	}
+
+	gasCost := state.GasCost
+	if gasCost == nil {
+		gasCost = big.NewInt(0)
+	}
+
+	// Every leg paid its own gas, so only the balance change net of all of it
+	// was actually made
+	realized := new(big.Int).Sub(finalBalance, state.InitialBalance)
+	if gasInBase, err := s.gasCostInBase(legs[0], gasCost); err != nil {
+		slog.Warn("⚠️ Realized profit excludes gas", "base", legs[0].SymbolIn, "err", err)
+	} else {
+		realized.Sub(realized, gasInBase)
+	}
+
+	result := &models.ExecutionResult{
+		PairName:       pair.Name,
+		Route:          route.String(),
+		TxHashes:       state.TxHashes,
+		GasUsed:        state.GasUsed,
+		GasCost:        gasCost,
+		InitialBalance: state.InitialBalance,
+		FinalBalance:   finalBalance,
+		AmountIn:       state.InitialAmount,
+		RealizedProfit: realized,
+	}
+	result.ProfitPercent = profitRatio(result.RealizedProfit, state.InitialAmount)
+
+	s.logExecutionResult(result)
+	return result, nil
+}
+
+// addGasCost adds the cost of gasUsed at gasPrice to total, which may be nil.
+// An unknown gas price adds nothing.
+func addGasCost(total *big.Int, gasUsed uint64, gasPrice *big.Int) *big.Int {
+	sum := new(big.Int)
+	if total != nil {
+		sum.Set(total)
+	}
+	if gasPrice != nil {
+		sum.Add(sum, new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), gasPrice))
+	}
+	return sum
+}
+
+// gasCostInBase converts a gas cost in wei to the route's base token, which
+// is the cost itself when the base is WBNB
+func (s *ArbitrageService) gasCostInBase(first Hop, gasCost *big.Int) (*big.Int, error) {
+	if first.SymbolIn == "WBNB" || gasCost.Sign() == 0 {
+		return gasCost, nil
+	}
+	return s.baseAmount(first, s.TokenService.ConvertToReadable(gasCost, 18), 18)
+}
+
+// executeManualLeg sends one swap, waits for it to be mined and returns the
+// amount of the output token actually received along with the receipt.
+// beforeSend, when set, is passed on to DEX.Swap.
+func (s *ArbitrageService) executeManualLeg(leg Hop, amountIn *big.Int, slippage float64, beforeSend func(*types.Transaction) error) (*big.Int, *types.Receipt, error) {
+	tokenOut := leg.TokenOut
+
+	minOut, err := minAmountOut(leg, amountIn, slippage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balanceBefore, err := s.TokenService.GetTokenBalance(tokenOut, s.Client.Address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting %s balance: %v", leg.SymbolOut, err)
+	}
+
+	tx, err := leg.DEX.Swap(amountIn, minOut, leg.Path(), beforeSend)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slog.Info("Waiting for confirmation", "from", leg.SymbolIn, "to", leg.SymbolOut, "tx", tx.Hash().Hex())
+
+	receipt, err := s.waitMined(tx)
+	if err != nil {
+		return nil, receipt, err
+	}
+	if receipt.Status == 0 {
+		return nil, receipt, s.legRevertError(receipt, tx)
+	}
+
+	// Size the next leg from what this swap delivered, not the whole balance
+	balanceAfter, err := s.TokenService.GetTokenBalance(tokenOut, s.Client.Address)
+	if err != nil {
+		return nil, receipt, fmt.Errorf("error getting %s balance: %v", leg.SymbolOut, err)
+	}
+
+	received := new(big.Int).Sub(balanceAfter, balanceBefore)
+	if received.Sign() <= 0 {
+		return nil, receipt, fmt.Errorf("no %s received from %s", leg.SymbolOut, tx.Hash().Hex())
+	}
+
+	slog.Info("Received", "amount", s.readableAmount(tokenOut, received), "token", leg.SymbolOut, "gas_used", receipt.GasUsed)
+
+	return received, receipt, nil
+}
+
+// legRevertError describes a manual leg that was mined but reverted, carrying
+// the revert its replay at the same block hits so IsSlippageRevert can tell a
+// missed minimum output from any other failure
+func (s *ArbitrageService) legRevertError(receipt *types.Receipt, tx *types.Transaction) error {
+	replayErr := s.replayTransaction(receipt, tx)
+	switch {
+	case replayErr == nil:
+		return fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+	case IsRevertError(replayErr):
+		if reason := RevertReason(replayErr); reason != replayErr.Error() {
+			return fmt.Errorf("transaction %s reverted with %q: %w", tx.Hash().Hex(), reason, replayErr)
+		}
+		return fmt.Errorf("transaction %s reverted: %w", tx.Hash().Hex(), replayErr)
+	default:
+		return fmt.Errorf("transaction %s reverted (replay failed: %v)", tx.Hash().Hex(), replayErr)
+	}
+}
+
+// minAmountOut quotes a leg and returns its output less slippage
+func minAmountOut(leg Hop, amountIn *big.Int, slippage float64) (*big.Int, error) {
+	amountsOut, err := leg.DEX.GetAmountsOut(amountIn, leg.Path())
+	if err != nil {
+		return nil, fmt.Errorf("error calculating amounts: %v", err)
+	}
+	return applySlippage(amountsOut[len(amountsOut)-1], slippage), nil
+}
+
+// applySlippage returns amount less a slippage fraction, rounded to whole
+// basis points of tolerance
+func applySlippage(amount *big.Int, slippage float64) *big.Int {
+	keepBps := int64(math.Round((1 - slippage) * 10000))
+	return new(big.Int).Div(new(big.Int).Mul(amount, big.NewInt(keepBps)), big.NewInt(10000))
+}
+
+// slippageFor returns the slippage tolerance for a pair's category, which is
+// MAX_SLIPPAGE unless the category overrides it
+func (s *ArbitrageService) slippageFor(pair models.TokenPair) float64 {
+	return s.Config.SlippageFor(s.getMemeCategory(pair.Name))
+}
+
+// waitMined polls for the transaction receipt until it is mined or the
+// configured receipt timeout expires
+func (s *ArbitrageService) waitMined(tx *types.Transaction) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.ReceiptTimeout)
+	defer cancel()
+
+	// Tracked until it is seen mined, which may be after this gives up
+	s.trackPending(tx.Hash())
+
+	receipt, err := bind.WaitMined(ctx, s.Backend, tx)
+	if err == nil {
+		s.clearPending(tx.Hash())
+	}
+	if err == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%w: %s within %v (it may still confirm later)",
+			ErrNotMined, tx.Hash().Hex(), s.Config.ReceiptTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for %s: %v", tx.Hash().Hex(), err)
+	}
+	return receipt, nil
+}
+
+// trackPending records a sent transaction as unconfirmed
+func (s *ArbitrageService) trackPending(hash common.Hash) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	if s.pendingTxs == nil {
+		s.pendingTxs = make(map[common.Hash]bool)
+	}
+	s.pendingTxs[hash] = true
+}
+
+// clearPending forgets a transaction once it is mined
+func (s *ArbitrageService) clearPending(hash common.Hash) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	delete(s.pendingTxs, hash)
+}
+
+// PendingTransactions polls the receipt of every transaction that was still
+// unconfirmed when its execution stopped waiting, and returns how many remain
+// unmined
+func (s *ArbitrageService) PendingTransactions() int {
+	s.pendingMu.Lock()
+	hashes := make([]common.Hash, 0, len(s.pendingTxs))
+	for hash := range s.pendingTxs {
+		hashes = append(hashes, hash)
+	}
+	s.pendingMu.Unlock()
+
+	for _, hash := range hashes {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		receipt, err := s.Backend.TransactionReceipt(ctx, hash)
+		cancel()
+		if err == nil && receipt != nil {
+			slog.Info("✅ Pending transaction confirmed", "tx", hash.Hex(), "status", receipt.Status)
+			s.clearPending(hash)
+		}
+	}
+
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	return len(s.pendingTxs)
+}
+
+// checkPendingLimit fails with ErrTooManyPending while MAX_PENDING_TX
+// transactions are still unconfirmed
+func (s *ArbitrageService) checkPendingLimit() error {
+	if s.Config.MaxPendingTx <= 0 {
+		return nil
+	}
+	if pending := s.PendingTransactions(); pending >= s.Config.MaxPendingTx {
+		return fmt.Errorf("%w: %d unconfirmed, max %d", ErrTooManyPending, pending, s.Config.MaxPendingTx)
+	}
+	return nil
+}
+
+// checkNativeReserve fails with ErrNativeReserve if the wallet's native BNB,
+// less the most the execution can spend on gas, is below
+// MIN_NATIVE_RESERVE_BNB. A manual route sends one transaction per leg.
+func (s *ArbitrageService) checkNativeReserve(flash bool, route Route) error {
+	if s.Config.MinNativeReserveBNB <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	balances, err := fetchBalances(ctx, s.Backend, nil, s.Client.Address)
+	if err != nil {
+		return fmt.Errorf("failed to get native balance: %v", err)
+	}
+	gasPrice, err := suggestGasPrice(ctx, s.Backend, s.Config.GasPriceBufferPercent)
+	if err != nil {
+		return err
+	}
+
+	transactions := int64(1)
+	if !flash {
+		transactions = int64(len(route.Hops))
+	}
+	gasCost := new(big.Int).Mul(gasPrice, big.NewInt(int64(s.Config.GasLimit)*transactions))
+
+	reserve := s.TokenService.FormatTokenAmount(s.Config.MinNativeReserveBNB, 18)
+	remaining := new(big.Int).Sub(balances[NativeBalance], gasCost)
+	if remaining.Cmp(reserve) < 0 {
+		return fmt.Errorf("%w: %.6f BNB less up to %.6f BNB of gas leaves under %.4f BNB",
+			ErrNativeReserve, s.TokenService.ConvertToReadable(balances[NativeBalance], 18),
+			s.TokenService.ConvertToReadable(gasCost, 18), s.Config.MinNativeReserveBNB)
+	}
+	return nil
+}
+
+// TradeabilityProblem returns why none of the wallets can trade, or "" if one
+// can. A wallet needs BNB for gas and, unless every route can go through the
+// flash contract, WBNB to start a manual route with.
+func (s *ArbitrageService) TradeabilityProblem(ctx context.Context) (string, error) {
+	wbnb := common.HexToAddress(config.WBNB)
+	needsWBNB := s.FlashContract == (common.Address{}) || s.flashContractErr != nil
+
+	var problem string
+	for _, wallet := range s.Client.Wallets() {
+		balances, err := fetchBalances(ctx, s.Backend, []common.Address{wbnb}, wallet)
+		if err != nil {
+			return "", fmt.Errorf("failed to get wallet balances: %v", err)
+		}
+
+		switch {
+		case balances[NativeBalance].Sign() == 0:
+			problem = "wallet has no BNB for gas"
+		case needsWBNB && (balances[wbnb] == nil || balances[wbnb].Sign() == 0):
+			problem = "wallet has no WBNB and no flash contract to borrow it"
+		default:
+			return "", nil
+		}
+	}
+	return problem, nil
+}
+
+// CheckTradeable rechecks the wallet and switches between trading and
+// monitor-only mode, logging the reason whenever the mode changes. If the
+// balances can't be fetched the current mode is kept.
+func (s *ArbitrageService) CheckTradeable(ctx context.Context) error {
+	reason, err := s.TradeabilityProblem(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.monitorMu.Lock()
+	previous := s.monitorReason
+	s.monitorReason = reason
+	s.monitorMu.Unlock()
+
+	switch {
+	case reason != "" && reason != previous:
+		slog.Warn("👀 Wallet can't trade, running monitor-only: opportunities are reported but not executed",
+			"reason", reason)
+	case reason == "" && previous != "":
+		slog.Info("✅ Wallet can trade again, resuming execution")
+	}
+	return nil
+}
+
+// MonitorOnly reports whether execution is suspended because the wallet
+// can't trade, and why
+func (s *ArbitrageService) MonitorOnly() (string, bool) {
+	s.monitorMu.Lock()
+	defer s.monitorMu.Unlock()
+	return s.monitorReason, s.monitorReason != ""
+}
+
+// logExecutionResult prints the outcome of a completed execution
+func (s *ArbitrageService) logExecutionResult(result *models.ExecutionResult) {
+	const decimals = 18 // WBNB
+
+	profitReadable := s.TokenService.ConvertToReadable(result.RealizedProfit, decimals)
+
+	mode := "manual"
+	if result.Flash {
+		mode = "flash"
+	}
+
+	for i, hash := range result.TxHashes {
+		slog.Info("Execution transaction", "index", i+1, "tx", hash.Hex(), "gas_used", result.GasUsed[i])
+	}
+
+	fields := []any{
+		"mode", mode,
+		"pair", result.PairName,
+		"route", result.Route,
+		"amount_in_wbnb", s.TokenService.ConvertToReadable(result.AmountIn, decimals),
+		"initial_balance_wbnb", s.TokenService.ConvertToReadable(result.InitialBalance, decimals),
+		"final_balance_wbnb", s.TokenService.ConvertToReadable(result.FinalBalance, decimals),
+		"profit_wbnb", profitReadable,
+		"profit_pct", result.ProfitPercent * 100,
+		"gas_used", result.TotalGasUsed(),
+	}
+	if result.GasCost != nil {
+		fields = append(fields, "gas_cost_bnb", s.TokenService.ConvertToReadable(result.GasCost, decimals))
+	}
+	if result.PlatformFee != nil {
+		fields = append(fields,
+			"gross_profit_wbnb", s.TokenService.ConvertToReadable(result.Profit, decimals),
+			"platform_fee_wbnb", s.TokenService.ConvertToReadable(result.PlatformFee, decimals))
+	}
+
+	// Check if profitable
+	if result.RealizedProfit.Cmp(big.NewInt(0)) > 0 {
+		slog.Info("✅ Arbitrage successful!", fields...)
+	} else {
+		slog.Error("❌ Arbitrage resulted in loss", fields...)
+	}
+}
+
+// profitRatio returns profit as a fraction of amount
+func profitRatio(profit, amount *big.Int) float64 {
+	if amount == nil || amount.Sign() <= 0 {
+		return 0
+	}
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(profit), new(big.Float).SetInt(amount)).Float64()
+	return ratio
+}
+
+// readableAmount converts a raw amount using the token's decimals, assuming 18
+// if they cannot be read
+func (s *ArbitrageService) readableAmount(token common.Address, amount *big.Int) float64 {
+	decimals, err := s.TokenService.GetTokenDecimals(token)
+	if err != nil {
+		decimals = 18
+	}
+	return s.TokenService.ConvertToReadable(amount, decimals)
+}
+
+// FindTokenPair looks up a configured pair by name
+func (s *ArbitrageService) FindTokenPair(name string) (models.TokenPair, error) {
+	for _, pair := range s.TokenPairs {
+		if pair.Name == name {
+			return pair, nil
+		}
+	}
+	return models.TokenPair{}, fmt.Errorf("pair %s is not configured", name)
+}
+
+// VerifyAndUpdatePairs verifies all pairs and dynamically updates addresses
+func (s *ArbitrageService) VerifyAndUpdatePairs() error {
+	slog.Info("Verifying and updating pair addresses...")
+
+	changed := 0
+	for i, pair := range s.TokenPairs {
+		slog.Debug("Verifying pair", "pair", pair.Name)
+
+		if err := checkPairTokenKeys(pair); err != nil {
+			slog.Error("🚨 Skipping misconfigured pair", "pair", pair.Name, "err", err)
+			continue
+		}
+
+		tokenAAddr := common.HexToAddress(pair.Tokens["WBNB"])
+		otherTokens := getOtherTokens(pair.Tokens, "WBNB")
+
+		tokenBAddr := common.HexToAddress(pair.Tokens[otherTokens[0]])
+		tokenCAddr := common.HexToAddress(pair.Tokens[otherTokens[1]])
+
+		// Update pair addresses on every exchange
+		for _, dex := range s.DEXes {
+			changed += s.updatePairAddresses(&s.TokenPairs[i], dex, tokenAAddr, tokenBAddr, tokenCAddr, otherTokens)
+		}
+	}
+
+	slog.Info("Pair addresses verified", "changed", changed)
+	return nil
+}
+
+// updatePairAddresses updates a token pair's pool addresses on one exchange.
+// Each pool is stored under its generated key; an entry under the reversed
+// key (e.g. a hardcoded "BUSD-WBNB") is replaced, and an unlisted pool's entry
+// is removed so nothing quotes or checks a stale address. It returns how many
+// of the pair's pools were added, moved or removed.
+func (s *ArbitrageService) updatePairAddresses(
+	pair *models.TokenPair,
+	dex DEX,
+	tokenA, tokenB, tokenC common.Address,
+	otherTokens []string,
+) int {
+	pools := dex.PairAddresses(pair)
+
+	legs := []struct {
+		symbolA, symbolB string
+		tokenA, tokenB   common.Address
+	}{
+		{"WBNB", otherTokens[0], tokenA, tokenB},
+		{otherTokens[0], otherTokens[1], tokenB, tokenC},
+		{otherTokens[1], "WBNB", tokenC, tokenA},
+	}
+
+	changed := 0
+	for _, leg := range legs {
+		key := leg.symbolA + "-" + leg.symbolB
+		reversedKey := leg.symbolB + "-" + leg.symbolA
+
+		previous := pools[key]
+		if previous == "" {
+			previous = pools[reversedKey]
+		}
+
+		pairAddr, err := dex.FactoryGetPair(leg.tokenA, leg.tokenB)
+		switch {
+		case err == nil:
+			delete(pools, reversedKey)
+			pools[key] = pairAddr.Hex()
+			s.setPoolStatus(dex, leg.tokenA, leg.tokenB, true)
+			if common.HexToAddress(previous) != pairAddr {
+				changed++
+				slog.Info("Updated pair address", "dex", dex.Name(), "pool", key,
+					"previous", previous, "address", pairAddr.Hex())
+			} else {
+				slog.Debug("Pair address unchanged", "dex", dex.Name(), "pool", key, "address", pairAddr.Hex())
+			}
+		case errors.Is(err, ErrPairNotFound):
+			delete(pools, key)
+			delete(pools, reversedKey)
+			s.setPoolStatus(dex, leg.tokenA, leg.tokenB, false)
+			if previous != "" {
+				changed++
+			}
+			slog.Info("Pool not listed, routes through it will be skipped", "dex", dex.Name(), "pool", key)
+		default:
+			slog.Warn("Pair lookup failed", "dex", dex.Name(), "pool", key, "err", err)
+		}
+	}
+	return changed
+}
 
-	// Wait for transaction to be mined
-	receipt, err := bind.WaitMined(context.Background(), s.Client.Client, signedTx)
-	if err != nil {
-		return err
+// poolStatusKey identifies a pool by exchange and token pair, in either order
+func poolStatusKey(dex DEX, tokenA, tokenB common.Address) string {
+	a, b := tokenA.Hex(), tokenB.Hex()
+	if a > b {
+		a, b = b, a
 	}
+	return dex.Name() + ":" + a + "-" + b
+}
 
-	// Check if transaction was successful
-	if receipt.Status == 0 {
-		return fmt.Errorf("transaction failed")
+// setPoolStatus records whether a pool exists on an exchange
+func (s *ArbitrageService) setPoolStatus(dex DEX, tokenA, tokenB common.Address, exists bool) {
+	if s.poolStatus == nil {
+		s.poolStatus = make(map[string]bool)
 	}
+	s.poolStatus[poolStatusKey(dex, tokenA, tokenB)] = exists
+}
 
-	log.Printf("Arbitrage transaction successful, gas used: %d", receipt.GasUsed)
+// poolMissing reports whether the hop's pool is known not to exist
+func (s *ArbitrageService) poolMissing(hop Hop) bool {
+	return s.poolUnlisted(hop.DEX, hop.TokenIn, hop.TokenOut)
+}
 
-	return nil
+// poolUnlisted reports whether the factory said a pool doesn't exist
+func (s *ArbitrageService) poolUnlisted(dex DEX, tokenA, tokenB common.Address) bool {
+	exists, checked := s.poolStatus[poolStatusKey(dex, tokenA, tokenB)]
+	return checked && !exists
 }
 
-// ExecuteManualArbitrage executes a triangular arbitrage manually (without flash loans)
-func (s *ArbitrageService) ExecuteManualArbitrage(
-	pair models.TokenPair,
-	amount *big.Int,
-	pancakeFirst bool,
-) error {
-	log.Println("Executing manual arbitrage (warning: not using flash loans)...")
+// Helper function to get the tokens of a pair other than its base token
+func getOtherTokens(tokens map[string]string, base string) []string {
+	var otherTokens []string
+	for key := range tokens {
+		if key != base {
+			otherTokens = append(otherTokens, key)
+		}
+	}
 
-	// Get token addresses safely
-	tokenA := common.HexToAddress(pair.Tokens["WBNB"])
+	// Sort for consistency
+	sort.Strings(otherTokens)
+	return otherTokens
+}
 
-	otherTokens := getOtherTokens(pair.Tokens)
-	if len(otherTokens) < 2 {
-		return fmt.Errorf("need at least 3 tokens for triangular arbitrage")
+// Helper function to find a pair address regardless of the key's token order
+func findPairAddress(pairs map[string]string, symbolA, symbolB string) string {
+	if addr, exists := pairs[symbolA+"-"+symbolB]; exists && addr != "" {
+		return addr
 	}
+	return pairs[symbolB+"-"+symbolA]
+}
 
-	tokenB := common.HexToAddress(pair.Tokens[otherTokens[0]])
-	tokenC := common.HexToAddress(pair.Tokens[otherTokens[1]])
+// FindEnhancedArbitrageOpportunities runs one enhanced scan and returns the
+// opportunities it found, most profitable first, without executing any, or
+// ErrNoOpportunity when it found nothing. Pass the one to trade to Execute.
+func (s *ArbitrageService) FindEnhancedArbitrageOpportunities(ctx context.Context) ([]models.Opportunity, error) {
+	if s.Config.AutoWrapThresholdWBNB > 0 {
+		if err := s.AutoWrapWBNB(); err != nil {
+			slog.Warn("⚠️ Auto-wrap skipped", "err", err)
+		}
+	}
 
-	// Get decimals for logging
-	decimalsA, err := s.TokenService.GetTokenDecimals(tokenA)
+	opportunities, err := s.ScanEnhancedOpportunities(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get WBNB decimals: %v", err)
+		return nil, err
 	}
+	if len(opportunities) == 0 {
+		return nil, ErrNoOpportunity
+	}
+	return opportunities, nil
+}
 
-	log.Printf("Initial amount: %.6f WBNB",
-		s.TokenService.ConvertToReadable(amount, decimalsA))
-
-	// Prepare paths
-	path1 := []common.Address{tokenA, tokenB}
-	path2 := []common.Address{tokenB, tokenC}
-	path3 := []common.Address{tokenC, tokenA}
-
-	var route1Router, route2Router, route3Router common.Address
-	var routeDescription string
-
-	if pancakeFirst {
-		// PancakeSwap -> BiSwap -> PancakeSwap
-		route1Router = s.PancakeRouter
-		route2Router = s.BiswapRouter
-		route3Router = s.PancakeRouter
-		routeDescription = "PancakeSwap -> BiSwap -> PancakeSwap"
-	} else {
-		// BiSwap -> PancakeSwap -> BiSwap
-		route1Router = s.BiswapRouter
-		route2Router = s.PancakeRouter
-		route3Router = s.BiswapRouter
-		routeDescription = "BiSwap -> PancakeSwap -> BiSwap"
+// AutoWrapWBNB wraps native BNB when the wallet's WBNB balance is below
+// AUTO_WRAP_THRESHOLD_WBNB, topping it up to AUTO_WRAP_TARGET_WBNB while
+// leaving GAS_RESERVE_BNB for gas. Until a wrap is mined the balance doesn't
+// show it, so no other wrap is sent while one is unconfirmed.
+func (s *ArbitrageService) AutoWrapWBNB() error {
+	if pending, err := s.wrapPending(); err != nil || pending {
+		return err
 	}
 
-	log.Printf("Executing route: %s", routeDescription)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	// Step 1: Calculate min amounts out with 1% slippage tolerance
-	amountsOut1, err := s.RouterService.GetAmountsOut(route1Router, amount, path1)
+	wbnb := common.HexToAddress(config.WBNB)
+	balances, err := fetchBalances(ctx, s.Backend, []common.Address{wbnb}, s.Client.Address)
 	if err != nil {
-		return fmt.Errorf("error calculating amounts for step 1: %v", err)
+		return fmt.Errorf("failed to get balances: %v", err)
+	}
+	wbnbBalance, ok := balances[wbnb]
+	if !ok {
+		return fmt.Errorf("failed to get WBNB balance")
 	}
-	minOut1 := new(big.Int).Div(new(big.Int).Mul(amountsOut1[1], big.NewInt(99)), big.NewInt(100))
+	bnbBalance := balances[NativeBalance]
 
-	// Step 1: WBNB -> TokenB
-	log.Printf("Step 1: Swapping %.6f WBNB for %s",
-		s.TokenService.ConvertToReadable(amount, decimalsA), otherTokens[0])
+	threshold := s.TokenService.FormatTokenAmount(s.Config.AutoWrapThresholdWBNB, 18)
+	if wbnbBalance.Cmp(threshold) >= 0 {
+		return nil
+	}
 
-	hash1, err := s.RouterService.SwapExactTokensForTokens(
-		route1Router,
-		amount,
-		minOut1,
-		path1,
+	amount := autoWrapAmount(
+		wbnbBalance,
+		bnbBalance,
+		threshold,
+		s.TokenService.FormatTokenAmount(s.Config.AutoWrapTargetWBNB, 18),
+		s.TokenService.FormatTokenAmount(s.Config.GasReserveBNB, 18),
 	)
-	if err != nil {
-		return fmt.Errorf("error executing step 1 swap: %v", err)
+	if amount.Sign() == 0 {
+		slog.Warn("⚠️ WBNB below threshold but native BNB is within the gas reserve - cannot auto-wrap",
+			"threshold_wbnb", s.Config.AutoWrapThresholdWBNB, "gas_reserve_bnb", s.Config.GasReserveBNB)
+		return nil
 	}
 
-	log.Printf("Step 1 transaction sent: %s", hash1.Hex())
-
-	// Wait for transaction confirmation
-	log.Println("Waiting for step 1 confirmation...")
-	time.Sleep(15 * time.Second)
-
-	// Get TokenB balance after step 1
-	balanceB, err := s.TokenService.GetTokenBalance(tokenB, s.Client.Address)
+	tx, err := s.TokenService.wrapTransaction(amount)
 	if err != nil {
-		return fmt.Errorf("error getting %s balance: %v", otherTokens[0], err)
+		return fmt.Errorf("failed to wrap BNB: %v", err)
 	}
-
-	decimalsB, err := s.TokenService.GetTokenDecimals(tokenB)
+	signedTx, err := sendWithGasBump(ctx, s.Backend, s.Client.Signer, s.Config, tx, sendPublicTransaction)
 	if err != nil {
-		return fmt.Errorf("error getting %s decimals: %v", otherTokens[0], err)
+		return fmt.Errorf("failed to wrap BNB: %v", err)
 	}
 
-	log.Printf("Received: %.6f %s",
-		s.TokenService.ConvertToReadable(balanceB, decimalsB), otherTokens[0])
+	s.trackPending(signedTx.Hash())
+	s.pendingMu.Lock()
+	s.pendingWrap = signedTx.Hash()
+	s.pendingMu.Unlock()
 
-	// Step 2: Calculate min amounts out for TokenB -> TokenC
-	amountsOut2, err := s.RouterService.GetAmountsOut(route2Router, balanceB, path2)
-	if err != nil {
-		return fmt.Errorf("error calculating amounts for step 2: %v", err)
-	}
-	minOut2 := new(big.Int).Div(new(big.Int).Mul(amountsOut2[1], big.NewInt(99)), big.NewInt(100))
-
-	// Step 2: TokenB -> TokenC
-	log.Printf("Step 2: Swapping %.6f %s for %s",
-		s.TokenService.ConvertToReadable(balanceB, decimalsB),
-		otherTokens[0], otherTokens[1])
+	slog.Info("🔄 Auto-wrapped BNB",
+		"amount_bnb", s.TokenService.ConvertToReadable(amount, 18),
+		"wbnb_before", s.TokenService.ConvertToReadable(wbnbBalance, 18),
+		"tx", signedTx.Hash().Hex())
+	return nil
+}
 
-	hash2, err := s.RouterService.SwapExactTokensForTokens(
-		route2Router,
-		balanceB,
-		minOut2,
-		path2,
-	)
-	if err != nil {
-		return fmt.Errorf("error executing step 2 swap: %v", err)
+// wrapPending reports whether the last auto-wrap is still unmined, and
+// forgets it once it is mined
+func (s *ArbitrageService) wrapPending() (bool, error) {
+	s.pendingMu.Lock()
+	wrap := s.pendingWrap
+	s.pendingMu.Unlock()
+	if wrap == (common.Hash{}) {
+		return false, nil
 	}
 
-	log.Printf("Step 2 transaction sent: %s", hash2.Hex())
-
-	// Wait for transaction confirmation
-	log.Println("Waiting for step 2 confirmation...")
-	time.Sleep(15 * time.Second)
-
-	// Get TokenC balance after step 2
-	balanceC, err := s.TokenService.GetTokenBalance(tokenC, s.Client.Address)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	receipt, err := s.Backend.TransactionReceipt(ctx, wrap)
+	cancel()
+	if errors.Is(err, ethereum.NotFound) {
+		slog.Debug("Auto-wrap waiting for the previous wrap", "tx", wrap.Hex())
+		return true, nil
+	}
 	if err != nil {
-		return fmt.Errorf("error getting %s balance: %v", otherTokens[1], err)
+		return true, fmt.Errorf("failed to check auto-wrap %s: %v", wrap.Hex(), err)
 	}
 
-	decimalsC, err := s.TokenService.GetTokenDecimals(tokenC)
-	if err != nil {
-		return fmt.Errorf("error getting %s decimals: %v", otherTokens[1], err)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		slog.Warn("⚠️ Auto-wrap reverted", "tx", wrap.Hex())
 	}
+	s.clearPending(wrap)
+	s.pendingMu.Lock()
+	s.pendingWrap = common.Hash{}
+	s.pendingMu.Unlock()
+	return false, nil
+}
 
-	log.Printf("Received: %.6f %s",
-		s.TokenService.ConvertToReadable(balanceC, decimalsC), otherTokens[1])
+// ScanPeriod returns the trading window the service's clock is in: peak, low
+// activity or standard hours
+func (s *ArbitrageService) ScanPeriod() string {
+	return s.Config.ScanPeriodFor(s.Clock.Now())
+}
 
-	// Step 3: Calculate min amounts out for TokenC -> WBNB
-	amountsOut3, err := s.RouterService.GetAmountsOut(route3Router, balanceC, path3)
-	if err != nil {
-		return fmt.Errorf("error calculating amounts for step 3: %v", err)
-	}
-	minOut3 := new(big.Int).Div(new(big.Int).Mul(amountsOut3[1], big.NewInt(99)), big.NewInt(100))
+// ScanEnhancedOpportunities runs one enhanced scan of the pairs in this
+// scan's batch (see scanBatch) and returns the opportunities found ranked by
+// net profit, best first; nothing is executed. When nothing was found it
+// returns the scan's most significant pair error, so a connection failure isn't hidden
+// behind a revert on another pair. Cancelling ctx abandons the scan's reads
+// and returns ErrScanCancelled.
+func (s *ArbitrageService) ScanEnhancedOpportunities(ctx context.Context) ([]models.Opportunity, error) {
+	slog.Info("🎯 Enhanced Arbitrage: Targeting meme coins for higher spreads...")
+	s.RouterService.ResetQuoteCache()
+	s.RefreshGasPrice()
 
-	// Step 3: TokenC -> WBNB
-	log.Printf("Step 3: Swapping %.6f %s for WBNB",
-		s.TokenService.ConvertToReadable(balanceC, decimalsC), otherTokens[1])
+	// Check if we're in peak trading hours
+	period := s.ScanPeriod()
+	isPeakHour := period == config.ScanPeriodPeak
 
-	hash3, err := s.RouterService.SwapExactTokensForTokens(
-		route3Router,
-		balanceC,
-		minOut3,
-		path3,
-	)
-	if err != nil {
-		return fmt.Errorf("error executing step 3 swap: %v", err)
+	if isPeakHour {
+		slog.Info("🔥 PEAK HOURS - High meme coin volatility expected!")
+	} else if period == config.ScanPeriodLow {
+		slog.Info("😴 Low activity hours - reduced opportunities expected")
 	}
 
-	log.Printf("Step 3 transaction sent: %s", hash3.Hex())
-
-	// Wait for final transaction confirmation
-	log.Println("Waiting for step 3 confirmation...")
-	time.Sleep(15 * time.Second)
+	pairs := s.scanBatch()
+	var opportunities []models.Opportunity
+	var scanErr error
 
-	// Get final WBNB balance
-	finalBalance, err := s.TokenService.GetTokenBalance(tokenA, s.Client.Address)
-	if err != nil {
-		return fmt.Errorf("error getting final WBNB balance: %v", err)
-	}
+	for _, pair := range pairs {
+		if err := scanCancelled(ctx); err != nil {
+			return nil, err
+		}
 
-	// Calculate profit/loss
-	profit := new(big.Int).Sub(finalBalance, amount)
-	profitReadable := s.TokenService.ConvertToReadable(profit, decimalsA)
-	initialReadable := s.TokenService.ConvertToReadable(amount, decimalsA)
-	finalReadable := s.TokenService.ConvertToReadable(finalBalance, decimalsA)
+		opportunity, err := s.scanEnhancedPair(ctx, pair)
+		if err != nil && (scanErr == nil || ClassifyError(scanErr) == ErrorRevert) {
+			scanErr = err
+		}
 
-	// Calculate profit percentage
-	var profitPercent float64
-	if amount.Cmp(big.NewInt(0)) > 0 {
-		profitFloat := new(big.Float).SetInt(profit)
-		initialFloat := new(big.Float).SetInt(amount)
-		percentFloat := new(big.Float).Quo(profitFloat, initialFloat)
-		profitPercent, _ = percentFloat.Float64()
+		if opportunity != nil {
+			opportunities = append(opportunities, *opportunity)
+		}
 	}
 
-	// Display results
-	log.Println("========================================")
-	log.Println("Manual Arbitrage Execution Complete")
-	log.Println("========================================")
-	log.Printf("Route: %s", routeDescription)
-	log.Printf("Initial WBNB: %.6f", initialReadable)
-	log.Printf("Final WBNB: %.6f", finalReadable)
-	log.Printf("Profit/Loss: %.6f WBNB", profitReadable)
-	log.Printf("Profit Percentage: %.4f%%", profitPercent*100)
-	log.Println("========================================")
-	log.Println("Transaction Hashes:")
-	log.Printf("Step 1 (WBNB -> %s): %s", otherTokens[0], hash1.Hex())
-	log.Printf("Step 2 (%s -> %s): %s", otherTokens[0], otherTokens[1], hash2.Hex())
-	log.Printf("Step 3 (%s -> WBNB): %s", otherTokens[1], hash3.Hex())
-	log.Println("========================================")
-
-	// Check if profitable
-	if profit.Cmp(big.NewInt(0)) > 0 {
-		log.Printf("✅ Arbitrage successful! Profit: %.6f WBNB (%.4f%%)",
-			profitReadable, profitPercent*100)
-	} else {
-		log.Printf("❌ Arbitrage resulted in loss: %.6f WBNB (%.4f%%)",
-			profitReadable, profitPercent*100)
+	if len(opportunities) > 0 {
+		rankOpportunities(opportunities)
+		return opportunities, nil
+	}
+	if scanErr != nil {
+		return nil, scanErr
 	}
 
-	return nil
+	slog.Info("😞 No enhanced opportunities found this round")
+	s.suggestEnhancedOptimizations(isPeakHour)
+	return nil, nil
 }
 
-// VerifyAndUpdatePairs verifies all pairs and dynamically updates addresses
-func (s *ArbitrageService) VerifyAndUpdatePairs() error {
-	log.Println("Verifying and updating pair addresses...")
+// scanBatch returns the pairs to evaluate this scan in priority order, 1
+// first. With MAX_PAIRS_PER_SCAN set, the top half of the budget always goes
+// to the highest-priority pairs and the rest rotates through the remaining
+// pairs, carrying over from where the previous scan stopped.
+func (s *ArbitrageService) scanBatch() []models.TokenPair {
+	pairs := make([]models.TokenPair, len(s.TokenPairs))
+	copy(pairs, s.TokenPairs)
+	sortByPriority(pairs)
 
-	pancakeFactory := common.HexToAddress(config.PancakeswapFactory)
-	biswapFactory := common.HexToAddress(config.BiswapFactory)
-
-	for i, pair := range s.TokenPairs {
-		log.Printf("Verifying pair: %s", pair.Name)
+	budget := s.Config.MaxPairsPerScan
+	if budget <= 0 || budget >= len(pairs) {
+		return pairs
+	}
 
-		tokenAAddr := common.HexToAddress(pair.Tokens["WBNB"])
-		otherTokens := getOtherTokens(pair.Tokens)
+	fixed := budget / 2
+	batch := append([]models.TokenPair{}, pairs[:fixed]...)
+	rotating := pairs[fixed:]
 
-		if len(otherTokens) < 2 {
-			log.Printf("Skipping pair %s: insufficient tokens", pair.Name)
-			continue
-		}
+	s.cursorMu.Lock()
+	start := s.pairCursor % len(rotating)
+	s.pairCursor = start + budget - fixed
+	s.cursorMu.Unlock()
 
-		tokenBAddr := common.HexToAddress(pair.Tokens[otherTokens[0]])
-		tokenCAddr := common.HexToAddress(pair.Tokens[otherTokens[1]])
+	for i := 0; i < budget-fixed; i++ {
+		batch = append(batch, rotating[(start+i)%len(rotating)])
+	}
+	sortByPriority(batch)
 
-		// Update pair addresses for both exchanges
-		s.updatePairAddresses(&s.TokenPairs[i], pancakeFactory, biswapFactory,
-			tokenAAddr, tokenBAddr, tokenCAddr, otherTokens)
+	names := make([]string, len(batch))
+	for i, pair := range batch {
+		names[i] = pair.Name
 	}
+	slog.Debug("📋 Scan budget", "pairs", len(batch), "of", len(pairs), "scanning", names)
+	return batch
+}
 
-	return nil
+// sortByPriority orders pairs by Priority, 1 first, keeping the configured
+// order between equal priorities
+func sortByPriority(pairs []models.TokenPair) {
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Priority < pairs[j].Priority
+	})
 }
 
-// updatePairAddresses updates pair addresses for a given token pair
-func (s *ArbitrageService) updatePairAddresses(
-	pair *models.TokenPair,
-	pancakeFactory, biswapFactory, tokenA, tokenB, tokenC common.Address,
-	otherTokens []string,
-) {
-	// Update PancakeSwap pairs
-	if pairAB, err := s.GetPairAddressFromFactory(pancakeFactory, tokenA, tokenB); err == nil {
-		pair.PancakeswapPair["WBNB-"+otherTokens[0]] = pairAB.Hex()
-		log.Printf("Updated PancakeSwap pair WBNB-%s: %s", otherTokens[0], pairAB.Hex())
-	}
+// rankOpportunities sorts opportunities by net profit in WBNB, after the
+// platform fee and gas, best first. Pairs in priority order break ties.
+func rankOpportunities(opportunities []models.Opportunity) {
+	sort.SliceStable(opportunities, func(i, j int) bool {
+		return opportunities[i].NetProfitWBNB > opportunities[j].NetProfitWBNB
+	})
 
-	if pairBC, err := s.GetPairAddressFromFactory(pancakeFactory, tokenB, tokenC); err == nil {
-		pair.PancakeswapPair[otherTokens[0]+"-"+otherTokens[1]] = pairBC.Hex()
-		log.Printf("Updated PancakeSwap pair %s-%s: %s", otherTokens[0], otherTokens[1], pairBC.Hex())
+	for i, opportunity := range opportunities {
+		slog.Debug("🏅 Ranked opportunity", "rank", i+1, "pair", opportunity.PairName,
+			"strategy", opportunity.Strategy, "route", opportunity.Route(),
+			"amount_wbnb", opportunity.AmountWBNB, "net_wbnb", opportunity.NetProfitWBNB)
 	}
+}
 
-	if pairCA, err := s.GetPairAddressFromFactory(pancakeFactory, tokenC, tokenA); err == nil {
-		pair.PancakeswapPair[otherTokens[1]+"-WBNB"] = pairCA.Hex()
-		log.Printf("Updated PancakeSwap pair %s-WBNB: %s", otherTokens[1], pairCA.Hex())
+// ScanPair runs a targeted enhanced scan of one pair, e.g. when a pending swap
+// is about to move its pools. It returns the pair's opportunity, if any,
+// without executing it.
+func (s *ArbitrageService) ScanPair(ctx context.Context, pairName string) ([]models.Opportunity, error) {
+	pair, err := s.FindTokenPair(pairName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update BiSwap pairs
-	if pairAB, err := s.GetPairAddressFromFactory(biswapFactory, tokenA, tokenB); err == nil {
-		pair.BiswapPair["WBNB-"+otherTokens[0]] = pairAB.Hex()
-		log.Printf("Updated BiSwap pair WBNB-%s: %s", otherTokens[0], pairAB.Hex())
+	s.RouterService.ResetQuoteCache()
+	s.RefreshGasPrice()
+	opportunity, err := s.scanEnhancedPair(ctx, pair)
+	if err != nil || opportunity == nil {
+		return nil, err
 	}
+	return []models.Opportunity{*opportunity}, nil
+}
 
-	if pairBC, err := s.GetPairAddressFromFactory(biswapFactory, tokenB, tokenC); err == nil {
-		pair.BiswapPair[otherTokens[0]+"-"+otherTokens[1]] = pairBC.Hex()
-		log.Printf("Updated BiSwap pair %s-%s: %s", otherTokens[0], otherTokens[1], pairBC.Hex())
-	}
+// scanEnhancedPair returns the pair's best enhanced opportunity, or nil if it
+// has none or is cooling down. It returns an error when the pair couldn't be
+// quoted at all.
+func (s *ArbitrageService) scanEnhancedPair(ctx context.Context, pair models.TokenPair) (*models.Opportunity, error) {
+	if until, cooling := s.pairCooldown(pair.Name); cooling {
+		slog.Debug("🧊 Pair cooling down after a failed execution", "pair", pair.Name,
+			"until", until.Format(time.RFC3339))
+		return nil, nil
+	}
+
+	// Quote the whole pair at one block so a new block landing between legs
+	// can't produce phantom profit; execution re-quotes at the latest block
+	s.bindQuoteContext(ctx)
+	s.pinLatestBlock(ctx)
+	candidate, err := s.findEnhancedCandidate(ctx, pair)
+	if err == nil {
+		s.recordPairSpread(pair)
+	}
+	s.PinBlock(nil)
+	s.bindQuoteContext(nil)
+	if err != nil || candidate == nil {
+		return nil, err
+	}
+
+	slog.Info("💰 ENHANCED OPPORTUNITY FOUND!",
+		"pair", pair.Name,
+		"strategy", candidate.Strategy,
+		"category", candidate.Category,
+		"route", candidate.Route(),
+		"profit_pct", candidate.AdjustedProfit*100,
+		"amount_wbnb", candidate.AmountWBNB,
+		"net_wbnb", candidate.NetProfitWBNB,
+		"gas_wbnb", candidate.GasCostWBNB)
+	return candidate, nil
+}
 
-	if pairCA, err := s.GetPairAddressFromFactory(biswapFactory, tokenC, tokenA); err == nil {
-		pair.BiswapPair[otherTokens[1]+"-WBNB"] = pairCA.Hex()
-		log.Printf("Updated BiSwap pair %s-WBNB: %s", otherTokens[1], pairCA.Hex())
+// Execute preflights, confirms and executes an opportunity returned by a
+// scan. It returns the execution when a trade went through. A skipped or
+// failed trade returns a nil execution and no error: the outcome is logged,
+// recorded in the opportunity log and, for failures, cools the pair down. An
+// error means the opportunity couldn't be considered for execution at all,
+// e.g. ctx was cancelled or the preflight couldn't reach the node.
+func (s *ArbitrageService) Execute(ctx context.Context, opportunity models.Opportunity) (*models.ExecutionResult, error) {
+	pair, route, err := s.OpportunityRoute(opportunity)
+	if err != nil {
+		return nil, err
 	}
-}
 
-// GetPairAddressFromFactory gets pair address from factory contract
-func (s *ArbitrageService) GetPairAddressFromFactory(factoryAddress, tokenA, tokenB common.Address) (common.Address, error) {
-	// Factory ABI
-	factoryABI := `[{"inputs":[{"internalType":"address","name":"tokenA","type":"address"},{"internalType":"address","name":"tokenB","type":"address"}],"name":"getPair","outputs":[{"internalType":"address","name":"pair","type":"address"}],"stateMutability":"view","type":"function"}]`
+	// Don't start a trade the scan has already been given up on
+	if err := scanCancelled(ctx); err != nil {
+		s.logOpportunity(opportunity, OutcomeCancelled, nil)
+		return nil, err
+	}
 
-	parsed, err := abi.JSON(strings.NewReader(factoryABI))
-	if err != nil {
-		return common.Address{}, fmt.Errorf("failed to parse factory ABI: %v", err)
+	if reason, monitorOnly := s.MonitorOnly(); monitorOnly {
+		slog.Info("👀 Monitor-only, skipping execution", "pair", pair.Name, "reason", reason)
+		s.logOpportunity(opportunity, OutcomeMonitorOnly, nil)
+		return nil, nil
 	}
 
-	// Pack parameters
-	callData, err := parsed.Pack("getPair", tokenA, tokenB)
-	if err != nil {
-		return common.Address{}, fmt.Errorf("failed to pack getPair: %v", err)
+	// A reverting gas estimate means the trade would fail on-chain; skip it
+	// rather than pay for a doomed transaction
+	if _, err := s.PreflightArbitrage(pair, opportunity.Result.TargetAmount, route); err != nil {
+		if errors.Is(err, ErrManualArbitrageDisabled) {
+			slog.Warn("🚫 Execution skipped: no flash contract for this route and ALLOW_MANUAL_ARBITRAGE is off",
+				"pair", pair.Name, "route", route.String())
+			s.logOpportunity(opportunity, OutcomeManualDisabled, nil)
+			return nil, nil
+		}
+		if errors.Is(err, ErrPreflightReverted) {
+			slog.Warn("🛑 Preflight reverted, skipping execution", "pair", pair.Name,
+				"route", route.String(), "err", err)
+			if IsSlippageRevert(err) {
+				s.recordMissedOpportunity() // the price moved before we could act
+			}
+			s.startPairCooldown(pair.Name)
+			s.logOpportunity(opportunity, OutcomePreflightReverted, nil)
+			return nil, nil
+		}
+		s.logOpportunity(opportunity, OutcomePreflightFailed, nil)
+		return nil, err
 	}
 
-	// Call contract
-	result, err := s.Client.Client.CallContract(context.Background(), ethereum.CallMsg{
-		To:   &factoryAddress,
-		Data: callData,
-	}, nil)
-	if err != nil {
-		return common.Address{}, fmt.Errorf("failed to call getPair: %v", err)
+	if s.ConfirmTrade != nil && !s.ConfirmTrade(pair.Name, route, opportunity.Result) {
+		slog.Info("🙅 Trade not confirmed, skipping execution", "pair", pair.Name)
+		s.logOpportunity(opportunity, OutcomeNotConfirmed, nil)
+		return nil, nil
 	}
 
-	// Unpack result
-	var pairAddress common.Address
-	err = parsed.UnpackIntoInterface(&pairAddress, "getPair", result)
+	execution, err := s.ExecuteArbitrage(pair, opportunity.Result.TargetAmount, route)
+	if errors.Is(err, ErrGasPriceTooHigh) {
+		slog.Warn("⛽ Gas too expensive, skipping execution", "pair", pair.Name, "err", err)
+		s.logOpportunity(opportunity, OutcomeGasTooHigh, nil)
+		return nil, nil
+	}
+	if errors.Is(err, ErrTooManyPending) {
+		slog.Warn("⏳ Waiting on unconfirmed transactions, skipping execution", "pair", pair.Name, "err", err)
+		s.logOpportunity(opportunity, OutcomeTooManyPending, nil)
+		return nil, nil
+	}
+	if errors.Is(err, ErrNativeReserve) {
+		slog.Warn("🪙 Native BNB too low to trade, skipping execution", "pair", pair.Name, "err", err)
+		s.logOpportunity(opportunity, OutcomeLowNativeBalance, nil)
+		return nil, nil
+	}
+	if errors.Is(err, ErrLostRace) {
+		slog.Warn("🏁 Lost race, trade reverted at its minimum outputs", "pair", pair.Name,
+			"route", route.String(), "err", err)
+		s.recordMissedOpportunity()
+		s.startPairCooldown(pair.Name)
+		s.logOpportunity(opportunity, OutcomeLostRace, execution)
+		return nil, nil
+	}
 	if err != nil {
-		return common.Address{}, fmt.Errorf("failed to unpack getPair result: %v", err)
+		slog.Error("❌ Enhanced execution failed", "pair", pair.Name, "err", err)
+		s.startPairCooldown(pair.Name)
+		s.logOpportunity(opportunity, OutcomeFailed, execution)
+		return nil, nil
 	}
 
-	// Check if pair exists
-	if pairAddress == (common.Address{}) {
-		return common.Address{}, fmt.Errorf("pair does not exist")
+	slog.Info("✅ Enhanced trade executed successfully!", "pair", pair.Name)
+	s.clearPairCooldown(pair.Name)
+	s.recordEnhancedTrade(opportunity, execution)
+	s.logOpportunity(opportunity, OutcomeExecuted, execution)
+	return execution, nil
+}
+
+// startPairCooldown keeps the scanner off a pair for PAIR_FAILURE_COOLDOWN
+// after its execution failed, so a pair that keeps losing races or has a bad
+// pool configured stops burning gas estimates every scan
+func (s *ArbitrageService) startPairCooldown(pairName string) {
+	if s.Config.PairFailureCooldown <= 0 {
+		return
 	}
 
-	return pairAddress, nil
-}
+	until := s.Clock.Now().Add(s.Config.PairFailureCooldown)
 
-// Helper function to get other tokens (non-WBNB tokens) from a pair
-func getOtherTokens(tokens map[string]string) []string {
-	var otherTokens []string
-	for key := range tokens {
-		if key != "WBNB" {
-			otherTokens = append(otherTokens, key)
-		}
+	s.cooldownMu.Lock()
+	defer s.cooldownMu.Unlock()
+	if s.pairCooldowns == nil {
+		s.pairCooldowns = make(map[string]time.Time)
 	}
+	s.pairCooldowns[pairName] = until
 
-	// Sort for consistency
-	sort.Strings(otherTokens)
-	return otherTokens
+	slog.Info("🧊 Cooling down pair after failed execution", "pair", pairName,
+		"cooldown", s.Config.PairFailureCooldown)
 }
 
-/// arbitrage.go - FIXED VERSION - Replace line 754 onwards with this
-// (Keep everything above line 754, replace everything after)
-
-func (s *ArbitrageService) FindEnhancedArbitrageOpportunities() error {
-	log.Println("🎯 Enhanced Arbitrage: Targeting meme coins for higher spreads...")
+// clearPairCooldown lifts a pair's cooldown after a successful execution
+func (s *ArbitrageService) clearPairCooldown(pairName string) {
+	s.cooldownMu.Lock()
+	defer s.cooldownMu.Unlock()
+	delete(s.pairCooldowns, pairName)
+}
 
-	// Check if we're in peak trading hours
-	hour := time.Now().UTC().Hour()
-	isPeakHour := (hour >= 13 && hour <= 16) || (hour >= 21 && hour <= 23)
+// pairCooldown reports whether a pair is cooling down and until when. An
+// expired cooldown is forgotten.
+func (s *ArbitrageService) pairCooldown(pairName string) (time.Time, bool) {
+	s.cooldownMu.Lock()
+	defer s.cooldownMu.Unlock()
 
-	if isPeakHour {
-		log.Println("🔥 PEAK HOURS - High meme coin volatility expected!")
-	} else if hour >= 2 && hour <= 6 {
-		log.Println("😴 Low activity hours - reduced opportunities expected")
+	until, exists := s.pairCooldowns[pairName]
+	if !exists {
+		return time.Time{}, false
 	}
+	if !s.Clock.Now().Before(until) {
+		delete(s.pairCooldowns, pairName)
+		return time.Time{}, false
+	}
+	return until, true
+}
 
-	// Get all pairs but prioritize meme coins
-	pairs := s.TokenPairs
-	foundOpportunity := false
+// logOpportunity writes a detected opportunity and what became of it to the
+// opportunity log, if one is configured. execution may be nil when nothing
+// was sent.
+func (s *ArbitrageService) logOpportunity(opportunity models.Opportunity, outcome string, execution *models.ExecutionResult) {
+	if s.OpportunityLog == nil {
+		return
+	}
+
+	event := OpportunityEvent{
+		Timestamp:     s.Clock.Now().UTC(),
+		Pair:          opportunity.PairName,
+		Route:         opportunity.Route(),
+		AmountWBNB:    opportunity.AmountWBNB,
+		GrossPercent:  opportunity.GrossPercent,
+		NetProfitWBNB: opportunity.NetProfitWBNB,
+		GasCostWBNB:   opportunity.GasCostWBNB,
+		Executed:      outcome == OutcomeExecuted,
+		Outcome:       outcome,
+	}
+	if execution != nil && len(execution.TxHashes) > 0 {
+		event.TxHash = execution.TxHashes[len(execution.TxHashes)-1].Hex()
+	}
+	s.OpportunityLog.Record(event)
+}
 
-	for _, pair := range pairs {
-		// Determine pair category and settings
-		category := getMemeCategory(pair.Name)
-		minProfit := getMinProfitForCategory(category)
-		gasAdjustment := getGasAdjustmentForCategory(category)
+// findEnhancedCandidate runs every strategy on a pair and returns the most
+// profitable opportunity they found, or nil if none did. It returns an error
+// when nothing was found and a strategy couldn't quote the pair; a pool below
+// the reserve minimum is a skip.
+func (s *ArbitrageService) findEnhancedCandidate(ctx context.Context, pair models.TokenPair) (*models.Opportunity, error) {
+	// Skip pairs whose pools are too thin to quote reliably
+	if err := s.CheckPairLiquidity(pair); errors.Is(err, ErrPoolTooThin) {
+		slog.Warn("💧 Skipping pair", "pair", pair.Name, "err", err)
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%s liquidity check failed: %w", pair.Name, err)
+	}
+
+	var best *models.Opportunity
+	var strategyErr error
+	for _, strategy := range s.Strategies {
+		if err := scanCancelled(ctx); err != nil {
+			return nil, err
+		}
 
-		log.Printf("🎯 Checking %s: %s (min profit: %.2f%%)", category, pair.Name, minProfit*100)
+		opportunities, err := strategy.Evaluate(ctx, pair)
+		if err != nil {
+			slog.Debug("Strategy failed", "strategy", strategy.Name(), "pair", pair.Name, "err", err)
+			strategyErr = err
+			continue
+		}
+		for i := range opportunities {
+			if best == nil || opportunities[i].AdjustedProfit > best.AdjustedProfit {
+				best = &opportunities[i]
+			}
+		}
+	}
 
-		// Try enhanced test amounts
-		for _, amount := range pair.TestAmounts {
-			// Check triangular arbitrage opportunities
-			result1, err1 := s.CheckTriangularArbitrage(pair, amount, true)
-			result2, err2 := s.CheckTriangularArbitrage(pair, amount, false)
+	if best == nil && strategyErr != nil {
+		return nil, strategyErr
+	}
+	return best, nil
+}
 
-			if err1 != nil && err2 != nil {
-				log.Printf("⚠️ Both routes failed for %s: %v", pair.Name, err1)
+// evaluateRoutes quotes routes of a pair at each test amount and returns the
+// first amount's best route that clears the pair's thresholds and the price
+// impact limit, or nil if none does. It returns an error when no route could
+// be quoted at all.
+func (s *ArbitrageService) evaluateRoutes(pair models.TokenPair, routes []Route) (*models.Opportunity, error) {
+	// Determine pair category and settings
+	category := s.getMemeCategory(pair.Name)
+	minProfit := s.getMinProfitForCategory(pair, category)
+	gasAdjustment := s.getGasAdjustmentForCategory(pair, category)
+
+	slog.Debug("🎯 Checking pair", "category", category, "pair", pair.Name, "min_profit_pct", minProfit*100)
+
+	// The last quote error, returned if no amount could be quoted on any route
+	var quoteErr error
+	anyQuoted := false
+
+	// Try enhanced test amounts
+	for _, amount := range pair.TestAmounts {
+		var bestResult *models.ArbitrageResult
+		var bestRoute Route
+		var adjustedProfit float64
+		var lastErr error
+		quoted := 0
+
+		// Check triangular arbitrage opportunities on every route
+		for _, route := range routes {
+			if s.Config.ReservePrefilter && s.prefiltered(pair, route, amount, minProfit) {
 				continue
 			}
 
-			var bestResult *models.ArbitrageResult
-			var pancakeFirst bool
-			var adjustedProfit float64
-
-			// Evaluate Pancake->Biswap route
-			if err1 == nil {
-				adjustedProfit1 := result1.ProfitPercent - gasAdjustment
-				log.Printf("📊 Pancake->Biswap: %.4f%% (Gas adj: %.4f%%)",
-					result1.ProfitPercent*100, adjustedProfit1*100)
+			result, err := s.CheckTriangularArbitrage(pair, amount, route)
+			if errors.Is(err, ErrNoOpportunity) {
+				continue
+			}
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			quoted++
+
+			// Gate on the user's share, not gross profit before the platform fee
+			routeProfit := s.UserProfitPercent(result) - gasAdjustment
+			slog.Debug("📊 Route quoted", "route", route.String(), "base", route.Hops[0].SymbolIn, "profit_pct", result.ProfitPercent*100,
+				"gas_adjusted_pct", routeProfit*100, "net_wbnb", result.NetProfitWBNB)
+
+			if routeProfit >= minProfit && s.meetsMinNetProfit(result) &&
+				(bestResult == nil || routeProfit > adjustedProfit) {
+				bestResult = result
+				bestRoute = route
+				adjustedProfit = routeProfit
+			}
+		}
 
-				if adjustedProfit1 >= minProfit {
-					bestResult = result1
-					pancakeFirst = true
-					adjustedProfit = adjustedProfit1
-				}
+		if quoted == 0 {
+			if lastErr != nil {
+				slog.Warn("⚠️ All routes failed", "pair", pair.Name, "err", lastErr)
+				quoteErr = lastErr
 			}
+			continue
+		}
+		anyQuoted = true
 
-			// Evaluate Biswap->Pancake route
-			if err2 == nil {
-				adjustedProfit2 := result2.ProfitPercent - gasAdjustment
-				log.Printf("📊 Biswap->Pancake: %.4f%% (Gas adj: %.4f%%)",
-					result2.ProfitPercent*100, adjustedProfit2*100)
+		// Compare with the best venue per leg, mixing V2 and V3 pools. The
+		// result can't be executed, so this spends quoter calls only on a log
+		// line and stays off unless asked for.
+		if s.Config.EnableV3 && s.Config.LogV3Routes {
+			s.logBestVenueRoute(pair, amount, minProfit, gasAdjustment, bestResult, adjustedProfit)
+		}
 
-				if adjustedProfit2 >= minProfit && (bestResult == nil || adjustedProfit2 > adjustedProfit) {
-					bestResult = result2
-					pancakeFirst = false
-					adjustedProfit = adjustedProfit2
-				}
+		// Keep the trade a small share of every pool it swaps through
+		tradeAmount := amount
+		if bestResult != nil {
+			cappedAmount, cappedResult, err := s.capToPools(pair, bestRoute, amount, bestResult)
+			if err != nil {
+				slog.Warn("⚠️ Pool size check failed", "pair", pair.Name, "err", err)
+				continue
 			}
-
-			// Execute if profitable
-			if bestResult != nil {
-				log.Printf("💰 ENHANCED OPPORTUNITY FOUND!")
-				log.Printf("🚀 %s: %.4f%% profit (%.6f WBNB)", pair.Name, adjustedProfit*100, amount)
-				log.Printf("📈 Category: %s, Route: %s", category, getRouteDescription(pancakeFirst))
-
-				// Execute the arbitrage
-				err := s.ExecuteArbitrage(pair, bestResult.TargetAmount, pancakeFirst)
-				if err != nil {
-					log.Printf("❌ Enhanced execution failed: %v", err)
-				} else {
-					foundOpportunity = true
-					log.Printf("✅ Enhanced trade executed successfully!")
-					recordEnhancedTrade(pair.Name, adjustedProfit, amount, category)
+			if cappedResult != bestResult {
+				cappedProfit := s.UserProfitPercent(cappedResult) - gasAdjustment
+				if cappedProfit < minProfit || !s.meetsMinNetProfit(cappedResult) {
+					slog.Info("✂️ Skipping pair: capped trade is below thresholds", "pair", pair.Name,
+						"amount_wbnb", cappedAmount, "profit_pct", cappedProfit*100, "net_wbnb", cappedResult.NetProfitWBNB)
+					continue
 				}
-				break // Move to next pair after execution
+				tradeAmount, bestResult, adjustedProfit = cappedAmount, cappedResult, cappedProfit
 			}
 		}
 
-		if foundOpportunity {
-			break // Focus on one opportunity at a time
+		// Skip routes where our own trade size moves the price too much
+		if bestResult != nil {
+			impact, err := s.GetRoutePriceImpact(pair, bestRoute, bestResult.TargetAmount)
+			if err != nil {
+				slog.Warn("⚠️ Price impact check failed", "pair", pair.Name, "err", err)
+				continue
+			}
+
+			if impact/100 > s.Config.MaxPriceImpact {
+				slog.Info("🌊 Skipping pair: price impact exceeds max",
+					"pair", pair.Name, "impact_pct", impact, "max_pct", s.Config.MaxPriceImpact*100)
+				continue
+			}
+
+			slog.Info("🌊 Price impact", "pair", pair.Name, "impact_pct", impact)
+
+			return &models.Opportunity{
+				PairName:       pair.Name,
+				Category:       category,
+				Symbols:        bestRoute.Symbols(),
+				Venues:         bestRoute.DEXNames(),
+				AmountWBNB:     tradeAmount,
+				GrossPercent:   profitRatio(bestResult.Profit, bestResult.TargetAmount) * 100,
+				AdjustedProfit: adjustedProfit,
+				NetProfitWBNB:  bestResult.NetProfitWBNB,
+				GasCostWBNB:    bestResult.GasCostWBNB,
+				Result:         bestResult,
+			}, nil
 		}
 	}
 
-	if !foundOpportunity {
-		log.Println("😞 No enhanced opportunities found this round")
-		suggestEnhancedOptimizations(isPeakHour)
+	if !anyQuoted && quoteErr != nil {
+		return nil, fmt.Errorf("%s: %w", pair.Name, quoteErr)
 	}
+	return nil, nil
+}
 
-	return nil
+// logBestVenueRoute quotes the best-venue route and reports when it would beat
+// the executable V2 routes. Best-venue routes are quote-only: the flash
+// contract and manual legs only swap through V2 routers.
+func (s *ArbitrageService) logBestVenueRoute(
+	pair models.TokenPair,
+	amount float64,
+	minProfit float64,
+	gasAdjustment float64,
+	bestResult *models.ArbitrageResult,
+	bestAdjustedProfit float64,
+) {
+	result, err := s.CheckBestVenueArbitrage(pair, amount)
+	if err != nil {
+		slog.Warn("⚠️ Best-venue quote failed", "pair", pair.Name, "err", err)
+		return
+	}
+
+	adjustedProfit := s.UserProfitPercent(result) - gasAdjustment
+	slog.Debug("📊 Best venue quoted", "venues", strings.Join(result.Venues, " -> "), "profit_pct", result.ProfitPercent*100,
+		"gas_adjusted_pct", adjustedProfit*100, "net_wbnb", result.NetProfitWBNB)
+
+	if !usesV3(result) || adjustedProfit < minProfit || !s.meetsMinNetProfit(result) {
+		return
+	}
+	if bestResult == nil || adjustedProfit > bestAdjustedProfit {
+		slog.Info("🧪 Mixed V2/V3 route beats the V2 routes, but V3 legs are quote-only - not executing", "pair", pair.Name)
+	}
 }
 
 // Helper functions for enhanced arbitrage
-func getMemeCategory(pairName string) string {
+func (s *ArbitrageService) getMemeCategory(pairName string) string {
 	switch {
 	case strings.Contains(pairName, "SHIB") || strings.Contains(pairName, "DOGE") ||
 		strings.Contains(pairName, "FLOKI") || strings.Contains(pairName, "SAFEMOON"):
@@ -1013,83 +3042,284 @@ func getMemeCategory(pairName string) string {
 	}
 }
 
-func getMinProfitForCategory(category string) float64 {
-	switch category {
-	case "meme":
-		return 0.005 // 0.5% for meme coins (higher volatility expected)
-	case "volatile":
-		return 0.003 // 0.3% for volatile tokens
-	case "established":
-		return 0.002 // 0.2% for established tokens
-	case "stable":
-		return 0.001 // 0.1% for stable pairs
-	default:
-		return 0.002
+func (s *ArbitrageService) getMinProfitForCategory(pair models.TokenPair, category string) float64 {
+	if pair.MinProfitOverride > 0 {
+		return pair.MinProfitOverride
 	}
+	if minProfit, exists := s.Config.CategoryMinProfit[category]; exists {
+		return minProfit
+	}
+	return s.Config.CategoryMinProfit["unknown"]
 }
 
-func getGasAdjustmentForCategory(category string) float64 {
-	switch category {
-	case "meme":
-		return 0.0015 // 0.15% - meme coins may have higher gas costs
-	case "volatile":
-		return 0.0012 // 0.12%
-	case "established":
-		return 0.0010 // 0.10%
-	case "stable":
-		return 0.0008 // 0.08%
-	default:
-		return 0.0010
+func (s *ArbitrageService) getGasAdjustmentForCategory(pair models.TokenPair, category string) float64 {
+	if pair.GasAdjustmentOverride > 0 {
+		return pair.GasAdjustmentOverride
 	}
+	if gasAdjustment, exists := s.Config.CategoryGasAdjustment[category]; exists {
+		return gasAdjustment
+	}
+	return s.Config.CategoryGasAdjustment["unknown"]
 }
 
-func getRouteDescription(pancakeFirst bool) string {
-	if pancakeFirst {
-		return "Pancake→Biswap→Pancake"
+// meetsMinNetProfit reports whether a route clears the absolute net-profit
+// floor, so percentage-profitable micro trades that don't cover gas are skipped
+func (s *ArbitrageService) meetsMinNetProfit(result *models.ArbitrageResult) bool {
+	if result.NetProfitWBNB >= s.Config.MinNetProfitWBNB {
+		return true
+	}
+
+	if result.ProfitPercent > 0 {
+		slog.Debug("💸 Net profit below minimum, skipping route",
+			"net_wbnb", result.NetProfitWBNB, "min_wbnb", s.Config.MinNetProfitWBNB)
 	}
-	return "Biswap→Pancake→Biswap"
+	return false
 }
 
-// Enhanced statistics tracking
-var enhancedStats = struct {
+// EnhancedStats tracks trades executed by the enhanced scanner
+type EnhancedStats struct {
 	TotalTrades   int
 	MemeTrades    int
-	TotalProfit   float64
+	TotalProfit   float64 // expected WBNB, from the quotes trades were executed on
 	BestTrade     float64
 	CategoryStats map[string]int
-}{
-	CategoryStats: make(map[string]int),
+	PairSpreads   map[string]*SpreadStats
+
+	// Realized WBNB from each trade's wallet balance change, and the number
+	// of trades that actually ended up with more WBNB than they started
+	RealizedProfit float64
+	WinningTrades  int
+
+	// Opportunities that were gone by the time they were executed: the trade
+	// or its preflight reverted at the minimum output
+	MissedOpportunities int
+}
+
+// TradeSummary compares what executed trades were expected to make with what
+// they actually made, in WBNB. Realized profit is net of gas for manual trades
+// and before gas for flash trades.
+type TradeSummary struct {
+	Trades         int
+	Wins           int
+	ExpectedProfit float64
+	RealizedProfit float64
+
+	// Real opportunities lost on speed rather than phantom ones; see
+	// EnhancedStats.MissedOpportunities
+	MissedOpportunities int
+}
+
+// WinRate returns the percentage of trades with a positive realized profit
+func (t TradeSummary) WinRate() float64 {
+	if t.Trades == 0 {
+		return 0
+	}
+	return float64(t.Wins) / float64(t.Trades) * 100
 }
 
-func recordEnhancedTrade(pairName string, profit, amount float64, category string) {
-	enhancedStats.TotalTrades++
-	enhancedStats.CategoryStats[category]++
+// SpreadStats accumulates a pair's cross-DEX spread, in percent, over scans
+type SpreadStats struct {
+	Samples int
+	Total   float64
+	Last    float64
+}
+
+// Average returns the mean spread over all samples
+func (s *SpreadStats) Average() float64 {
+	if s.Samples == 0 {
+		return 0
+	}
+	return s.Total / float64(s.Samples)
+}
+
+// PairSpread quotes each leg of a pair's cycle on every exchange and returns
+// the largest percentage by which the best exchange's output beat the
+// worst's on any leg, before gas. Each leg is fed the first exchange's
+// output of the previous leg so most quotes come from the cache. With the
+// reserve pre-filter on, legs are estimated from reserves instead.
+func (s *ArbitrageService) PairSpread(pair models.TokenPair, testAmount float64) (float64, error) {
+	if len(s.DEXes) < 2 {
+		return 0, fmt.Errorf("need at least 2 exchanges to measure a spread")
+	}
+
+	route, err := s.BuildRoute(pair, "WBNB", []DEX{s.DEXes[0], s.DEXes[0], s.DEXes[0]})
+	if err != nil {
+		return 0, err
+	}
+
+	decimals, err := s.TokenService.GetTokenDecimals(route.Hops[0].TokenIn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get decimals for WBNB: %v", err)
+	}
+	legIn := s.TokenService.FormatTokenAmount(testAmount, decimals)
+
+	maxSpread := 0.0
+	for _, hop := range route.Hops {
+		var best, worst, next *big.Int
+		for _, dex := range s.DEXes {
+			if s.poolUnlisted(dex, hop.TokenIn, hop.TokenOut) {
+				continue
+			}
+			out, err := s.spreadQuote(pair, dex, hop, legIn)
+			if err != nil {
+				continue
+			}
+
+			if best == nil || out.Cmp(best) > 0 {
+				best = out
+			}
+			if worst == nil || out.Cmp(worst) < 0 {
+				worst = out
+			}
+			if dex == s.DEXes[0] || next == nil {
+				next = out
+			}
+		}
+		if next == nil {
+			return 0, fmt.Errorf("no exchange quoted %s -> %s", hop.SymbolIn, hop.SymbolOut)
+		}
+
+		if spread := utils.CalculatePercentage(best, worst); spread > maxSpread {
+			maxSpread = spread
+		}
+		legIn = next
+	}
+
+	return maxSpread, nil
+}
+
+// spreadQuote quotes one leg of PairSpread on dex. When the reserve pre-filter
+// is on it estimates from the pool's reserves, so measuring the spread doesn't
+// spend the router calls the pre-filter saved.
+func (s *ArbitrageService) spreadQuote(pair models.TokenPair, dex DEX, hop Hop, amountIn *big.Int) (*big.Int, error) {
+	if s.Config.ReservePrefilter {
+		if pool := findPairAddress(dex.PairAddresses(&pair), hop.SymbolIn, hop.SymbolOut); pool != "" {
+			return dex.EstimateAmountOut(common.HexToAddress(pool), hop.TokenIn, amountIn)
+		}
+	}
+
+	amounts, err := dex.GetAmountsOut(amountIn, hop.Path())
+	if err != nil {
+		return nil, err
+	}
+	return amounts[len(amounts)-1], nil
+}
+
+// recordPairSpread measures a pair's cross-DEX spread at its first test amount
+// and adds it to the running averages
+func (s *ArbitrageService) recordPairSpread(pair models.TokenPair) {
+	if len(pair.TestAmounts) == 0 {
+		return
+	}
+
+	spread, err := s.PairSpread(pair, pair.TestAmounts[0])
+	if err != nil {
+		slog.Debug("Spread not measured", "pair", pair.Name, "err", err)
+		return
+	}
+
+	s.spreadMu.Lock()
+	if s.enhancedStats.PairSpreads == nil {
+		s.enhancedStats.PairSpreads = make(map[string]*SpreadStats)
+	}
+	stats, exists := s.enhancedStats.PairSpreads[pair.Name]
+	if !exists {
+		stats = &SpreadStats{}
+		s.enhancedStats.PairSpreads[pair.Name] = stats
+	}
+	stats.Samples++
+	stats.Total += spread
+	stats.Last = spread
+	average := stats.Average()
+	s.spreadMu.Unlock()
+
+	slog.Debug("📏 Cross-DEX spread", "pair", pair.Name, "spread_pct", spread, "avg_spread_pct", average)
+}
+
+// AverageSpreads returns each scanned pair's average cross-DEX spread in percent
+func (s *ArbitrageService) AverageSpreads() map[string]float64 {
+	s.spreadMu.Lock()
+	defer s.spreadMu.Unlock()
 
-	tradeProfit := profit * amount
-	enhancedStats.TotalProfit += tradeProfit
+	averages := make(map[string]float64, len(s.enhancedStats.PairSpreads))
+	for name, stats := range s.enhancedStats.PairSpreads {
+		averages[name] = stats.Average()
+	}
+	return averages
+}
+
+// recordEnhancedTrade adds an executed trade to the stats: the profit its
+// quotes promised and the WBNB balance change it actually produced
+func (s *ArbitrageService) recordEnhancedTrade(opportunity models.Opportunity, execution *models.ExecutionResult) {
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+
+	stats := &s.enhancedStats
+	stats.TotalTrades++
+	stats.CategoryStats[opportunity.Category]++
+
+	expected := opportunity.AdjustedProfit * opportunity.AmountWBNB
+	stats.TotalProfit += expected
+
+	realized := formatWBNB(execution.RealizedProfit)
+	if len(opportunity.Symbols) > 0 && opportunity.Symbols[0] != "WBNB" {
+		// Value profit in another base token at the rate the trade was sized at
+		realized = profitRatio(execution.RealizedProfit, execution.AmountIn) * opportunity.AmountWBNB
+	}
+	stats.RealizedProfit += realized
+	if execution.RealizedProfit.Sign() > 0 {
+		stats.WinningTrades++
+	}
 
-	if category == "meme" {
-		enhancedStats.MemeTrades++
+	if opportunity.Category == "meme" {
+		stats.MemeTrades++
 	}
 
-	if tradeProfit > enhancedStats.BestTrade {
-		enhancedStats.BestTrade = tradeProfit
+	if realized > stats.BestTrade {
+		stats.BestTrade = realized
 	}
 
-	log.Printf("📊 Enhanced Stats: %d total trades, %d meme trades, %.6f WBNB profit",
-		enhancedStats.TotalTrades, enhancedStats.MemeTrades, enhancedStats.TotalProfit)
+	slog.Info("📊 Enhanced Stats", "pair", opportunity.PairName, "total_trades", stats.TotalTrades,
+		"meme_trades", stats.MemeTrades, "expected_wbnb", expected, "realized_wbnb", realized,
+		"total_expected_wbnb", stats.TotalProfit, "total_realized_wbnb", stats.RealizedProfit)
 }
 
-func suggestEnhancedOptimizations(isPeakHour bool) {
+// TradeSummary returns the expected and realized results of the trades the
+// enhanced scanner has executed so far
+func (s *ArbitrageService) TradeSummary() TradeSummary {
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+
+	return TradeSummary{
+		Trades:              s.enhancedStats.TotalTrades,
+		Wins:                s.enhancedStats.WinningTrades,
+		ExpectedProfit:      s.enhancedStats.TotalProfit,
+		RealizedProfit:      s.enhancedStats.RealizedProfit,
+		MissedOpportunities: s.enhancedStats.MissedOpportunities,
+	}
+}
+
+// recordMissedOpportunity counts an opportunity that reverted at its minimum
+// output when executed
+func (s *ArbitrageService) recordMissedOpportunity() {
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+
+	s.enhancedStats.MissedOpportunities++
+}
+
+func (s *ArbitrageService) suggestEnhancedOptimizations(isPeakHour bool) {
 	if !isPeakHour {
-		log.Println("💡 Not in peak hours - meme coins typically less volatile")
-		log.Println("   Peak hours: 13-16 UTC (Asia), 21-23 UTC (US)")
+		slog.Info("💡 Not in peak hours - meme coins typically less volatile",
+			"peak_hours_utc", config.FormatHourRanges(s.Config.PeakHours))
 	}
 
-	if enhancedStats.TotalTrades > 3 && enhancedStats.MemeTrades == 0 {
-		log.Println("💡 No meme trades yet - consider:")
-		log.Println("   • Checking if SHIB/DOGE are actively traded")
-		log.Println("   • Lowering meme coin threshold to 0.3%")
-		log.Println("   • Waiting for market volatility")
+	s.tradeMu.Lock()
+	noMemeTrades := s.enhancedStats.TotalTrades > 3 && s.enhancedStats.MemeTrades == 0
+	s.tradeMu.Unlock()
+
+	if noMemeTrades {
+		slog.Info("💡 No meme trades yet - consider checking if SHIB/DOGE are actively traded, " +
+			"lowering the meme coin threshold to 0.3%, or waiting for market volatility")
 	}
 }
@@ -0,0 +1,2165 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+	"arbitrage-bot/models"
+	"arbitrage-bot/utils"
+)
+
+func testPair() models.TokenPair {
+	return models.TokenPair{
+		Name: "WBNB-USDT-BUSD",
+		Tokens: map[string]string{
+			"WBNB": config.WBNB,
+			"USDT": config.USDT,
+			"BUSD": config.BUSD,
+		},
+		PancakeswapPair: map[string]string{},
+		BiswapPair:      map[string]string{},
+		TestAmounts:     []float64{0.5},
+	}
+}
+
+// mustRoute builds a route from exchange names or fails the test
+func mustRoute(t *testing.T, service *ArbitrageService, pair models.TokenPair, names ...string) Route {
+	t.Helper()
+
+	route, err := service.RouteFromNames(pair, "WBNB", names)
+	if err != nil {
+		t.Fatalf("RouteFromNames(%v) returned error: %v", names, err)
+	}
+	return route
+}
+
+// scanAndExecute runs a scan and executes its first opportunity the way the
+// bot loop does, returning the number of trades made
+func scanAndExecute(t *testing.T, service *ArbitrageService) int {
+	t.Helper()
+
+	opportunities, err := service.ScanEnhancedOpportunities(context.Background())
+	if err != nil {
+		t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+	}
+	if len(opportunities) == 0 {
+		return 0
+	}
+
+	execution, err := service.Execute(context.Background(), opportunities[0])
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if execution == nil {
+		return 0
+	}
+	return 1
+}
+
+func TestCheckTriangularArbitrageProfitPercent(t *testing.T) {
+	tests := []struct {
+		name          string
+		route         []string
+		wantProfit    *big.Int
+		wantPercent   float64
+		wantUser      float64
+		wantFee       *big.Int
+		wantNetProfit float64
+	}{
+		{
+			// 0.5 WBNB * 2 (Pancake) * 101/400 (BiSwap) * 2 (Pancake) = 0.505 WBNB
+			name:          "pancake first is profitable",
+			route:         []string{"PancakeSwap", "BiSwap", "PancakeSwap"},
+			wantProfit:    big.NewInt(5000000000000000),
+			wantPercent:   0.01 - 0.006, // 0.003 WBNB gas on 0.5 WBNB
+			wantUser:      0.009 - 0.006,
+			wantFee:       big.NewInt(500000000000000),
+			wantNetProfit: 0.0045 - 0.003,
+		},
+		{
+			// 0.5 WBNB * 101/400 (BiSwap) * 2 (Pancake) * 101/400 (BiSwap) = 0.06375625 WBNB
+			name:          "biswap first is a loss",
+			route:         []string{"BiSwap", "PancakeSwap", "BiSwap"},
+			wantProfit:    big.NewInt(-436243750000000000),
+			wantPercent:   -0.8724875 - 0.006,
+			wantUser:      -0.8724875 - 0.006,
+			wantFee:       big.NewInt(0),
+			wantNetProfit: -0.43624375 - 0.003,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(2, 1)
+			backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(101, 400)
+
+			service := newTestArbitrageService(t, backend)
+			route := mustRoute(t, service, testPair(), tt.route...)
+
+			result, err := service.CheckTriangularArbitrage(testPair(), 0.5, route)
+			if err != nil {
+				t.Fatalf("CheckTriangularArbitrage returned error: %v", err)
+			}
+
+			if result.Profit.Cmp(tt.wantProfit) != 0 {
+				t.Errorf("Profit = %s, want %s", result.Profit, tt.wantProfit)
+			}
+
+			if math.Abs(result.ProfitPercent-tt.wantPercent) > 1e-9 {
+				t.Errorf("ProfitPercent = %v, want %v", result.ProfitPercent, tt.wantPercent)
+			}
+
+			if got := service.UserProfitPercent(result); math.Abs(got-tt.wantUser) > 1e-9 {
+				t.Errorf("UserProfitPercent = %v, want %v", got, tt.wantUser)
+			}
+
+			if result.PlatformFee.Cmp(tt.wantFee) != 0 {
+				t.Errorf("PlatformFee = %s, want %s", result.PlatformFee, tt.wantFee)
+			}
+
+			if math.Abs(result.GasCostWBNB-0.003) > 1e-12 {
+				t.Errorf("GasCostWBNB = %v, want 0.003", result.GasCostWBNB)
+			}
+
+			if math.Abs(result.NetProfitWBNB-tt.wantNetProfit) > 1e-9 {
+				t.Errorf("NetProfitWBNB = %v, want %v", result.NetProfitWBNB, tt.wantNetProfit)
+			}
+
+			if got := strings.Join(result.Venues, ","); got != strings.Join(tt.route, ",") {
+				t.Errorf("Venues = %s, want %s", got, strings.Join(tt.route, ","))
+			}
+
+			checkCyclePath(t, result)
+		})
+	}
+}
+
+// checkCyclePath checks a result's path is the whole WBNB cycle with one
+// venue per hop
+func checkCyclePath(t *testing.T, result *models.ArbitrageResult) {
+	t.Helper()
+
+	wbnb := common.HexToAddress(config.WBNB).Hex()
+	if len(result.Path) != 4 || result.Path[0] != wbnb || result.Path[3] != wbnb {
+		t.Errorf("Path = %v, want a WBNB -> X -> Y -> WBNB cycle", result.Path)
+	}
+	if len(result.Venues) != len(result.Path)-1 {
+		t.Errorf("%d venues for %d tokens, want one per hop", len(result.Venues), len(result.Path))
+	}
+	for _, token := range result.Path {
+		if token != common.HexToAddress(token).Hex() {
+			t.Errorf("Path token %s is not checksummed", token)
+		}
+	}
+}
+
+// captureLogs routes the default logger into a buffer at the given level for
+// the rest of the test
+func captureLogs(t *testing.T, level slog.Level) *bytes.Buffer {
+	t.Helper()
+
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level})))
+	return &buf
+}
+
+func TestCheckTriangularArbitrageOnOtherBaseToken(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(2, 1)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(101, 400)
+
+	service := newTestArbitrageService(t, backend)
+	dexes, err := service.findDEXes([]string{"PancakeSwap", "BiSwap", "PancakeSwap"})
+	if err != nil {
+		t.Fatalf("findDEXes returned error: %v", err)
+	}
+	route, err := service.BuildRoute(testPair(), "BUSD", dexes)
+	if err != nil {
+		t.Fatalf("BuildRoute returned error: %v", err)
+	}
+
+	// 0.5 WBNB is priced at 1 BUSD on PancakeSwap; the cycle turns that into
+	// 1 * 2 * 101/400 * 2 = 1.01 BUSD, a 1% profit worth 0.005 WBNB
+	result, err := service.CheckTriangularArbitrage(testPair(), 0.5, route)
+	if err != nil {
+		t.Fatalf("CheckTriangularArbitrage returned error: %v", err)
+	}
+
+	if want := wbnbAmount(1); result.TargetAmount.Cmp(want) != 0 {
+		t.Errorf("TargetAmount = %s BUSD wei, want %s", result.TargetAmount, want)
+	}
+	if want := big.NewInt(10000000000000000); result.Profit.Cmp(want) != 0 {
+		t.Errorf("Profit = %s BUSD wei, want %s", result.Profit, want)
+	}
+	if result.AmountWBNB != 0.5 {
+		t.Errorf("AmountWBNB = %v, want 0.5", result.AmountWBNB)
+	}
+
+	// Gas and net profit stay in WBNB: 0.003 WBNB gas on 0.5 WBNB
+	if math.Abs(result.ProfitPercent-(0.01-0.006)) > 1e-9 {
+		t.Errorf("ProfitPercent = %v, want %v", result.ProfitPercent, 0.01-0.006)
+	}
+	if math.Abs(result.NetProfitWBNB-(0.0045-0.003)) > 1e-9 {
+		t.Errorf("NetProfitWBNB = %v, want %v", result.NetProfitWBNB, 0.0045-0.003)
+	}
+	if got := result.Path[0]; got != common.HexToAddress(config.BUSD).Hex() {
+		t.Errorf("path starts at %s, want BUSD", got)
+	}
+}
+
+func TestCheckTriangularArbitrageStepLogsOnlyAtDebug(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(2, 1)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(101, 400)
+
+	service := newTestArbitrageService(t, backend)
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+	logs := captureLogs(t, slog.LevelInfo)
+	if _, err := service.CheckTriangularArbitrage(testPair(), 0.5, route); err != nil {
+		t.Fatalf("CheckTriangularArbitrage returned error: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected no logs at info level, got:\n%s", logs)
+	}
+
+	logs = captureLogs(t, slog.LevelDebug)
+	if _, err := service.CheckTriangularArbitrage(testPair(), 0.5, route); err != nil {
+		t.Fatalf("CheckTriangularArbitrage returned error: %v", err)
+	}
+	if got := strings.Count(logs.String(), "Quoted step"); got != 3 {
+		t.Errorf("logged %d steps at debug level, want 3:\n%s", got, logs)
+	}
+}
+
+func TestCheckTriangularArbitrageSkipsUnlistedPool(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(2, 1)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(101, 400)
+
+	service := newTestArbitrageService(t, backend)
+	pancakeFirst := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+	biswapFirst := mustRoute(t, service, testPair(), "BiSwap", "PancakeSwap", "BiSwap")
+
+	// BiSwap lists WBNB-BUSD but not BUSD-USDT; record it in the opposite
+	// token order to the hop to check the lookup is order-independent
+	middle := pancakeFirst.Hops[1]
+	service.setPoolStatus(middle.DEX, middle.TokenOut, middle.TokenIn, false)
+	first := biswapFirst.Hops[0]
+	service.setPoolStatus(first.DEX, first.TokenIn, first.TokenOut, true)
+
+	_, err := service.CheckTriangularArbitrage(testPair(), 0.5, pancakeFirst)
+	if !errors.Is(err, ErrNoOpportunity) {
+		t.Fatalf("expected ErrNoOpportunity for a route through an unlisted pool, got %v", err)
+	}
+	if backend.calls != 0 {
+		t.Errorf("made %d contract calls for a route that should be skipped", backend.calls)
+	}
+
+	// Listed and unchecked pools are still quoted
+	if _, err := service.CheckTriangularArbitrage(testPair(), 0.5, biswapFirst); err != nil {
+		t.Errorf("CheckTriangularArbitrage on listed pools returned error: %v", err)
+	}
+}
+
+func TestVerifyAndUpdatePairsSkipsUnlistedPool(t *testing.T) {
+	const (
+		pancakeWBNBBUSD = "0x00000000000000000000000000000000000000a1"
+		pancakeBUSDUSDT = "0x00000000000000000000000000000000000000a2"
+		pancakeUSDTWBNB = "0x00000000000000000000000000000000000000a3"
+		biswapWBNBBUSD  = "0x00000000000000000000000000000000000000b1"
+		biswapUSDTWBNB  = "0x00000000000000000000000000000000000000b3"
+	)
+
+	reserve := new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(1, 1)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(1, 1)
+	backend.listPool(config.PancakeswapFactory, pancakeWBNBBUSD, config.WBNB, config.BUSD, reserve)
+	backend.listPool(config.PancakeswapFactory, pancakeBUSDUSDT, config.BUSD, config.USDT, reserve)
+	backend.listPool(config.PancakeswapFactory, pancakeUSDTWBNB, config.USDT, config.WBNB, reserve)
+	backend.listPool(config.BiswapFactory, biswapWBNBBUSD, config.WBNB, config.BUSD, reserve)
+	backend.listPool(config.BiswapFactory, biswapUSDTWBNB, config.USDT, config.WBNB, reserve)
+
+	// Hardcoded entries: reversed keys and a BiSwap pool that isn't listed
+	pair := testPair()
+	pair.PancakeswapPair["BUSD-WBNB"] = "0x00000000000000000000000000000000000000d1"
+	pair.BiswapPair["BUSD-WBNB"] = "0x00000000000000000000000000000000000000d2"
+	pair.BiswapPair["BUSD-USDT"] = "0x00000000000000000000000000000000000000d3"
+
+	service := newTestArbitrageService(t, backend)
+	service.Config.MinReserveWBNB = 1
+	service.TokenPairs = []models.TokenPair{pair}
+
+	if err := service.VerifyAndUpdatePairs(); err != nil {
+		t.Fatalf("VerifyAndUpdatePairs returned error: %v", err)
+	}
+
+	checksum := func(address string) string { return common.HexToAddress(address).Hex() }
+	tests := []struct {
+		name string
+		got  map[string]string
+		want map[string]string
+	}{
+		{"PancakeSwap", service.TokenPairs[0].PancakeswapPair, map[string]string{
+			"WBNB-BUSD": checksum(pancakeWBNBBUSD),
+			"BUSD-USDT": checksum(pancakeBUSDUSDT),
+			"USDT-WBNB": checksum(pancakeUSDTWBNB),
+		}},
+		{"BiSwap", service.TokenPairs[0].BiswapPair, map[string]string{
+			"WBNB-BUSD": checksum(biswapWBNBBUSD),
+			"USDT-WBNB": checksum(biswapUSDTWBNB),
+		}},
+	}
+	for _, tt := range tests {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("%s pools = %v, want %v", tt.name, tt.got, tt.want)
+			continue
+		}
+		for key, address := range tt.want {
+			if tt.got[key] != address {
+				t.Errorf("%s pools = %v, want %v", tt.name, tt.got, tt.want)
+				break
+			}
+		}
+	}
+
+	// The liquidity check and the routes through BiSwap's listed pools still run
+	opportunities, err := service.ScanEnhancedOpportunities(context.Background())
+	if err != nil || len(opportunities) != 0 {
+		t.Fatalf("ScanEnhancedOpportunities = %v, %v; want none, nil", opportunities, err)
+	}
+	if backend.quoted[common.HexToAddress(config.BiswapRouter)] == 0 {
+		t.Error("no BiSwap quotes: routes through its listed pools were not scanned")
+	}
+}
+
+func TestVerifyAndUpdatePairsReportsMovedPool(t *testing.T) {
+	const (
+		oldPool = "0x00000000000000000000000000000000000000a1"
+		newPool = "0x00000000000000000000000000000000000000a9"
+	)
+
+	backend := newMockBackend()
+	for i, tokens := range [][2]string{{config.WBNB, config.BUSD}, {config.BUSD, config.USDT}, {config.USDT, config.WBNB}} {
+		address := fmt.Sprintf("0x00000000000000000000000000000000000000a%d", i+1)
+		backend.listPool(config.PancakeswapFactory, address, tokens[0], tokens[1], wbnbAmount(1000))
+	}
+
+	service := newTestArbitrageService(t, backend)
+	service.DEXes = service.DEXes[:1] // PancakeSwap
+	service.TokenPairs = []models.TokenPair{testPair()}
+
+	logs := captureLogs(t, slog.LevelInfo)
+	if err := service.VerifyAndUpdatePairs(); err != nil {
+		t.Fatalf("VerifyAndUpdatePairs returned error: %v", err)
+	}
+	if !strings.Contains(logs.String(), "changed=3") {
+		t.Errorf("first verification should add all three pools:\n%s", logs)
+	}
+
+	// Liquidity migrates to a new WBNB-BUSD pool; the others are unchanged
+	pool := backend.pools[common.HexToAddress(oldPool)]
+	delete(backend.pools, common.HexToAddress(oldPool))
+	backend.pools[common.HexToAddress(newPool)] = pool
+
+	logs.Reset()
+	if err := service.VerifyAndUpdatePairs(); err != nil {
+		t.Fatalf("VerifyAndUpdatePairs returned error: %v", err)
+	}
+	if got := service.TokenPairs[0].PancakeswapPair["WBNB-BUSD"]; got != common.HexToAddress(newPool).Hex() {
+		t.Errorf("WBNB-BUSD = %s, want the new pool %s", got, newPool)
+	}
+	if !strings.Contains(logs.String(), "changed=1") || !strings.Contains(logs.String(), common.HexToAddress(oldPool).Hex()) {
+		t.Errorf("re-verification should report the one moved pool and its old address:\n%s", logs)
+	}
+}
+
+func TestVerifyPairTokensRequiresWBNBAndThreeTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  map[string]string
+		wantErr string
+	}{
+		{
+			name:   "valid pair",
+			tokens: map[string]string{"WBNB": config.WBNB, "USDT": config.USDT, "BUSD": config.BUSD},
+		},
+		{
+			name:    "no WBNB",
+			tokens:  map[string]string{"CAKE": config.CAKE, "USDT": config.USDT, "BUSD": config.BUSD},
+			wantErr: "pair TEST has no WBNB token",
+		},
+		{
+			name:    "WBNB under the wrong key",
+			tokens:  map[string]string{"wbnb": config.WBNB, "USDT": config.USDT, "BUSD": config.BUSD},
+			wantErr: `lists wbnb (` + config.WBNB + `): token keys must be exactly "WBNB"`,
+		},
+		{
+			name:    "too few tokens",
+			tokens:  map[string]string{"WBNB": config.WBNB, "USDT": config.USDT},
+			wantErr: "pair TEST has 2 tokens, triangular arbitrage needs at least 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestArbitrageService(t, newMockBackend())
+			pair := models.TokenPair{Name: "TEST", Tokens: tt.tokens}
+
+			err := service.VerifyPairTokens(pair)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("VerifyPairTokens returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("VerifyPairTokens error = %v, want %q", err, tt.wantErr)
+			}
+
+			// Pair verification skips it rather than querying the zero address
+			service.TokenPairs = []models.TokenPair{pair}
+			logs := captureLogs(t, slog.LevelInfo)
+			if err := service.VerifyAndUpdatePairs(); err != nil {
+				t.Fatalf("VerifyAndUpdatePairs returned error: %v", err)
+			}
+			if !strings.Contains(logs.String(), "Skipping misconfigured pair") {
+				t.Errorf("VerifyAndUpdatePairs didn't report the pair:\n%s", logs)
+			}
+		})
+	}
+}
+
+func TestGetRoutePriceImpactFromCachedReserves(t *testing.T) {
+	backend := newMockBackend()
+	pair := testPair()
+	for i, key := range []string{"WBNB-BUSD", "BUSD-USDT", "USDT-WBNB"} {
+		symbols := strings.Split(key, "-")
+		address := fmt.Sprintf("0x00000000000000000000000000000000000000a%d", i+1)
+		backend.listPool(config.PancakeswapFactory, address, pair.Tokens[symbols[0]], pair.Tokens[symbols[1]], wbnbAmount(100))
+		pair.PancakeswapPair[key] = address
+	}
+
+	service := newTestArbitrageService(t, backend)
+	route := mustRoute(t, service, pair, "PancakeSwap", "PancakeSwap", "PancakeSwap")
+
+	// 1 WBNB moves the first 100/100 pool by 1/101, and each later leg by its
+	// input after the 0.25% fee
+	impact, err := service.GetRoutePriceImpact(pair, route, wbnbAmount(1))
+	if err != nil {
+		t.Fatalf("GetRoutePriceImpact returned error: %v", err)
+	}
+	if math.Abs(impact-2.905626698963537) > 1e-9 {
+		t.Errorf("impact = %v%%, want 2.905627%%", impact)
+	}
+
+	// Reserves are read once per quote block and the router is never asked
+	calls := backend.calls
+	if _, err := service.GetRoutePriceImpact(pair, route, wbnbAmount(2)); err != nil {
+		t.Fatalf("GetRoutePriceImpact returned error: %v", err)
+	}
+	if backend.calls != calls {
+		t.Errorf("second impact check made %d calls, want cached reserves", backend.calls-calls)
+	}
+	if n := backend.quoted[common.HexToAddress(config.PancakeswapRouter)]; n != 0 {
+		t.Errorf("router quoted %d times, want 0", n)
+	}
+}
+
+func TestEvaluateRoutesCapsTradeToPoolFraction(t *testing.T) {
+	pools := map[string][2]string{
+		"WBNB-BUSD": {config.WBNB, config.BUSD},
+		"BUSD-USDT": {config.BUSD, config.USDT},
+		"USDT-WBNB": {config.USDT, config.WBNB},
+	}
+
+	tests := []struct {
+		name         string
+		minNetProfit float64
+		wantAmount   float64 // 0 for no opportunity
+	}{
+		// 5 WBNB is 5% of the 100 WBNB first pool, so it's cut to the 1% cap
+		{"capped and still profitable", 0, 1},
+		// 5 WBNB would net 0.042 WBNB, but 1 WBNB only nets 0.006
+		{"capped below the net profit floor", 0.02, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(2, 1)
+			backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(101, 400)
+
+			pair := testPair()
+			pair.TestAmounts = []float64{5}
+			i := 0
+			for key, tokens := range pools {
+				i++
+				pancake := fmt.Sprintf("0x00000000000000000000000000000000000000a%d", i)
+				biswap := fmt.Sprintf("0x00000000000000000000000000000000000000b%d", i)
+				backend.listPool(config.PancakeswapFactory, pancake, tokens[0], tokens[1], wbnbAmount(100))
+				backend.listPool(config.BiswapFactory, biswap, tokens[0], tokens[1], wbnbAmount(100))
+				pair.PancakeswapPair[key] = pancake
+				pair.BiswapPair[key] = biswap
+			}
+
+			service := newTestArbitrageService(t, backend)
+			service.Config.MaxPoolFractionBps = 100
+			service.Config.MaxPriceImpact = 0.05 // a 1 WBNB trade moves the 100 WBNB pools about 3%
+			service.Config.MinNetProfitWBNB = tt.minNetProfit
+			route := mustRoute(t, service, pair, "PancakeSwap", "BiSwap", "PancakeSwap")
+
+			logs := captureLogs(t, slog.LevelInfo)
+			opportunity, err := service.evaluateRoutes(pair, []Route{route})
+			if err != nil {
+				t.Fatalf("evaluateRoutes returned error: %v", err)
+			}
+			if !strings.Contains(logs.String(), "Capping trade size") {
+				t.Errorf("capping wasn't logged:\n%s", logs)
+			}
+
+			if tt.wantAmount == 0 {
+				if opportunity != nil {
+					t.Errorf("got opportunity at %v WBNB, want none", opportunity.AmountWBNB)
+				}
+				return
+			}
+			if opportunity == nil {
+				t.Fatal("no opportunity found")
+			}
+			if math.Abs(opportunity.AmountWBNB-tt.wantAmount) > 1e-9 {
+				t.Errorf("AmountWBNB = %v, want %v", opportunity.AmountWBNB, tt.wantAmount)
+			}
+			if want := wbnbAmount(1); opportunity.Result.TargetAmount.Cmp(want) != 0 {
+				t.Errorf("TargetAmount = %s, want the re-quoted %s", opportunity.Result.TargetAmount, want)
+			}
+		})
+	}
+}
+
+func TestCheckTriangularArbitrageQuoteFailure(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(2, 1)
+
+	service := newTestArbitrageService(t, backend)
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+	if _, err := service.CheckTriangularArbitrage(testPair(), 0.5, route); err == nil {
+		t.Fatal("expected an error when the BiSwap leg cannot be quoted")
+	}
+}
+
+func TestScanEnhancedOpportunitiesReturnsPairErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(backend *mockBackend)
+		wantErr bool
+		want    ErrorCategory
+	}{
+		{
+			name: "no profitable route",
+			setup: func(backend *mockBackend) {
+				backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(1, 1)
+				backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(1, 1)
+			},
+		},
+		{
+			name:    "every quote reverts",
+			setup:   func(backend *mockBackend) {},
+			wantErr: true,
+			want:    ErrorRevert,
+		},
+		{
+			name: "node unreachable",
+			setup: func(backend *mockBackend) {
+				backend.callErr = errors.New("dial tcp 1.2.3.4:443: connection refused")
+			},
+			wantErr: true,
+			want:    ErrorConnection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			tt.setup(backend)
+
+			service := newTestArbitrageService(t, backend)
+			service.TokenPairs = []models.TokenPair{testPair()}
+
+			opportunities, err := service.ScanEnhancedOpportunities(context.Background())
+			if len(opportunities) != 0 {
+				t.Errorf("opportunities = %v, want none", opportunities)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScanEnhancedOpportunities error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && ClassifyError(err) != tt.want {
+				t.Errorf("error %v classified as %s, want %s", err, ClassifyError(err), tt.want)
+			}
+		})
+	}
+}
+
+func TestReservePrefilterSkipsUnprofitableRoutes(t *testing.T) {
+	pancake := common.HexToAddress(config.PancakeswapRouter)
+	biswap := common.HexToAddress(config.BiswapRouter)
+
+	reserve := new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	backend := newMockBackend()
+	backend.quotes[pancake] = rateQuote(1, 1)
+	backend.quotes[biswap] = rateQuote(1, 1)
+
+	pair := testPair()
+	pools := map[string][2]string{
+		"WBNB-BUSD": {"0x00000000000000000000000000000000000000a1", "0x00000000000000000000000000000000000000b1"},
+		"BUSD-USDT": {"0x00000000000000000000000000000000000000a2", "0x00000000000000000000000000000000000000b2"},
+		"USDT-WBNB": {"0x00000000000000000000000000000000000000a3", "0x00000000000000000000000000000000000000b3"},
+	}
+	for key, addresses := range pools {
+		symbols := strings.Split(key, "-")
+		tokenA, tokenB := pair.Tokens[symbols[0]], pair.Tokens[symbols[1]]
+		backend.listPool(config.PancakeswapFactory, addresses[0], tokenA, tokenB, reserve)
+		backend.listPool(config.BiswapFactory, addresses[1], tokenA, tokenB, reserve)
+		pair.PancakeswapPair[key] = addresses[0]
+		pair.BiswapPair[key] = addresses[1]
+	}
+
+	service := newTestArbitrageService(t, backend)
+	service.Config.MinReserveWBNB = 1
+	service.Config.ReservePrefilter = true
+	service.TokenPairs = []models.TokenPair{pair}
+
+	// Balanced pools lose the fee on every hop, so no route is worth quoting.
+	// The only router calls left value the BUSD-USDT pools' liquidity.
+	if _, err := service.ScanEnhancedOpportunities(context.Background()); err != nil {
+		t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+	}
+	if backend.quoted[pancake] != 1 || backend.quoted[biswap] != 1 {
+		t.Errorf("quoted PancakeSwap %d and BiSwap %d times, want once each for liquidity only",
+			backend.quoted[pancake], backend.quoted[biswap])
+	}
+
+	// USDT twice as cheap on BiSwap lets PancakeSwap→BiSwap→PancakeSwap through
+	backend.pools[common.HexToAddress(pools["BUSD-USDT"][1])].reserve1 = new(big.Int).Mul(reserve, big.NewInt(2))
+	if _, err := service.ScanEnhancedOpportunities(context.Background()); err != nil {
+		t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+	}
+	if backend.quoted[biswap] <= 2 {
+		t.Error("the route through the skewed pool was not quoted on-chain")
+	}
+}
+
+func TestScanPeriodFollowsClock(t *testing.T) {
+	clock := &utils.FixedClock{}
+	service := newTestArbitrageService(t, newMockBackend())
+	service.Config.PeakHours = []config.HourRange{{Start: 13, End: 16}}
+	service.Config.LowHours = []config.HourRange{{Start: 2, End: 6}}
+	service.Clock = clock
+
+	tests := []struct {
+		hour    int
+		want    string
+		wantLog string
+	}{
+		{14, config.ScanPeriodPeak, "PEAK HOURS"},
+		{3, config.ScanPeriodLow, "Low activity hours"},
+		{9, config.ScanPeriodStandard, "Not in peak hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			clock.Time = time.Date(2024, 1, 1, tt.hour, 0, 0, 0, time.UTC)
+
+			if got := service.ScanPeriod(); got != tt.want {
+				t.Errorf("ScanPeriod at %02d:00 UTC = %s, want %s", tt.hour, got, tt.want)
+			}
+
+			logs := captureLogs(t, slog.LevelInfo)
+			if _, err := service.ScanEnhancedOpportunities(context.Background()); err != nil {
+				t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+			}
+			if !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("scan at %02d:00 UTC did not log %q:\n%s", tt.hour, tt.wantLog, logs)
+			}
+		})
+	}
+}
+
+func TestScanStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	backend := newMockBackend()
+	quote := rateQuote(1, 1)
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = func(amountIn *big.Int, path []common.Address) []*big.Int {
+		cancel() // the scan is cancelled while its first quote is in flight
+		return quote(amountIn, path)
+	}
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(1, 1)
+
+	service := newTestArbitrageService(t, backend)
+	pair := testPair()
+	pair.TestAmounts = []float64{0.5, 1, 2}
+	service.TokenPairs = []models.TokenPair{pair, pair}
+
+	opportunities, err := service.ScanEnhancedOpportunities(ctx)
+	if len(opportunities) != 0 || !errors.Is(err, ErrScanCancelled) {
+		t.Fatalf("ScanEnhancedOpportunities = %v, %v; want none, ErrScanCancelled", opportunities, err)
+	}
+	if ClassifyError(err) == ErrorConnection {
+		t.Errorf("cancelled scan classified as a connection error: %v", err)
+	}
+
+	quoted := backend.quoted[common.HexToAddress(config.PancakeswapRouter)] + backend.quoted[common.HexToAddress(config.BiswapRouter)]
+	if quoted != 1 {
+		t.Errorf("%d quotes reached the node, want only the one in flight when cancelled", quoted)
+	}
+	if service.RouterService.QuoteContext() != context.Background() {
+		t.Error("quote context left bound to the cancelled scan")
+	}
+	if len(backend.sent) != 0 {
+		t.Errorf("sent %d transactions from a cancelled scan", len(backend.sent))
+	}
+}
+
+func TestScanQuotesPairAtOneBlock(t *testing.T) {
+	backend := newMockBackend()
+	backend.head = 200
+	// Only block 200 has quotes, so an unpinned call would revert
+	backend.atBlock = map[uint64]map[common.Address]quoteFunc{200: {
+		common.HexToAddress(config.PancakeswapRouter): rateQuote(1, 1),
+		common.HexToAddress(config.BiswapRouter):      rateQuote(1, 1),
+	}}
+
+	service := newTestArbitrageService(t, backend)
+	service.TokenPairs = []models.TokenPair{testPair()}
+
+	if _, err := service.ScanEnhancedOpportunities(context.Background()); err != nil {
+		t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+	}
+	if backend.quoted[common.HexToAddress(config.BiswapRouter)] == 0 {
+		t.Fatal("no routes were quoted")
+	}
+	if block := service.RouterService.QuoteBlock(); block != nil {
+		t.Errorf("quote block left pinned at %s after the scan", block)
+	}
+}
+
+func TestScanFetchesGasPriceOnce(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(1, 1)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(1, 1)
+
+	service := newTestArbitrageService(t, backend)
+	pair := testPair()
+	pair.TestAmounts = []float64{0.5, 1, 2}
+	service.TokenPairs = []models.TokenPair{pair}
+
+	if _, err := service.ScanEnhancedOpportunities(context.Background()); err != nil {
+		t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+	}
+	if backend.quoted[common.HexToAddress(config.PancakeswapRouter)] < 2 {
+		t.Fatal("expected several routes to be quoted")
+	}
+	if backend.gasCalls != 1 {
+		t.Errorf("SuggestGasPrice called %d times in one scan, want 1", backend.gasCalls)
+	}
+
+	// The next scan picks up a new gas price
+	backend.gasPrice = big.NewInt(10000000000)
+	if _, err := service.ScanEnhancedOpportunities(context.Background()); err != nil {
+		t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+	}
+	if backend.gasCalls != 2 {
+		t.Errorf("SuggestGasPrice called %d times after two scans, want 2", backend.gasCalls)
+	}
+
+	// 10 Gwei * 600000 gas
+	if got := service.EstimateGasCostWBNB(); got < 0.00599 || got > 0.00601 {
+		t.Errorf("EstimateGasCostWBNB = %v, want 0.006", got)
+	}
+
+	// Trades are sent with the buffer, so the estimate includes it
+	service.Config.GasPriceBufferPercent = 20
+	if got := service.EstimateGasCostWBNB(); got < 0.00719 || got > 0.00721 {
+		t.Errorf("EstimateGasCostWBNB with a 20%% buffer = %v, want 0.0072", got)
+	}
+}
+
+func TestApplyPairOverrides(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+	service.Config.CategoryMinProfit = map[string]float64{"stable": 0.001, "unknown": 0.002}
+	service.Config.CategoryGasAdjustment = map[string]float64{"stable": 0.0008, "unknown": 0.001}
+
+	pairs := applyPairOverrides([]models.TokenPair{testPair(), {Name: "WBNB-CAKE-USDT"}},
+		map[string]config.PairOverride{
+			"WBNB-USDT-BUSD": {MinProfit: 0.004},
+			"WBNB-XYZ-USDT":  {MinProfit: 0.01, GasAdjustment: 0.01},
+		})
+
+	tests := []struct {
+		pair          models.TokenPair
+		wantMinProfit float64
+		wantGasAdj    float64
+	}{
+		{pairs[0], 0.004, 0.0008}, // min profit overridden, gas from the category
+		{pairs[1], 0.002, 0.001},  // no override
+	}
+
+	for _, tt := range tests {
+		category := service.getMemeCategory(tt.pair.Name)
+		if got := service.getMinProfitForCategory(tt.pair, category); got != tt.wantMinProfit {
+			t.Errorf("%s min profit = %v, want %v", tt.pair.Name, got, tt.wantMinProfit)
+		}
+		if got := service.getGasAdjustmentForCategory(tt.pair, category); got != tt.wantGasAdj {
+			t.Errorf("%s gas adjustment = %v, want %v", tt.pair.Name, got, tt.wantGasAdj)
+		}
+	}
+}
+
+// revertDataError is an RPC error carrying an Error(string) revert payload,
+// as returned by a node whose message omits the reason
+type revertDataError struct {
+	data string
+}
+
+func (e revertDataError) Error() string          { return "execution reverted" }
+func (e revertDataError) ErrorData() interface{} { return e.data }
+
+func newRevertDataError(t *testing.T, reason string) revertDataError {
+	t.Helper()
+
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatalf("failed to create string type: %v", err)
+	}
+	packed, err := abi.Arguments{{Type: stringType}}.Pack(reason)
+	if err != nil {
+		t.Fatalf("failed to pack revert reason: %v", err)
+	}
+	selector := crypto.Keccak256([]byte("Error(string)"))[:4]
+	return revertDataError{data: hexutil.Encode(append(selector, packed...))}
+}
+
+func TestPreflightArbitrage(t *testing.T) {
+	flashContract := common.HexToAddress("0x00000000000000000000000000000000000F1a54")
+
+	tests := []struct {
+		name         string
+		flash        bool
+		estimateErr  error
+		wantTo       common.Address
+		wantReverted bool
+		wantReason   string
+		wantErr      bool
+	}{
+		{
+			name:   "manual route estimates first leg",
+			wantTo: common.HexToAddress(config.PancakeswapRouter),
+		},
+		{
+			name:   "flash route estimates flash call",
+			flash:  true,
+			wantTo: flashContract,
+		},
+		{
+			name:         "revert reason decoded from error data",
+			estimateErr:  newRevertDataError(t, "Pancake: TRANSFER_FAILED"),
+			wantTo:       common.HexToAddress(config.PancakeswapRouter),
+			wantReverted: true,
+			wantReason:   "Pancake: TRANSFER_FAILED",
+			wantErr:      true,
+		},
+		{
+			name:         "revert reason in message",
+			flash:        true,
+			estimateErr:  errors.New("execution reverted: Arbitrage not profitable"),
+			wantTo:       flashContract,
+			wantReverted: true,
+			wantReason:   "Arbitrage not profitable",
+			wantErr:      true,
+		},
+		{
+			name:        "connection error is not a revert",
+			estimateErr: errors.New("dial tcp 1.2.3.4:443: connection refused"),
+			wantTo:      common.HexToAddress(config.PancakeswapRouter),
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(1, 1)
+			backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(1, 1)
+			backend.estimateErr = tt.estimateErr
+
+			service := newTestArbitrageService(t, backend)
+			service.Config.AllowManualArbitrage = true
+			service.Client = &EthClient{Address: common.HexToAddress("0x1")}
+			service.RouterService.Client = service.Client
+
+			pair := testPair()
+			pair.PancakeswapPair["WBNB-USDT"] = "0x16b9a82891338f9bA80E2D6970FddA79D1eb0daE"
+			pair.PancakeswapPair["WBNB-BUSD"] = "0x58F876857a02D6762E0101bb5C46A8c1ED44Dc16"
+			if tt.flash {
+				service.FlashContract = flashContract
+			}
+			route := mustRoute(t, service, pair, "PancakeSwap", "BiSwap", "PancakeSwap")
+
+			gas, err := service.PreflightArbitrage(pair, wbnbAmount(1), route)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PreflightArbitrage error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && gas < 200000 {
+				t.Errorf("gas = %d, want at least the 200000 estimate", gas)
+			}
+			if errors.Is(err, ErrPreflightReverted) != tt.wantReverted {
+				t.Errorf("error %v: reverted = %v, want %v", err, !tt.wantReverted, tt.wantReverted)
+			}
+			if tt.wantReason != "" && !strings.Contains(err.Error(), tt.wantReason) {
+				t.Errorf("error %q missing reason %q", err, tt.wantReason)
+			}
+
+			if len(backend.estimated) != 1 {
+				t.Fatalf("EstimateGas called %d times, want 1", len(backend.estimated))
+			}
+			if to := backend.estimated[0].To; to == nil || *to != tt.wantTo {
+				t.Errorf("estimated call to %v, want %s", to, tt.wantTo.Hex())
+			}
+			if len(backend.sent) != 0 {
+				t.Errorf("preflight sent %d transactions", len(backend.sent))
+			}
+		})
+	}
+}
+
+func TestExecuteArbitrageRespectsGasCeiling(t *testing.T) {
+	backend := newMockBackend()
+	backend.gasPrice = big.NewInt(30000000000) // 30 Gwei
+
+	service := newTestArbitrageService(t, backend)
+	service.Config.MaxGasPriceGwei = 20
+	service.Config.AllowManualArbitrage = true
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+	_, err := service.ExecuteArbitrage(testPair(), wbnbAmount(1), route)
+	if !errors.Is(err, ErrGasPriceTooHigh) {
+		t.Fatalf("ExecuteArbitrage error = %v, want ErrGasPriceTooHigh", err)
+	}
+	if len(backend.sent) != 0 || backend.calls != 0 {
+		t.Errorf("execution went ahead above the ceiling: %d sent, %d calls", len(backend.sent), backend.calls)
+	}
+
+	// Below the ceiling the check passes
+	backend.gasPrice = big.NewInt(10000000000)
+	if err := service.checkGasCeiling(); err != nil {
+		t.Errorf("checkGasCeiling at 10 Gwei returned error: %v", err)
+	}
+
+	// 0 disables the ceiling without fetching the gas price
+	service.Config.MaxGasPriceGwei = 0
+	backend.gasPrice = big.NewInt(1000000000000)
+	gasCalls := backend.gasCalls
+	if err := service.checkGasCeiling(); err != nil || backend.gasCalls != gasCalls {
+		t.Errorf("disabled ceiling returned %v after %d gas price calls", err, backend.gasCalls-gasCalls)
+	}
+}
+
+func TestExecuteArbitrageRespectsPendingLimit(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestArbitrageService(t, backend)
+	service.Config.MaxPendingTx = 1
+	service.Config.AllowManualArbitrage = true
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+	// A swap that outlived its receipt timeout is still pending
+	stuck := common.HexToHash("0x01")
+	service.trackPending(stuck)
+
+	_, err := service.ExecuteArbitrage(testPair(), wbnbAmount(1), route)
+	if !errors.Is(err, ErrTooManyPending) {
+		t.Fatalf("ExecuteArbitrage error = %v, want ErrTooManyPending", err)
+	}
+	if len(backend.sent) != 0 {
+		t.Errorf("sent %d transactions past the pending limit", len(backend.sent))
+	}
+
+	// Once its receipt shows up the slot is free again
+	backend.receipts = map[common.Hash]*types.Receipt{stuck: {Status: 1}}
+	if pending := service.PendingTransactions(); pending != 0 {
+		t.Errorf("PendingTransactions = %d after the receipt appeared, want 0", pending)
+	}
+	if err := service.checkPendingLimit(); err != nil {
+		t.Errorf("checkPendingLimit returned %v with nothing pending", err)
+	}
+
+	// 0 disables the limit
+	service.trackPending(stuck)
+	service.Config.MaxPendingTx = 0
+	if err := service.checkPendingLimit(); err != nil {
+		t.Errorf("disabled limit returned %v", err)
+	}
+}
+
+func TestExecuteArbitrageRespectsNativeReserve(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestArbitrageService(t, backend)
+	service.Config.MinNativeReserveBNB = 0.01
+	service.Config.AllowManualArbitrage = true
+	service.Client = &EthClient{Address: common.HexToAddress("0x1")}
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+	// 600,000 gas at 5 Gwei is 0.003 BNB per transaction
+	tests := []struct {
+		name    string
+		native  *big.Int
+		flash   bool
+		wantErr bool
+	}{
+		{"manual route keeps the reserve", big.NewInt(2e16), false, false},
+		{"three manual legs dip into the reserve", big.NewInt(15e15), false, true},
+		{"one flash transaction keeps the reserve", big.NewInt(15e15), true, false},
+		{"reserve already spent", big.NewInt(5e15), true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend.native = tt.native
+			err := service.checkNativeReserve(tt.flash, route)
+			if tt.wantErr != errors.Is(err, ErrNativeReserve) || (!tt.wantErr && err != nil) {
+				t.Errorf("checkNativeReserve error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	backend.native = big.NewInt(5e15)
+	if _, err := service.ExecuteArbitrage(testPair(), wbnbAmount(1), route); !errors.Is(err, ErrNativeReserve) {
+		t.Fatalf("ExecuteArbitrage error = %v, want ErrNativeReserve", err)
+	}
+	if len(backend.sent) != 0 {
+		t.Errorf("sent %d transactions below the native reserve", len(backend.sent))
+	}
+
+	// 0 disables the check
+	service.Config.MinNativeReserveBNB = 0
+	if err := service.checkNativeReserve(false, route); err != nil {
+		t.Errorf("disabled reserve returned %v", err)
+	}
+}
+
+func TestExecuteArbitrageRequiresManualOptIn(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestArbitrageService(t, backend)
+	pair := testPair()
+
+	flashable := mustRoute(t, service, pair, "PancakeSwap", "BiSwap", "PancakeSwap")
+	manualOnly := mustRoute(t, service, pair, "PancakeSwap", "PancakeSwap", "BiSwap")
+
+	// No flash contract: nothing executes without the opt-in
+	if _, err := service.ExecuteArbitrage(pair, wbnbAmount(1), flashable); !errors.Is(err, ErrManualArbitrageDisabled) {
+		t.Fatalf("ExecuteArbitrage error = %v, want ErrManualArbitrageDisabled", err)
+	}
+	if _, err := service.PreflightArbitrage(pair, wbnbAmount(1), flashable); !errors.Is(err, ErrManualArbitrageDisabled) {
+		t.Errorf("PreflightArbitrage error = %v, want ErrManualArbitrageDisabled", err)
+	}
+	if len(backend.sent) != 0 || len(backend.estimated) != 0 || backend.gasCalls != 0 {
+		t.Errorf("disabled manual route reached the node: %d sent, %d estimates, %d gas price calls",
+			len(backend.sent), len(backend.estimated), backend.gasCalls)
+	}
+
+	// A flash contract covers the routes it supports, but not the others
+	service.FlashContract = common.HexToAddress("0x00000000000000000000000000000000000F1a54")
+	if flash, err := service.executionMode(flashable); !flash || err != nil {
+		t.Errorf("executionMode(%s) = %v, %v, want flash", flashable, flash, err)
+	}
+	if _, err := service.executionMode(manualOnly); !errors.Is(err, ErrManualArbitrageDisabled) {
+		t.Errorf("executionMode(%s) error = %v, want ErrManualArbitrageDisabled", manualOnly, err)
+	}
+
+	service.Config.AllowManualArbitrage = true
+	if flash, err := service.executionMode(manualOnly); flash || err != nil {
+		t.Errorf("executionMode(%s) with opt-in = %v, %v, want manual", manualOnly, flash, err)
+	}
+}
+
+func TestScanSkipsUnconfirmedTrade(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+
+	service := newTestArbitrageService(t, backend)
+	service.Config.AllowManualArbitrage = true
+	service.Client = &EthClient{Address: common.HexToAddress("0x1")}
+	service.RouterService.Client = service.Client
+	service.TokenPairs = []models.TokenPair{testPair()}
+
+	var asked []string
+	service.ConfirmTrade = func(pairName string, route Route, result *models.ArbitrageResult) bool {
+		asked = append(asked, pairName)
+		if result.NetProfitWBNB <= 0 {
+			t.Errorf("asked to confirm %s with net profit %v", route, result.NetProfitWBNB)
+		}
+		return false
+	}
+
+	foundCount := scanAndExecute(t, service)
+	if len(asked) != 1 || asked[0] != "WBNB-USDT-BUSD" {
+		t.Fatalf("ConfirmTrade asked for %v, want one prompt for WBNB-USDT-BUSD", asked)
+	}
+	if foundCount != 0 || len(backend.sent) != 0 {
+		t.Errorf("declined trade executed: found %d, sent %d", foundCount, len(backend.sent))
+	}
+}
+
+func TestScanDetectsWithoutExecuting(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+	backend.estimateErr = newRevertDataError(t, "Pancake: K")
+
+	service := newTestArbitrageService(t, backend)
+	service.Config.AllowManualArbitrage = true
+	service.Client = &EthClient{Address: common.HexToAddress("0x1")}
+	service.RouterService.Client = service.Client
+	service.TokenPairs = []models.TokenPair{testPair()}
+
+	opportunities, err := service.ScanEnhancedOpportunities(context.Background())
+	if err != nil || len(opportunities) != 1 {
+		t.Fatalf("ScanEnhancedOpportunities = %v, %v; want one opportunity", opportunities, err)
+	}
+	if len(backend.estimated) != 0 || len(backend.sent) != 0 {
+		t.Fatalf("scan estimated %d and sent %d transactions, want none", len(backend.estimated), len(backend.sent))
+	}
+
+	opportunity := opportunities[0]
+	if opportunity.PairName != "WBNB-USDT-BUSD" || opportunity.Strategy != config.StrategyTriangular {
+		t.Errorf("opportunity on %s by %q, want WBNB-USDT-BUSD by triangular", opportunity.PairName, opportunity.Strategy)
+	}
+	if opportunity.NetProfitWBNB <= 0 || opportunity.GrossPercent <= 0 || opportunity.AmountWBNB != 0.5 {
+		t.Errorf("opportunity figures = %+v", opportunity)
+	}
+
+	// The opportunity rebuilds the route it was quoted on
+	_, route, err := service.OpportunityRoute(opportunity)
+	if err != nil {
+		t.Fatalf("OpportunityRoute returned error: %v", err)
+	}
+	if route.String() != opportunity.Route() || len(route.Hops) != 3 {
+		t.Errorf("rebuilt route %s, want %s", route, opportunity.Route())
+	}
+
+	// Executing it runs the preflight, which reverts here
+	execution, err := service.Execute(context.Background(), opportunity)
+	if err != nil || execution != nil {
+		t.Fatalf("Execute = %v, %v; want a skipped trade", execution, err)
+	}
+	if len(backend.estimated) != 1 {
+		t.Errorf("estimates = %d, want 1 from the preflight", len(backend.estimated))
+	}
+}
+
+func TestScanRanksOpportunitiesAcrossPairs(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+
+	// The same rates on a larger amount net more, so the pair scanned last
+	// is the best trade
+	small, large, flat := testPair(), testPair(), testPair()
+	large.Name, large.TestAmounts = "WBNB-USDT-BUSD-LARGE", []float64{2}
+	flat.Name, flat.TestAmounts = "WBNB-USDT-BUSD-FLAT", []float64{0.001}
+
+	service := newTestArbitrageService(t, backend)
+	service.TokenPairs = []models.TokenPair{small, flat, large}
+
+	logs := captureLogs(t, slog.LevelDebug)
+	opportunities, err := service.ScanEnhancedOpportunities(context.Background())
+	if err != nil {
+		t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+	}
+
+	// The dust amount can't pay for gas and is left out
+	want := []string{large.Name, small.Name}
+	if len(opportunities) != len(want) {
+		t.Fatalf("got %d opportunities, want %d", len(opportunities), len(want))
+	}
+	for i, opportunity := range opportunities {
+		if opportunity.PairName != want[i] {
+			t.Errorf("rank %d = %s, want %s", i+1, opportunity.PairName, want[i])
+		}
+	}
+	if opportunities[0].NetProfitWBNB <= opportunities[1].NetProfitWBNB {
+		t.Errorf("net profits %v, %v not ranked best first", opportunities[0].NetProfitWBNB, opportunities[1].NetProfitWBNB)
+	}
+	if strings.Count(logs.String(), "Ranked opportunity") != 2 {
+		t.Errorf("ranking not logged:\n%s", logs)
+	}
+}
+
+func TestScanBatchByPriorityWithCarryOver(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+	for _, priority := range []int{3, 1, 5, 2, 4} {
+		service.TokenPairs = append(service.TokenPairs, models.TokenPair{Name: fmt.Sprintf("P%d", priority), Priority: priority})
+	}
+
+	names := func(pairs []models.TokenPair) string {
+		var joined []string
+		for _, pair := range pairs {
+			joined = append(joined, pair.Name)
+		}
+		return strings.Join(joined, " ")
+	}
+
+	if got := names(service.scanBatch()); got != "P1 P2 P3 P4 P5" {
+		t.Errorf("unbudgeted scan = %s, want every pair by priority", got)
+	}
+
+	// The top pair is in every scan and the others take turns
+	service.Config.MaxPairsPerScan = 3
+	for i, want := range []string{"P1 P2 P3", "P1 P4 P5", "P1 P2 P3"} {
+		if got := names(service.scanBatch()); got != want {
+			t.Errorf("scan %d = %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestScanCoolsDownFailedPair(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+	backend.estimateErr = newRevertDataError(t, "Pancake: K")
+
+	clock := &utils.FixedClock{Time: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	service := newTestArbitrageService(t, backend)
+	service.Config.AllowManualArbitrage = true
+	service.Config.PairFailureCooldown = 5 * time.Minute
+	service.Client = &EthClient{Address: common.HexToAddress("0x1")}
+	service.RouterService.Client = service.Client
+	service.TokenPairs = []models.TokenPair{testPair()}
+	service.Clock = clock
+
+	scan := func() {
+		t.Helper()
+		scanAndExecute(t, service)
+	}
+
+	// The preflight revert puts the pair on cooldown
+	scan()
+	if len(backend.estimated) != 1 {
+		t.Fatalf("estimates = %d, want 1", len(backend.estimated))
+	}
+
+	// Within the cooldown the pair isn't quoted or estimated
+	clock.Time = clock.Time.Add(4 * time.Minute)
+	quoted := backend.calls
+	scan()
+	if len(backend.estimated) != 1 || backend.calls != quoted {
+		t.Errorf("pair scanned during cooldown: %d estimates, %d new calls", len(backend.estimated), backend.calls-quoted)
+	}
+
+	// Once it expires the pair is scanned again
+	clock.Time = clock.Time.Add(2 * time.Minute)
+	scan()
+	if len(backend.estimated) != 2 {
+		t.Errorf("estimates after cooldown = %d, want 2", len(backend.estimated))
+	}
+
+	// A success lifts the cooldown early
+	service.clearPairCooldown(testPair().Name)
+	if _, cooling := service.pairCooldown(testPair().Name); cooling {
+		t.Error("pair still cooling down after a successful execution")
+	}
+
+	// Disabled, failures don't cool the pair down
+	service.Config.PairFailureCooldown = 0
+	scan()
+	scan()
+	if len(backend.estimated) != 4 {
+		t.Errorf("estimates with cooldown disabled = %d, want 4", len(backend.estimated))
+	}
+}
+
+func TestMissedOpportunitiesCountSlippageReverts(t *testing.T) {
+	tests := []struct {
+		name       string
+		revert     error
+		wantMissed int
+	}{
+		{"insufficient output", newRevertDataError(t, "PancakeRouter: INSUFFICIENT_OUTPUT_AMOUNT"), 1},
+		{"too little received", newRevertDataError(t, "Too little received"), 1},
+		{"unrelated revert", newRevertDataError(t, "Pancake: K"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+			backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+			backend.estimateErr = tt.revert
+
+			service := newTestArbitrageService(t, backend)
+			service.Config.AllowManualArbitrage = true
+			service.Client = &EthClient{Address: common.HexToAddress("0x1")}
+			service.RouterService.Client = service.Client
+			service.TokenPairs = []models.TokenPair{testPair()}
+
+			if trades := scanAndExecute(t, service); trades != 0 {
+				t.Fatalf("trades = %d, want the reverted preflight to skip execution", trades)
+			}
+			if got := service.TradeSummary().MissedOpportunities; got != tt.wantMissed {
+				t.Errorf("MissedOpportunities = %d, want %d", got, tt.wantMissed)
+			}
+		})
+	}
+}
+
+func TestPairSpread(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(1, 1)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(101, 100)
+
+	service := newTestArbitrageService(t, backend)
+
+	// BiSwap pays 1% more on every leg
+	spread, err := service.PairSpread(testPair(), 0.5)
+	if err != nil {
+		t.Fatalf("PairSpread returned error: %v", err)
+	}
+	if math.Abs(spread-1) > 1e-9 {
+		t.Errorf("PairSpread = %v, want 1", spread)
+	}
+
+	// Each scan adds a sample to the pair's average
+	service.TokenPairs = []models.TokenPair{testPair()}
+	for i := 0; i < 2; i++ {
+		if _, err := service.ScanEnhancedOpportunities(context.Background()); err != nil {
+			t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+		}
+	}
+	stats := service.enhancedStats.PairSpreads["WBNB-USDT-BUSD"]
+	if stats == nil || stats.Samples != 2 {
+		t.Fatalf("spread stats = %+v, want 2 samples", stats)
+	}
+	if avg := service.AverageSpreads()["WBNB-USDT-BUSD"]; math.Abs(avg-1) > 1e-9 {
+		t.Errorf("average spread = %v, want 1", avg)
+	}
+}
+
+func TestCalculatePlatformFee(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+	service.Config.PlatformFeeBps = 250
+
+	fee := service.CalculatePlatformFee(big.NewInt(1000000))
+	if fee.Cmp(big.NewInt(25000)) != 0 {
+		t.Errorf("fee = %s, want 25000", fee)
+	}
+
+	if fee := service.CalculatePlatformFee(big.NewInt(-1000000)); fee.Sign() != 0 {
+		t.Errorf("fee on a loss = %s, want 0", fee)
+	}
+}
+
+func TestCheckBestVenueArbitrageMixesVenues(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(2, 1)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(101, 400)
+	backend.v3Quotes[2500] = rateQuote(201, 100)
+
+	service := newTestArbitrageService(t, backend)
+
+	// V3 disabled: every leg goes through the better V2 router
+	result, err := service.CheckBestVenueArbitrage(testPair(), 0.5)
+	if err != nil {
+		t.Fatalf("CheckBestVenueArbitrage returned error: %v", err)
+	}
+	for i, venue := range result.Venues {
+		if venue != "PancakeSwap" {
+			t.Errorf("leg %d venue = %s, want PancakeSwap", i+1, venue)
+		}
+	}
+	checkCyclePath(t, result)
+	if usesV3(result) {
+		t.Error("usesV3 = true with V3 disabled")
+	}
+
+	// V3 enabled: the 0.25% tier quotes slightly better on every leg
+	service.Config.EnableV3 = true
+	service.RouterService.ResetQuoteCache()
+
+	result, err = service.CheckBestVenueArbitrage(testPair(), 0.5)
+	if err != nil {
+		t.Fatalf("CheckBestVenueArbitrage returned error: %v", err)
+	}
+	for i, venue := range result.Venues {
+		if venue != "PancakeSwap V3 0.25%" {
+			t.Errorf("leg %d venue = %s, want PancakeSwap V3 0.25%%", i+1, venue)
+		}
+	}
+	if !usesV3(result) {
+		t.Error("usesV3 = false for an all-V3 route")
+	}
+
+	// 0.5 WBNB * 2.01^3 = 4.0603005 WBNB
+	wantProfit, _ := new(big.Int).SetString("3560300500000000000", 10)
+	if result.Profit.Cmp(wantProfit) != 0 {
+		t.Errorf("profit = %s, want %s", result.Profit, wantProfit)
+	}
+}
+
+func TestScanQuotesV3RoutesOnlyWhenLogged(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+
+	v3Calls := 0
+	backend.v3Quotes[2500] = func(amountIn *big.Int, path []common.Address) []*big.Int {
+		v3Calls++
+		return rateQuote(11, 10)(amountIn, path)
+	}
+
+	service := newTestArbitrageService(t, backend)
+	service.TokenPairs = []models.TokenPair{testPair()}
+	service.Config.EnableV3 = true
+
+	if _, err := service.ScanEnhancedOpportunities(context.Background()); err != nil {
+		t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+	}
+	if v3Calls != 0 {
+		t.Errorf("scan made %d V3 quoter calls without LOG_V3_ROUTES", v3Calls)
+	}
+
+	service.Config.LogV3Routes = true
+	service.RouterService.ResetQuoteCache()
+	if _, err := service.ScanEnhancedOpportunities(context.Background()); err != nil {
+		t.Fatalf("ScanEnhancedOpportunities returned error: %v", err)
+	}
+	if v3Calls == 0 {
+		t.Error("scan made no V3 quoter calls with LOG_V3_ROUTES")
+	}
+}
+
+func TestTradeSummaryTracksRealizedProfit(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+
+	if summary := service.TradeSummary(); summary.Trades != 0 || summary.WinRate() != 0 {
+		t.Fatalf("summary before any trade = %+v", summary)
+	}
+
+	// Both trades expected 1% on 1 WBNB; the first made 0.008 WBNB, the
+	// second was front-run and lost 0.002 WBNB
+	opportunity := models.Opportunity{PairName: "WBNB-USDT-BUSD", Category: "stable", AmountWBNB: 1, AdjustedProfit: 0.01}
+	service.recordEnhancedTrade(opportunity, &models.ExecutionResult{RealizedProfit: big.NewInt(8e15)})
+	service.recordEnhancedTrade(opportunity, &models.ExecutionResult{RealizedProfit: big.NewInt(-2e15)})
+
+	summary := service.TradeSummary()
+	if summary.Trades != 2 || summary.Wins != 1 {
+		t.Errorf("trades = %d, wins = %d, want 2 and 1", summary.Trades, summary.Wins)
+	}
+	if math.Abs(summary.ExpectedProfit-0.02) > 1e-9 {
+		t.Errorf("expected profit = %v, want 0.02", summary.ExpectedProfit)
+	}
+	if math.Abs(summary.RealizedProfit-0.006) > 1e-9 {
+		t.Errorf("realized profit = %v, want 0.006", summary.RealizedProfit)
+	}
+	if summary.WinRate() != 50 {
+		t.Errorf("win rate = %v, want 50", summary.WinRate())
+	}
+}
+
+func TestDecodeArbitrageExecuted(t *testing.T) {
+	if err := contracts.Initialize(); err != nil {
+		t.Fatalf("failed to initialize ABIs: %v", err)
+	}
+
+	flash := common.HexToAddress("0x00000000000000000000000000000000000000f1")
+	event := contracts.FlashABI.Events["ArbitrageExecuted"]
+
+	data, err := event.Inputs.NonIndexed().Pack(wbnbAmount(10), big.NewInt(5e16), big.NewInt(5e15), big.NewInt(45e15))
+	if err != nil {
+		t.Fatalf("failed to pack event: %v", err)
+	}
+	executed := &types.Log{
+		Address: flash,
+		Topics:  []common.Hash{event.ID, common.BytesToHash(common.HexToAddress("0x1").Bytes()), common.HexToHash(config.WBNB)},
+		Data:    data,
+	}
+	transfer := &types.Log{
+		Address: common.HexToAddress(config.WBNB),
+		Topics:  []common.Hash{crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))},
+		Data:    common.LeftPadBytes(big.NewInt(1).Bytes(), 32),
+	}
+	elsewhere := *executed
+	elsewhere.Address = common.HexToAddress("0x00000000000000000000000000000000000000f2")
+
+	profit, ok := decodeArbitrageExecuted([]*types.Log{transfer, executed}, flash)
+	if !ok {
+		t.Fatal("event not decoded")
+	}
+	if profit.Profit.Cmp(big.NewInt(5e16)) != 0 || profit.PlatformFee.Cmp(big.NewInt(5e15)) != 0 ||
+		profit.UserProfit.Cmp(big.NewInt(45e15)) != 0 {
+		t.Errorf("decoded profit %s, fee %s, user %s", profit.Profit, profit.PlatformFee, profit.UserProfit)
+	}
+
+	if _, ok := decodeArbitrageExecuted([]*types.Log{transfer, &elsewhere}, flash); ok {
+		t.Error("decoded an event emitted by another contract")
+	}
+	if _, ok := decodeArbitrageExecuted(nil, flash); ok {
+		t.Error("decoded an event from an empty receipt")
+	}
+}
+
+func TestCheckPairLiquidityUSDFloor(t *testing.T) {
+	const (
+		wbnbBUSD = "0x00000000000000000000000000000000000000a1"
+		busdUSDT = "0x00000000000000000000000000000000000000a2"
+		usdtWBNB = "0x00000000000000000000000000000000000000a3"
+	)
+
+	tests := []struct {
+		name        string
+		floorUSD    float64
+		usdtReserve int64 // whole USDT in BUSD-USDT
+		wantThin    bool
+	}{
+		{"all pools above floor", 50000, 30000, false},
+		{"floor above every pool", 70000, 30000, true},
+		{"thin USDT side", 50000, 1000, true},
+		{"floor disabled", 0, 1000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1 WBNB quotes 300 USDT. WBNB-BUSD is valued from its 100 WBNB
+			// at that price, the USDT pools from their USDT reserve.
+			backend := newMockBackend()
+			backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(300, 1)
+			backend.listPool(config.PancakeswapFactory, wbnbBUSD, config.WBNB, config.BUSD, wbnbAmount(100))
+			backend.listPool(config.PancakeswapFactory, busdUSDT, config.BUSD, config.USDT, wbnbAmount(100))
+			backend.listPool(config.PancakeswapFactory, usdtWBNB, config.USDT, config.WBNB, wbnbAmount(100))
+			backend.pools[common.HexToAddress(busdUSDT)].reserve1 = wbnbAmount(tt.usdtReserve)
+			backend.pools[common.HexToAddress(usdtWBNB)].reserve0 = wbnbAmount(30000)
+
+			pair := testPair()
+			pair.PancakeswapPair = map[string]string{"WBNB-BUSD": wbnbBUSD, "BUSD-USDT": busdUSDT, "USDT-WBNB": usdtWBNB}
+
+			service := newTestArbitrageService(t, backend)
+			service.Config.MinReserveWBNB = 1
+			service.Config.MinLiquidityUSD = tt.floorUSD
+
+			err := service.CheckPairLiquidity(pair)
+			if tt.wantThin && !errors.Is(err, ErrPoolTooThin) {
+				t.Errorf("CheckPairLiquidity = %v, want ErrPoolTooThin", err)
+			}
+			if !tt.wantThin && err != nil {
+				t.Errorf("CheckPairLiquidity returned error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckPairLiquidityUsesQuoteBlockReserves(t *testing.T) {
+	const pool = "0x00000000000000000000000000000000000000a1"
+
+	backend := newMockBackend()
+	backend.listPool(config.PancakeswapFactory, pool, config.WBNB, config.BUSD, wbnbAmount(100))
+
+	pair := testPair()
+	pair.PancakeswapPair = map[string]string{"WBNB-BUSD": pool}
+
+	service := newTestArbitrageService(t, backend)
+	service.Config.MinReserveWBNB = 10
+
+	if err := service.CheckPairLiquidity(pair); err != nil {
+		t.Fatalf("CheckPairLiquidity returned error: %v", err)
+	}
+
+	// The pool drains, but within the same quote block the cached reserves stand
+	backend.pools[common.HexToAddress(pool)].reserve0 = wbnbAmount(1)
+	if err := service.CheckPairLiquidity(pair); err != nil {
+		t.Errorf("CheckPairLiquidity re-read reserves within a quote block: %v", err)
+	}
+
+	service.RouterService.ResetQuoteCache()
+	if err := service.CheckPairLiquidity(pair); !errors.Is(err, ErrPoolTooThin) {
+		t.Errorf("CheckPairLiquidity after a new block = %v, want ErrPoolTooThin", err)
+	}
+}
+
+func TestFlashMinAmountsOut(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestArbitrageService(t, backend)
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+	// Each leg returns 1.01x: 1 → 1.01 → 1.0201 → 1.030301 WBNB
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(101, 100)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(101, 100)
+
+	mins, err := flashMinAmountsOut(route, wbnbAmount(1), 0.02, wbnbAmount(1))
+	if err != nil {
+		t.Fatalf("flashMinAmountsOut returned error: %v", err)
+	}
+	want := []string{"989800000000000000", "999698000000000000", "1009694980000000000"}
+	for i := range want {
+		if mins[i].String() != want[i] {
+			t.Errorf("leg %d minimum = %s, want %s", i+1, mins[i], want[i])
+		}
+	}
+
+	// Slippage never lets the last leg fall below break-even: the loan
+	// repaid with PancakeSwap's 0.25% fee plus 600000 gas at 5 gwei
+	// buffered 20%, 1002506265664160402 + 3600000000000000 wei
+	service.Config.GasPriceBufferPercent = 20
+	breakEven := service.flashBreakEven(route, wbnbAmount(1))
+	if breakEven.String() != "1006106265664160402" {
+		t.Errorf("break-even = %s, want 1006106265664160402", breakEven)
+	}
+
+	mins, err = flashMinAmountsOut(route, wbnbAmount(1), 0.05, breakEven)
+	if err != nil {
+		t.Fatalf("flashMinAmountsOut returned error: %v", err)
+	}
+	if mins[2].Cmp(breakEven) != 0 {
+		t.Errorf("last leg minimum = %s, want break-even %s", mins[2], breakEven)
+	}
+	if mins[0].String() != "959500000000000000" {
+		t.Errorf("first leg minimum at 5%% slippage = %s, want 959500000000000000", mins[0])
+	}
+}
+
+func TestCheckFlashTargets(t *testing.T) {
+	const pool = "0x00000000000000000000000000000000000000a1"
+	flashContract := common.HexToAddress("0x00000000000000000000000000000000000F1a54")
+	wbnb := common.HexToAddress(config.WBNB)
+	usdt := common.HexToAddress(config.USDT)
+
+	tests := []struct {
+		name     string
+		setup    func(backend *mockBackend)
+		borrowed common.Address
+		paired   common.Address
+		amount   *big.Int
+		wantErr  bool
+	}{
+		{
+			name:     "deployed and funded",
+			borrowed: wbnb,
+			paired:   usdt,
+			amount:   wbnbAmount(10),
+		},
+		{
+			name:     "flash contract not deployed",
+			setup:    func(backend *mockBackend) { backend.codeless[flashContract] = true },
+			borrowed: wbnb,
+			paired:   usdt,
+			amount:   wbnbAmount(10),
+			wantErr:  true,
+		},
+		{
+			name:     "pool not deployed",
+			setup:    func(backend *mockBackend) { backend.codeless[common.HexToAddress(pool)] = true },
+			borrowed: wbnb,
+			paired:   usdt,
+			amount:   wbnbAmount(10),
+			wantErr:  true,
+		},
+		{
+			name:     "pool without the borrowed token",
+			borrowed: common.HexToAddress(config.CAKE),
+			paired:   usdt,
+			amount:   wbnbAmount(10),
+			wantErr:  true,
+		},
+		{
+			name:     "pool of another pair",
+			borrowed: wbnb,
+			paired:   common.HexToAddress(config.BUSD),
+			amount:   wbnbAmount(10),
+			wantErr:  true,
+		},
+		{
+			name:     "loan larger than the pool",
+			borrowed: wbnb,
+			paired:   usdt,
+			amount:   wbnbAmount(100),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.listPool(config.PancakeswapFactory, pool, config.WBNB, config.USDT, wbnbAmount(50))
+			if tt.setup != nil {
+				tt.setup(backend)
+			}
+
+			service := newTestArbitrageService(t, backend)
+			service.FlashContract = flashContract
+
+			err := service.checkFlashTargets(common.HexToAddress(pool), tt.borrowed, tt.paired, tt.amount)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("checkFlashTargets returned error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrFlashMisconfigured) {
+				t.Errorf("checkFlashTargets error = %v, want ErrFlashMisconfigured", err)
+			}
+		})
+	}
+}
+
+func TestFlashRevertError(t *testing.T) {
+	tx := types.NewTransaction(0, common.HexToAddress("0xf1"), big.NewInt(0), 600000, big.NewInt(5e9), []byte{1, 2, 3, 4})
+
+	tests := []struct {
+		name     string
+		gasUsed  uint64
+		replay   error
+		lostRace bool
+		reason   string
+	}{
+		{"minimum output missed", 180000, newRevertDataError(t, "PancakeRouter: INSUFFICIENT_OUTPUT_AMOUNT"), true, "INSUFFICIENT_OUTPUT_AMOUNT"},
+		{"other revert reason", 180000, newRevertDataError(t, "Pancake: K"), false, "Pancake: K"},
+		{"replay succeeds", 180000, nil, false, "not when replayed"},
+		{"out of gas", 600000, newRevertDataError(t, "PancakeRouter: INSUFFICIENT_OUTPUT_AMOUNT"), false, "ran out of gas"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.codeless[common.HexToAddress("0xf1")] = true
+			if tt.replay != nil {
+				backend.callErr = tt.replay
+			}
+			service := newTestArbitrageService(t, backend)
+			service.Client = newTestTokenService(t, backend).Client
+
+			receipt := &types.Receipt{Status: types.ReceiptStatusFailed, GasUsed: tt.gasUsed, BlockNumber: big.NewInt(100)}
+			err := service.flashRevertError(receipt, tx)
+			if errors.Is(err, ErrLostRace) != tt.lostRace {
+				t.Errorf("flashRevertError = %v, lost race %v", err, tt.lostRace)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("flashRevertError = %v, want it to mention %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestMinAmountOutUsesConfiguredSlippage(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestArbitrageService(t, backend)
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "PancakeSwap", "PancakeSwap")
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(1, 1)
+
+	tests := []struct {
+		name        string
+		maxSlippage float64
+		stable      float64 // MAX_SLIPPAGE_STABLE, 0 when unset
+		want        string
+	}{
+		{"default max slippage", 0.03, 0, "970000000000000000"},
+		{"tighter max slippage", 0.005, 0, "995000000000000000"},
+		{"category override", 0.03, 0.07, "930000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.Config.MaxSlippage = tt.maxSlippage
+			service.Config.CategoryMaxSlippage = map[string]float64{}
+			if tt.stable > 0 {
+				service.Config.CategoryMaxSlippage["stable"] = tt.stable
+			}
+			service.RouterService.ResetQuoteCache()
+
+			got, err := minAmountOut(route.Hops[0], wbnbAmount(1), service.slippageFor(testPair()))
+			if err != nil {
+				t.Fatalf("minAmountOut returned error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("minAmountOut = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitorOnlyWhileWalletCannotTrade(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+
+	service := newTestArbitrageService(t, backend)
+	service.Config.AllowManualArbitrage = true
+	service.Client = &EthClient{Address: common.HexToAddress("0x1")}
+	service.RouterService.Client = service.Client
+	service.TokenPairs = []models.TokenPair{testPair()}
+
+	wbnb := common.HexToAddress(config.WBNB)
+	tests := []struct {
+		name       string
+		native     *big.Int
+		wbnb       *big.Int
+		flash      bool
+		wantReason string
+	}{
+		{"no BNB or WBNB", nil, nil, false, "wallet has no BNB for gas"},
+		{"no BNB for gas", nil, wbnbAmount(1), true, "wallet has no BNB for gas"},
+		{"no WBNB for a manual route", wbnbAmount(1), nil, false, "wallet has no WBNB and no flash contract to borrow it"},
+		{"flash contract borrows the WBNB", wbnbAmount(1), nil, true, ""},
+		{"funded", wbnbAmount(1), wbnbAmount(1), false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend.native = tt.native
+			delete(backend.balances, wbnb)
+			if tt.wbnb != nil {
+				backend.balances[wbnb] = tt.wbnb
+			}
+			service.FlashContract = common.Address{}
+			if tt.flash {
+				service.FlashContract = common.HexToAddress("0xf1")
+			}
+
+			if err := service.CheckTradeable(context.Background()); err != nil {
+				t.Fatalf("CheckTradeable returned error: %v", err)
+			}
+			if reason, monitorOnly := service.MonitorOnly(); reason != tt.wantReason || monitorOnly != (tt.wantReason != "") {
+				t.Errorf("MonitorOnly = %q, %v; want %q", reason, monitorOnly, tt.wantReason)
+			}
+		})
+	}
+
+	// Opportunities are still found, but nothing is estimated or sent
+	backend.native = nil
+	if err := service.CheckTradeable(context.Background()); err != nil {
+		t.Fatalf("CheckTradeable returned error: %v", err)
+	}
+	if executed := scanAndExecute(t, service); executed != 0 {
+		t.Errorf("executed %d trades in monitor-only mode", executed)
+	}
+	if len(backend.estimated) != 0 || len(backend.sent) != 0 {
+		t.Errorf("monitor-only estimated %d and sent %d transactions, want none", len(backend.estimated), len(backend.sent))
+	}
+}
+
+func TestUnwindPositionPicksBestExchange(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(1, 300)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(1, 290)
+
+	service := newTestArbitrageService(t, backend)
+	service.Client = &EthClient{Address: common.HexToAddress("0x1")}
+
+	cake := common.HexToAddress(config.CAKE)
+	hop, err := service.bestUnwindHop(cake, "CAKE", wbnbAmount(30))
+	if err != nil {
+		t.Fatalf("bestUnwindHop returned error: %v", err)
+	}
+	if hop.DEX.Name() != "BiSwap" || hop.TokenIn != cake || hop.TokenOut != common.HexToAddress(config.WBNB) {
+		t.Errorf("unwind hop = %s %s→%s, want BiSwap CAKE→WBNB", hop.DEX.Name(), hop.SymbolIn, hop.SymbolOut)
+	}
+
+	// Nothing is sent without a balance to sell, or for WBNB itself
+	if _, err := service.UnwindPosition(cake); err == nil || !strings.Contains(err.Error(), "holds no CAKE") {
+		t.Errorf("UnwindPosition with no balance = %v, want a no-balance error", err)
+	}
+	backend.balances[common.HexToAddress(config.WBNB)] = wbnbAmount(1)
+	if _, err := service.UnwindPosition(common.HexToAddress(config.WBNB)); err == nil {
+		t.Error("UnwindPosition accepted WBNB")
+	}
+	if len(backend.sent) != 0 {
+		t.Errorf("sent %d transactions, want none", len(backend.sent))
+	}
+}
+
+// newManualTestService returns a service whose wallet holds 10 WBNB and signs
+// and sends manual legs, persisting its execution state in a temporary
+// directory
+func newManualTestService(t *testing.T, backend *mockBackend) *ArbitrageService {
+	t.Helper()
+
+	backend.balances[common.HexToAddress(config.WBNB)] = wbnbAmount(10)
+
+	service := newTestArbitrageService(t, backend)
+	service.Config.AllowManualArbitrage = true
+	service.Config.ExecutionStateFile = filepath.Join(t.TempDir(), "execution_state.json")
+	service.Config.ReceiptTimeout = 50 * time.Millisecond
+	service.Client = newTestTokenService(t, backend).Client
+	service.RouterService.Client = service.Client
+	service.TokenService.Client = service.Client
+	service.TokenPairs = []models.TokenPair{testPair()}
+	return service
+}
+
+func TestManualLegPersistedBeforeSend(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+
+	service := newManualTestService(t, backend)
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+	// The state on disk names each transaction before the node sees it
+	backend.onSend = func(tx *types.Transaction) {
+		state, err := LoadExecutionState(service.Config.ExecutionStateFile)
+		if err != nil || state == nil {
+			t.Fatalf("no execution state when sending %s: %v", tx.Hash().Hex(), err)
+		}
+		if state.PendingTx != tx.Hash() {
+			t.Errorf("state pending tx = %s when sending %s", state.PendingTx.Hex(), tx.Hash().Hex())
+		}
+	}
+
+	// Step 1 is never mined, so it stays on record as in flight
+	_, err := service.ExecuteManualArbitrage(testPair(), wbnbAmount(1), route)
+	if err == nil || len(backend.sent) != 1 {
+		t.Fatalf("ExecuteManualArbitrage = %v after %d sends, want a step 1 timeout", err, len(backend.sent))
+	}
+	state, err := LoadExecutionState(service.Config.ExecutionStateFile)
+	if err != nil || state == nil || state.PendingTx != backend.sent[0].Hash() || state.CompletedLegs != 0 {
+		t.Fatalf("state after timeout = %+v, %v; want step 1 pending as %s", state, err, backend.sent[0].Hash().Hex())
+	}
+
+	// Resuming while it is still unmined sends nothing
+	if _, err := service.ResumeManualArbitrage(state); !errors.Is(err, ErrNotMined) {
+		t.Fatalf("ResumeManualArbitrage with step 1 unmined = %v, want ErrNotMined", err)
+	}
+	if len(backend.sent) != 1 {
+		t.Fatalf("resume resent step 1: %d sends", len(backend.sent))
+	}
+
+	// Once it is mined the resume picks up at step 2 from its receipt
+	backend.mine = backend.fillSwaps(service.Client.Address)
+	result, err := service.ResumeManualArbitrage(state)
+	if err != nil {
+		t.Fatalf("ResumeManualArbitrage returned error: %v", err)
+	}
+	if len(backend.sent) != 3 || len(result.TxHashes) != 3 || result.TxHashes[0] != backend.sent[0].Hash() {
+		t.Errorf("resume sent %d transactions with hashes %v, want steps 2 and 3 after %s",
+			len(backend.sent), result.TxHashes, backend.sent[0].Hash().Hex())
+	}
+	if state, err := LoadExecutionState(service.Config.ExecutionStateFile); err != nil || state != nil {
+		t.Errorf("execution state left after completion: %+v, %v", state, err)
+	}
+}
+
+func TestManualLegSendFailureClearsState(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+	backend.sendErrs = []error{errors.New("nonce too low")}
+
+	service := newManualTestService(t, backend)
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+	if _, err := service.ExecuteManualArbitrage(testPair(), wbnbAmount(1), route); err == nil {
+		t.Fatal("ExecuteManualArbitrage succeeded with a rejected step 1")
+	}
+	if state, err := LoadExecutionState(service.Config.ExecutionStateFile); err != nil || state != nil {
+		t.Errorf("rejected step 1 left execution state %+v, %v", state, err)
+	}
+}
+
+func TestManualLegTimeoutReportsPendingLeg(t *testing.T) {
+	tests := []struct {
+		name        string
+		lateQueries int // receipt queries step 2 stays unmined for; -1 never mines
+		wantPending bool
+	}{
+		{name: "never mined", lateQueries: -1, wantPending: true},
+		{name: "mined after the wait gave up", lateQueries: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+			backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+
+			service := newManualTestService(t, backend)
+			route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+			fill := backend.fillSwaps(service.Client.Address)
+			queries := 0
+			backend.mine = func(tx *types.Transaction) *types.Receipt {
+				if len(backend.sent) > 1 && tx.Hash() == backend.sent[1].Hash() {
+					queries++
+					if tt.lateQueries < 0 || queries <= tt.lateQueries {
+						return nil
+					}
+				}
+				return fill(tx)
+			}
+
+			result, err := service.ExecuteManualArbitrage(testPair(), wbnbAmount(1), route)
+			if !tt.wantPending {
+				if err != nil || len(result.TxHashes) != 3 {
+					t.Fatalf("ExecuteManualArbitrage = %+v, %v; want all three legs", result, err)
+				}
+				return
+			}
+
+			var stranded *StrandedPositionError
+			if !errors.As(err, &stranded) {
+				t.Fatalf("ExecuteManualArbitrage error = %v, want a StrandedPositionError", err)
+			}
+			if stranded.CompletedLegs != 1 || stranded.PendingTx != backend.sent[1].Hash() ||
+				stranded.Token != route.Hops[1].TokenIn || stranded.PendingSymbol != route.Hops[1].SymbolOut {
+				t.Errorf("stranded = %+v, want step 2 pending as %s", stranded, backend.sent[1].Hash().Hex())
+			}
+			if !strings.Contains(err.Error(), "pending as "+backend.sent[1].Hash().Hex()) {
+				t.Errorf("error %q does not name the pending transaction", err)
+			}
+			if len(backend.sent) != 2 {
+				t.Errorf("sent %d transactions, want none after the pending leg", len(backend.sent))
+			}
+		})
+	}
+}
+
+func TestManualRealizedProfitNetOfGas(t *testing.T) {
+	backend := newMockBackend()
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+
+	service := newManualTestService(t, backend)
+	route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+	wbnb := common.HexToAddress(config.WBNB)
+	backend.balances[wbnb] = wbnbAmount(2)
+	backend.mine = backend.fillSwaps(service.Client.Address)
+
+	result, err := service.ExecuteManualArbitrage(testPair(), wbnbAmount(1), route)
+	if err != nil {
+		t.Fatalf("ExecuteManualArbitrage returned error: %v", err)
+	}
+
+	// Three legs of 150,000 gas at 5 Gwei
+	gasCost := big.NewInt(3 * 150000 * 5e9)
+	if result.GasCost == nil || result.GasCost.Cmp(gasCost) != 0 {
+		t.Errorf("gas cost = %v, want %s", result.GasCost, gasCost)
+	}
+	if result.InitialBalance.Cmp(wbnbAmount(2)) != 0 || result.FinalBalance.Cmp(backend.balances[wbnb]) != 0 {
+		t.Errorf("balances %s → %s, want 2 WBNB → %s", result.InitialBalance, result.FinalBalance, backend.balances[wbnb])
+	}
+	want := new(big.Int).Sub(new(big.Int).Sub(result.FinalBalance, result.InitialBalance), gasCost)
+	if result.RealizedProfit.Cmp(want) != 0 {
+		t.Errorf("realized profit = %s, want balance change less gas %s", result.RealizedProfit, want)
+	}
+}
+
+func TestAutoWrapWaitsForPendingWrap(t *testing.T) {
+	backend := newMockBackend()
+	service := newManualTestService(t, backend)
+	backend.balances[common.HexToAddress(config.WBNB)] = wbnbAmount(0)
+	backend.native = wbnbAmount(5)
+	service.Config.AutoWrapThresholdWBNB = 1
+	service.Config.AutoWrapTargetWBNB = 2
+	service.Config.GasReserveBNB = 0.1
+
+	mined := false
+	backend.mine = func(tx *types.Transaction) *types.Receipt {
+		if !mined {
+			return nil
+		}
+		return &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash()}
+	}
+
+	if err := service.AutoWrapWBNB(); err != nil {
+		t.Fatalf("AutoWrapWBNB() error = %v", err)
+	}
+	if len(backend.sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(backend.sent))
+	}
+	if got := backend.sent[0].Value(); got.Cmp(wbnbAmount(2)) != 0 {
+		t.Errorf("wrapped %s, want %s", got, wbnbAmount(2))
+	}
+
+	// The balance doesn't show the wrap until it is mined
+	if err := service.AutoWrapWBNB(); err != nil {
+		t.Fatalf("AutoWrapWBNB() error = %v", err)
+	}
+	if len(backend.sent) != 1 {
+		t.Fatalf("sent %d transactions while the first wrap was unconfirmed, want 1", len(backend.sent))
+	}
+	if pending := service.PendingTransactions(); pending != 1 {
+		t.Errorf("pending transactions = %d, want 1", pending)
+	}
+
+	mined = true
+	if err := service.AutoWrapWBNB(); err != nil {
+		t.Fatalf("AutoWrapWBNB() error = %v", err)
+	}
+	if len(backend.sent) != 2 {
+		t.Errorf("sent %d transactions after the first wrap was mined, want 2", len(backend.sent))
+	}
+}
+
+func TestManualLegMinedRevertIsClassified(t *testing.T) {
+	tests := []struct {
+		name     string
+		reason   string
+		lostRace bool
+	}{
+		{"missed minimum output", "PancakeRouter: INSUFFICIENT_OUTPUT_AMOUNT", true},
+		{"other revert", "TransferHelper: TRANSFER_FROM_FAILED", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = rateQuote(11, 10)
+			backend.quotes[common.HexToAddress(config.BiswapRouter)] = rateQuote(11, 10)
+
+			service := newManualTestService(t, backend)
+			route := mustRoute(t, service, testPair(), "PancakeSwap", "BiSwap", "PancakeSwap")
+
+			// Step 1 is mined but reverts, and replaying it gives the reason
+			backend.mine = func(tx *types.Transaction) *types.Receipt {
+				backend.callErr = newRevertDataError(t, tt.reason)
+				return &types.Receipt{Status: types.ReceiptStatusFailed, TxHash: tx.Hash(), GasUsed: 120000, BlockNumber: big.NewInt(100)}
+			}
+
+			_, err := service.ExecuteManualArbitrage(testPair(), wbnbAmount(1), route)
+			if err == nil {
+				t.Fatal("expected an error for a reverted step 1")
+			}
+			if errors.Is(err, ErrLostRace) != tt.lostRace {
+				t.Errorf("ExecuteManualArbitrage error = %v, lost race %v", err, tt.lostRace)
+			}
+			if !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("error %q does not carry the revert reason %q", err, tt.reason)
+			}
+			if len(backend.sent) != 1 {
+				t.Errorf("sent %d transactions after step 1 reverted, want 1", len(backend.sent))
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+// services/backend.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+)
+
+// ContractCaller is the subset of node access used by the services. EthClient
+// satisfies it by delegating to whichever RPC is currently connected, and tests
+// can substitute a mock that returns canned contract responses.
+type ContractCaller interface {
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// throttled waits for the active RPC's call budget and returns its client.
+// The error deliberately avoids "rate limit" wording: the wait is local, so it
+// must not count as the node rate limiting us.
+func (e *EthClient) throttled(ctx context.Context) (*ethclient.Client, error) {
+	e.mu.RLock()
+	client, limiter := e.Client, e.limiters[e.currentRPC]
+	e.mu.RUnlock()
+
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("RPC call throttled: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// CallContract executes a read-only contract call on the active RPC
+func (e *EthClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.CallContract(ctx, call, blockNumber)
+}
+
+// CodeAt returns the contract code at an address on the active RPC
+func (e *EthClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.CodeAt(ctx, account, blockNumber)
+}
+
+// BlockNumber returns the latest block number on the active RPC
+func (e *EthClient) BlockNumber(ctx context.Context) (uint64, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return client.BlockNumber(ctx)
+}
+
+// EstimateGas estimates the gas needed for a call on the active RPC
+func (e *EthClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return client.EstimateGas(ctx, call)
+}
+
+// SuggestGasPrice returns the gas price suggested by the active RPC
+func (e *EthClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.SuggestGasPrice(ctx)
+}
+
+// PendingNonceAt returns the pending nonce of an account on the active RPC
+func (e *EthClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return client.PendingNonceAt(ctx, account)
+}
+
+// SendTransaction broadcasts a signed transaction through the active RPC
+func (e *EthClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return err
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	e.noteSent(tx)
+	return nil
+}
+
+// TransactionReceipt returns the receipt of a mined transaction from the active RPC
+func (e *EthClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.TransactionReceipt(ctx, txHash)
+}
+
+// NetworkID returns the network ID of the active RPC
+func (e *EthClient) NetworkID(ctx context.Context) (*big.Int, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.NetworkID(ctx)
+}
+
+// BalanceAt returns the native BNB balance of an account on the active RPC
+func (e *EthClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.BalanceAt(ctx, account, blockNumber)
+}
+
+// SubscribeSyncEvents streams the Sync event each pool emits whenever its
+// reserves change. The active RPC must support subscriptions (ws or wss); the
+// subscription ends with an error when it drops or the RPC is switched away.
+func (e *EthClient) SubscribeSyncEvents(ctx context.Context, pools []common.Address, sink chan<- types.Log) (ethereum.Subscription, error) {
+	query := ethereum.FilterQuery{
+		Addresses: pools,
+		Topics:    [][]common.Hash{{contracts.PairABI.Events["Sync"].ID}},
+	}
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.SubscribeFilterLogs(ctx, query, sink)
+}
+
+// HeaderByNumber returns a block header from the active RPC, the latest when
+// number is nil
+func (e *EthClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	client, err := e.throttled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.HeaderByNumber(ctx, number)
+}
+
+// suggestGasPrice returns the active gas price raised by bufferPercent, the
+// price the bot's trades are sent at
+func suggestGasPrice(ctx context.Context, backend ContractCaller, bufferPercent int) (*big.Int, error) {
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return applyGasPriceBuffer(gasPrice, bufferPercent), nil
+}
+
+// applyGasPriceBuffer raises a gas price by bufferPercent
+func applyGasPriceBuffer(gasPrice *big.Int, bufferPercent int) *big.Int {
+	buffered := new(big.Int).Mul(gasPrice, big.NewInt(int64(100+bufferPercent)))
+	return buffered.Div(buffered, big.NewInt(100))
+}
+
+// weiToGwei converts a gas price in wei to Gwei
+func weiToGwei(wei *big.Int) float64 {
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Float64()
+	return gwei
+}
+
+// readRetrier is implemented by backends that can retry a read through
+// transient RPC failures
+type readRetrier interface {
+	ReadWithRetry(operation string, fn func() error) error
+}
+
+// callContract performs a read-only contract call with a 10 second timeout
+// per attempt, retrying connection and rate-limit failures when the backend
+// supports it. Reverts are returned from the first attempt. Once ctx is
+// cancelled the call is abandoned with ErrScanCancelled instead of retried.
+func callContract(ctx context.Context, backend ContractCaller, operation string, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var result []byte
+	attempt := func() error {
+		if err := scanCancelled(ctx); err != nil {
+			return err
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		var err error
+		result, err = backend.CallContract(attemptCtx, call, blockNumber)
+		if err != nil && ctx.Err() != nil {
+			return scanCancelled(ctx) // the caller gave up, the node didn't fail
+		}
+		return err
+	}
+
+	if retrier, ok := backend.(readRetrier); ok {
+		return result, retrier.ReadWithRetry(operation, attempt)
+	}
+	return result, attempt()
+}
+
+// privateSender is implemented by backends that can submit transactions
+// through a private relay
+type privateSender interface {
+	SendPrivateTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// sendPublicTransaction submits a transaction through the connected RPC
+func sendPublicTransaction(ctx context.Context, backend ContractCaller, tx *types.Transaction) error {
+	return backend.SendTransaction(ctx, tx)
+}
+
+// sendProtectedTransaction submits a trade through the private relay when the
+// backend supports one, so it is not exposed to the public mempool
+func sendProtectedTransaction(ctx context.Context, backend ContractCaller, tx *types.Transaction) error {
+	if relay, ok := backend.(privateSender); ok {
+		return relay.SendPrivateTransaction(ctx, tx)
+	}
+	return backend.SendTransaction(ctx, tx)
+}
+
+// sendWithGasBump signs tx and submits it with send. When the node rejects it
+// as underpriced, it is re-signed at the same nonce with the gas price raised
+// by GAS_BUMP_PERCENT and resubmitted, up to GAS_BUMP_RETRIES times and never
+// above MAX_GAS_PRICE_GWEI. It returns the transaction the node accepted.
+func sendWithGasBump(
+	ctx context.Context,
+	backend ContractCaller,
+	signer Signer,
+	cfg *config.Config,
+	tx *types.Transaction,
+	send func(context.Context, ContractCaller, *types.Transaction) error,
+) (*types.Transaction, error) {
+	for attempt := 0; ; attempt++ {
+		signedTx, err := signer.SignTx(tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		}
+
+		err = send(ctx, backend, signedTx)
+		if err == nil {
+			return signedTx, nil
+		}
+		if !IsUnderpricedError(err) || attempt >= cfg.GasBumpRetries {
+			return nil, fmt.Errorf("failed to send transaction: %w", err)
+		}
+
+		gasPrice := applyGasPriceBuffer(tx.GasPrice(), cfg.GasBumpPercent)
+		gasPriceGwei := weiToGwei(gasPrice)
+		if cfg.MaxGasPriceGwei > 0 && gasPriceGwei > cfg.MaxGasPriceGwei {
+			return nil, fmt.Errorf("%w: underpriced transaction would need %.2f Gwei > %.2f Gwei",
+				ErrGasPriceTooHigh, gasPriceGwei, cfg.MaxGasPriceGwei)
+		}
+
+		slog.Warn("⛽ Transaction underpriced, resubmitting with higher gas",
+			"nonce", tx.Nonce(), "gas_price_gwei", gasPriceGwei, "attempt", attempt+1, "err", err)
+		tx = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+	}
+}
+
+// SendPrivateTransaction submits a signed transaction to the configured
+// private relay, falling back to the public RPC if the relay rejects it
+func (e *EthClient) SendPrivateTransaction(ctx context.Context, tx *types.Transaction) error {
+	if e.privateTxURL == "" {
+		return e.SendTransaction(ctx, tx)
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	relay, err := rpc.DialContext(ctx, e.privateTxURL)
+	if err == nil {
+		defer relay.Close()
+		err = relay.CallContext(ctx, nil, e.privateTxMethod, hexutil.Encode(raw))
+	}
+	if err != nil {
+		slog.Warn("⚠️ Private relay rejected transaction, falling back to public RPC", "tx", tx.Hash().Hex(), "err", err)
+		return e.SendTransaction(ctx, tx)
+	}
+
+	e.noteSent(tx)
+	slog.Info("🛡️ Submitted via private relay", "tx", tx.Hash().Hex())
+	return nil
+}
@@ -0,0 +1,168 @@
+// services/backtest.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+	"arbitrage-bot/models"
+	"arbitrage-bot/utils"
+)
+
+// BacktestReport summarizes what the enhanced scanner would have executed
+// over a range of historical blocks with the current thresholds
+type BacktestReport struct {
+	FromBlock uint64
+	ToBlock   uint64
+	Step      uint64
+
+	Blocks        int // blocks evaluated
+	Opportunities int
+	Errors        int // pair evaluations that failed, e.g. state pruned by the node
+
+	TotalNetProfitWBNB float64
+	BestNetProfitWBNB  float64
+	BestBlock          uint64
+	BestPair           string
+
+	Pairs map[string]*BacktestPairStats
+}
+
+// BacktestPairStats is one pair's share of a backtest
+type BacktestPairStats struct {
+	Opportunities     int
+	NetProfitWBNB     float64
+	BestProfitPercent float64
+}
+
+// PairNames returns the pairs that had opportunities, most profitable first
+func (r *BacktestReport) PairNames() []string {
+	names := make([]string, 0, len(r.Pairs))
+	for name := range r.Pairs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return r.Pairs[names[i]].NetProfitWBNB > r.Pairs[names[j]].NetProfitWBNB
+	})
+	return names
+}
+
+// NewBacktestService builds a quote-only ArbitrageService on top of backend,
+// typically an archive node. It can quote and backtest but has no wallet, so
+// it must not be used to execute.
+func NewBacktestService(backend ContractCaller, cfg *config.Config) *ArbitrageService {
+	tokenService := &TokenService{Backend: backend}
+	routerService := &RouterService{
+		Backend:      backend,
+		TokenService: tokenService,
+		Config:       cfg,
+		RouterABI:    contracts.RouterABI,
+	}
+
+	service := &ArbitrageService{
+		Backend:       backend,
+		TokenService:  tokenService,
+		RouterService: routerService,
+		V3Router: &V3RouterService{
+			Backend:   backend,
+			Config:    cfg,
+			QuoterABI: contracts.QuoterV2ABI,
+			Quoter:    common.HexToAddress(config.PancakeswapV3Quoter),
+		},
+		Config:     cfg,
+		TokenPairs: applyPairOverrides(models.InitializeTokenPairs(), cfg.PairOverrides),
+		Clock:      utils.SystemClock{},
+		DEXes:      DefaultDEXes(routerService),
+		poolStatus: make(map[string]bool),
+
+		enhancedStats: EnhancedStats{
+			CategoryStats: make(map[string]int),
+		},
+	}
+	service.Strategies = NewStrategies(service, cfg.Strategies)
+	return service
+}
+
+// Backtest replays the enhanced scanner's quoting path at every step-th block
+// from fromBlock to toBlock, recording the opportunities it would have
+// executed. Nothing is executed. Gas is priced at GAS_PRICE since the node's
+// suggested price is only available for the present.
+func (s *ArbitrageService) Backtest(fromBlock, toBlock, step uint64) (*BacktestReport, error) {
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("from block %d is after to block %d", fromBlock, toBlock)
+	}
+	if step == 0 {
+		return nil, fmt.Errorf("step must be at least 1")
+	}
+
+	report := &BacktestReport{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Step:      step,
+		Pairs:     make(map[string]*BacktestPairStats),
+	}
+
+	s.gasPriceMu.Lock()
+	s.scanGasPrice = big.NewInt(s.Config.GasPrice)
+	s.gasPriceMu.Unlock()
+	defer s.PinBlock(nil)
+
+	for block := fromBlock; block <= toBlock; block += step {
+		s.PinBlock(new(big.Int).SetUint64(block))
+		report.Blocks++
+
+		for _, pair := range s.TokenPairs {
+			candidate, err := s.findEnhancedCandidate(context.Background(), pair)
+			if err != nil {
+				report.Errors++
+				slog.Warn("⚠️ Backtest evaluation failed", "block", block, "pair", pair.Name, "err", err)
+				continue
+			}
+			if candidate == nil {
+				continue
+			}
+
+			report.record(block, pair.Name, candidate)
+			slog.Info("💰 Backtest opportunity", "block", block, "pair", pair.Name,
+				"route", candidate.Route(), "amount_wbnb", candidate.AmountWBNB,
+				"profit_pct", candidate.AdjustedProfit*100, "net_wbnb", candidate.NetProfitWBNB)
+		}
+
+		if block+step < block {
+			break // step would overflow past toBlock
+		}
+	}
+
+	return report, nil
+}
+
+// record adds an opportunity found at block to the report
+func (r *BacktestReport) record(block uint64, pairName string, candidate *models.Opportunity) {
+	net := candidate.NetProfitWBNB
+
+	r.Opportunities++
+	r.TotalNetProfitWBNB += net
+	if r.Opportunities == 1 || net > r.BestNetProfitWBNB {
+		r.BestNetProfitWBNB = net
+		r.BestBlock = block
+		r.BestPair = pairName
+	}
+
+	stats, exists := r.Pairs[pairName]
+	if !exists {
+		stats = &BacktestPairStats{}
+		r.Pairs[pairName] = stats
+	}
+	stats.Opportunities++
+	stats.NetProfitWBNB += net
+	if candidate.AdjustedProfit > stats.BestProfitPercent {
+		stats.BestProfitPercent = candidate.AdjustedProfit
+	}
+}
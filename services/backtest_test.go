@@ -0,0 +1,104 @@
+package services
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+	"arbitrage-bot/models"
+)
+
+// newTestBacktestService builds a backtest service on top of a mock backend
+func newTestBacktestService(t *testing.T, backend *mockBackend) *ArbitrageService {
+	t.Helper()
+
+	if err := contracts.Initialize(); err != nil {
+		t.Fatalf("failed to initialize ABIs: %v", err)
+	}
+	return NewBacktestService(backend, newTestConfig())
+}
+
+func TestBacktestQuotesAtEachBlock(t *testing.T) {
+	pancake := common.HexToAddress(config.PancakeswapRouter)
+	biswap := common.HexToAddress(config.BiswapRouter)
+
+	backend := newMockBackend()
+	backend.atBlock = map[uint64]map[common.Address]quoteFunc{
+		100: {pancake: rateQuote(1, 1), biswap: rateQuote(1, 1)},
+		102: {pancake: rateQuote(2, 1), biswap: rateQuote(101, 400)}, // 1% round trip
+		104: {pancake: rateQuote(1, 1), biswap: rateQuote(1, 1)},
+		// 106 is missing, like a block the node has pruned
+	}
+
+	service := newTestBacktestService(t, backend)
+	service.Config.MaxPriceImpact = 0.05
+	service.TokenPairs = []models.TokenPair{testPair()}
+
+	report, err := service.Backtest(100, 106, 2)
+	if err != nil {
+		t.Fatalf("Backtest returned error: %v", err)
+	}
+
+	if report.Blocks != 4 || report.Opportunities != 1 || report.Errors != 1 {
+		t.Fatalf("blocks/opportunities/errors = %d/%d/%d, want 4/1/1",
+			report.Blocks, report.Opportunities, report.Errors)
+	}
+	if report.BestBlock != 102 || report.BestPair != "WBNB-USDT-BUSD" {
+		t.Errorf("best = %s at block %d, want WBNB-USDT-BUSD at 102", report.BestPair, report.BestBlock)
+	}
+
+	// 0.5 WBNB -> 0.505 WBNB, less the 10% platform fee and 0.003 WBNB gas
+	if math.Abs(report.TotalNetProfitWBNB-0.0015) > 1e-9 {
+		t.Errorf("TotalNetProfitWBNB = %v, want 0.0015", report.TotalNetProfitWBNB)
+	}
+	if names := report.PairNames(); len(names) != 1 || report.Pairs[names[0]].Opportunities != 1 {
+		t.Errorf("pair stats = %v", report.Pairs)
+	}
+
+	// Quotes go back to the latest block afterwards
+	if block := service.RouterService.QuoteBlock(); block != nil {
+		t.Errorf("quote block left pinned at %s", block)
+	}
+}
+
+func TestBacktestRejectsInvalidRange(t *testing.T) {
+	service := newTestBacktestService(t, newMockBackend())
+
+	if _, err := service.Backtest(200, 100, 1); err == nil {
+		t.Error("expected an error when from is after to")
+	}
+	if _, err := service.Backtest(100, 200, 0); err == nil {
+		t.Error("expected an error for a zero step")
+	}
+}
+
+func TestSetQuoteBlockPinsQuotes(t *testing.T) {
+	backend := newMockBackend()
+	router := common.HexToAddress(config.PancakeswapRouter)
+	backend.quotes[router] = rateQuote(1, 1)
+	backend.atBlock = map[uint64]map[common.Address]quoteFunc{50: {router: rateQuote(3, 1)}}
+
+	service := newTestArbitrageService(t, backend)
+	path := []common.Address{common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT)}
+	amountIn := big.NewInt(1000)
+
+	latest, err := service.RouterService.GetAmountsOut(router, amountIn, path)
+	if err != nil {
+		t.Fatalf("GetAmountsOut returned error: %v", err)
+	}
+
+	// Pinning drops the cached latest-block quote
+	service.PinBlock(big.NewInt(50))
+	pinned, err := service.RouterService.GetAmountsOut(router, amountIn, path)
+	if err != nil {
+		t.Fatalf("GetAmountsOut returned error: %v", err)
+	}
+
+	if latest[1].Int64() != 1000 || pinned[1].Int64() != 3000 {
+		t.Errorf("latest/pinned out = %s/%s, want 1000/3000", latest[1], pinned[1])
+	}
+}
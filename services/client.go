@@ -4,39 +4,85 @@ package services
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
 
 	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
 )
 
 // EthClient wraps ethereum client with enhanced RPC management
 type EthClient struct {
-	Client     *ethclient.Client
-	Address    common.Address
-	PrivateKey *ecdsa.PrivateKey
-	Auth       *bind.TransactOpts
+	Client  *ethclient.Client
+	Address common.Address
+	Signer  Signer
+	Auth    *bind.TransactOpts
 
 	// RPC management
 	currentRPC   string
 	rpcEndpoints []string
 	rpcIndex     int
 	failedRPCs   map[string]time.Time
+	failedFile   string
 	mu           sync.RWMutex
 
 	// Connection health
 	lastHealthCheck time.Time
 	isHealthy       bool
+
+	// Health check and retry tuning
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
+	maxRetries          int
+	retryBaseDelay      time.Duration
+	retryMaxDelay       time.Duration
+
+	// Consecutive rate-limit responses from the current RPC
+	rateLimitHits            int
+	rateLimitBackoff         time.Duration
+	rateLimitSwitchThreshold int
+
+	// Token bucket per RPC endpoint from RPC_CALLS_PER_SECOND and
+	// RPC_CALL_LIMITS; endpoints without one are unlimited
+	limiters map[string]*rate.Limiter
+
+	// Optional private relay for trade submission
+	privateTxURL    string
+	privateTxMethod string
+
+	// Nonce after the wallet's last sent transaction, checked against the
+	// new RPC on a switch; 0 until a transaction is sent
+	nextNonce uint64
+
+	// Callbacks run after every successful RPC switch
+	switchHooks []func(from, to string)
+
+	// Every wallet executions rotate through; Address and Signer are the
+	// active one. nextNonce of inactive wallets is parked in walletNonces.
+	wallets      []Signer
+	walletCursor int
+	walletNonces map[common.Address]uint64
 }
 
 // NewEthClient creates a new Ethereum client with RPC failover
@@ -47,29 +93,36 @@ func NewEthClient(cfg *config.Config) (*EthClient, error) {
 		return nil, fmt.Errorf("no RPC endpoints configured")
 	}
 
-	log.Printf("🌐 Found %d RPC endpoints for failover", len(rpcEndpoints))
+	slog.Info("🌐 Found RPC endpoints for failover", "count", len(rpcEndpoints))
 
-	// Parse private key
-	privateKey, err := crypto.HexToECDSA(cfg.PrivateKey)
+	// Local keys (env or keystore) or external signer
+	signers, err := NewSigners(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
-	}
-
-	// Get address from private key
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("error casting public key to ECDSA")
+		return nil, err
 	}
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
 
 	// Create EthClient instance
 	ethClient := &EthClient{
-		Address:      address,
-		PrivateKey:   privateKey,
+		Address:      signers[0].Address(),
+		Signer:       signers[0],
+		wallets:      signers,
 		rpcEndpoints: rpcEndpoints,
 		rpcIndex:     0,
-		failedRPCs:   make(map[string]time.Time),
+		failedRPCs:   loadFailedRPCs(cfg.FailedRPCFile),
+		failedFile:   cfg.FailedRPCFile,
+
+		healthCheckInterval: cfg.HealthCheckInterval,
+		healthCheckTimeout:  cfg.HealthCheckTimeout,
+		maxRetries:          cfg.MaxRetries,
+		retryBaseDelay:      cfg.RetryBaseDelay,
+		retryMaxDelay:       cfg.RetryMaxDelay,
+
+		rateLimitBackoff:         cfg.RateLimitBackoff,
+		rateLimitSwitchThreshold: cfg.RateLimitSwitchThreshold,
+		limiters:                 newRPCLimiters(cfg, rpcEndpoints),
+
+		privateTxURL:    cfg.PrivateTxURL,
+		privateTxMethod: cfg.PrivateTxMethod,
 	}
 
 	// Try to connect to first working RPC
@@ -84,10 +137,63 @@ func NewEthClient(cfg *config.Config) (*EthClient, error) {
 		return nil, fmt.Errorf("failed to setup transaction auth: %v", err)
 	}
 
-	log.Printf("✅ Connected to BSC via: %s", getShortRPCName(ethClient.currentRPC))
+	slog.Info("✅ Connected to BSC", "rpc", getShortRPCName(ethClient.currentRPC))
 	return ethClient, nil
 }
 
+// newRPCLimiters builds a token bucket for every endpoint with a call limit.
+// The burst is one second's worth of calls so a scan can fan out briefly.
+func newRPCLimiters(cfg *config.Config, rpcEndpoints []string) map[string]*rate.Limiter {
+	limiters := make(map[string]*rate.Limiter)
+	for _, rpcURL := range rpcEndpoints {
+		perSecond := cfg.RPCCallLimit(rpcURL)
+		if perSecond <= 0 {
+			continue
+		}
+
+		burst := int(perSecond)
+		if burst < 1 {
+			burst = 1
+		}
+		limiters[rpcURL] = rate.NewLimiter(rate.Limit(perSecond), burst)
+	}
+	return limiters
+}
+
+// loadPrivateKey returns the signing key from PRIVATE_KEY or, when configured,
+// by decrypting a go-ethereum keystore file
+func loadPrivateKey(cfg *config.Config) (*ecdsa.PrivateKey, error) {
+	if cfg.KeystorePath == "" {
+		privateKey, err := crypto.HexToECDSA(cfg.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %v", err)
+		}
+		return privateKey, nil
+	}
+
+	keyJSON, err := os.ReadFile(cfg.KeystorePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+
+	password := cfg.KeystorePassword
+	if cfg.KeystorePasswordFile != "" {
+		data, err := os.ReadFile(cfg.KeystorePasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore password file: %v", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore: %v", err)
+	}
+
+	slog.Info("🔐 Loaded wallet from keystore", "address", key.Address.Hex())
+	return key.PrivateKey, nil
+}
+
 // collectRPCEndpoints extracts all RPC URLs from config
 func collectRPCEndpoints(cfg *config.Config) []string {
 	var endpoints []string
@@ -114,7 +220,7 @@ func collectRPCEndpoints(cfg *config.Config) []string {
 
 	// Add fallback public RPCs if none configured
 	if len(endpoints) == 0 {
-		log.Println("⚠️ No RPC configured in config, using fallback public endpoints")
+		slog.Warn("⚠️ No RPC configured in config, using fallback public endpoints")
 		endpoints = []string{
 			"https://bsc-dataseed1.defibit.io/",
 			"https://bsc-dataseed1.ninicoin.io/",
@@ -135,11 +241,14 @@ func (e *EthClient) connectToWorkingRPC() error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	// Persist whatever changed in the failed set on the way out
+	defer e.saveFailedRPCs()
+
 	// Clean up expired failed RPCs (retry after 5 minutes)
 	for rpc, failTime := range e.failedRPCs {
 		if time.Since(failTime) > 5*time.Minute {
 			delete(e.failedRPCs, rpc)
-			log.Printf("🔄 RPC %s eligible for retry", getShortRPCName(rpc))
+			slog.Info("🔄 RPC eligible for retry", "rpc", getShortRPCName(rpc))
 		}
 	}
 
@@ -157,11 +266,11 @@ func (e *EthClient) connectToWorkingRPC() error {
 		}
 
 		attemptsCount++
-		log.Printf("🔗 Attempting connection to %s...", getShortRPCName(rpcURL))
+		slog.Debug("🔗 Attempting connection", "rpc", getShortRPCName(rpcURL))
 
 		client, err := ethclient.Dial(rpcURL)
 		if err != nil {
-			log.Printf("❌ Failed to connect to %s: %v", getShortRPCName(rpcURL), err)
+			slog.Error("❌ Failed to connect", "rpc", getShortRPCName(rpcURL), "err", err)
 			e.failedRPCs[rpcURL] = time.Now()
 			lastErr = err
 			continue
@@ -173,7 +282,7 @@ func (e *EthClient) connectToWorkingRPC() error {
 		cancel()
 
 		if err != nil {
-			log.Printf("❌ RPC %s failed health check: %v", getShortRPCName(rpcURL), err)
+			slog.Error("❌ RPC failed health check", "rpc", getShortRPCName(rpcURL), "err", err)
 			client.Close()
 			e.failedRPCs[rpcURL] = time.Now()
 			lastErr = err
@@ -191,7 +300,7 @@ func (e *EthClient) connectToWorkingRPC() error {
 		e.isHealthy = true
 		e.lastHealthCheck = time.Now()
 
-		log.Printf("✅ Successfully connected to %s", getShortRPCName(rpcURL))
+		slog.Info("✅ Successfully connected", "rpc", getShortRPCName(rpcURL))
 		return nil
 	}
 
@@ -202,14 +311,19 @@ func (e *EthClient) connectToWorkingRPC() error {
 	return fmt.Errorf("failed to connect to any RPC endpoint after %d attempts. Last error: %v", attemptsCount, lastErr)
 }
 
-// SwitchRPC switches to the next available RPC endpoint
+// SwitchRPC switches to the next available RPC endpoint. Once connected it
+// rebuilds the transaction auth, resyncs the wallet nonce from the new node
+// and runs the OnRPCSwitch callbacks.
 func (e *EthClient) SwitchRPC() error {
-	log.Printf("🔄 Switching RPC from %s due to connection issues...", getShortRPCName(e.currentRPC))
+	from := e.currentRPC
+	slog.Warn("🔄 Switching RPC due to connection issues", "from", getShortRPCName(from))
 
 	// Mark current RPC as failed
 	e.mu.Lock()
 	e.failedRPCs[e.currentRPC] = time.Now()
 	e.isHealthy = false
+	e.rateLimitHits = 0
+	e.saveFailedRPCs()
 	e.mu.Unlock()
 
 	// Try to connect to next working RPC
@@ -224,20 +338,165 @@ func (e *EthClient) SwitchRPC() error {
 		return fmt.Errorf("failed to setup auth after RPC switch: %v", err)
 	}
 
-	log.Printf("✅ Successfully switched to %s", getShortRPCName(e.currentRPC))
+	e.resyncNonce()
+
+	slog.Info("✅ Successfully switched RPC", "rpc", getShortRPCName(e.currentRPC))
+	e.runSwitchHooks(from, e.currentRPC)
 	return nil
 }
 
+// OnRPCSwitch registers a callback run after every successful RPC switch with
+// the old and new endpoint URLs, for anything that has to react to failover
+func (e *EthClient) OnRPCSwitch(hook func(from, to string)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.switchHooks = append(e.switchHooks, hook)
+}
+
+// runSwitchHooks calls the OnRPCSwitch callbacks in registration order
+func (e *EthClient) runSwitchHooks(from, to string) {
+	e.mu.RLock()
+	hooks := append([]func(from, to string){}, e.switchHooks...)
+	e.mu.RUnlock()
+
+	for _, hook := range hooks {
+		hook(from, to)
+	}
+}
+
+// noteSent records a transaction the wallet sent, so a switch can tell
+// whether the new RPC has seen it
+func (e *EthClient) noteSent(tx *types.Transaction) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if tx.Nonce()+1 > e.nextNonce {
+		e.nextNonce = tx.Nonce() + 1
+	}
+}
+
+// resyncNonce reads the wallet's pending nonce from the new RPC. A node that
+// hasn't seen the transactions sent through the old one reports a lower
+// nonce; those transactions may still be propagating, or may be lost.
+func (e *EthClient) resyncNonce() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.healthCheckTimeout)
+	defer cancel()
+
+	pending, err := e.PendingNonceAt(ctx, e.Address)
+	if err != nil {
+		slog.Warn("⚠️ Failed to resync nonce after RPC switch", "err", err)
+		return
+	}
+
+	e.mu.Lock()
+	expected := e.nextNonce
+	if pending > e.nextNonce {
+		e.nextNonce = pending
+	}
+	e.mu.Unlock()
+
+	if pending < expected {
+		slog.Warn("🔢 New RPC is behind on the wallet's transactions",
+			"pending_nonce", pending, "expected_nonce", expected)
+		return
+	}
+	slog.Info("🔢 Nonce resynced", "pending_nonce", pending)
+}
+
+// Wallets returns the address of every configured wallet
+func (e *EthClient) Wallets() []common.Address {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if len(e.wallets) == 0 {
+		return []common.Address{e.Address}
+	}
+	addresses := make([]common.Address, len(e.wallets))
+	for i, wallet := range e.wallets {
+		addresses[i] = wallet.Address()
+	}
+	return addresses
+}
+
+// ActiveAddress returns the wallet transactions are currently sent from
+func (e *EthClient) ActiveAddress() common.Address {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Address
+}
+
+// NextWallet activates the next wallet in round-robin order and returns its
+// address. It must only be called between executions, since everything that
+// signs or reads balances uses the active wallet.
+func (e *EthClient) NextWallet() common.Address {
+	e.mu.Lock()
+	if len(e.wallets) <= 1 {
+		e.mu.Unlock()
+		return e.ActiveAddress()
+	}
+	wallet := e.wallets[e.walletCursor%len(e.wallets)]
+	e.walletCursor++
+	e.mu.Unlock()
+
+	e.activateWallet(wallet)
+	return wallet.Address()
+}
+
+// UseWallet activates the configured wallet with the given address, reporting
+// false if there is none
+func (e *EthClient) UseWallet(address common.Address) bool {
+	if e.ActiveAddress() == address {
+		return true
+	}
+
+	e.mu.RLock()
+	var wallet Signer
+	for _, candidate := range e.wallets {
+		if candidate.Address() == address {
+			wallet = candidate
+		}
+	}
+	e.mu.RUnlock()
+
+	if wallet == nil {
+		return false
+	}
+	e.activateWallet(wallet)
+	return true
+}
+
+// activateWallet makes wallet the signer, parking the outgoing wallet's nonce
+func (e *EthClient) activateWallet(wallet Signer) {
+	e.mu.Lock()
+	if e.walletNonces == nil {
+		e.walletNonces = make(map[common.Address]uint64)
+	}
+	e.walletNonces[e.Address] = e.nextNonce
+	e.Signer = wallet
+	e.Address = wallet.Address()
+	e.nextNonce = e.walletNonces[e.Address]
+	e.mu.Unlock()
+
+	e.setupAuth()
+}
+
 // setupAuth creates transaction auth for the current connection
 func (e *EthClient) setupAuth() error {
-	chainID := big.NewInt(56) // BSC chain ID
-	auth, err := bind.NewKeyedTransactorWithChainID(e.PrivateKey, chainID)
-	if err != nil {
-		return err
+	auth := &bind.TransactOpts{
+		From: e.Address,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != e.Address {
+				return nil, bind.ErrNotAuthorized
+			}
+			return e.Signer.SignTx(tx)
+		},
+		Context: context.Background(),
 	}
 
-	auth.GasLimit = uint64(600000)         // Default gas limit
-	auth.GasPrice = big.NewInt(5000000000) // 5 Gwei
+	// GasPrice and GasLimit stay unset so bound contract calls price each
+	// transaction from the node and estimate its gas when sent, rather than
+	// reusing a value fixed at connect time
 
 	e.Auth = auth
 	return nil
@@ -250,16 +509,22 @@ func (e *EthClient) HealthCheck() bool {
 	isHealthy := e.isHealthy
 	e.mu.RUnlock()
 
-	// Only check every 30 seconds
-	if time.Since(lastCheck) < 30*time.Second && isHealthy {
+	// Reuse a healthy result for half the monitoring interval
+	if time.Since(lastCheck) < e.healthCheckInterval/2 && isHealthy {
 		return true
 	}
 
 	// Perform health check
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), e.healthCheckTimeout)
 	defer cancel()
 
-	_, err := e.Client.NetworkID(ctx)
+	_, err := e.NetworkID(ctx)
+
+	// A rate-limited node still answered, so it is alive
+	if IsRateLimitError(err) {
+		slog.Warn("🚦 Health check rate limited, keeping RPC in rotation", "rpc", getShortRPCName(e.currentRPC))
+		err = nil
+	}
 
 	e.mu.Lock()
 	e.lastHealthCheck = time.Now()
@@ -267,7 +532,7 @@ func (e *EthClient) HealthCheck() bool {
 	e.mu.Unlock()
 
 	if err != nil {
-		log.Printf("⚠️ Health check failed for %s: %v", getShortRPCName(e.currentRPC), err)
+		slog.Warn("⚠️ Health check failed", "rpc", getShortRPCName(e.currentRPC), "err", err)
 		return false
 	}
 
@@ -280,39 +545,37 @@ func (e *EthClient) AutoSwitchOnError(err error) bool {
 		return false
 	}
 
-	errorStr := strings.ToLower(err.Error())
+	// A rate-limited node is alive; keep it unless it keeps rate-limiting
+	if IsRateLimitError(err) {
+		hits := e.recordRateLimit()
+		if hits < e.rateLimitSwitchThreshold {
+			slog.Warn("🚦 Rate limited, backing off instead of switching",
+				"rpc", getShortRPCName(e.currentRPC), "hits", hits, "threshold", e.rateLimitSwitchThreshold)
+			return false
+		}
 
-	// Check for connection-related errors
-	connectionErrors := []string{
-		"connection refused",
-		"connection reset",
-		"timeout",
-		"dial tcp",
-		"i/o timeout",
-		"network is unreachable",
-		"no such host",
-		"connection timed out",
-		"context deadline exceeded",
-		"eof",
-		"broken pipe",
+		slog.Warn("🚦 Rate limited repeatedly, attempting RPC switch", "hits", hits)
+		if switchErr := e.SwitchRPC(); switchErr != nil {
+			slog.Error("❌ Auto RPC switch failed", "err", switchErr)
+			return false
+		}
+		return true
 	}
 
-	for _, connErr := range connectionErrors {
-		if strings.Contains(errorStr, connErr) {
-			log.Printf("🔄 Detected connection error, attempting RPC switch: %v", err)
-
-			switchErr := e.SwitchRPC()
-			if switchErr != nil {
-				log.Printf("❌ Auto RPC switch failed: %v", switchErr)
-				return false
-			}
+	// Reverts and unknown errors come from a working node; only a
+	// connection failure is worth moving to another RPC
+	if ClassifyError(err) != ErrorConnection {
+		return false
+	}
 
-			log.Printf("✅ Auto RPC switch successful")
-			return true
-		}
+	slog.Warn("🔄 Detected connection error, attempting RPC switch", "err", err)
+	if switchErr := e.SwitchRPC(); switchErr != nil {
+		slog.Error("❌ Auto RPC switch failed", "err", switchErr)
+		return false
 	}
 
-	return false
+	slog.Info("✅ Auto RPC switch successful")
+	return true
 }
 
 // GetCurrentRPCInfo returns information about current RPC
@@ -341,40 +604,46 @@ func (e *EthClient) LogConnectionStatus() {
 		status = "🔴 Unhealthy"
 	}
 
-	log.Printf("🌐 RPC Status: %s | Current: %s (%d/%d) | Failed: %d",
-		status, getShortRPCName(currentRPC), rpcIndex, totalRPCs, failedCount)
+	slog.Info("🌐 RPC status", "status", status, "rpc", getShortRPCName(currentRPC),
+		"index", rpcIndex, "total", totalRPCs, "failed", failedCount)
 }
 
 // WithRetry executes a function with automatic retry and RPC switching
 func (e *EthClient) WithRetry(operation string, fn func() error) error {
-	maxRetries := 3
-	baseDelay := 2 * time.Second
+	maxRetries := e.maxRetries
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		// Check RPC health before operation
 		if !e.HealthCheck() {
-			log.Printf("⚠️ RPC unhealthy before %s, attempting switch...", operation)
+			slog.Warn("⚠️ RPC unhealthy, attempting switch", "operation", operation)
 			if err := e.SwitchRPC(); err != nil {
-				log.Printf("❌ RPC switch failed: %v", err)
+				slog.Error("❌ RPC switch failed", "err", err)
 			}
 		}
 
 		// Execute the operation
 		err := fn()
 		if err == nil {
+			e.resetRateLimit()
+
 			// Success
 			if attempt > 0 {
-				log.Printf("✅ %s succeeded after %d retries", operation, attempt)
+				slog.Info("✅ Operation succeeded after retries", "operation", operation, "retries", attempt)
 			}
 			return nil
 		}
 
+		// Asking again won't turn an address into a token
+		if errors.Is(err, ErrNotERC20) {
+			return err
+		}
+
 		// Log the error
-		log.Printf("❌ %s attempt %d/%d failed: %v", operation, attempt+1, maxRetries, err)
+		slog.Error("❌ Operation attempt failed", "operation", operation, "attempt", attempt+1, "max", maxRetries, "err", err)
 
 		// Check if this is a connection error that warrants RPC switching
 		if e.AutoSwitchOnError(err) {
-			log.Printf("🔄 RPC switched due to connection error in %s", operation)
+			slog.Info("🔄 RPC switched due to connection error", "operation", operation)
 			// Don't count RPC switch attempts against retry limit
 			continue
 		}
@@ -384,15 +653,108 @@ func (e *EthClient) WithRetry(operation string, fn func() error) error {
 			return fmt.Errorf("%s failed after %d attempts: %v", operation, maxRetries, err)
 		}
 
-		// Wait before retrying (exponential backoff)
-		delay := time.Duration(attempt+1) * baseDelay
-		log.Printf("⏳ Retrying %s in %v...", operation, delay)
+		// Wait before retrying (exponential backoff with jitter), longer
+		// when the node is rate limiting us
+		delay := e.retryDelay(attempt)
+		if IsRateLimitError(err) {
+			delay += e.rateLimitDelay()
+		}
+		slog.Info("⏳ Retrying", "operation", operation, "delay", delay)
 		time.Sleep(delay)
 	}
 
 	return fmt.Errorf("%s failed after all retries", operation)
 }
 
+// ReadWithRetry retries a read-only call through transient RPC failures.
+// Unlike WithRetry it skips the per-attempt health check and gives up at once
+// on reverts and unknown errors, since repeating a call the contract rejected
+// returns the same answer and must not push the client onto another RPC.
+func (e *EthClient) ReadWithRetry(operation string, fn func() error) error {
+	maxRetries := e.maxRetries
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		err = fn()
+		if err == nil {
+			if attempt > 0 {
+				e.resetRateLimit()
+				slog.Info("✅ Read succeeded after retries", "operation", operation, "retries", attempt)
+			}
+			return nil
+		}
+
+		category := ClassifyError(err)
+		if category != ErrorConnection && category != ErrorRateLimit {
+			return err
+		}
+		if attempt == maxRetries-1 {
+			break
+		}
+
+		slog.Warn("⚠️ Read failed, retrying", "operation", operation, "attempt", attempt+1,
+			"max", maxRetries, "category", category.String(), "err", err)
+
+		// Switched RPCs are retried straight away; otherwise back off
+		if e.AutoSwitchOnError(err) {
+			continue
+		}
+		delay := e.retryDelay(attempt)
+		if category == ErrorRateLimit {
+			delay += e.rateLimitDelay()
+		}
+		time.Sleep(delay)
+	}
+
+	return fmt.Errorf("%s failed after %d attempts: %v", operation, maxRetries, err)
+}
+
+// retryDelay returns baseDelay * 2^attempt, capped at the max delay, with
+// ±20% jitter so concurrent retries don't hit the next RPC in lockstep
+func (e *EthClient) retryDelay(attempt int) time.Duration {
+	delay := e.retryBaseDelay
+	for i := 0; i < attempt && delay < e.retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > e.retryMaxDelay {
+		delay = e.retryMaxDelay
+	}
+
+	jitter := (rand.Float64()*0.4 - 0.2) * float64(delay)
+	return delay + time.Duration(jitter)
+}
+
+// recordRateLimit counts a rate-limit response from the current RPC
+func (e *EthClient) recordRateLimit() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.rateLimitHits++
+	return e.rateLimitHits
+}
+
+// resetRateLimit clears the rate-limit counter after a successful call
+func (e *EthClient) resetRateLimit() {
+	e.mu.Lock()
+	e.rateLimitHits = 0
+	e.mu.Unlock()
+}
+
+// rateLimitDelay grows the rate-limit backoff with each consecutive hit
+func (e *EthClient) rateLimitDelay() time.Duration {
+	e.mu.RLock()
+	hits := e.rateLimitHits
+	e.mu.RUnlock()
+
+	if hits < 1 {
+		hits = 1
+	}
+	return time.Duration(hits) * e.rateLimitBackoff
+}
+
 // GetTokenBalanceWithRetry gets token balance with automatic retry
 func (e *EthClient) GetTokenBalanceWithRetry(tokenAddr, walletAddr common.Address) (*big.Int, error) {
 	var balance *big.Int
@@ -415,13 +777,133 @@ func (e *EthClient) GetNativeBalanceWithRetry(walletAddr common.Address) (*big.I
 		defer cancel()
 
 		var err error
-		balance, err = e.Client.BalanceAt(ctx, walletAddr, nil)
+		balance, err = e.BalanceAt(ctx, walletAddr, nil)
 		return err
 	})
 
 	return balance, err
 }
 
+// NativeBalance is the GetBalances key of the wallet's native BNB balance
+var NativeBalance = common.Address{}
+
+// multicallCall is one call of a Multicall3 aggregate3 batch
+type multicallCall struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicallResult is the outcome of one call of an aggregate3 batch
+type multicallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// GetBalances fetches the wallet's native BNB balance, keyed by NativeBalance,
+// and its balance of each token in a single Multicall3 round trip. Tokens
+// whose balanceOf fails are left out of the map.
+func (e *EthClient) GetBalances(tokens []common.Address, wallet common.Address) (map[common.Address]*big.Int, error) {
+	var balances map[common.Address]*big.Int
+
+	err := e.WithRetry("GetBalances", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var err error
+		balances, err = fetchBalances(ctx, e, tokens, wallet)
+		return err
+	})
+
+	return balances, err
+}
+
+// fetchBalances batches getEthBalance and each token's balanceOf into one
+// aggregate3 call. Only the token calls are allowed to fail.
+func fetchBalances(ctx context.Context, backend ContractCaller, tokens []common.Address, wallet common.Address) (map[common.Address]*big.Int, error) {
+	multicall := common.HexToAddress(config.Multicall3)
+
+	nativeCall, err := contracts.Multicall3ABI.Pack("getEthBalance", wallet)
+	if err != nil {
+		return nil, err
+	}
+	balanceOf, err := contracts.ERC20ABI.Pack("balanceOf", wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := []multicallCall{{Target: multicall, CallData: nativeCall}}
+	for _, token := range tokens {
+		calls = append(calls, multicallCall{Target: token, AllowFailure: true, CallData: balanceOf})
+	}
+
+	data, err := contracts.Multicall3ABI.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := backend.CallContract(ctx, ethereum.CallMsg{To: &multicall, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("multicall failed: %w", err)
+	}
+
+	var results []multicallResult
+	if err := contracts.Multicall3ABI.UnpackIntoInterface(&results, "aggregate3", result); err != nil {
+		return nil, fmt.Errorf("failed to decode multicall result: %v", err)
+	}
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", len(results), len(calls))
+	}
+
+	balances := make(map[common.Address]*big.Int, len(results))
+	for i, call := range results {
+		if !call.Success || len(call.ReturnData) < 32 {
+			if i == 0 {
+				return nil, fmt.Errorf("multicall getEthBalance returned no balance")
+			}
+			slog.Debug("Token balance not fetched", "token", tokens[i-1].Hex())
+			continue
+		}
+
+		key := NativeBalance
+		if i > 0 {
+			key = tokens[i-1]
+		}
+		balances[key] = new(big.Int).SetBytes(call.ReturnData[:32])
+	}
+	return balances, nil
+}
+
+// loadFailedRPCs restores failure timestamps saved by a previous run so
+// endpoints that were down stay skipped until their 5-minute expiry
+func loadFailedRPCs(path string) map[string]time.Time {
+	failed := make(map[string]time.Time)
+	if path == "" {
+		return failed
+	}
+
+	if _, err := readJSONFile(path, &failed); err != nil {
+		slog.Warn("⚠️ Ignoring failed RPC state", "path", path, "err", err)
+		return make(map[string]time.Time)
+	}
+
+	if len(failed) > 0 {
+		slog.Info("📂 Loaded failed RPC state", "count", len(failed), "path", path)
+	}
+	return failed
+}
+
+// saveFailedRPCs persists the failed RPC set. Callers must hold e.mu.
+func (e *EthClient) saveFailedRPCs() {
+	if e.failedFile == "" {
+		return
+	}
+
+	if err := writeJSONFile(e.failedFile, e.failedRPCs); err != nil {
+		slog.Warn("⚠️ Failed to save failed RPC state", "err", err)
+	}
+}
+
 // Internal helper functions
 func (e *EthClient) getTokenBalanceOnce(tokenAddr, walletAddr common.Address) (*big.Int, error) {
 	// ERC20 balanceOf function signature
@@ -438,12 +920,15 @@ func (e *EthClient) getTokenBalanceOnce(tokenAddr, walletAddr common.Address) (*
 		Data: callData,
 	}
 
-	result, err := e.Client.CallContract(ctx, callMsg, nil)
+	result, err := e.CallContract(ctx, callMsg, nil)
 	if err != nil {
 		return nil, err
 	}
+	if len(result) < 32 {
+		return nil, emptyResultError(e, tokenAddr, "balanceOf")
+	}
 
-	balance := new(big.Int).SetBytes(result)
+	balance := new(big.Int).SetBytes(result[:32])
 	return balance, nil
 }
 
@@ -487,12 +972,125 @@ func getShortRPCName(rpcURL string) string {
 	} else if strings.Contains(rpcURL, "bscrpc.com") {
 		return "BSC-RPC"
 	}
+
+	// Unknown provider: show the host so custom nodes are distinguishable
+	if parsed, err := url.Parse(rpcURL); err == nil && parsed.Hostname() != "" {
+		return parsed.Hostname()
+	}
 	return "Custom"
 }
 
+// ErrorCategory groups errors by how the scan loop should react to them
+type ErrorCategory int
+
+const (
+	// ErrorUnknown is anything not recognised below
+	ErrorUnknown ErrorCategory = iota
+	// ErrorConnection means the RPC node is unreachable or dropping requests
+	ErrorConnection
+	// ErrorRevert means a call reached the chain and the contract rejected
+	// it, e.g. insufficient liquidity. This is a market condition, not an
+	// RPC problem.
+	ErrorRevert
+	// ErrorRateLimit means the node is healthy but throttling us
+	ErrorRateLimit
+)
+
+// String returns the category name for logging
+func (c ErrorCategory) String() string {
+	switch c {
+	case ErrorConnection:
+		return "connection"
+	case ErrorRevert:
+		return "revert"
+	case ErrorRateLimit:
+		return "rate-limit"
+	default:
+		return "unknown"
+	}
+}
+
+// ClassifyError sorts an error into an ErrorCategory
+func ClassifyError(err error) ErrorCategory {
+	switch {
+	case err == nil:
+		return ErrorUnknown
+	case IsRateLimitError(err):
+		return ErrorRateLimit
+	case IsRevertError(err):
+		return ErrorRevert
+	case IsConnectionError(err):
+		return ErrorConnection
+	default:
+		return ErrorUnknown
+	}
+}
+
+// IsRevertError checks if an error is a contract rejecting a call, as opposed
+// to the call failing to reach the chain
+func IsRevertError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrPairNotFound) {
+		return true
+	}
+
+	errorStr := strings.ToLower(err.Error())
+	revertErrors := []string{
+		"execution reverted",
+		"insufficient_liquidity",
+		"insufficient liquidity",
+		"insufficient_output_amount",
+		"insufficient_input_amount",
+		"invalid opcode",
+		"zero output amount",
+	}
+
+	for _, revertErr := range revertErrors {
+		if strings.Contains(errorStr, revertErr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsSlippageRevert reports whether a call reverted because the trade no
+// longer met its minimum output, i.e. the price moved after it was quoted
+func IsSlippageRevert(err error) bool {
+	if !IsRevertError(err) && !errors.Is(err, ErrPreflightReverted) {
+		return false
+	}
+
+	reason := strings.ToLower(RevertReason(err))
+	for _, slippage := range []string{"insufficient_output_amount", "too little received", "slippage"} {
+		if strings.Contains(reason, slippage) {
+			return true
+		}
+	}
+	return false
+}
+
+// RevertReason returns the reason a call reverted, decoding the Error(string)
+// payload the node attaches as error data when the message doesn't carry it
+func RevertReason(err error) string {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if raw, decodeErr := hexutil.Decode(data); decodeErr == nil {
+				if reason, unpackErr := abi.UnpackRevert(raw); unpackErr == nil {
+					return reason
+				}
+			}
+		}
+	}
+	return err.Error()
+}
+
 // IsConnectionError checks if an error is connection-related (exported for use in other packages)
 func IsConnectionError(err error) bool {
-	if err == nil {
+	if err == nil || errors.Is(err, ErrScanCancelled) || IsRateLimitError(err) || IsRevertError(err) {
 		return false
 	}
 
@@ -519,3 +1117,52 @@ func IsConnectionError(err error) bool {
 
 	return false
 }
+
+// IsRateLimitError checks if an error is an RPC rate-limit response (HTTP 429
+// or a JSON-RPC -32005 limit-exceeded error) rather than a dead node. A bare
+// "limit exceeded" is not enough: gas and size limits say that too.
+func IsRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32005 {
+		return true
+	}
+
+	errorStr := strings.ToLower(err.Error())
+	rateLimitErrors := []string{
+		"429 too many requests",
+		"too many requests",
+		"rate limit",
+		"-32005",
+		"request rate exceeded",
+		"exceeded the quota",
+	}
+
+	for _, rateErr := range rateLimitErrors {
+		if strings.Contains(errorStr, rateErr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsUnderpricedError checks if a node rejected a transaction for its gas
+// price, either below the pool minimum or too low to replace a pending
+// transaction with the same nonce
+func IsUnderpricedError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	errorStr := strings.ToLower(err.Error())
+	return strings.Contains(errorStr, "replacement transaction underpriced") ||
+		strings.Contains(errorStr, "transaction underpriced")
+}
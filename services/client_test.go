@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+)
+
+func TestRetryDelayBackoffWithJitter(t *testing.T) {
+	client := &EthClient{
+		retryBaseDelay: 2 * time.Second,
+		retryMaxDelay:  10 * time.Second,
+	}
+
+	tests := []struct {
+		attempt int
+		nominal time.Duration
+	}{
+		{0, 2 * time.Second},
+		{1, 4 * time.Second},
+		{2, 8 * time.Second},
+		{3, 10 * time.Second}, // capped
+		{10, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			delay := client.retryDelay(tt.attempt)
+			low := time.Duration(float64(tt.nominal) * 0.8)
+			high := time.Duration(float64(tt.nominal) * 1.2)
+			if delay < low || delay > high {
+				t.Fatalf("retryDelay(%d) = %v, want within [%v, %v]", tt.attempt, delay, low, high)
+			}
+		}
+	}
+}
+
+// rpcCodeError is a JSON-RPC error response with a code
+type rpcCodeError struct {
+	code    int
+	message string
+}
+
+func (e rpcCodeError) Error() string  { return e.message }
+func (e rpcCodeError) ErrorCode() int { return e.code }
+
+func TestIsRateLimitError(t *testing.T) {
+	tests := []struct {
+		err        error
+		rateLimit  bool
+		connection bool
+	}{
+		{errors.New("429 Too Many Requests: {\"jsonrpc\":\"2.0\"}"), true, false},
+		{rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"}, true, false},
+		{fmt.Errorf("eth_call: %w", rpcCodeError{code: -32005, message: "limit exceeded"}), true, false},
+		{errors.New("{\"code\":-32005,\"message\":\"request rate exceeded\"}"), true, false},
+		{errors.New("Rate limit reached for this endpoint"), true, false},
+		{errors.New("gas limit exceeded"), false, false},
+		{errors.New("exceeds block gas limit"), false, false},
+		{errors.New("response size limit exceeded"), false, false},
+		{errors.New("dial tcp 1.2.3.4:443: connection refused"), false, true},
+		{errors.New("context deadline exceeded"), false, true},
+		{errors.New("execution reverted"), false, false},
+		{nil, false, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRateLimitError(tt.err); got != tt.rateLimit {
+			t.Errorf("IsRateLimitError(%v) = %v, want %v", tt.err, got, tt.rateLimit)
+		}
+		if got := IsConnectionError(tt.err); got != tt.connection {
+			t.Errorf("IsConnectionError(%v) = %v, want %v", tt.err, got, tt.connection)
+		}
+	}
+}
+
+func TestGetShortRPCName(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://bsc-dataseed1.defibit.io/", "DefiBit"},
+		{"https://rpc.ankr.com/bsc", "Ankr"},
+		{"https://mynode.example.com:8545/rpc", "mynode.example.com"},
+		{"ws://10.0.0.5:8546", "10.0.0.5"},
+		{"not a url", "Custom"},
+	}
+
+	for _, tt := range tests {
+		if got := getShortRPCName(tt.url); got != tt.want {
+			t.Errorf("getShortRPCName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorCategory
+	}{
+		{errors.New("dial tcp 1.2.3.4:443: connection refused"), ErrorConnection},
+		{errors.New("failed to call getAmountsOut: context deadline exceeded"), ErrorConnection},
+		{errors.New("429 Too Many Requests"), ErrorRateLimit},
+		{errors.New("failed to call getAmountsOut: execution reverted: PancakeLibrary: INSUFFICIENT_LIQUIDITY"), ErrorRevert},
+		{errors.New("zero output amount from getAmountsOut, possible liquidity issue"), ErrorRevert},
+		{fmt.Errorf("lookup failed: %w", ErrPairNotFound), ErrorRevert},
+		{errors.New("execution reverted: timeout"), ErrorRevert},
+		{errors.New("scan timed out"), ErrorUnknown},
+		{nil, ErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+
+	// A revert must never count as a connection error, even if its reason
+	// happens to contain a connection keyword
+	if IsConnectionError(errors.New("execution reverted: timeout")) {
+		t.Error("IsConnectionError treated a revert as a connection error")
+	}
+}
+
+func TestAutoSwitchOnErrorIgnoresNonConnectionErrors(t *testing.T) {
+	client := &EthClient{
+		currentRPC: "https://bsc-dataseed1.defibit.io/",
+		failedRPCs: make(map[string]time.Time),
+	}
+
+	for _, err := range []error{
+		errors.New("failed to call getAmountsOut: execution reverted: PancakeLibrary: INSUFFICIENT_LIQUIDITY"),
+		errors.New("execution reverted: timeout"),
+		fmt.Errorf("lookup failed: %w", ErrPairNotFound),
+		errors.New("scan timed out"),
+		nil,
+	} {
+		if client.AutoSwitchOnError(err) {
+			t.Errorf("AutoSwitchOnError(%v) switched RPC", err)
+		}
+	}
+
+	if len(client.failedRPCs) != 0 {
+		t.Errorf("non-connection errors marked RPCs as failed: %v", client.failedRPCs)
+	}
+}
+
+func TestReadWithRetryRetriesOnlyTransientErrors(t *testing.T) {
+	client := &EthClient{
+		maxRetries:               3,
+		retryBaseDelay:           time.Millisecond,
+		retryMaxDelay:            time.Millisecond,
+		rateLimitSwitchThreshold: 10,
+		failedRPCs:               make(map[string]time.Time),
+	}
+
+	tests := []struct {
+		name      string
+		errs      []error // returned by successive attempts, then nil
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "rate limit then success",
+			errs:      []error{errors.New("429 Too Many Requests")},
+			wantCalls: 2,
+		},
+		{
+			name:      "revert is not retried",
+			errs:      []error{errors.New("execution reverted: PancakeLibrary: INSUFFICIENT_LIQUIDITY")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "unknown error is not retried",
+			errs:      []error{errors.New("abi: cannot unmarshal")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name: "gives up after max retries",
+			errs: []error{
+				errors.New("429 Too Many Requests"),
+				errors.New("429 Too Many Requests"),
+				errors.New("429 Too Many Requests"),
+			},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := client.ReadWithRetry("getAmountsOut", func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadWithRetry error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("ReadWithRetry made %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+
+	if len(client.failedRPCs) != 0 {
+		t.Errorf("reads marked RPCs as failed: %v", client.failedRPCs)
+	}
+}
+
+func TestNewRPCLimitersPerEndpoint(t *testing.T) {
+	cfg := &config.Config{
+		RPCCallsPerSecond: 0.5,
+		RPCCallLimits:     map[string]float64{"ankr.com": 20, "defibit.io": 0},
+	}
+	limiters := newRPCLimiters(cfg, []string{
+		"https://rpc.ankr.com/bsc",
+		"https://bsc-dataseed1.defibit.io/",
+		"https://bsc-dataseed.binance.org/",
+	})
+
+	if _, ok := limiters["https://bsc-dataseed1.defibit.io/"]; ok {
+		t.Error("endpoint with a 0 limit got a limiter")
+	}
+	if ankr := limiters["https://rpc.ankr.com/bsc"]; ankr == nil || ankr.Limit() != 20 || ankr.Burst() != 20 {
+		t.Errorf("ankr limiter = %+v, want 20/s with a burst of 20", ankr)
+	}
+
+	// A sub-1/s limit still allows one call, then makes the next one wait
+	client := &EthClient{
+		currentRPC: "https://bsc-dataseed.binance.org/",
+		limiters:   limiters,
+	}
+	if _, err := client.throttled(context.Background()); err != nil {
+		t.Fatalf("first call throttled: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.throttled(ctx)
+	if err == nil {
+		t.Fatal("second call was not throttled")
+	}
+	if IsRateLimitError(err) {
+		t.Errorf("local throttling looks like a node rate limit: %v", err)
+	}
+}
+
+func TestRPCSwitchHooksAndSentNonce(t *testing.T) {
+	client := &EthClient{}
+
+	var calls []string
+	client.OnRPCSwitch(func(from, to string) { calls = append(calls, "first "+from+" "+to) })
+	client.OnRPCSwitch(func(from, to string) { calls = append(calls, "second "+from+" "+to) })
+	client.runSwitchHooks("a", "b")
+
+	if len(calls) != 2 || calls[0] != "first a b" || calls[1] != "second a b" {
+		t.Errorf("hook calls = %v, want both hooks in registration order", calls)
+	}
+
+	// The expected nonce only moves forward, whatever order sends land in
+	for _, nonce := range []uint64{4, 7, 5} {
+		client.noteSent(types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil))
+	}
+	if client.nextNonce != 8 {
+		t.Errorf("nextNonce = %d, want 8", client.nextNonce)
+	}
+}
+
+func TestNextWalletRoundRobin(t *testing.T) {
+	var wallets []Signer
+	for i := 0; i < 3; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		signer, err := NewLocalSigner(key, bscChainID)
+		if err != nil {
+			t.Fatalf("failed to create signer: %v", err)
+		}
+		wallets = append(wallets, signer)
+	}
+
+	client := &EthClient{Address: wallets[0].Address(), Signer: wallets[0], wallets: wallets}
+
+	// Each execution takes the next wallet, starting from the first
+	for i, want := range []int{0, 1, 2, 0} {
+		if got := client.NextWallet(); got != wallets[want].Address() {
+			t.Errorf("execution %d used %s, want wallet %d", i+1, got.Hex(), want)
+		}
+		if client.Signer != wallets[want] || client.Auth.From != wallets[want].Address() {
+			t.Errorf("execution %d: signer and auth not switched to wallet %d", i+1, want)
+		}
+	}
+
+	// Each wallet keeps its own sent nonce
+	client.noteSent(types.NewTransaction(9, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil))
+	client.NextWallet()
+	if client.nextNonce != 0 {
+		t.Errorf("wallet 1 nextNonce = %d, want 0", client.nextNonce)
+	}
+	if !client.UseWallet(wallets[0].Address()) || client.nextNonce != 10 {
+		t.Errorf("wallet 0 nextNonce = %d after switching back, want 10", client.nextNonce)
+	}
+
+	if client.UseWallet(common.HexToAddress("0x1")) {
+		t.Error("UseWallet activated an unconfigured wallet")
+	}
+}
+
+func TestFetchBalancesInOneMulticall(t *testing.T) {
+	if err := contracts.Initialize(); err != nil {
+		t.Fatalf("failed to initialize ABIs: %v", err)
+	}
+
+	wbnb, usdt := common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT)
+	broken := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+
+	backend := newMockBackend()
+	backend.native = big.NewInt(3e16)
+	backend.balances[wbnb] = wbnbAmount(2)
+	backend.codeless[broken] = true
+
+	balances, err := fetchBalances(context.Background(), backend, []common.Address{wbnb, usdt, broken}, common.HexToAddress("0x1"))
+	if err != nil {
+		t.Fatalf("fetchBalances returned error: %v", err)
+	}
+	if backend.calls != 5 { // aggregate3 plus the getEthBalance and three balanceOf calls it runs
+		t.Errorf("mock saw %d calls, want 5 from a single multicall", backend.calls)
+	}
+
+	want := map[common.Address]*big.Int{NativeBalance: big.NewInt(3e16), wbnb: wbnbAmount(2), usdt: big.NewInt(0)}
+	if len(balances) != len(want) {
+		t.Errorf("balances = %v, want %v without the failed token", balances, want)
+	}
+	for token, balance := range want {
+		if balances[token] == nil || balances[token].Cmp(balance) != 0 {
+			t.Errorf("balance of %s = %v, want %s", token.Hex(), balances[token], balance)
+		}
+	}
+}
+
+func TestSendWithGasBump(t *testing.T) {
+	underpriced := errors.New("replacement transaction underpriced")
+
+	tests := []struct {
+		name      string
+		sendErrs  []error
+		maxGwei   float64
+		wantSent  []int64 // gas price of each submission, in wei
+		wantErr   bool
+		wantLimit bool // failed on MAX_GAS_PRICE_GWEI
+	}{
+		{
+			name:     "accepted first time",
+			wantSent: []int64{5000000000},
+		},
+		{
+			name:     "bumped until accepted",
+			sendErrs: []error{underpriced, errors.New("transaction underpriced")},
+			wantSent: []int64{5000000000, 5500000000, 6050000000},
+		},
+		{
+			name:     "gives up after the retries",
+			sendErrs: []error{underpriced, underpriced, underpriced},
+			wantSent: []int64{5000000000, 5500000000, 6050000000},
+			wantErr:  true,
+		},
+		{
+			name:     "other errors are not retried",
+			sendErrs: []error{errors.New("nonce too low")},
+			wantSent: []int64{5000000000},
+			wantErr:  true,
+		},
+		{
+			name:      "bump stops at the gas ceiling",
+			sendErrs:  []error{underpriced, underpriced},
+			maxGwei:   5.8,
+			wantSent:  []int64{5000000000, 5500000000},
+			wantErr:   true,
+			wantLimit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.sendErrs = tt.sendErrs
+			signer := newTestTokenService(t, backend).Client.Signer
+
+			cfg := newTestConfig()
+			cfg.GasBumpPercent = 10
+			cfg.GasBumpRetries = 2
+			cfg.MaxGasPriceGwei = tt.maxGwei
+
+			tx := types.NewTransaction(7, common.HexToAddress(config.PancakeswapRouter), big.NewInt(0), 300000, big.NewInt(5e9), []byte{0x38})
+			sent, err := sendWithGasBump(context.Background(), backend, signer, cfg, tx, sendProtectedTransaction)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sendWithGasBump error = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrGasPriceTooHigh) != tt.wantLimit {
+				t.Errorf("error %v, want ErrGasPriceTooHigh %v", err, tt.wantLimit)
+			}
+
+			if len(backend.sent) != len(tt.wantSent) {
+				t.Fatalf("sent %d transactions, want %d", len(backend.sent), len(tt.wantSent))
+			}
+			for i, want := range tt.wantSent {
+				if backend.sent[i].Nonce() != 7 || backend.sent[i].GasPrice().Int64() != want {
+					t.Errorf("submission %d: nonce %d at %s wei, want nonce 7 at %d wei",
+						i+1, backend.sent[i].Nonce(), backend.sent[i].GasPrice(), want)
+				}
+			}
+			if err == nil && sent.Hash() != backend.sent[len(backend.sent)-1].Hash() {
+				t.Errorf("returned %s, want the accepted transaction", sent.Hash().Hex())
+			}
+		})
+	}
+}
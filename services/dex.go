@@ -0,0 +1,155 @@
+// services/dex.go
+package services
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/models"
+)
+
+// DEX is an exchange the scanner can quote and route legs through
+type DEX interface {
+	Name() string
+	Router() common.Address
+
+	// FeeBps is the exchange's swap fee in basis points. Pools that set their
+	// own fee may differ; EstimateAmountOut accounts for that.
+	FeeBps() int64
+	GetAmountsOut(amountIn *big.Int, path []common.Address) ([]*big.Int, error)
+
+	// Swap sends a swap, calling beforeSend, when set, with each signed
+	// transaction before it is submitted; an error from it cancels the send
+	Swap(amountIn, amountOutMin *big.Int, path []common.Address, beforeSend func(*types.Transaction) error) (*types.Transaction, error)
+	EstimateSwap(amountIn, amountOutMin *big.Int, path []common.Address) (uint64, error)
+	FactoryGetPair(tokenA, tokenB common.Address) (common.Address, error)
+
+	// EstimateAmountOut estimates a swap through one of this exchange's pools
+	// locally from its reserves and fee, without quoting the router
+	EstimateAmountOut(pool, tokenIn common.Address, amountIn *big.Int) (*big.Int, error)
+
+	// PairAddresses returns the pool addresses configured for a token pair on
+	// this exchange, keyed like "WBNB-USDT". The map is writable.
+	PairAddresses(pair *models.TokenPair) map[string]string
+}
+
+// V2DEX is a Uniswap-V2-style exchange: a router with getAmountsOut and
+// swapExactTokensForTokens, and a factory with getPair
+type V2DEX struct {
+	name          string
+	router        common.Address
+	factory       common.Address
+	feeBps        int64
+	routerService *RouterService
+
+	// Whether pools report their own fee through swapFee(), as BiSwap's do
+	poolFees bool
+}
+
+// NewV2DEX creates a V2-style exchange served by the given router and factory
+// that charges feeBps on every swap
+func NewV2DEX(name string, router, factory common.Address, feeBps int64, routerService *RouterService) *V2DEX {
+	return &V2DEX{
+		name:          name,
+		router:        router,
+		factory:       factory,
+		feeBps:        feeBps,
+		routerService: routerService,
+	}
+}
+
+// DefaultDEXes returns the exchanges the bot trades on, charging the swap
+// fees from the router service's config. The first entry is used to unwind
+// stranded positions.
+func DefaultDEXes(routerService *RouterService) []DEX {
+	cfg := routerService.Config
+
+	// BiSwap pools each set their own fee, 0.1% or 0.2%
+	biswap := NewV2DEX("BiSwap", common.HexToAddress(config.BiswapRouter),
+		common.HexToAddress(config.BiswapFactory), cfg.BiswapFeeBps, routerService)
+	biswap.poolFees = true
+
+	return []DEX{
+		NewV2DEX("PancakeSwap", common.HexToAddress(config.PancakeswapRouter),
+			common.HexToAddress(config.PancakeswapFactory), cfg.PancakeswapFeeBps, routerService),
+		biswap,
+	}
+}
+
+// Name returns the exchange name
+func (d *V2DEX) Name() string {
+	return d.name
+}
+
+// Router returns the router address
+func (d *V2DEX) Router() common.Address {
+	return d.router
+}
+
+// FeeBps returns the exchange's swap fee in basis points
+func (d *V2DEX) FeeBps() int64 {
+	return d.feeBps
+}
+
+// GetAmountsOut quotes a swap path on this exchange's router
+func (d *V2DEX) GetAmountsOut(amountIn *big.Int, path []common.Address) ([]*big.Int, error) {
+	quote, err := d.routerService.Quote(models.QuoteRequest{Router: d.router, AmountIn: amountIn, Path: path})
+	if err != nil {
+		return nil, err
+	}
+	return quote.Amounts, nil
+}
+
+// Swap sends a swapExactTokensForTokens transaction to this exchange's router
+func (d *V2DEX) Swap(amountIn, amountOutMin *big.Int, path []common.Address, beforeSend func(*types.Transaction) error) (*types.Transaction, error) {
+	return d.routerService.SwapExactTokensForTokens(d.router, amountIn, amountOutMin, path, beforeSend)
+}
+
+// EstimateSwap estimates the gas of the transaction Swap would send, failing
+// if the swap would revert
+func (d *V2DEX) EstimateSwap(amountIn, amountOutMin *big.Int, path []common.Address) (uint64, error) {
+	return d.routerService.EstimateGasForSwap(d.router, amountIn, amountOutMin, path)
+}
+
+// FactoryGetPair looks up the pool for two tokens in this exchange's factory
+func (d *V2DEX) FactoryGetPair(tokenA, tokenB common.Address) (common.Address, error) {
+	return d.routerService.GetPairFromFactory(d.factory, tokenA, tokenB)
+}
+
+// EstimateAmountOut estimates a swap through pool from its reserves, charging
+// the pool's own fee on exchanges that set one per pool
+func (d *V2DEX) EstimateAmountOut(pool, tokenIn common.Address, amountIn *big.Int) (*big.Int, error) {
+	feeBps := d.feeBps
+	if d.poolFees {
+		feeBps = d.routerService.PoolFeeBps(pool, d.feeBps)
+	}
+	return d.routerService.EstimateAmountOut(pool, tokenIn, amountIn, feeBps)
+}
+
+// PairAddresses returns the configured pools for this exchange. PancakeSwap
+// and BiSwap have dedicated fields; any other exchange is keyed by name.
+func (d *V2DEX) PairAddresses(pair *models.TokenPair) map[string]string {
+	switch d.name {
+	case "PancakeSwap":
+		if pair.PancakeswapPair == nil {
+			pair.PancakeswapPair = make(map[string]string)
+		}
+		return pair.PancakeswapPair
+	case "BiSwap":
+		if pair.BiswapPair == nil {
+			pair.BiswapPair = make(map[string]string)
+		}
+		return pair.BiswapPair
+	}
+
+	if pair.DEXPairs == nil {
+		pair.DEXPairs = make(map[string]map[string]string)
+	}
+	if pair.DEXPairs[d.name] == nil {
+		pair.DEXPairs[d.name] = make(map[string]string)
+	}
+	return pair.DEXPairs[d.name]
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/config"
+)
+
+func TestDefaultDEXesUseConfiguredFees(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+	service.Config.PancakeswapFeeBps = 30
+	service.Config.BiswapFeeBps = 10
+
+	want := map[string]int64{"PancakeSwap": 30, "BiSwap": 10}
+	for _, dex := range DefaultDEXes(service.RouterService) {
+		if dex.FeeBps() != want[dex.Name()] {
+			t.Errorf("%s FeeBps = %d, want %d", dex.Name(), dex.FeeBps(), want[dex.Name()])
+		}
+	}
+}
+
+// The expected outputs are what each router's getAmountOut returns for 1 WBNB
+// into a 5,000 WBNB / 1,500,000 USDT pool: PancakeSwap charges 25/10000 and
+// BiSwap swapFee/1000. No getAmountsOut results recorded from mainnet are
+// available to this test, so the values come from the routers' verified
+// contract source, transcribed below as pancakeGetAmountOut and
+// biswapGetAmountOut, rather than from the code under test. Replace them with
+// a recorded quote (reserves, fee and router output at a known block) when
+// one is captured.
+func TestEstimateAmountOutMatchesRouterQuotes(t *testing.T) {
+	const pool = "0x00000000000000000000000000000000000000a1"
+
+	reserveIn := wbnbAmount(5000)
+	reserveOut := wbnbAmount(1500000)
+
+	tests := []struct {
+		name      string
+		dex       string
+		factory   string
+		swapFee   uint32
+		want      string
+		reference func(amountIn, reserveIn, reserveOut *big.Int) *big.Int
+	}{
+		{"pancakeswap 0.25%", "PancakeSwap", config.PancakeswapFactory, 0, "299190311532849196585", pancakeGetAmountOut},
+		{"biswap pool at 0.1%", "BiSwap", config.BiswapFactory, 1, "299640131901646051118", biswapGetAmountOut(1)},
+		{"biswap pool at 0.2%", "BiSwap", config.BiswapFactory, 2, "299340251685763521601", biswapGetAmountOut(2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.listPool(tt.factory, pool, config.WBNB, config.USDT, reserveIn)
+			backend.pools[common.HexToAddress(pool)].reserve1 = reserveOut
+			backend.pools[common.HexToAddress(pool)].swapFee = tt.swapFee
+
+			service := newTestArbitrageService(t, backend)
+
+			var dex DEX
+			for _, candidate := range service.DEXes {
+				if candidate.Name() == tt.dex {
+					dex = candidate
+				}
+			}
+
+			got, err := dex.EstimateAmountOut(common.HexToAddress(pool), common.HexToAddress(config.WBNB), wbnbAmount(1))
+			if err != nil {
+				t.Fatalf("EstimateAmountOut returned error: %v", err)
+			}
+
+			want, _ := new(big.Int).SetString(tt.want, 10)
+			if got.Cmp(want) != 0 {
+				t.Errorf("EstimateAmountOut = %s, want %s", got, want)
+			}
+
+			if reference := tt.reference(wbnbAmount(1), reserveIn, reserveOut); reference.Cmp(want) != 0 {
+				t.Fatalf("router formula gives %s, table says %s", reference, want)
+			}
+
+			// A Uniswap-style 0.3% fee must not match, or the fee isn't applied
+			uniswap := service.RouterService.GetAmountOutFromReserves(wbnbAmount(1), reserveIn, reserveOut, 30)
+			if uniswap.Cmp(want) == 0 {
+				t.Errorf("0.3%% fee gave the same output %s", uniswap)
+			}
+		})
+	}
+}
+
+// pancakeGetAmountOut is PancakeLibrary.getAmountOut from PancakeSwap's
+// verified router: amountIn * 9975 * reserveOut / (reserveIn * 10000 +
+// amountIn * 9975)
+func pancakeGetAmountOut(amountIn, reserveIn, reserveOut *big.Int) *big.Int {
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(9975))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Add(new(big.Int).Mul(reserveIn, big.NewInt(10000)), amountInWithFee)
+	return numerator.Div(numerator, denominator)
+}
+
+// biswapGetAmountOut is BiswapLibrary.getAmountOut from BiSwap's verified
+// router for a pool whose swapFee() is swapFee: amountIn * (1000 - swapFee) *
+// reserveOut / (reserveIn * 1000 + amountIn * (1000 - swapFee))
+func biswapGetAmountOut(swapFee int64) func(amountIn, reserveIn, reserveOut *big.Int) *big.Int {
+	return func(amountIn, reserveIn, reserveOut *big.Int) *big.Int {
+		amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(1000-swapFee))
+		numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+		denominator := new(big.Int).Add(new(big.Int).Mul(reserveIn, big.NewInt(1000)), amountInWithFee)
+		return numerator.Div(numerator, denominator)
+	}
+}
@@ -0,0 +1,70 @@
+// services/execution_state.go
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/models"
+)
+
+// StrandedPositionError is returned when a manual arbitrage stops between legs
+// and the wallet is left holding an intermediate token. When the next leg was
+// sent but not seen mined, PendingTx is its hash and the wallet holds Token
+// only until it confirms, then PendingSymbol.
+type StrandedPositionError struct {
+	Pair          string
+	CompletedLegs int
+	Token         common.Address
+	Symbol        string
+	Amount        *big.Int
+	PendingTx     common.Hash
+	PendingSymbol string
+	Err           error
+}
+
+func (e *StrandedPositionError) Error() string {
+	if e.PendingTx != (common.Hash{}) {
+		return fmt.Sprintf("arbitrage on %s stopped with leg %d pending as %s, holding %s %s (%s) or, once it confirms, its %s: %v",
+			e.Pair, e.CompletedLegs+1, e.PendingTx.Hex(), e.Amount.String(), e.Symbol, e.Token.Hex(), e.PendingSymbol, e.Err)
+	}
+	return fmt.Sprintf("arbitrage on %s stopped after leg %d, holding %s %s (%s): %v",
+		e.Pair, e.CompletedLegs, e.Amount.String(), e.Symbol, e.Token.Hex(), e.Err)
+}
+
+func (e *StrandedPositionError) Unwrap() error {
+	return e.Err
+}
+
+// LoadExecutionState reads a persisted in-progress execution. It returns nil
+// without error when no execution was left open.
+func LoadExecutionState(path string) (*models.ExecutionState, error) {
+	var state models.ExecutionState
+	found, err := readJSONFile(path, &state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read execution state: %v", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// saveExecutionState persists the position held between legs
+func saveExecutionState(path string, state *models.ExecutionState) error {
+	if err := writeJSONFile(path, state); err != nil {
+		return fmt.Errorf("failed to write execution state: %v", err)
+	}
+	return nil
+}
+
+// clearExecutionState removes the persisted state once no position is open
+func clearExecutionState(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear execution state: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// services/health.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// HealthNode is the node access the liveness check needs on top of contract calls
+type HealthNode interface {
+	ContractCaller
+	NetworkID(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// HealthReport is the /healthz response. Failures lists every condition that
+// keeps the bot from trading; it is healthy when there are none.
+type HealthReport struct {
+	Healthy         bool     `json:"healthy"`
+	BlockNumber     uint64   `json:"block_number,omitempty"`
+	BlockLagSeconds float64  `json:"block_lag_seconds,omitempty"`
+	BalanceBNB      float64  `json:"balance_bnb"`
+	Failures        []string `json:"failures,omitempty"`
+
+	// Opportunities lost to faster trades so far, see TradeSummary
+	MissedOpportunities int `json:"missed_opportunities"`
+}
+
+// CheckHealth reports whether the bot can still trade: the RPC answers, its
+// latest block is no more than maxBlockLag older than now, and the wallet
+// holds at least minBalanceBNB of native BNB for gas
+func CheckHealth(ctx context.Context, node HealthNode, wallet common.Address, maxBlockLag time.Duration, minBalanceBNB float64, now time.Time) *HealthReport {
+	report := &HealthReport{}
+
+	if _, err := node.NetworkID(ctx); err != nil {
+		report.Failures = append(report.Failures, fmt.Sprintf("rpc unreachable: %v", err))
+		return report
+	}
+
+	header, err := node.HeaderByNumber(ctx, nil)
+	if err != nil {
+		report.Failures = append(report.Failures, fmt.Sprintf("failed to read latest block: %v", err))
+	} else {
+		lag := now.Sub(time.Unix(int64(header.Time), 0))
+		report.BlockNumber = header.Number.Uint64()
+		report.BlockLagSeconds = lag.Seconds()
+		if lag > maxBlockLag {
+			report.Failures = append(report.Failures, fmt.Sprintf("node is lagging: block %d is %v old (max %v)",
+				report.BlockNumber, lag.Round(time.Second), maxBlockLag))
+		}
+	}
+
+	balances, err := fetchBalances(ctx, node, nil, wallet)
+	if err != nil {
+		report.Failures = append(report.Failures, fmt.Sprintf("failed to read wallet balance: %v", err))
+	} else {
+		report.BalanceBNB = formatWBNB(balances[NativeBalance])
+		if report.BalanceBNB < minBalanceBNB {
+			report.Failures = append(report.Failures, fmt.Sprintf("native balance %.6f BNB is below the %.6f BNB gas reserve",
+				report.BalanceBNB, minBalanceBNB))
+		}
+	}
+
+	report.Healthy = len(report.Failures) == 0
+	return report
+}
+
+// HealthHandler serves the report from check as JSON, with 503 Service
+// Unavailable when it is unhealthy
+func HealthHandler(check func(ctx context.Context) *HealthReport) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := check(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
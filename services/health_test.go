@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/contracts"
+)
+
+func TestCheckHealth(t *testing.T) {
+	if err := contracts.Initialize(); err != nil {
+		t.Fatalf("failed to initialize ABIs: %v", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000b1")
+
+	tests := []struct {
+		name        string
+		setup       func(m *mockBackend)
+		wantHealthy bool
+		wantFailure string
+	}{
+		{
+			name:        "healthy",
+			setup:       func(m *mockBackend) {},
+			wantHealthy: true,
+		},
+		{
+			name:        "rpc down",
+			setup:       func(m *mockBackend) { m.networkErr = errors.New("connection refused") },
+			wantFailure: "rpc unreachable",
+		},
+		{
+			name:        "node lagging",
+			setup:       func(m *mockBackend) { m.headTime = uint64(now.Add(-2 * time.Minute).Unix()) },
+			wantFailure: "node is lagging",
+		},
+		{
+			name:        "balance below gas reserve",
+			setup:       func(m *mockBackend) { m.native = wbnbAmount(0) },
+			wantFailure: "below the 0.010000 BNB gas reserve",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.head = 100
+			backend.headTime = uint64(now.Add(-3 * time.Second).Unix())
+			backend.native = wbnbAmount(1)
+			tt.setup(backend)
+
+			report := CheckHealth(context.Background(), backend, wallet, 30*time.Second, 0.01, now)
+			if report.Healthy != tt.wantHealthy {
+				t.Fatalf("Healthy = %v, want %v (failures %v)", report.Healthy, tt.wantHealthy, report.Failures)
+			}
+			if tt.wantFailure == "" {
+				return
+			}
+			if len(report.Failures) != 1 || !strings.Contains(report.Failures[0], tt.wantFailure) {
+				t.Errorf("Failures = %v, want one containing %q", report.Failures, tt.wantFailure)
+			}
+		})
+	}
+}
+
+func TestHealthHandlerStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *HealthReport
+		want   int
+	}{
+		{"healthy", &HealthReport{Healthy: true}, http.StatusOK},
+		{"unhealthy", &HealthReport{Failures: []string{"node is lagging"}}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HealthHandler(func(ctx context.Context) *HealthReport { return tt.report })
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+			var body HealthReport
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body is not JSON: %v", err)
+			}
+			if len(body.Failures) != len(tt.report.Failures) {
+				t.Errorf("body failures = %v, want %v", body.Failures, tt.report.Failures)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// services/jsonfile.go
+package services
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// readJSONFile decodes path into v. It reports false without error when the
+// file does not exist.
+func readJSONFile(path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeJSONFile encodes v to path atomically so a crash mid-write never
+// leaves a truncated file behind
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
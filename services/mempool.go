@@ -0,0 +1,399 @@
+// services/mempool.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+	"arbitrage-bot/models"
+)
+
+const (
+	// Pending hashes are looked up in batches of up to mempoolBatchSize,
+	// flushed at least every mempoolBatchInterval, with at most
+	// mempoolFetchWorkers batches in flight
+	mempoolBatchSize     = 100
+	mempoolBatchInterval = 200 * time.Millisecond
+	mempoolFetchWorkers  = 4
+
+	// A hash not looked up within mempoolHashMaxAge is dropped: by then the
+	// transaction has likely been mined, and the lookup would be wasted
+	mempoolHashMaxAge = 3 * time.Second
+
+	// A large swap still unmined after mempoolSwapMaxAge is given up on
+	mempoolSwapMaxAge = time.Minute
+)
+
+// batchCaller sends several JSON-RPC calls in one request, as rpc.Client does
+type batchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// seenHash is a pending transaction hash and when it was announced
+type seenHash struct {
+	hash common.Hash
+	seen time.Time
+}
+
+// triggeredSwap is a large pending swap and the pairs to scan once it's mined
+type triggeredSwap struct {
+	hash  common.Hash
+	pairs []string
+	seen  time.Time
+}
+
+// PendingSwap is a decoded swapExactTokensForTokens call that hasn't been mined
+type PendingSwap struct {
+	Hash         common.Hash
+	DEX          string
+	AmountIn     *big.Int
+	AmountOutMin *big.Int
+	Path         []common.Address
+}
+
+// MempoolWatcher subscribes to pending transactions and reports tracked pairs
+// that a large pending swap moved, so they can be scanned as soon as it lands
+// instead of on the next interval
+type MempoolWatcher struct {
+	Config     *config.Config
+	DEXes      []DEX
+	TokenPairs []models.TokenPair
+
+	minSwap *big.Int
+
+	// When each pair last triggered a scan, for MEMPOOL_PAIR_COOLDOWN_SECONDS
+	mu          sync.Mutex
+	lastTrigger map[string]time.Time
+}
+
+// NewMempoolWatcher creates a watcher for the given exchanges and pairs
+func NewMempoolWatcher(cfg *config.Config, dexes []DEX, pairs []models.TokenPair) *MempoolWatcher {
+	minSwap, _ := new(big.Float).Mul(big.NewFloat(cfg.MempoolMinSwapWBNB), big.NewFloat(1e18)).Int(nil)
+
+	return &MempoolWatcher{
+		Config:      cfg,
+		DEXes:       dexes,
+		TokenPairs:  pairs,
+		minSwap:     minSwap,
+		lastTrigger: make(map[string]time.Time),
+	}
+}
+
+// Watch subscribes to pending transaction hashes on MEMPOOL_WS_URL and, once
+// a large swap it saw pending is mined, sends the name of each affected pair
+// to triggers, so the scan sees the reserves the swap left behind. It returns
+// when ctx is done or a subscription fails; callers reconnect by calling it
+// again.
+func (w *MempoolWatcher) Watch(ctx context.Context, triggers chan<- string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rpcClient, err := rpc.DialContext(ctx, w.Config.MempoolWSURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to mempool websocket: %v", err)
+	}
+	defer rpcClient.Close()
+
+	hashes := make(chan common.Hash, 256)
+	sub, err := rpcClient.EthSubscribe(ctx, hashes, "newPendingTransactions")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to pending transactions: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	heads := make(chan *types.Header, 16)
+	headSub, err := rpcClient.EthSubscribe(ctx, heads, "newHeads")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to new heads: %v", err)
+	}
+	defer headSub.Unsubscribe()
+
+	slog.Info("👀 Watching mempool for large swaps", "min_swap_wbnb", w.Config.MempoolMinSwapWBNB)
+
+	found := make(chan triggeredSwap, 64)
+	workers := make(chan struct{}, mempoolFetchWorkers)
+	awaiting := make(map[common.Hash]triggeredSwap)
+	var batch []seenHash
+
+	// flush hands the next batch of hashes to a free worker. With every
+	// worker busy the batch waits, and its stale hashes are dropped later.
+	flush := func() {
+		batch = freshHashes(batch, time.Now())
+		if len(batch) == 0 {
+			return
+		}
+
+		select {
+		case workers <- struct{}{}:
+		default:
+			return
+		}
+
+		size := len(batch)
+		if size > mempoolBatchSize {
+			size = mempoolBatchSize
+		}
+		next := batch[:size:size]
+		batch = batch[size:]
+
+		go func() {
+			defer func() { <-workers }()
+			for _, swap := range w.lookupSwaps(ctx, rpcClient, next) {
+				select {
+				case found <- swap:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(mempoolBatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return fmt.Errorf("pending transaction subscription dropped: %v", err)
+		case err := <-headSub.Err():
+			return fmt.Errorf("new head subscription dropped: %v", err)
+		case hash := <-hashes:
+			batch = append(batch, seenHash{hash: hash, seen: time.Now()})
+			if len(batch) >= mempoolBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case swap := <-found:
+			awaiting[swap.hash] = swap
+		case <-heads:
+			w.triggerMined(ctx, rpcClient, awaiting, triggers)
+		}
+	}
+}
+
+// freshHashes drops the hashes announced more than mempoolHashMaxAge ago
+func freshHashes(batch []seenHash, now time.Time) []seenHash {
+	fresh := batch[:0]
+	for _, entry := range batch {
+		if now.Sub(entry.seen) <= mempoolHashMaxAge {
+			fresh = append(fresh, entry)
+		}
+	}
+	return fresh
+}
+
+// lookupSwaps fetches a batch of pending transactions in one request and
+// returns the swaps among them that trigger a scan
+func (w *MempoolWatcher) lookupSwaps(ctx context.Context, client batchCaller, batch []seenHash) []triggeredSwap {
+	txs := make([]*types.Transaction, len(batch))
+	elems := make([]rpc.BatchElem, len(batch))
+	for i, entry := range batch {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_getTransactionByHash",
+			Args:   []interface{}{entry.hash},
+			Result: &txs[i],
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := client.BatchCallContext(ctx, elems); err != nil {
+		slog.Debug("Pending transaction lookup failed", "hashes", len(batch), "err", err)
+		return nil
+	}
+
+	var swaps []triggeredSwap
+	for i, elem := range elems {
+		if elem.Error != nil || txs[i] == nil {
+			continue // usually already mined or dropped
+		}
+		if pairs := w.Triggers(txs[i]); len(pairs) > 0 {
+			swaps = append(swaps, triggeredSwap{hash: batch[i].hash, pairs: pairs, seen: batch[i].seen})
+		}
+	}
+	return swaps
+}
+
+// triggerMined looks up the receipts of the awaited swaps in one request and
+// sends the pairs of each mined one to triggers. A reverted swap moved no
+// reserves and triggers nothing; one still pending after mempoolSwapMaxAge is
+// given up on.
+func (w *MempoolWatcher) triggerMined(ctx context.Context, client batchCaller, awaiting map[common.Hash]triggeredSwap, triggers chan<- string) {
+	now := time.Now()
+	var hashes []common.Hash
+	for hash, swap := range awaiting {
+		if now.Sub(swap.seen) > mempoolSwapMaxAge {
+			delete(awaiting, hash)
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+	if len(hashes) == 0 {
+		return
+	}
+
+	receipts := make([]*types.Receipt, len(hashes))
+	elems := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{hash},
+			Result: &receipts[i],
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := client.BatchCallContext(ctx, elems); err != nil {
+		slog.Debug("Pending swap receipt lookup failed", "swaps", len(hashes), "err", err)
+		return
+	}
+
+	for i, elem := range elems {
+		if elem.Error != nil || receipts[i] == nil {
+			continue // not mined yet
+		}
+		swap := awaiting[hashes[i]]
+		delete(awaiting, hashes[i])
+		if receipts[i].Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+
+		for _, pairName := range swap.pairs {
+			select {
+			case triggers <- pairName:
+			default:
+				slog.Debug("Mempool trigger dropped, scan queue full", "pair", pairName)
+			}
+		}
+	}
+}
+
+// Triggers returns the tracked pairs a pending transaction should trigger a
+// scan of: it must be a large enough swap on a known router whose path trades
+// through one of the pair's pools, and the pair must be out of its cooldown
+func (w *MempoolWatcher) Triggers(tx *types.Transaction) []string {
+	swap, ok := w.DecodeSwap(tx)
+	if !ok {
+		return nil
+	}
+
+	size, ok := swapSizeWBNB(swap)
+	if !ok || size.Cmp(w.minSwap) < 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var triggered []string
+	now := time.Now()
+	for _, pairName := range w.AffectedPairs(swap.Path) {
+		if last, exists := w.lastTrigger[pairName]; exists && now.Sub(last) < w.Config.MempoolPairCooldown {
+			continue
+		}
+		w.lastTrigger[pairName] = now
+
+		slog.Info("👀 Large pending swap", "pair", pairName, "dex", swap.DEX,
+			"size_wbnb", formatWBNB(size), "tx", swap.Hash.Hex())
+		triggered = append(triggered, pairName)
+	}
+	return triggered
+}
+
+// DecodeSwap decodes a swapExactTokensForTokens call sent to one of the
+// configured routers
+func (w *MempoolWatcher) DecodeSwap(tx *types.Transaction) (*PendingSwap, bool) {
+	if tx.To() == nil || len(tx.Data()) < 4 {
+		return nil, false
+	}
+
+	dexName := ""
+	for _, dex := range w.DEXes {
+		if dex.Router() == *tx.To() {
+			dexName = dex.Name()
+			break
+		}
+	}
+	if dexName == "" {
+		return nil, false
+	}
+
+	method, err := contracts.RouterABI.MethodById(tx.Data()[:4])
+	if err != nil || method.Name != "swapExactTokensForTokens" {
+		return nil, false
+	}
+
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	if err != nil || len(args) < 3 {
+		return nil, false
+	}
+
+	amountIn, ok1 := args[0].(*big.Int)
+	amountOutMin, ok2 := args[1].(*big.Int)
+	path, ok3 := args[2].([]common.Address)
+	if !ok1 || !ok2 || !ok3 || len(path) < 2 {
+		return nil, false
+	}
+
+	return &PendingSwap{
+		Hash:         tx.Hash(),
+		DEX:          dexName,
+		AmountIn:     amountIn,
+		AmountOutMin: amountOutMin,
+		Path:         path,
+	}, true
+}
+
+// AffectedPairs returns the tracked pairs that hold both tokens of at least one
+// hop in path, i.e. whose pools the swap will trade against
+func (w *MempoolWatcher) AffectedPairs(path []common.Address) []string {
+	var affected []string
+	for _, pair := range w.TokenPairs {
+		tokens := make(map[common.Address]bool, len(pair.Tokens))
+		for _, address := range pair.Tokens {
+			tokens[common.HexToAddress(address)] = true
+		}
+
+		for i := 0; i+1 < len(path); i++ {
+			if tokens[path[i]] && tokens[path[i+1]] {
+				affected = append(affected, pair.Name)
+				break
+			}
+		}
+	}
+	return affected
+}
+
+// swapSizeWBNB sizes a swap in WBNB from its own calldata: the input amount
+// when it sells WBNB, or the minimum output when it buys WBNB. Swaps that
+// don't start or end in WBNB can't be sized without a quote and are skipped.
+func swapSizeWBNB(swap *PendingSwap) (*big.Int, bool) {
+	wbnb := common.HexToAddress(config.WBNB)
+	switch {
+	case swap.Path[0] == wbnb:
+		return swap.AmountIn, true
+	case swap.Path[len(swap.Path)-1] == wbnb:
+		return swap.AmountOutMin, true
+	}
+	return nil, false
+}
+
+// formatWBNB converts a wei amount to WBNB for logging
+func formatWBNB(amount *big.Int) float64 {
+	value, _ := new(big.Float).Quo(new(big.Float).SetInt(amount), big.NewFloat(1e18)).Float64()
+	return value
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+	"arbitrage-bot/models"
+)
+
+// wbnbAmount returns whole WBNB in wei
+func wbnbAmount(whole int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(whole), big.NewInt(1e18))
+}
+
+// newTestMempoolWatcher builds a watcher over the default exchanges and the
+// test pair, triggering on swaps of 5 WBNB or more
+func newTestMempoolWatcher(t *testing.T) *MempoolWatcher {
+	t.Helper()
+
+	service := newTestArbitrageService(t, newMockBackend())
+	service.Config.MempoolMinSwapWBNB = 5
+	service.Config.MempoolPairCooldown = 10 * time.Second
+
+	cake := models.TokenPair{
+		Name: "WBNB-CAKE-USDT",
+		Tokens: map[string]string{
+			"WBNB": config.WBNB,
+			"CAKE": config.CAKE,
+			"USDT": config.USDT,
+		},
+	}
+	return NewMempoolWatcher(service.Config, service.DEXes, []models.TokenPair{testPair(), cake})
+}
+
+// swapTx builds a pending swapExactTokensForTokens transaction to router
+func swapTx(t *testing.T, router string, amountIn, amountOutMin *big.Int, path ...string) *types.Transaction {
+	t.Helper()
+
+	addresses := make([]common.Address, len(path))
+	for i, token := range path {
+		addresses[i] = common.HexToAddress(token)
+	}
+
+	data, err := contracts.RouterABI.Pack("swapExactTokensForTokens",
+		amountIn, amountOutMin, addresses, common.HexToAddress("0x1"), big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to pack swap: %v", err)
+	}
+	return types.NewTransaction(0, common.HexToAddress(router), big.NewInt(0), 300000, big.NewInt(5e9), data)
+}
+
+func TestMempoolDecodeSwap(t *testing.T) {
+	watcher := newTestMempoolWatcher(t)
+
+	approve, err := contracts.ERC20ABI.Pack("approve", common.HexToAddress(config.PancakeswapRouter), big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to pack approve: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		wantDEX string
+		wantOK  bool
+	}{
+		{
+			name:    "pancake swap",
+			tx:      swapTx(t, config.PancakeswapRouter, wbnbAmount(10), big.NewInt(1), config.WBNB, config.USDT),
+			wantDEX: "PancakeSwap",
+			wantOK:  true,
+		},
+		{
+			name:    "biswap swap",
+			tx:      swapTx(t, config.BiswapRouter, wbnbAmount(10), big.NewInt(1), config.USDT, config.BUSD),
+			wantDEX: "BiSwap",
+			wantOK:  true,
+		},
+		{
+			name: "unknown router",
+			tx:   swapTx(t, "0x000000000000000000000000000000000000dEaD", wbnbAmount(10), big.NewInt(1), config.WBNB, config.USDT),
+		},
+		{
+			name: "not a swap",
+			tx:   types.NewTransaction(0, common.HexToAddress(config.PancakeswapRouter), big.NewInt(0), 60000, big.NewInt(5e9), approve),
+		},
+		{
+			name: "short calldata",
+			tx:   types.NewTransaction(0, common.HexToAddress(config.PancakeswapRouter), big.NewInt(0), 21000, big.NewInt(5e9), []byte{0x38}),
+		},
+		{
+			name: "contract creation",
+			tx:   types.NewContractCreation(0, big.NewInt(0), 21000, big.NewInt(5e9), nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			swap, ok := watcher.DecodeSwap(tt.tx)
+			if ok != tt.wantOK {
+				t.Fatalf("DecodeSwap ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if swap.DEX != tt.wantDEX {
+				t.Errorf("DEX = %s, want %s", swap.DEX, tt.wantDEX)
+			}
+			if swap.AmountIn.Cmp(wbnbAmount(10)) != 0 || len(swap.Path) != 2 {
+				t.Errorf("decoded amountIn %s, path %v", swap.AmountIn, swap.Path)
+			}
+		})
+	}
+}
+
+func TestMempoolAffectedPairs(t *testing.T) {
+	watcher := newTestMempoolWatcher(t)
+
+	tests := []struct {
+		name string
+		path []string
+		want []string
+	}{
+		{"pool in both pairs", []string{config.WBNB, config.USDT}, []string{"WBNB-USDT-BUSD", "WBNB-CAKE-USDT"}},
+		{"pool in one pair", []string{config.USDT, config.BUSD}, []string{"WBNB-USDT-BUSD"}},
+		{"multi-hop through one pool", []string{config.DOGE, config.WBNB, config.CAKE}, []string{"WBNB-CAKE-USDT"}},
+		{"tokens in a pair but not adjacent", []string{config.CAKE, config.DOGE, config.USDT}, nil},
+		{"untracked tokens", []string{config.DOGE, config.SHIB}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := make([]common.Address, len(tt.path))
+			for i, token := range tt.path {
+				path[i] = common.HexToAddress(token)
+			}
+
+			got := watcher.AffectedPairs(path)
+			if len(got) != len(tt.want) {
+				t.Fatalf("AffectedPairs = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("AffectedPairs = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMempoolTriggersSizeAndCooldown(t *testing.T) {
+	watcher := newTestMempoolWatcher(t)
+
+	tests := []struct {
+		name  string
+		tx    *types.Transaction
+		setup func()
+		want  int
+	}{
+		{
+			name: "small WBNB sell",
+			tx:   swapTx(t, config.PancakeswapRouter, wbnbAmount(1), big.NewInt(1), config.WBNB, config.BUSD),
+			want: 0,
+		},
+		{
+			name: "large WBNB sell",
+			tx:   swapTx(t, config.PancakeswapRouter, wbnbAmount(10), big.NewInt(1), config.WBNB, config.BUSD),
+			want: 1,
+		},
+		{
+			name: "same pair again within cooldown",
+			tx:   swapTx(t, config.BiswapRouter, wbnbAmount(20), big.NewInt(1), config.WBNB, config.BUSD),
+			want: 0,
+		},
+		{
+			name: "same pair after cooldown",
+			tx:   swapTx(t, config.BiswapRouter, wbnbAmount(20), big.NewInt(1), config.WBNB, config.BUSD),
+			setup: func() {
+				watcher.lastTrigger["WBNB-USDT-BUSD"] = time.Now().Add(-11 * time.Second)
+			},
+			want: 1,
+		},
+		{
+			name: "large WBNB buy sized by minimum output",
+			tx:   swapTx(t, config.PancakeswapRouter, big.NewInt(1), wbnbAmount(8), config.CAKE, config.WBNB),
+			want: 1,
+		},
+		{
+			name: "no WBNB leg cannot be sized",
+			tx:   swapTx(t, config.PancakeswapRouter, wbnbAmount(1000), wbnbAmount(1000), config.CAKE, config.USDT),
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			if got := watcher.Triggers(tt.tx); len(got) != tt.want {
+				t.Errorf("Triggers = %v, want %d pair(s)", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeBatchCaller answers batched lookups from fixed transactions and
+// receipts, counting the requests it serves
+type fakeBatchCaller struct {
+	txs      map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	requests int
+}
+
+func (f *fakeBatchCaller) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	f.requests++
+	for _, elem := range elems {
+		hash := elem.Args[0].(common.Hash)
+		switch elem.Method {
+		case "eth_getTransactionByHash":
+			*elem.Result.(**types.Transaction) = f.txs[hash]
+		case "eth_getTransactionReceipt":
+			*elem.Result.(**types.Receipt) = f.receipts[hash]
+		}
+	}
+	return nil
+}
+
+func TestMempoolFreshHashes(t *testing.T) {
+	now := time.Now()
+	batch := []seenHash{
+		{hash: common.HexToHash("0x1"), seen: now.Add(-mempoolHashMaxAge - time.Second)},
+		{hash: common.HexToHash("0x2"), seen: now.Add(-time.Second)},
+		{hash: common.HexToHash("0x3"), seen: now},
+	}
+
+	fresh := freshHashes(batch, now)
+	if len(fresh) != 2 || fresh[0].hash != common.HexToHash("0x2") || fresh[1].hash != common.HexToHash("0x3") {
+		t.Errorf("freshHashes kept %v, want the last two", fresh)
+	}
+}
+
+func TestMempoolTriggersOnceSwapIsMined(t *testing.T) {
+	watcher := newTestMempoolWatcher(t)
+
+	large := swapTx(t, config.PancakeswapRouter, wbnbAmount(10), big.NewInt(1), config.WBNB, config.BUSD)
+	small := swapTx(t, config.PancakeswapRouter, wbnbAmount(1), big.NewInt(1), config.WBNB, config.CAKE)
+	client := &fakeBatchCaller{
+		txs: map[common.Hash]*types.Transaction{
+			large.Hash(): large,
+			small.Hash(): small,
+		},
+		receipts: make(map[common.Hash]*types.Receipt),
+	}
+
+	now := time.Now()
+	batch := []seenHash{
+		{hash: large.Hash(), seen: now},
+		{hash: small.Hash(), seen: now},
+		{hash: common.HexToHash("0xdead"), seen: now}, // already gone
+	}
+	swaps := watcher.lookupSwaps(context.Background(), client, batch)
+	if client.requests != 1 {
+		t.Errorf("looked up %d hashes in %d requests, want 1", len(batch), client.requests)
+	}
+	if len(swaps) != 1 || swaps[0].hash != large.Hash() {
+		t.Fatalf("lookupSwaps = %v, want only the large swap", swaps)
+	}
+
+	awaiting := map[common.Hash]triggeredSwap{large.Hash(): swaps[0]}
+	triggers := make(chan string, 4)
+
+	// Nothing is scanned while the swap is still pending
+	watcher.triggerMined(context.Background(), client, awaiting, triggers)
+	if len(triggers) != 0 || len(awaiting) != 1 {
+		t.Fatalf("triggered %d scan(s) before the swap was mined", len(triggers))
+	}
+
+	client.receipts[large.Hash()] = &types.Receipt{Status: types.ReceiptStatusSuccessful}
+	watcher.triggerMined(context.Background(), client, awaiting, triggers)
+	if len(triggers) != 1 || <-triggers != "WBNB-USDT-BUSD" {
+		t.Errorf("expected a scan of WBNB-USDT-BUSD once the swap was mined")
+	}
+	if len(awaiting) != 0 {
+		t.Errorf("still awaiting %d swap(s) after it was mined", len(awaiting))
+	}
+
+	// A reverted swap moved nothing, and an old one is given up on
+	reverted := common.HexToHash("0x2")
+	stale := common.HexToHash("0x3")
+	client.receipts[reverted] = &types.Receipt{Status: types.ReceiptStatusFailed}
+	awaiting[reverted] = triggeredSwap{hash: reverted, pairs: []string{"WBNB-USDT-BUSD"}, seen: now}
+	awaiting[stale] = triggeredSwap{hash: stale, pairs: []string{"WBNB-USDT-BUSD"}, seen: now.Add(-mempoolSwapMaxAge - time.Second)}
+	watcher.triggerMined(context.Background(), client, awaiting, triggers)
+	if len(triggers) != 0 || len(awaiting) != 0 {
+		t.Errorf("triggered %d scan(s) and still awaiting %d swap(s), want none", len(triggers), len(awaiting))
+	}
+}
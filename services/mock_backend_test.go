@@ -0,0 +1,456 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+	"arbitrage-bot/utils"
+)
+
+// quoteFunc returns the amounts a router would report for getAmountsOut
+type quoteFunc func(amountIn *big.Int, path []common.Address) []*big.Int
+
+// getPairSelector is the factory's getPair(address,address) method ID
+var getPairSelector = crypto.Keccak256([]byte("getPair(address,address)"))[:4]
+
+// mockPool is a V2 pool listed by a factory
+type mockPool struct {
+	factory            common.Address
+	token0, token1     common.Address
+	reserve0, reserve1 *big.Int
+	swapFee            uint32 // BiSwap-style fee in tenths of a percent; 0 has no swapFee()
+}
+
+// mockBackend is a ContractCaller that answers contract calls from canned
+// handlers instead of a live node
+type mockBackend struct {
+	gasPrice *big.Int
+	decimals map[common.Address]uint8
+	quotes   map[common.Address]quoteFunc
+	atBlock  map[uint64]map[common.Address]quoteFunc // quotes at a block, else quotes
+	head     uint64                                  // latest block number
+	v3Quotes map[uint32]quoteFunc
+	pools    map[common.Address]*mockPool
+	calls    int
+	gasCalls int                    // SuggestGasPrice calls
+	quoted   map[common.Address]int // getAmountsOut calls per router
+	sent     []*types.Transaction
+	callErr  error // returned by every contract call when set
+
+	allowances  map[common.Address]map[common.Address]*big.Int // token → spender → allowance
+	rawDecimals map[common.Address][]byte                      // raw decimals() results, nil reverts
+	balances    map[common.Address]*big.Int                    // balanceOf results per token
+	codeless    map[common.Address]bool                        // addresses with no contract deployed
+	native      *big.Int                                       // getEthBalance result, 0 when nil
+
+	estimated   []ethereum.CallMsg // EstimateGas calls
+	estimateErr error              // returned by EstimateGas when set
+
+	headTime   uint64 // timestamp of the latest block
+	networkErr error  // returned by NetworkID when set
+
+	sendErrs []error                        // returned by successive SendTransaction calls, then nil
+	receipts map[common.Hash]*types.Receipt // mined transactions, others are not found
+
+	onSend func(tx *types.Transaction)                // called with each sent transaction
+	mine   func(tx *types.Transaction) *types.Receipt // receipt of a sent transaction, nil while unmined
+}
+
+func newMockBackend() *mockBackend {
+	return &mockBackend{
+		gasPrice: big.NewInt(5000000000), // 5 Gwei
+		decimals: make(map[common.Address]uint8),
+		quotes:   make(map[common.Address]quoteFunc),
+		v3Quotes: make(map[uint32]quoteFunc),
+		pools:    make(map[common.Address]*mockPool),
+		quoted:   make(map[common.Address]int),
+
+		allowances:  make(map[common.Address]map[common.Address]*big.Int),
+		rawDecimals: make(map[common.Address][]byte),
+		balances:    make(map[common.Address]*big.Int),
+		codeless:    make(map[common.Address]bool),
+	}
+}
+
+// listPool registers a pool with equal reserves of both tokens on a factory
+func (m *mockBackend) listPool(factory, address string, tokenA, tokenB string, reserve *big.Int) {
+	m.pools[common.HexToAddress(address)] = &mockPool{
+		factory:  common.HexToAddress(factory),
+		token0:   common.HexToAddress(tokenA),
+		token1:   common.HexToAddress(tokenB),
+		reserve0: reserve,
+		reserve1: reserve,
+	}
+}
+
+func (m *mockBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	m.calls++
+	if m.callErr != nil {
+		return nil, m.callErr
+	}
+
+	if call.To == nil || len(call.Data) < 4 {
+		return nil, fmt.Errorf("invalid call")
+	}
+	if m.codeless[*call.To] {
+		return []byte{}, nil // calls to an address without code succeed empty
+	}
+
+	if method, err := contracts.RouterABI.MethodById(call.Data[:4]); err == nil && method.Name == "getAmountsOut" {
+		m.quoted[*call.To]++
+		quote, exists := m.quotes[*call.To]
+		if blockNumber != nil {
+			if quotes, pinned := m.atBlock[blockNumber.Uint64()]; pinned {
+				quote, exists = quotes[*call.To]
+			}
+		}
+		if !exists {
+			return nil, fmt.Errorf("execution reverted: no quote for router %s", call.To.Hex())
+		}
+
+		args, err := method.Inputs.Unpack(call.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+
+		amounts := quote(args[0].(*big.Int), args[1].([]common.Address))
+		return method.Outputs.Pack(amounts)
+	}
+
+	if method, err := contracts.QuoterV2ABI.MethodById(call.Data[:4]); err == nil && method.Name == "quoteExactInputSingle" {
+		args, err := method.Inputs.Unpack(call.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+
+		params := args[0].(struct {
+			TokenIn           common.Address `json:"tokenIn"`
+			TokenOut          common.Address `json:"tokenOut"`
+			AmountIn          *big.Int       `json:"amountIn"`
+			Fee               *big.Int       `json:"fee"`
+			SqrtPriceLimitX96 *big.Int       `json:"sqrtPriceLimitX96"`
+		})
+
+		quote, exists := m.v3Quotes[uint32(params.Fee.Uint64())]
+		if !exists {
+			return nil, fmt.Errorf("execution reverted: no pool for fee %s", params.Fee)
+		}
+
+		amounts := quote(params.AmountIn, []common.Address{params.TokenIn, params.TokenOut})
+		return method.Outputs.Pack(amounts[1], big.NewInt(0), uint32(0), big.NewInt(0))
+	}
+
+	if *call.To == common.HexToAddress(config.Multicall3) {
+		return m.multicall(ctx, call, blockNumber)
+	}
+
+	if method, err := contracts.ERC20ABI.MethodById(call.Data[:4]); err == nil && method.Name == "balanceOf" {
+		balance, exists := m.balances[*call.To]
+		if !exists {
+			balance = big.NewInt(0)
+		}
+		return method.Outputs.Pack(balance)
+	}
+
+	if method, err := contracts.ERC20ABI.MethodById(call.Data[:4]); err == nil && method.Name == "allowance" {
+		args, err := method.Inputs.Unpack(call.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+
+		allowance, exists := m.allowances[*call.To][args[1].(common.Address)]
+		if !exists {
+			allowance = big.NewInt(0)
+		}
+		return method.Outputs.Pack(allowance)
+	}
+
+	if method, err := contracts.ERC20ABI.MethodById(call.Data[:4]); err == nil && method.Name == "decimals" {
+		if raw, quirky := m.rawDecimals[*call.To]; quirky {
+			if raw == nil {
+				return nil, fmt.Errorf("execution reverted")
+			}
+			return raw, nil
+		}
+
+		decimals, exists := m.decimals[*call.To]
+		if !exists {
+			decimals = 18
+		}
+		return method.Outputs.Pack(decimals)
+	}
+
+	if bytes.Equal(call.Data[:4], getPairSelector) && len(call.Data) >= 68 {
+		tokenA := common.BytesToAddress(call.Data[4:36])
+		tokenB := common.BytesToAddress(call.Data[36:68])
+		for address, pool := range m.pools {
+			if pool.factory == *call.To &&
+				(pool.token0 == tokenA && pool.token1 == tokenB || pool.token0 == tokenB && pool.token1 == tokenA) {
+				return common.LeftPadBytes(address.Bytes(), 32), nil
+			}
+		}
+		return make([]byte, 32), nil // factories return the zero address
+	}
+
+	if method, err := contracts.PairABI.MethodById(call.Data[:4]); err == nil {
+		pool, exists := m.pools[*call.To]
+		if !exists {
+			return nil, fmt.Errorf("execution reverted: no pool at %s", call.To.Hex())
+		}
+
+		switch method.Name {
+		case "token0":
+			return method.Outputs.Pack(pool.token0)
+		case "token1":
+			return method.Outputs.Pack(pool.token1)
+		case "getReserves":
+			return method.Outputs.Pack(pool.reserve0, pool.reserve1, uint32(0))
+		case "swapFee":
+			if pool.swapFee == 0 {
+				return nil, fmt.Errorf("execution reverted")
+			}
+			return method.Outputs.Pack(pool.swapFee)
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected call to %s", call.To.Hex())
+}
+
+// multicall answers Multicall3 getEthBalance and aggregate3, running each
+// batched call through CallContract
+func (m *mockBackend) multicall(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	method, err := contracts.Multicall3ABI.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	if method.Name == "getEthBalance" {
+		native := m.native
+		if native == nil {
+			native = big.NewInt(0)
+		}
+		return method.Outputs.Pack(native)
+	}
+
+	var calls []multicallCall
+	if err := method.Inputs.Copy(&calls, args); err != nil {
+		return nil, err
+	}
+
+	results := make([]multicallResult, len(calls))
+	for i, batched := range calls {
+		target := batched.Target
+		data, err := m.CallContract(ctx, ethereum.CallMsg{To: &target, Data: batched.CallData}, blockNumber)
+		if err != nil && !batched.AllowFailure {
+			return nil, err
+		}
+		results[i] = multicallResult{Success: err == nil, ReturnData: data}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func (m *mockBackend) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if m.codeless[account] {
+		return nil, nil
+	}
+	return []byte{0x60, 0x80}, nil
+}
+
+func (m *mockBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return m.head, nil
+}
+
+func (m *mockBackend) NetworkID(ctx context.Context) (*big.Int, error) {
+	if m.networkErr != nil {
+		return nil, m.networkErr
+	}
+	return big.NewInt(56), nil
+}
+
+func (m *mockBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(m.head), Time: m.headTime}, nil
+}
+
+func (m *mockBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	m.estimated = append(m.estimated, call)
+	if m.estimateErr != nil {
+		return 0, m.estimateErr
+	}
+	return 200000, nil
+}
+
+func (m *mockBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	m.gasCalls++
+	return new(big.Int).Set(m.gasPrice), nil
+}
+
+func (m *mockBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (m *mockBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if m.onSend != nil {
+		m.onSend(tx)
+	}
+	m.sent = append(m.sent, tx)
+	if len(m.sendErrs) > 0 {
+		err := m.sendErrs[0]
+		m.sendErrs = m.sendErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func (m *mockBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if receipt, mined := m.receipts[txHash]; mined {
+		return receipt, nil
+	}
+	if m.mine != nil {
+		for _, tx := range m.sent {
+			if tx.Hash() == txHash {
+				if receipt := m.mine(tx); receipt != nil {
+					return receipt, nil
+				}
+			}
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+// credit adds amount, which may be negative, to the wallet's balance of token
+func (m *mockBackend) credit(token common.Address, amount *big.Int) {
+	balance, exists := m.balances[token]
+	if !exists {
+		balance = big.NewInt(0)
+	}
+	m.balances[token] = new(big.Int).Add(balance, amount)
+}
+
+// fillSwaps returns a mine hook that fills each router swap at its minimum
+// output, moving both tokens in the wallet's balances and logging the Transfer
+func (m *mockBackend) fillSwaps(wallet common.Address) func(tx *types.Transaction) *types.Receipt {
+	filled := make(map[common.Hash]*types.Receipt)
+	return func(tx *types.Transaction) *types.Receipt {
+		if receipt, done := filled[tx.Hash()]; done {
+			return receipt
+		}
+
+		method, err := contracts.RouterABI.MethodById(tx.Data()[:4])
+		if err != nil || method.Name != "swapExactTokensForTokens" {
+			return nil
+		}
+		args, err := method.Inputs.Unpack(tx.Data()[4:])
+		if err != nil {
+			return nil
+		}
+		amountIn, minOut := args[0].(*big.Int), args[1].(*big.Int)
+		path := args[2].([]common.Address)
+		tokenOut := path[len(path)-1]
+
+		m.credit(path[0], new(big.Int).Neg(amountIn))
+		m.credit(tokenOut, minOut)
+
+		receipt := &types.Receipt{
+			Status:  types.ReceiptStatusSuccessful,
+			TxHash:  tx.Hash(),
+			GasUsed: 150000,
+			Logs:    []*types.Log{transferLog(tokenOut, *tx.To(), wallet, minOut)},
+		}
+		filled[tx.Hash()] = receipt
+		return receipt
+	}
+}
+
+// transferLog builds an ERC20 Transfer event of amount of token
+func transferLog(token, from, to common.Address, amount *big.Int) *types.Log {
+	return &types.Log{
+		Address: token,
+		Topics:  []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.LeftPadBytes(amount.Bytes(), 32),
+	}
+}
+
+// rateQuote returns a quoteFunc that multiplies each hop by num/den
+func rateQuote(num, den int64) quoteFunc {
+	return func(amountIn *big.Int, path []common.Address) []*big.Int {
+		amounts := []*big.Int{new(big.Int).Set(amountIn)}
+		current := amountIn
+		for i := 1; i < len(path); i++ {
+			current = new(big.Int).Div(new(big.Int).Mul(current, big.NewInt(num)), big.NewInt(den))
+			amounts = append(amounts, current)
+		}
+		return amounts
+	}
+}
+
+// newTestConfig returns a config with the same defaults LoadConfig uses
+func newTestConfig() *config.Config {
+	return &config.Config{
+		GasLimit:       600000,
+		GasPrice:       5000000000,
+		MinProfit:      0.005,
+		MaxSlippage:    0.02,
+		CooldownPeriod: 30,
+		PlatformFeeBps: 1000,
+		ReceiptTimeout: 90 * time.Second,
+		V3FeeTiers:     []uint32{100, 500, 2500, 10000},
+		Strategies:     []string{config.StrategyTriangular},
+		BaseTokens:     []string{"WBNB"},
+
+		PancakeswapFeeBps: 25,
+		BiswapFeeBps:      20,
+	}
+}
+
+// newTestArbitrageService wires the services together on top of a mock backend
+func newTestArbitrageService(t *testing.T, backend *mockBackend) *ArbitrageService {
+	t.Helper()
+
+	if err := contracts.Initialize(); err != nil {
+		t.Fatalf("failed to initialize ABIs: %v", err)
+	}
+
+	cfg := newTestConfig()
+	tokenService := &TokenService{Backend: backend}
+	routerService := &RouterService{
+		Backend:      backend,
+		TokenService: tokenService,
+		Config:       cfg,
+		RouterABI:    contracts.RouterABI,
+	}
+
+	v3Router := &V3RouterService{
+		Backend:   backend,
+		Config:    cfg,
+		QuoterABI: contracts.QuoterV2ABI,
+		Quoter:    common.HexToAddress(config.PancakeswapV3Quoter),
+	}
+
+	service := &ArbitrageService{
+		Backend:       backend,
+		TokenService:  tokenService,
+		RouterService: routerService,
+		V3Router:      v3Router,
+		Config:        cfg,
+		Clock:         utils.SystemClock{},
+		DEXes:         DefaultDEXes(routerService),
+		enhancedStats: EnhancedStats{CategoryStats: make(map[string]int)},
+	}
+	service.Strategies = NewStrategies(service, cfg.Strategies)
+	return service
+}
@@ -0,0 +1,138 @@
+// services/opportunity_log.go
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Outcomes recorded for a detected opportunity
+const (
+	OutcomeExecuted          = "executed"
+	OutcomeCancelled         = "cancelled"
+	OutcomeMonitorOnly       = "monitor_only"
+	OutcomeManualDisabled    = "manual_disabled"
+	OutcomePreflightReverted = "preflight_reverted"
+	OutcomePreflightFailed   = "preflight_failed"
+	OutcomeNotConfirmed      = "not_confirmed"
+	OutcomeGasTooHigh        = "gas_too_high"
+	OutcomeTooManyPending    = "too_many_pending"
+	OutcomeLowNativeBalance  = "low_native_balance"
+	OutcomeLostRace          = "lost_race"
+	OutcomeFailed            = "failed"
+)
+
+// opportunityLogBuffer is how many events can wait for the writer before new
+// ones are dropped
+const opportunityLogBuffer = 256
+
+// OpportunityEvent is one line of the OPPORTUNITY_LOG stream
+type OpportunityEvent struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Pair          string    `json:"pair"`
+	Route         string    `json:"route"`
+	AmountWBNB    float64   `json:"amount_wbnb"`
+	GrossPercent  float64   `json:"gross_pct"`
+	NetProfitWBNB float64   `json:"net_wbnb"`
+	GasCostWBNB   float64   `json:"gas_wbnb"`
+	Executed      bool      `json:"executed"`
+	TxHash        string    `json:"tx_hash,omitempty"`
+	Outcome       string    `json:"outcome"`
+}
+
+// OpportunityLogger writes opportunity events as JSON lines from a background
+// goroutine. Record never blocks: when the writer falls behind, events are
+// dropped and counted rather than stalling the scan loop.
+type OpportunityLogger struct {
+	events  chan OpportunityEvent
+	done    chan struct{}
+	closer  io.Closer
+	dropped atomic.Int64
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// OpenOpportunityLog opens the OPPORTUNITY_LOG target: "stdout", or a file
+// path that is appended to
+func OpenOpportunityLog(target string) (*OpportunityLogger, error) {
+	if target == "stdout" {
+		return NewOpportunityLogger(os.Stdout, opportunityLogBuffer), nil
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open opportunity log: %v", err)
+	}
+	logger := NewOpportunityLogger(file, opportunityLogBuffer)
+	logger.closer = file
+	return logger, nil
+}
+
+// NewOpportunityLogger starts a logger writing to w that holds up to buffer
+// pending events
+func NewOpportunityLogger(w io.Writer, buffer int) *OpportunityLogger {
+	l := &OpportunityLogger{
+		events: make(chan OpportunityEvent, buffer),
+		done:   make(chan struct{}),
+	}
+	go l.run(w)
+	return l
+}
+
+// run writes events until the logger is closed
+func (l *OpportunityLogger) run(w io.Writer) {
+	defer close(l.done)
+
+	encoder := json.NewEncoder(w)
+	for event := range l.events {
+		if err := encoder.Encode(event); err != nil {
+			slog.Warn("⚠️ Failed to write opportunity event", "pair", event.Pair, "err", err)
+		}
+	}
+}
+
+// Record queues an event for writing, dropping it if the queue is full or the
+// logger is closed
+func (l *OpportunityLogger) Record(event OpportunityEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+
+	select {
+	case l.events <- event:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many events were discarded because the writer fell behind
+func (l *OpportunityLogger) Dropped() int64 {
+	return l.dropped.Load()
+}
+
+// Close writes any queued events and closes the underlying file
+func (l *OpportunityLogger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.events)
+	l.mu.Unlock()
+
+	<-l.done
+	if l.closer != nil {
+		return l.closer.Close()
+	}
+	return nil
+}
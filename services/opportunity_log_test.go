@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestOpportunityLoggerWritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewOpportunityLogger(&buf, 8)
+
+	logger.Record(OpportunityEvent{Pair: "WBNB-USDT-BUSD", Outcome: OutcomeNotConfirmed})
+	logger.Record(OpportunityEvent{Pair: "WBNB-CAKE-USDT", Executed: true, TxHash: "0xabc", Outcome: OutcomeExecuted})
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	var events []OpportunityEvent
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event OpportunityEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("wrote %d events, want 2", len(events))
+	}
+	if events[0].Executed || events[0].TxHash != "" || events[0].Outcome != OutcomeNotConfirmed {
+		t.Errorf("first event = %+v", events[0])
+	}
+	if !events[1].Executed || events[1].TxHash != "0xabc" {
+		t.Errorf("second event = %+v", events[1])
+	}
+
+	// Recording after Close is a no-op rather than a panic
+	logger.Record(OpportunityEvent{Pair: "late"})
+}
+
+// blockedWriter blocks every write until release is closed
+type blockedWriter struct {
+	release chan struct{}
+}
+
+func (w *blockedWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestOpportunityLoggerDropsWhenWriterStalls(t *testing.T) {
+	writer := &blockedWriter{release: make(chan struct{})}
+	logger := NewOpportunityLogger(writer, 2)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			logger.Record(OpportunityEvent{Pair: "WBNB-USDT-BUSD"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Record blocked on a stalled writer")
+	}
+
+	// One event may be held by the writer and two by the queue
+	if dropped := logger.Dropped(); dropped < 7 {
+		t.Errorf("Dropped = %d, want at least 7", dropped)
+	}
+
+	close(writer.release)
+	logger.Close()
+}
@@ -0,0 +1,102 @@
+// services/reserve_watcher.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// syncSubscriber is implemented by backends that can stream pool Sync events
+type syncSubscriber interface {
+	SubscribeSyncEvents(ctx context.Context, pools []common.Address, sink chan<- types.Log) (ethereum.Subscription, error)
+}
+
+// watchedPool is a tracked pool and what its quotes are cached under
+type watchedPool struct {
+	DEX            string
+	Router         common.Address
+	TokenA, TokenB common.Address
+}
+
+// WatchReserves subscribes to the Sync event of every tracked pool and, each
+// time one fires, drops only the cached quotes and reserves of that pool. It
+// returns when ctx is done or the subscription fails; callers resubscribe by
+// calling it again.
+func (s *ArbitrageService) WatchReserves(ctx context.Context) error {
+	subscriber, ok := s.Backend.(syncSubscriber)
+	if !ok {
+		return fmt.Errorf("backend does not support event subscriptions")
+	}
+
+	pools := s.trackedPools()
+	if len(pools) == 0 {
+		return fmt.Errorf("no pools to watch")
+	}
+	addresses := make([]common.Address, 0, len(pools))
+	for address := range pools {
+		addresses = append(addresses, address)
+	}
+
+	logs := make(chan types.Log, 256)
+	sub, err := subscriber.SubscribeSyncEvents(ctx, addresses, logs)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to Sync events: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	slog.Info("🔔 Watching pool reserves", "pools", len(addresses))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return fmt.Errorf("sync event subscription dropped: %v", err)
+		case event := <-logs:
+			s.handleSync(pools, event)
+		}
+	}
+}
+
+// handleSync invalidates the cache entries of the pool that emitted a Sync
+func (s *ArbitrageService) handleSync(pools map[common.Address]watchedPool, event types.Log) {
+	pool, tracked := pools[event.Address]
+	if !tracked || event.Removed {
+		return
+	}
+
+	dropped := s.RouterService.InvalidatePool(event.Address, pool.Router, pool.TokenA, pool.TokenB)
+	slog.Debug("🔔 Pool reserves changed", "dex", pool.DEX, "pool", event.Address.Hex(),
+		"block", event.BlockNumber, "quotes_dropped", dropped)
+}
+
+// trackedPools maps every configured pool of every pair to the exchange it
+// trades on and its two tokens
+func (s *ArbitrageService) trackedPools() map[common.Address]watchedPool {
+	pools := make(map[common.Address]watchedPool)
+	for i := range s.TokenPairs {
+		pair := &s.TokenPairs[i]
+		for _, dex := range s.DEXes {
+			for key, address := range dex.PairAddresses(pair) {
+				symbols := strings.SplitN(key, "-", 2)
+				if len(symbols) != 2 || pair.Tokens[symbols[0]] == "" || pair.Tokens[symbols[1]] == "" {
+					continue
+				}
+
+				pools[common.HexToAddress(address)] = watchedPool{
+					DEX:    dex.Name(),
+					Router: dex.Router(),
+					TokenA: common.HexToAddress(pair.Tokens[symbols[0]]),
+					TokenB: common.HexToAddress(pair.Tokens[symbols[1]]),
+				}
+			}
+		}
+	}
+	return pools
+}
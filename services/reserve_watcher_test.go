@@ -0,0 +1,67 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/models"
+)
+
+func TestHandleSyncInvalidatesTrackedPool(t *testing.T) {
+	backend := newMockBackend()
+	pancake := common.HexToAddress(config.PancakeswapRouter)
+	backend.quotes[pancake] = rateQuote(2, 1)
+
+	pool := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	pair := testPair()
+	pair.PancakeswapPair["WBNB-USDT"] = pool.Hex()
+	pair.BiswapPair["USDT-BUSD"] = "0x00000000000000000000000000000000000000b2"
+	pair.BiswapPair["WBNB-DOGE"] = "0x00000000000000000000000000000000000000b3"
+
+	service := newTestArbitrageService(t, backend)
+	service.TokenPairs = []models.TokenPair{pair}
+	wbnb, usdt := common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT)
+
+	// Pools whose tokens aren't in the pair can't be mapped to quotes
+	pools := service.trackedPools()
+	if len(pools) != 2 {
+		t.Fatalf("tracked %d pools, want 2: %v", len(pools), pools)
+	}
+	want := watchedPool{DEX: "PancakeSwap", Router: pancake, TokenA: wbnb, TokenB: usdt}
+	if pools[pool] != want {
+		t.Errorf("tracked pool = %+v, want %+v", pools[pool], want)
+	}
+
+	path := []common.Address{wbnb, usdt}
+	quote := func() {
+		if _, err := service.RouterService.GetAmountsOut(pancake, big.NewInt(1000), path); err != nil {
+			t.Fatalf("GetAmountsOut returned error: %v", err)
+		}
+	}
+
+	quote()
+
+	tests := []struct {
+		name      string
+		event     types.Log
+		wantCalls int
+	}{
+		{"untracked pool", types.Log{Address: common.HexToAddress("0xdead")}, 1},
+		{"removed by reorg", types.Log{Address: pool, Removed: true}, 1},
+		{"tracked pool", types.Log{Address: pool}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.handleSync(pools, tt.event)
+			quote()
+			if backend.calls != tt.wantCalls {
+				t.Errorf("backend calls = %d, want %d", backend.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// services/route.go
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/models"
+)
+
+// Hop is a single swap of a route on one exchange
+type Hop struct {
+	TokenIn   common.Address
+	TokenOut  common.Address
+	SymbolIn  string
+	SymbolOut string
+	DEX       DEX
+}
+
+// Path returns the router path for the hop
+func (h Hop) Path() []common.Address {
+	return []common.Address{h.TokenIn, h.TokenOut}
+}
+
+// Route is an ordered list of hops that starts and ends in the same base
+// token, usually WBNB. The scanner builds it once and both quoting and
+// execution walk the same hops.
+type Route struct {
+	Hops []Hop
+}
+
+// DEXNames returns the exchange name of each hop
+func (r Route) DEXNames() []string {
+	names := make([]string, len(r.Hops))
+	for i, hop := range r.Hops {
+		names[i] = hop.DEX.Name()
+	}
+	return names
+}
+
+// String names the exchange order, e.g. "PancakeSwap→BiSwap→PancakeSwap"
+func (r Route) String() string {
+	return strings.Join(r.DEXNames(), "→")
+}
+
+// Symbols returns the token symbols the route walks, first and last included
+func (r Route) Symbols() []string {
+	if len(r.Hops) == 0 {
+		return nil
+	}
+
+	symbols := make([]string, 0, len(r.Hops)+1)
+	for _, hop := range r.Hops {
+		symbols = append(symbols, hop.SymbolIn)
+	}
+	return append(symbols, r.Hops[len(r.Hops)-1].SymbolOut)
+}
+
+// BuildRoute lays out the triangular cycle base -> B -> C -> base of a pair,
+// swapping hop i on dexes[i]
+func (s *ArbitrageService) BuildRoute(pair models.TokenPair, base string, dexes []DEX) (Route, error) {
+	if pair.Tokens[base] == "" {
+		return Route{}, fmt.Errorf("pair %s has no %s token", pair.Name, base)
+	}
+
+	otherTokens := getOtherTokens(pair.Tokens, base)
+	if len(otherTokens) < 2 {
+		return Route{}, fmt.Errorf("need at least 3 tokens for triangular arbitrage, got %d", len(otherTokens)+1)
+	}
+
+	return buildCycle(pair, []string{base, otherTokens[0], otherTokens[1], base}, dexes)
+}
+
+// buildCycle lays out the hops from symbols[i] to symbols[i+1] of a pair,
+// swapping hop i on dexes[i]
+func buildCycle(pair models.TokenPair, symbols []string, dexes []DEX) (Route, error) {
+	if len(dexes) != len(symbols)-1 {
+		return Route{}, fmt.Errorf("route must have %d hops, got %d", len(symbols)-1, len(dexes))
+	}
+
+	for _, symbol := range symbols {
+		if pair.Tokens[symbol] == "" {
+			return Route{}, fmt.Errorf("pair %s has no %s token", pair.Name, symbol)
+		}
+	}
+
+	hops := make([]Hop, len(dexes))
+	for i, dex := range dexes {
+		hops[i] = Hop{
+			TokenIn:   common.HexToAddress(pair.Tokens[symbols[i]]),
+			TokenOut:  common.HexToAddress(pair.Tokens[symbols[i+1]]),
+			SymbolIn:  symbols[i],
+			SymbolOut: symbols[i+1],
+			DEX:       dex,
+		}
+
+		if hops[i].TokenIn == hops[i].TokenOut {
+			return Route{}, fmt.Errorf("token addresses must be different for arbitrage")
+		}
+	}
+
+	return Route{Hops: hops}, nil
+}
+
+// Routes returns every triangular route the scanner evaluates for a pair: the
+// outer hops on one exchange and the middle hop on another, for each ordered
+// pair of exchanges, anchored on each of the pair's base tokens
+func (s *ArbitrageService) Routes(pair models.TokenPair) ([]Route, error) {
+	var routes []Route
+	for _, base := range s.baseTokens(pair) {
+		for _, outer := range s.DEXes {
+			for _, middle := range s.DEXes {
+				if outer.Name() == middle.Name() {
+					continue
+				}
+
+				route, err := s.BuildRoute(pair, base, []DEX{outer, middle, outer})
+				if err != nil {
+					return nil, err
+				}
+				routes = append(routes, route)
+			}
+		}
+	}
+	return routes, nil
+}
+
+// baseTokens returns the BASE_TOKENS a pair holds, WBNB first when it is one
+// of them. Pairs holding none are anchored on WBNB.
+func (s *ArbitrageService) baseTokens(pair models.TokenPair) []string {
+	var bases []string
+	for _, symbol := range s.Config.BaseTokens {
+		if pair.Tokens[symbol] == "" {
+			continue
+		}
+		if symbol == "WBNB" {
+			bases = append([]string{symbol}, bases...)
+		} else {
+			bases = append(bases, symbol)
+		}
+	}
+
+	if len(bases) == 0 {
+		return []string{"WBNB"}
+	}
+	return bases
+}
+
+// BuildDirectRoute lays out the round trip WBNB -> symbol -> WBNB, buying on
+// one exchange and selling on another
+func (s *ArbitrageService) BuildDirectRoute(pair models.TokenPair, symbol string, buy, sell DEX) (Route, error) {
+	return buildCycle(pair, []string{"WBNB", symbol, "WBNB"}, []DEX{buy, sell})
+}
+
+// DirectRoutes returns every two-exchange round trip the direct strategy
+// evaluates for a pair: each of the pair's other tokens, bought on one
+// exchange and sold on another
+func (s *ArbitrageService) DirectRoutes(pair models.TokenPair) ([]Route, error) {
+	var routes []Route
+	for _, symbol := range getOtherTokens(pair.Tokens, "WBNB") {
+		for _, buy := range s.DEXes {
+			for _, sell := range s.DEXes {
+				if buy.Name() == sell.Name() {
+					continue
+				}
+
+				route, err := s.BuildDirectRoute(pair, symbol, buy, sell)
+				if err != nil {
+					return nil, err
+				}
+				routes = append(routes, route)
+			}
+		}
+	}
+	return routes, nil
+}
+
+// RouteFromNames rebuilds a pair's route on base from the exchange name of
+// each hop
+func (s *ArbitrageService) RouteFromNames(pair models.TokenPair, base string, names []string) (Route, error) {
+	dexes, err := s.findDEXes(names)
+	if err != nil {
+		return Route{}, err
+	}
+	return s.BuildRoute(pair, base, dexes)
+}
+
+// OpportunityRoute rebuilds the pair and route an opportunity was found on
+func (s *ArbitrageService) OpportunityRoute(opportunity models.Opportunity) (models.TokenPair, Route, error) {
+	pair, err := s.FindTokenPair(opportunity.PairName)
+	if err != nil {
+		return models.TokenPair{}, Route{}, err
+	}
+
+	dexes, err := s.findDEXes(opportunity.Venues)
+	if err != nil {
+		return models.TokenPair{}, Route{}, err
+	}
+
+	route, err := buildCycle(pair, opportunity.Symbols, dexes)
+	if err != nil {
+		return models.TokenPair{}, Route{}, fmt.Errorf("invalid %s route %s: %v", pair.Name, opportunity.Route(), err)
+	}
+	return pair, route, nil
+}
+
+// findDEXes looks up each named exchange
+func (s *ArbitrageService) findDEXes(names []string) ([]DEX, error) {
+	dexes := make([]DEX, len(names))
+	for i, name := range names {
+		dex, err := s.FindDEX(name)
+		if err != nil {
+			return nil, err
+		}
+		dexes[i] = dex
+	}
+	return dexes, nil
+}
+
+// FindDEX looks up a configured exchange by name, case-insensitively
+func (s *ArbitrageService) FindDEX(name string) (DEX, error) {
+	for _, dex := range s.DEXes {
+		if strings.EqualFold(dex.Name(), name) {
+			return dex, nil
+		}
+	}
+	return nil, fmt.Errorf("exchange %s is not configured", name)
+}
+
+// flashDirection maps a route onto the flash contract's fromPancake flag.
+// The contract only borrows WBNB and only knows PancakeSwap and BiSwap,
+// alternating.
+func flashDirection(route Route) (fromPancake bool, ok bool) {
+	if len(route.Hops) == 0 || route.Hops[0].SymbolIn != "WBNB" {
+		return false, false
+	}
+
+	switch route.String() {
+	case "PancakeSwap→BiSwap→PancakeSwap":
+		return true, true
+	case "BiSwap→PancakeSwap→BiSwap":
+		return false, true
+	}
+	return false, false
+}
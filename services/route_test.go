@@ -0,0 +1,128 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestRoutesAlternateExchanges(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+
+	routes, err := service.Routes(testPair())
+	if err != nil {
+		t.Fatalf("Routes returned error: %v", err)
+	}
+	want := []string{"PancakeSwap→BiSwap→PancakeSwap", "BiSwap→PancakeSwap→BiSwap"}
+	if len(routes) != len(want) {
+		t.Fatalf("got %d routes, want %d", len(routes), len(want))
+	}
+	for i, route := range routes {
+		if route.String() != want[i] {
+			t.Errorf("route %d = %s, want %s", i, route, want[i])
+		}
+	}
+
+	// Hops walk WBNB -> BUSD -> USDT -> WBNB
+	wantSymbols := []string{"WBNB", "BUSD", "USDT", "WBNB"}
+	for i, hop := range routes[0].Hops {
+		if hop.SymbolIn != wantSymbols[i] || hop.SymbolOut != wantSymbols[i+1] {
+			t.Errorf("hop %d = %s -> %s, want %s -> %s", i, hop.SymbolIn, hop.SymbolOut, wantSymbols[i], wantSymbols[i+1])
+		}
+	}
+
+	// A third exchange adds every ordered pairing with the existing two
+	service.DEXes = append(service.DEXes,
+		NewV2DEX("ApeSwap", common.HexToAddress("0x1"), common.HexToAddress("0x2"), 20, service.RouterService))
+	routes, err = service.Routes(testPair())
+	if err != nil {
+		t.Fatalf("Routes returned error: %v", err)
+	}
+	if got := len(routes); got != 6 {
+		t.Errorf("got %d routes with 3 exchanges, want 6", got)
+	}
+}
+
+func TestRoutesAnchoredOnEachBaseToken(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+	service.Config.BaseTokens = []string{"BUSD", "CAKE", "WBNB"}
+
+	routes, err := service.Routes(testPair())
+	if err != nil {
+		t.Fatalf("Routes returned error: %v", err)
+	}
+
+	// WBNB comes first and CAKE isn't in the pair
+	want := []string{
+		"WBNB BUSD USDT WBNB", "WBNB BUSD USDT WBNB",
+		"BUSD USDT WBNB BUSD", "BUSD USDT WBNB BUSD",
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("got %d routes, want %d", len(routes), len(want))
+	}
+	for i, route := range routes {
+		if got := strings.Join(route.Symbols(), " "); got != want[i] {
+			t.Errorf("route %d walks %s, want %s", i, got, want[i])
+		}
+	}
+
+	// The flash contract only borrows WBNB
+	if _, ok := flashDirection(routes[0]); !ok {
+		t.Errorf("flashDirection rejected WBNB route %s", routes[0])
+	}
+	if _, ok := flashDirection(routes[2]); ok {
+		t.Errorf("flashDirection accepted BUSD route %s", routes[2])
+	}
+}
+
+func TestDirectRoutesBuyAndSellOnDifferentExchanges(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+
+	routes, err := service.DirectRoutes(testPair())
+	if err != nil {
+		t.Fatalf("DirectRoutes returned error: %v", err)
+	}
+
+	// Each of BUSD and USDT, bought on one exchange and sold on the other
+	want := []string{"PancakeSwap→BiSwap", "BiSwap→PancakeSwap", "PancakeSwap→BiSwap", "BiSwap→PancakeSwap"}
+	if len(routes) != len(want) {
+		t.Fatalf("got %d routes, want %d", len(routes), len(want))
+	}
+	for i, route := range routes {
+		if route.String() != want[i] {
+			t.Errorf("route %d = %s, want %s", i, route, want[i])
+		}
+		first, last := route.Hops[0], route.Hops[len(route.Hops)-1]
+		if first.SymbolIn != "WBNB" || last.SymbolOut != "WBNB" || first.TokenOut != last.TokenIn {
+			t.Errorf("route %d = %s -> %s -> %s, want a WBNB round trip", i, first.SymbolIn, first.SymbolOut, last.SymbolOut)
+		}
+	}
+
+	// Direct routes never match the flash contract's triangular directions
+	if _, ok := flashDirection(routes[0]); ok {
+		t.Errorf("flashDirection accepted direct route %s", routes[0])
+	}
+}
+
+func TestFlashDirection(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+
+	tests := []struct {
+		names       []string
+		fromPancake bool
+		ok          bool
+	}{
+		{[]string{"PancakeSwap", "BiSwap", "PancakeSwap"}, true, true},
+		{[]string{"BiSwap", "PancakeSwap", "BiSwap"}, false, true},
+		{[]string{"PancakeSwap", "PancakeSwap", "BiSwap"}, false, false},
+	}
+
+	for _, tt := range tests {
+		route := mustRoute(t, service, testPair(), tt.names...)
+		fromPancake, ok := flashDirection(route)
+		if fromPancake != tt.fromPancake || ok != tt.ok {
+			t.Errorf("flashDirection(%s) = %v, %v, want %v, %v", route, fromPancake, ok, tt.fromPancake, tt.ok)
+		}
+	}
+}
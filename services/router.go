@@ -3,10 +3,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -16,26 +18,64 @@ import (
 
 	"arbitrage-bot/config"
 	"arbitrage-bot/contracts"
+	"arbitrage-bot/models"
 )
 
+// ErrPairNotFound is returned by GetPairFromFactory when the factory has no
+// pool for the two tokens
+var ErrPairNotFound = errors.New("pair does not exist")
+
 // RouterService handles operations related to DEX routers
 type RouterService struct {
 	Client       *EthClient
+	Backend      ContractCaller
 	TokenService *TokenService
 	Config       *config.Config
 	RouterABI    abi.ABI
+
+	// Per-scan getAmountsOut cache, cleared by ResetQuoteCache and per pool
+	// by InvalidatePool
+	quoteMu    sync.Mutex
+	quoteCache map[string]cachedQuote
+
+	// Block that quote and reserve reads are pinned to; nil reads latest
+	quoteBlock *big.Int
+
+	// Context of the scan the reads belong to; nil reads are never cancelled
+	quoteCtx context.Context
+
+	// Pool state for reserve-based estimates. Reserves are dropped with the
+	// quote cache; a pool's tokens and fee don't change.
+	reserveCache map[common.Address][2]*big.Int
+	poolTokens   map[common.Address][2]common.Address
+	poolFees     map[common.Address]int64
 }
 
 // NewRouterService creates a new RouterService
 func NewRouterService(client *EthClient, tokenService *TokenService, cfg *config.Config) *RouterService {
 	return &RouterService{
 		Client:       client,
+		Backend:      client,
 		TokenService: tokenService,
 		Config:       cfg,
 		RouterABI:    contracts.RouterABI,
 	}
 }
 
+// Quote quotes a request with getAmountsOut on its router
+func (s *RouterService) Quote(req models.QuoteRequest) (models.Quote, error) {
+	amounts, err := s.GetAmountsOut(req.Router, req.AmountIn, req.Path)
+	if err != nil {
+		return models.Quote{}, err
+	}
+
+	return models.Quote{
+		Request:   req,
+		Amounts:   amounts,
+		AmountOut: amounts[len(amounts)-1],
+	}, nil
+}
+
 // GetAmountsOut returns the expected output amounts for a given input amount and path
 func (s *RouterService) GetAmountsOut(router common.Address, amountIn *big.Int, path []common.Address) ([]*big.Int, error) {
 	if len(path) < 2 {
@@ -58,20 +98,23 @@ func (s *RouterService) GetAmountsOut(router common.Address, amountIn *big.Int,
 		}
 	}
 
+	// Reuse a quote already fetched during this scan
+	cacheKey := quoteCacheKey(router, amountIn, path)
+	if amounts, ok := s.loadQuote(cacheKey); ok {
+		return amounts, nil
+	}
+
 	// Pack the function call
 	callData, err := s.RouterABI.Pack("getAmountsOut", amountIn, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pack getAmountsOut: %v", err)
 	}
 
-	// Call the contract with timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	result, err := s.Client.Client.CallContract(ctx, ethereum.CallMsg{
+	// Call the contract, retrying transient RPC failures
+	result, err := callContract(s.QuoteContext(), s.Backend, "getAmountsOut", ethereum.CallMsg{
 		To:   &router,
 		Data: callData,
-	}, nil)
+	}, s.QuoteBlock())
 	if err != nil {
 		return nil, fmt.Errorf("failed to call getAmountsOut on router %s: %v", router.Hex(), err)
 	}
@@ -95,9 +138,144 @@ func (s *RouterService) GetAmountsOut(router common.Address, amountIn *big.Int,
 		}
 	}
 
+	s.storeQuote(cacheKey, router, path, amounts)
 	return amounts, nil
 }
 
+// SetQuoteBlock pins getAmountsOut and pair reserve reads to a block, or to
+// the latest block when nil. Cached quotes belong to the previous block, so
+// the cache is reset when the block changes.
+func (s *RouterService) SetQuoteBlock(block *big.Int) {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+
+	if block == nil && s.quoteBlock == nil || block != nil && s.quoteBlock != nil && block.Cmp(s.quoteBlock) == 0 {
+		return
+	}
+	s.quoteBlock = block
+	s.quoteCache = make(map[string]cachedQuote)
+	s.reserveCache = make(map[common.Address][2]*big.Int)
+}
+
+// QuoteBlock returns the block reads are pinned to, or nil for latest
+func (s *RouterService) QuoteBlock() *big.Int {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+	return s.quoteBlock
+}
+
+// SetQuoteContext ties quote and reserve reads to a scan's context so they are
+// abandoned when it is cancelled, or detaches them when nil
+func (s *RouterService) SetQuoteContext(ctx context.Context) {
+	s.quoteMu.Lock()
+	s.quoteCtx = ctx
+	s.quoteMu.Unlock()
+}
+
+// QuoteContext returns the context reads are tied to
+func (s *RouterService) QuoteContext() context.Context {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+	if s.quoteCtx == nil {
+		return context.Background()
+	}
+	return s.quoteCtx
+}
+
+// ResetQuoteCache drops all cached quotes. Call it at the start of each scan
+// and before executing, so quotes never outlive the block they came from.
+func (s *RouterService) ResetQuoteCache() {
+	s.quoteMu.Lock()
+	s.quoteCache = make(map[string]cachedQuote)
+	s.reserveCache = make(map[common.Address][2]*big.Int)
+	s.quoteMu.Unlock()
+}
+
+// InvalidatePool drops the cached reserves of a pool and every cached quote
+// from router whose path swaps between tokenA and tokenB, i.e. trades
+// through that pool, and returns how many quotes were dropped. Call it when
+// the pool's reserves change.
+func (s *RouterService) InvalidatePool(pool, router, tokenA, tokenB common.Address) int {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+
+	delete(s.reserveCache, pool)
+
+	dropped := 0
+	for key, quote := range s.quoteCache {
+		if quote.router == router && quote.tradesThrough(tokenA, tokenB) {
+			delete(s.quoteCache, key)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// cachedQuote is a getAmountsOut result and what it was quoted along
+type cachedQuote struct {
+	router  common.Address
+	path    []common.Address
+	amounts []*big.Int
+}
+
+// tradesThrough reports whether the quote swaps between tokenA and tokenB
+// in either direction on any hop
+func (q cachedQuote) tradesThrough(tokenA, tokenB common.Address) bool {
+	for i := 0; i+1 < len(q.path); i++ {
+		if q.path[i] == tokenA && q.path[i+1] == tokenB || q.path[i] == tokenB && q.path[i+1] == tokenA {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteCacheKey identifies a quote by router, input amount and path
+func quoteCacheKey(router common.Address, amountIn *big.Int, path []common.Address) string {
+	var key strings.Builder
+	key.WriteString(router.Hex())
+	key.WriteString(":")
+	key.WriteString(amountIn.String())
+	for _, addr := range path {
+		key.WriteString(":")
+		key.WriteString(addr.Hex())
+	}
+	return key.String()
+}
+
+func (s *RouterService) loadQuote(key string) ([]*big.Int, bool) {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+
+	quote, ok := s.quoteCache[key]
+	if !ok {
+		return nil, false
+	}
+	return copyAmounts(quote.amounts), true
+}
+
+func (s *RouterService) storeQuote(key string, router common.Address, path []common.Address, amounts []*big.Int) {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+
+	if s.quoteCache == nil {
+		s.quoteCache = make(map[string]cachedQuote)
+	}
+	s.quoteCache[key] = cachedQuote{
+		router:  router,
+		path:    append([]common.Address(nil), path...),
+		amounts: copyAmounts(amounts),
+	}
+}
+
+// copyAmounts deep-copies quote amounts so callers can't mutate the cache
+func copyAmounts(amounts []*big.Int) []*big.Int {
+	copied := make([]*big.Int, len(amounts))
+	for i, amount := range amounts {
+		copied[i] = new(big.Int).Set(amount)
+	}
+	return copied
+}
+
 // GetAmountOutSingle returns the expected output amount for a single swap
 func (s *RouterService) GetAmountOutSingle(router common.Address, amountIn *big.Int, path []common.Address) (*big.Int, error) {
 	amounts, err := s.GetAmountsOut(router, amountIn, path)
@@ -112,13 +290,16 @@ func (s *RouterService) GetAmountOutSingle(router common.Address, amountIn *big.
 	return amounts[len(amounts)-1], nil
 }
 
-// SwapExactTokensForTokens executes a token swap
+// SwapExactTokensForTokens executes a token swap. beforeSend, when set, sees
+// each signed transaction before it is submitted, so the caller can record its
+// hash first; an error from it cancels the send.
 func (s *RouterService) SwapExactTokensForTokens(
 	router common.Address,
 	amountIn *big.Int,
 	amountOutMin *big.Int,
 	path []common.Address,
-) (*common.Hash, error) {
+	beforeSend func(*types.Transaction) error,
+) (*types.Transaction, error) {
 	if len(path) < 2 {
 		return nil, fmt.Errorf("path must contain at least 2 tokens")
 	}
@@ -132,23 +313,19 @@ func (s *RouterService) SwapExactTokensForTokens(
 	}
 
 	// Get nonce
-	nonce, err := s.Client.Client.PendingNonceAt(context.Background(), s.Client.Address)
+	nonce, err := s.Backend.PendingNonceAt(context.Background(), s.Client.Address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get nonce: %v", err)
 	}
 
-	// Get gas price
-	gasPrice, err := s.Client.Client.SuggestGasPrice(context.Background())
+	// Get gas price, buffered for faster inclusion
+	gasPrice, err := suggestGasPrice(context.Background(), s.Backend, s.Config.GasPriceBufferPercent)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gas price: %v", err)
 	}
 
-	// Add 20% buffer to gas price for faster execution
-	gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(120))
-	gasPrice = new(big.Int).Div(gasPrice, big.NewInt(100))
-
-	// Calculate deadline (5 minutes from now)
-	deadline := big.NewInt(time.Now().Unix() + 300)
+	// Calculate deadline so stale transactions revert instead of filling late
+	deadline := big.NewInt(time.Now().Add(s.Config.SwapDeadline).Unix())
 
 	// Pack function call
 	callData, err := s.RouterABI.Pack(
@@ -173,23 +350,25 @@ func (s *RouterService) SwapExactTokensForTokens(
 		callData,
 	)
 
-	// Sign transaction
-	chainID := big.NewInt(56) // BSC chain ID
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), s.Client.PrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	send := sendProtectedTransaction
+	if beforeSend != nil {
+		send = func(ctx context.Context, backend ContractCaller, signedTx *types.Transaction) error {
+			if err := beforeSend(signedTx); err != nil {
+				return err
+			}
+			return sendProtectedTransaction(ctx, backend, signedTx)
+		}
 	}
 
-	// Send transaction
-	err = s.Client.Client.SendTransaction(context.Background(), signedTx)
+	// Sign and send, bumping the gas price if the node rejects it as underpriced
+	signedTx, err := sendWithGasBump(context.Background(), s.Backend, s.Client.Signer, s.Config, tx, send)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %v", err)
+		return nil, err
 	}
 
-	hash := signedTx.Hash()
-	log.Printf("Swap transaction sent: %s", hash.Hex())
+	slog.Info("Swap transaction sent", "tx", signedTx.Hash().Hex())
 
-	return &hash, nil
+	return signedTx, nil
 }
 
 // GetReserves gets the reserves of a liquidity pair
@@ -209,13 +388,10 @@ func (s *RouterService) GetReserves(pairAddress common.Address) (reserve0, reser
 	}
 
 	// Call the contract
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	result, err := s.Client.Client.CallContract(ctx, ethereum.CallMsg{
+	result, err := callContract(s.QuoteContext(), s.Backend, "getReserves", ethereum.CallMsg{
 		To:   &pairAddress,
 		Data: callData,
-	}, nil)
+	}, s.QuoteBlock())
 	if err != nil {
 		return nil, nil, 0, fmt.Errorf("failed to call getReserves: %v", err)
 	}
@@ -235,6 +411,368 @@ func (s *RouterService) GetReserves(pairAddress common.Address) (reserve0, reser
 	return reserves.Reserve0, reserves.Reserve1, reserves.BlockTimestampLast, nil
 }
 
+// GetPairTokens returns the token0 and token1 addresses of a liquidity pair
+func (s *RouterService) GetPairTokens(pairAddress common.Address) (token0, token1 common.Address, err error) {
+	token0, err = s.callPairAddress(pairAddress, "token0")
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+
+	token1, err = s.callPairAddress(pairAddress, "token1")
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+
+	return token0, token1, nil
+}
+
+// GetOrientedReserves returns the reserves of a liquidity pair ordered so that
+// reserveA belongs to tokenA. Pairs sort tokens by address, so without this
+// ratios come out inverted for roughly half of all pairs. It fails unless the
+// pair holds exactly tokenA and tokenB.
+func (s *RouterService) GetOrientedReserves(pairAddress, tokenA, tokenB common.Address) (reserveA, reserveB *big.Int, err error) {
+	reserve0, reserve1, _, err := s.GetReserves(pairAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token0, token1, err := s.GetPairTokens(pairAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return orientReserves(pairAddress, [2]common.Address{token0, token1}, [2]*big.Int{reserve0, reserve1}, tokenA, tokenB)
+}
+
+// orientReserves orders a pool's reserves as tokenA, tokenB, failing when the
+// pool's tokens are not those two
+func orientReserves(pool common.Address, tokens [2]common.Address, reserves [2]*big.Int, tokenA, tokenB common.Address) (reserveA, reserveB *big.Int, err error) {
+	switch {
+	case tokens[0] == tokenA && tokens[1] == tokenB:
+		return reserves[0], reserves[1], nil
+	case tokens[1] == tokenA && tokens[0] == tokenB:
+		return reserves[1], reserves[0], nil
+	default:
+		return nil, nil, fmt.Errorf("pair %s holds %s and %s, not %s and %s", pool.Hex(),
+			tokens[0].Hex(), tokens[1].Hex(), tokenA.Hex(), tokenB.Hex())
+	}
+}
+
+// GetAmountOutFromReserves applies the constant-product formula of a V2
+// pool: the input less feeBps joins reserveIn, and keeping reserveIn*reserveOut
+// constant gives the amount that leaves reserveOut
+func (s *RouterService) GetAmountOutFromReserves(amountIn, reserveIn, reserveOut *big.Int, feeBps int64) *big.Int {
+	if amountIn.Sign() <= 0 || reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(10000-feeBps))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(10000))
+	denominator.Add(denominator, amountInWithFee)
+	return numerator.Div(numerator, denominator)
+}
+
+// OptimalDirectAmount returns the input that maximizes the profit of a
+// two-pool arbitrage: buying on the first pool and selling straight back on
+// the second. Reserves are oriented along the trade, so the first pool's
+// reserveIn and the second pool's reserveOut are the same token. It returns 0
+// when the pools' prices leave no profit after fees.
+//
+// Chaining the two constant-product swaps gives out = A·x / (B + C·x), and
+// out - x peaks where its derivative A·B / (B + C·x)² is 1:
+//
+//	x = (√(A·B) - B) / C
+//	A = γ1·γ2·buyOut·sellOut,  B = buyIn·sellIn,  C = γ1·(sellIn + γ2·buyOut)
+//
+// with γ the share of the input each pool keeps after its fee. Everything is
+// scaled by 10000 to stay in integers.
+func (s *RouterService) OptimalDirectAmount(
+	buyReserveIn, buyReserveOut *big.Int, buyFeeBps int64,
+	sellReserveIn, sellReserveOut *big.Int, sellFeeBps int64,
+) *big.Int {
+	for _, reserve := range []*big.Int{buyReserveIn, buyReserveOut, sellReserveIn, sellReserveOut} {
+		if reserve.Sign() <= 0 {
+			return big.NewInt(0)
+		}
+	}
+
+	buyKeep := big.NewInt(10000 - buyFeeBps)
+	sellKeep := big.NewInt(10000 - sellFeeBps)
+	scale := big.NewInt(10000)
+
+	// a = γ1·γ2·buyOut·sellOut·10000², b = buyIn·sellIn
+	a := new(big.Int).Mul(buyKeep, sellKeep)
+	a.Mul(a, buyReserveOut).Mul(a, sellReserveOut)
+	b := new(big.Int).Mul(buyReserveIn, sellReserveIn)
+
+	// c = γ1·(sellIn + γ2·buyOut)·10000²
+	c := new(big.Int).Mul(sellReserveIn, scale)
+	c.Add(c, new(big.Int).Mul(sellKeep, buyReserveOut))
+	c.Mul(c, buyKeep)
+
+	// x = (√(a·b)·10000 - b·10000²) / c
+	numerator := new(big.Int).Sqrt(new(big.Int).Mul(a, b))
+	numerator.Mul(numerator, scale)
+	numerator.Sub(numerator, new(big.Int).Mul(b, new(big.Int).Mul(scale, scale)))
+	if numerator.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+	return numerator.Div(numerator, c)
+}
+
+// OptimalDirectPoolAmount sizes a two-pool arbitrage from the pools
+// themselves: buying tokenOut with tokenIn on buyPool and selling it back on
+// sellPool. It reads both pools' reserves oriented along the trade and charges
+// each exchange's fee, then returns OptimalDirectAmount.
+func (s *RouterService) OptimalDirectPoolAmount(
+	buyPool common.Address, buyDEX DEX,
+	sellPool common.Address, sellDEX DEX,
+	tokenIn, tokenOut common.Address,
+) (*big.Int, error) {
+	buyReserveIn, buyReserveOut, err := s.GetOrientedReserves(buyPool, tokenIn, tokenOut)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s reserves: %v", buyDEX.Name(), err)
+	}
+	sellReserveIn, sellReserveOut, err := s.GetOrientedReserves(sellPool, tokenOut, tokenIn)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s reserves: %v", sellDEX.Name(), err)
+	}
+
+	return s.OptimalDirectAmount(
+		buyReserveIn, buyReserveOut, buyDEX.FeeBps(),
+		sellReserveIn, sellReserveOut, sellDEX.FeeBps(),
+	), nil
+}
+
+// EstimateAmountOut estimates a swap of tokenIn through pool locally from
+// its reserves, which are read once per quote block, instead of quoting it
+// on the router
+func (s *RouterService) EstimateAmountOut(pool, tokenIn common.Address, amountIn *big.Int, feeBps int64) (*big.Int, error) {
+	reserveIn, reserveOut, err := s.cachedOrientedReserves(pool, tokenIn)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAmountOutFromReserves(amountIn, reserveIn, reserveOut, feeBps), nil
+}
+
+// cachedOrientedReserves orders a pool's reserves from tokenIn's side, served
+// from the per-block reserve cache
+func (s *RouterService) cachedOrientedReserves(pool, tokenIn common.Address) (reserveIn, reserveOut *big.Int, err error) {
+	tokens, reserves, err := s.cachedPool(pool)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch tokenIn {
+	case tokens[0]:
+		return reserves[0], reserves[1], nil
+	case tokens[1]:
+		return reserves[1], reserves[0], nil
+	default:
+		return nil, nil, fmt.Errorf("token %s is not part of pair %s", tokenIn.Hex(), pool.Hex())
+	}
+}
+
+// cachedPairReserves is GetOrientedReserves served from the per-block reserve
+// cache
+func (s *RouterService) cachedPairReserves(pool, tokenA, tokenB common.Address) (reserveA, reserveB *big.Int, err error) {
+	tokens, reserves, err := s.cachedPool(pool)
+	if err != nil {
+		return nil, nil, err
+	}
+	return orientReserves(pool, tokens, reserves, tokenA, tokenB)
+}
+
+// cachedPool returns a pool's tokens and reserves, reading the tokens once
+// and the reserves once per quote block
+func (s *RouterService) cachedPool(pool common.Address) (tokens [2]common.Address, reserves [2]*big.Int, err error) {
+	s.quoteMu.Lock()
+	tokens, knownTokens := s.poolTokens[pool]
+	reserves, knownReserves := s.reserveCache[pool]
+	s.quoteMu.Unlock()
+
+	if !knownTokens {
+		token0, token1, err := s.GetPairTokens(pool)
+		if err != nil {
+			return tokens, reserves, err
+		}
+		tokens = [2]common.Address{token0, token1}
+	}
+
+	if !knownReserves {
+		reserve0, reserve1, _, err := s.GetReserves(pool)
+		if err != nil {
+			return tokens, reserves, err
+		}
+		reserves = [2]*big.Int{reserve0, reserve1}
+	}
+
+	s.quoteMu.Lock()
+	if s.poolTokens == nil {
+		s.poolTokens = make(map[common.Address][2]common.Address)
+	}
+	if s.reserveCache == nil {
+		s.reserveCache = make(map[common.Address][2]*big.Int)
+	}
+	s.poolTokens[pool] = tokens
+	s.reserveCache[pool] = reserves
+	s.quoteMu.Unlock()
+
+	return tokens, reserves, nil
+}
+
+// PoolFeeBps returns the fee a pool reports through swapFee(), which BiSwap
+// pools express in tenths of a percent, or fallbackBps if it can't be read
+func (s *RouterService) PoolFeeBps(pool common.Address, fallbackBps int64) int64 {
+	s.quoteMu.Lock()
+	feeBps, known := s.poolFees[pool]
+	s.quoteMu.Unlock()
+	if known {
+		return feeBps
+	}
+
+	callData, err := contracts.PairABI.Pack("swapFee")
+	if err != nil {
+		return fallbackBps
+	}
+
+	result, err := callContract(s.QuoteContext(), s.Backend, "swapFee", ethereum.CallMsg{
+		To:   &pool,
+		Data: callData,
+	}, nil)
+	if err != nil {
+		// A revert means the pool has no swapFee(); a failed read is retried
+		if IsRevertError(err) {
+			s.storePoolFee(pool, fallbackBps)
+		}
+		return fallbackBps
+	}
+
+	var fee uint32
+	if err := contracts.PairABI.UnpackIntoInterface(&fee, "swapFee", result); err != nil {
+		s.storePoolFee(pool, fallbackBps) // answered, but not with a fee
+		return fallbackBps
+	}
+
+	feeBps = int64(fee) * 10
+	s.storePoolFee(pool, feeBps)
+	return feeBps
+}
+
+// storePoolFee remembers a pool's fee
+func (s *RouterService) storePoolFee(pool common.Address, feeBps int64) {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+
+	if s.poolFees == nil {
+		s.poolFees = make(map[common.Address]int64)
+	}
+	s.poolFees[pool] = feeBps
+}
+
+// GetSpotPrice returns the spot price of tokenIn in units of the other pair
+// token, computed from reserves and adjusted for both tokens' decimals
+func (s *RouterService) GetSpotPrice(pairAddress, tokenIn common.Address) (float64, error) {
+	token0, token1, err := s.GetPairTokens(pairAddress)
+	if err != nil {
+		return 0, err
+	}
+
+	tokenOut := token0
+	if tokenIn == token0 {
+		tokenOut = token1
+	}
+
+	reserveIn, reserveOut, err := s.GetOrientedReserves(pairAddress, tokenIn, tokenOut)
+	if err != nil {
+		return 0, err
+	}
+
+	if reserveIn.Sign() == 0 {
+		return 0, fmt.Errorf("pair %s has no reserves for %s", pairAddress.Hex(), tokenIn.Hex())
+	}
+
+	decimalsIn, err := s.TokenService.GetTokenDecimals(tokenIn)
+	if err != nil {
+		return 0, err
+	}
+
+	decimalsOut, err := s.TokenService.GetTokenDecimals(tokenOut)
+	if err != nil {
+		return 0, err
+	}
+
+	readableIn := s.TokenService.ConvertToReadable(reserveIn, decimalsIn)
+	readableOut := s.TokenService.ConvertToReadable(reserveOut, decimalsOut)
+
+	return readableOut / readableIn, nil
+}
+
+// callPairAddress calls an address-returning view function on a pair contract
+func (s *RouterService) callPairAddress(pairAddress common.Address, method string) (common.Address, error) {
+	callData, err := contracts.PairABI.Pack(method)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to pack %s: %v", method, err)
+	}
+
+	result, err := callContract(s.QuoteContext(), s.Backend, method, ethereum.CallMsg{
+		To:   &pairAddress,
+		Data: callData,
+	}, nil)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to call %s: %v", method, err)
+	}
+
+	var token common.Address
+	err = contracts.PairABI.UnpackIntoInterface(&token, method, result)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack %s result: %v", method, err)
+	}
+
+	return token, nil
+}
+
+// GetPairFromFactory gets a pair address from a V2 factory contract
+func (s *RouterService) GetPairFromFactory(factoryAddress, tokenA, tokenB common.Address) (common.Address, error) {
+	// Factory ABI
+	factoryABI := `[{"inputs":[{"internalType":"address","name":"tokenA","type":"address"},{"internalType":"address","name":"tokenB","type":"address"}],"name":"getPair","outputs":[{"internalType":"address","name":"pair","type":"address"}],"stateMutability":"view","type":"function"}]`
+
+	parsed, err := abi.JSON(strings.NewReader(factoryABI))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to parse factory ABI: %v", err)
+	}
+
+	// Pack parameters
+	callData, err := parsed.Pack("getPair", tokenA, tokenB)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to pack getPair: %v", err)
+	}
+
+	// Call contract
+	result, err := callContract(s.QuoteContext(), s.Backend, "getPair", ethereum.CallMsg{
+		To:   &factoryAddress,
+		Data: callData,
+	}, nil)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to call getPair: %v", err)
+	}
+
+	// Unpack result
+	var pairAddress common.Address
+	err = parsed.UnpackIntoInterface(&pairAddress, "getPair", result)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack getPair result: %v", err)
+	}
+
+	// Check if pair exists
+	if pairAddress == (common.Address{}) {
+		return common.Address{}, ErrPairNotFound
+	}
+
+	return pairAddress, nil
+}
+
 // ValidateSwapPath validates that a swap path is valid
 func (s *RouterService) ValidateSwapPath(path []common.Address) error {
 	if len(path) < 2 {
@@ -274,7 +812,7 @@ func (s *RouterService) EstimateGasForSwap(
 	}
 
 	// Calculate deadline
-	deadline := big.NewInt(time.Now().Unix() + 300)
+	deadline := big.NewInt(time.Now().Add(s.Config.SwapDeadline).Unix())
 
 	// Pack function call
 	callData, err := s.RouterABI.Pack(
@@ -293,17 +831,17 @@ func (s *RouterService) EstimateGasForSwap(
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	gasLimit, err := s.Client.Client.EstimateGas(ctx, ethereum.CallMsg{
+	gasLimit, err := s.Backend.EstimateGas(ctx, ethereum.CallMsg{
 		From: s.Client.Address,
 		To:   &router,
 		Data: callData,
 	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to estimate gas: %v", err)
+		return 0, fmt.Errorf("failed to estimate gas: %w", err)
 	}
 
-	// Add 20% buffer
-	gasLimit = gasLimit * 120 / 100
+	// Leave headroom for state changing before the swap lands
+	gasLimit = gasLimit * uint64(100+s.Config.GasLimitBufferPercent) / 100
 
 	return gasLimit, nil
 }
@@ -320,19 +858,30 @@ func (s *RouterService) CheckLiquidity(router common.Address, amountIn *big.Int,
 		return fmt.Errorf("insufficient liquidity: %v", err)
 	}
 
-	// Check that output amount is reasonable (not too small)
+	// Check that the output is more than dust in the output token's own units
+	tokenOut := path[len(path)-1]
+	decimals, err := s.TokenService.GetTokenDecimals(tokenOut)
+	if err != nil {
+		return fmt.Errorf("failed to get output token decimals: %v", err)
+	}
+
+	symbol := tokenSymbol(tokenOut)
+	minOutput := s.Config.MinOutputFor(symbol)
 	finalAmount := amounts[len(amounts)-1]
-	if finalAmount.Cmp(big.NewInt(1000)) < 0 { // Less than 1000 wei
-		return fmt.Errorf("output amount too small, possible liquidity issue")
+	if finalAmount.Cmp(s.TokenService.FormatTokenAmount(minOutput, decimals)) < 0 {
+		return fmt.Errorf("output of %g %s is under the %g minimum, possible liquidity issue",
+			s.TokenService.ConvertToReadable(finalAmount, decimals), symbol, minOutput)
 	}
 
 	return nil
 }
 
-// GetPriceImpact calculates the price impact of a trade
+// GetPriceImpact calculates the aggregate price impact of a trade across the
+// whole path by comparing the end-to-end price of a tiny reference amount with
+// the end-to-end price of the actual amount
 func (s *RouterService) GetPriceImpact(router common.Address, amountIn *big.Int, path []common.Address) (float64, error) {
-	if len(path) != 2 {
-		return 0, fmt.Errorf("price impact calculation only supports direct pairs")
+	if len(path) < 2 {
+		return 0, fmt.Errorf("path must contain at least 2 tokens")
 	}
 
 	// Get small amount for reference price
@@ -353,9 +902,12 @@ func (s *RouterService) GetPriceImpact(router common.Address, amountIn *big.Int,
 		return 0, err
 	}
 
-	// Calculate price per unit
-	refPrice := new(big.Float).Quo(new(big.Float).SetInt(refAmounts[1]), new(big.Float).SetInt(smallAmount))
-	actualPrice := new(big.Float).Quo(new(big.Float).SetInt(actualAmounts[1]), new(big.Float).SetInt(amountIn))
+	// Calculate price per unit using the final output of the path
+	refOut := refAmounts[len(refAmounts)-1]
+	actualOut := actualAmounts[len(actualAmounts)-1]
+
+	refPrice := new(big.Float).Quo(new(big.Float).SetInt(refOut), new(big.Float).SetInt(smallAmount))
+	actualPrice := new(big.Float).Quo(new(big.Float).SetInt(actualOut), new(big.Float).SetInt(amountIn))
 
 	// Calculate price impact
 	priceDiff := new(big.Float).Sub(refPrice, actualPrice)
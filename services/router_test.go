@@ -0,0 +1,513 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/models"
+)
+
+func TestGetAmountsOutCachesWithinScan(t *testing.T) {
+	backend := newMockBackend()
+	router := common.HexToAddress(config.PancakeswapRouter)
+	backend.quotes[router] = rateQuote(2, 1)
+
+	service := newTestArbitrageService(t, backend).RouterService
+	path := []common.Address{common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT)}
+	amountIn := big.NewInt(1000)
+
+	first, err := service.GetAmountsOut(router, amountIn, path)
+	if err != nil {
+		t.Fatalf("GetAmountsOut returned error: %v", err)
+	}
+
+	// Mutating a returned quote must not leak into the cache
+	first[1].SetInt64(0)
+
+	second, err := service.GetAmountsOut(router, amountIn, path)
+	if err != nil {
+		t.Fatalf("GetAmountsOut returned error: %v", err)
+	}
+	if backend.calls != 1 {
+		t.Errorf("backend calls = %d, want 1 (second quote should be cached)", backend.calls)
+	}
+	if second[1].Cmp(big.NewInt(2000)) != 0 {
+		t.Errorf("cached amount = %s, want 2000", second[1])
+	}
+
+	// A different amount is a different quote
+	if _, err := service.GetAmountsOut(router, big.NewInt(500), path); err != nil {
+		t.Fatalf("GetAmountsOut returned error: %v", err)
+	}
+	if backend.calls != 2 {
+		t.Errorf("backend calls = %d, want 2", backend.calls)
+	}
+
+	service.ResetQuoteCache()
+	if _, err := service.GetAmountsOut(router, amountIn, path); err != nil {
+		t.Fatalf("GetAmountsOut returned error: %v", err)
+	}
+	if backend.calls != 3 {
+		t.Errorf("backend calls = %d, want 3 after reset", backend.calls)
+	}
+}
+
+func TestInvalidatePoolDropsOnlyQuotesThroughPool(t *testing.T) {
+	backend := newMockBackend()
+	pancake, biswap := common.HexToAddress(config.PancakeswapRouter), common.HexToAddress(config.BiswapRouter)
+	backend.quotes[pancake] = rateQuote(2, 1)
+	backend.quotes[biswap] = rateQuote(2, 1)
+
+	service := newTestArbitrageService(t, backend).RouterService
+	wbnb, usdt, busd := common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT), common.HexToAddress(config.BUSD)
+
+	quotes := []struct {
+		router common.Address
+		path   []common.Address
+	}{
+		{pancake, []common.Address{wbnb, usdt}},
+		{pancake, []common.Address{usdt, wbnb}},
+		{pancake, []common.Address{wbnb, busd, usdt}},
+		{pancake, []common.Address{usdt, busd}},
+		{biswap, []common.Address{wbnb, usdt}},
+	}
+	quoteAll := func() {
+		for _, q := range quotes {
+			if _, err := service.GetAmountsOut(q.router, big.NewInt(1000), q.path); err != nil {
+				t.Fatalf("GetAmountsOut returned error: %v", err)
+			}
+		}
+	}
+
+	quoteAll()
+	if backend.calls != len(quotes) {
+		t.Fatalf("backend calls = %d, want %d", backend.calls, len(quotes))
+	}
+
+	// The PancakeSwap WBNB/USDT pool moved: both directions on that router
+	// are stale, BiSwap and the paths avoiding that hop are not
+	pool := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	if dropped := service.InvalidatePool(pool, pancake, usdt, wbnb); dropped != 2 {
+		t.Errorf("InvalidatePool dropped %d quotes, want 2", dropped)
+	}
+
+	quoteAll()
+	if backend.calls != len(quotes)+2 {
+		t.Errorf("backend calls = %d, want %d after invalidating one pool", backend.calls, len(quotes)+2)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	backend := newMockBackend()
+	pancake, biswap := common.HexToAddress(config.PancakeswapRouter), common.HexToAddress(config.BiswapRouter)
+	backend.quotes[pancake] = rateQuote(3, 1)
+
+	service := newTestArbitrageService(t, backend).RouterService
+	req := models.QuoteRequest{
+		Router:   pancake,
+		AmountIn: big.NewInt(1000),
+		Path:     []common.Address{common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT), common.HexToAddress(config.BUSD)},
+	}
+
+	quote, err := service.Quote(req)
+	if err != nil {
+		t.Fatalf("Quote returned error: %v", err)
+	}
+	if len(quote.Amounts) != 3 || quote.AmountOut.Cmp(big.NewInt(9000)) != 0 {
+		t.Errorf("Quote amounts %v, out %s, want 3 amounts ending in 9000", quote.Amounts, quote.AmountOut)
+	}
+	if quote.Request.Router != pancake {
+		t.Errorf("Quote request router = %s, want %s", quote.Request.Router.Hex(), pancake.Hex())
+	}
+
+	// The router comes from the request, so a router without a quote fails
+	req.Router = biswap
+	if _, err := service.Quote(req); err == nil {
+		t.Error("expected error quoting a router with no liquidity")
+	}
+}
+
+func TestGetAmountOutFromReserves(t *testing.T) {
+	service := &RouterService{}
+
+	tests := []struct {
+		name       string
+		amountIn   int64
+		reserveIn  int64
+		reserveOut int64
+		feeBps     int64
+		want       int64
+	}{
+		// 1000 * 9975 * 100000 / (100000 * 10000 + 1000 * 9975) = 987.66
+		{"pancake fee", 1000, 100000, 100000, 25, 987},
+		{"biswap 0.1% fee", 1000, 100000, 100000, 10, 989},
+		{"no fee still slips", 1000, 100000, 100000, 0, 990},
+		{"uneven reserves", 1000, 100000, 200000, 25, 1975},
+		{"empty pool", 1000, 0, 100000, 25, 0},
+		{"zero input", 0, 100000, 100000, 25, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := service.GetAmountOutFromReserves(big.NewInt(tt.amountIn), big.NewInt(tt.reserveIn), big.NewInt(tt.reserveOut), tt.feeBps)
+			if got.Int64() != tt.want {
+				t.Errorf("GetAmountOutFromReserves = %s, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// Worked example: buy USDT with WBNB on a 1,000 WBNB / 300,000 USDT pool at
+// 0.25%, sell it back on a 280,000 USDT / 1,000 WBNB pool at 0.2%.
+//
+//	A = 0.9975·0.998·300,000·1,000       = 298,651,500 (×1e36)
+//	B = 1,000·280,000                    = 280,000,000 (×1e36)
+//	C = 0.9975·(280,000 + 0.998·300,000) = 577,951.5   (×1e18)
+//	x = (√(A·B) - B) / C ≈ 15.8758 WBNB, netting ≈ 0.5202 WBNB
+func TestOptimalDirectAmount(t *testing.T) {
+	service := &RouterService{}
+	profit := func(amountIn *big.Int) *big.Int {
+		usdt := service.GetAmountOutFromReserves(amountIn, wbnbAmount(1000), wbnbAmount(300000), 25)
+		out := service.GetAmountOutFromReserves(usdt, wbnbAmount(280000), wbnbAmount(1000), 20)
+		return out.Sub(out, amountIn)
+	}
+
+	got := service.OptimalDirectAmount(wbnbAmount(1000), wbnbAmount(300000), 25, wbnbAmount(280000), wbnbAmount(1000), 20)
+	want, _ := new(big.Int).SetString("15875750634223086752", 10)
+	if got.Cmp(want) != 0 {
+		t.Fatalf("OptimalDirectAmount = %s, want %s", got, want)
+	}
+
+	// Trading 0.01 WBNB more or less nets less
+	best := profit(got)
+	step := new(big.Int).Div(wbnbAmount(1), big.NewInt(100))
+	for _, amount := range []*big.Int{new(big.Int).Sub(got, step), new(big.Int).Add(got, step)} {
+		if p := profit(amount); p.Cmp(best) >= 0 {
+			t.Errorf("profit at %s = %s, not below %s at the optimum", amount, p, best)
+		}
+	}
+
+	// Prices within the fees leave nothing to trade
+	if got := service.OptimalDirectAmount(wbnbAmount(1000), wbnbAmount(300000), 25, wbnbAmount(299000), wbnbAmount(1000), 20); got.Sign() != 0 {
+		t.Errorf("OptimalDirectAmount without an edge = %s, want 0", got)
+	}
+	if got := service.OptimalDirectAmount(big.NewInt(0), wbnbAmount(300000), 25, wbnbAmount(280000), wbnbAmount(1000), 20); got.Sign() != 0 {
+		t.Errorf("OptimalDirectAmount with an empty pool = %s, want 0", got)
+	}
+}
+
+func TestEstimateAmountOutCachesReservesPerBlock(t *testing.T) {
+	const pool = "0x00000000000000000000000000000000000000a1"
+
+	backend := newMockBackend()
+	backend.listPool(config.PancakeswapFactory, pool, config.WBNB, config.USDT, big.NewInt(100000))
+	backend.pools[common.HexToAddress(pool)].reserve1 = big.NewInt(200000)
+
+	service := newTestArbitrageService(t, backend).RouterService
+	wbnb, usdt := common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT)
+
+	out, err := service.EstimateAmountOut(common.HexToAddress(pool), wbnb, big.NewInt(1000), 25)
+	if err != nil || out.Int64() != 1975 {
+		t.Fatalf("EstimateAmountOut(WBNB) = %v, %v; want 1975", out, err)
+	}
+
+	// The reverse direction reads the same cached reserves, oriented the other way
+	calls := backend.calls
+	out, err = service.EstimateAmountOut(common.HexToAddress(pool), usdt, big.NewInt(2000), 25)
+	if err != nil || out.Int64() != 987 {
+		t.Fatalf("EstimateAmountOut(USDT) = %v, %v; want 987", out, err)
+	}
+	if backend.calls != calls {
+		t.Errorf("made %d calls for cached reserves", backend.calls-calls)
+	}
+
+	// A new block re-reads reserves but not the pool's tokens
+	service.SetQuoteBlock(big.NewInt(100))
+	if _, err := service.EstimateAmountOut(common.HexToAddress(pool), wbnb, big.NewInt(1000), 25); err != nil {
+		t.Fatalf("EstimateAmountOut returned error: %v", err)
+	}
+	if backend.calls != calls+1 {
+		t.Errorf("made %d calls after a new block, want 1 getReserves", backend.calls-calls)
+	}
+}
+
+func TestGetOrientedReserves(t *testing.T) {
+	const pool = "0x00000000000000000000000000000000000000a1"
+
+	backend := newMockBackend()
+	backend.listPool(config.PancakeswapFactory, pool, config.WBNB, config.USDT, big.NewInt(100000))
+	backend.pools[common.HexToAddress(pool)].reserve1 = big.NewInt(200000)
+
+	service := newTestArbitrageService(t, backend).RouterService
+	wbnb, usdt, busd := common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT), common.HexToAddress(config.BUSD)
+
+	tests := []struct {
+		name           string
+		tokenA, tokenB common.Address
+		wantA, wantB   int64
+		wantErr        bool
+	}{
+		{name: "pool order", tokenA: wbnb, tokenB: usdt, wantA: 100000, wantB: 200000},
+		{name: "reversed", tokenA: usdt, tokenB: wbnb, wantA: 200000, wantB: 100000},
+		{name: "other token not in pool", tokenA: wbnb, tokenB: busd, wantErr: true},
+		{name: "neither token in pool", tokenA: busd, tokenB: wbnb, wantErr: true},
+		{name: "same token twice", tokenA: wbnb, tokenB: wbnb, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reserveA, reserveB, err := service.GetOrientedReserves(common.HexToAddress(pool), tt.tokenA, tt.tokenB)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GetOrientedReserves = %v, %v; want error", reserveA, reserveB)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetOrientedReserves returned error: %v", err)
+			}
+			if reserveA.Int64() != tt.wantA || reserveB.Int64() != tt.wantB {
+				t.Errorf("GetOrientedReserves = %v, %v; want %d, %d", reserveA, reserveB, tt.wantA, tt.wantB)
+			}
+		})
+	}
+}
+
+func TestPoolFeeBps(t *testing.T) {
+	const (
+		biswapPool = "0x00000000000000000000000000000000000000b1"
+		plainPool  = "0x00000000000000000000000000000000000000b2"
+	)
+
+	backend := newMockBackend()
+	backend.listPool(config.BiswapFactory, biswapPool, config.WBNB, config.USDT, big.NewInt(100000))
+	backend.listPool(config.BiswapFactory, plainPool, config.WBNB, config.BUSD, big.NewInt(100000))
+	backend.pools[common.HexToAddress(biswapPool)].swapFee = 1 // 0.1%
+
+	service := newTestArbitrageService(t, backend).RouterService
+
+	if got := service.PoolFeeBps(common.HexToAddress(biswapPool), 20); got != 10 {
+		t.Errorf("PoolFeeBps = %d, want 10 from swapFee()", got)
+	}
+	if got := service.PoolFeeBps(common.HexToAddress(plainPool), 20); got != 20 {
+		t.Errorf("PoolFeeBps = %d, want the 20 bps fallback", got)
+	}
+
+	calls := backend.calls
+	service.PoolFeeBps(common.HexToAddress(biswapPool), 20)
+	service.PoolFeeBps(common.HexToAddress(plainPool), 20)
+	if backend.calls != calls {
+		t.Errorf("made %d calls for cached fees", backend.calls-calls)
+	}
+}
+
+// constantProductQuote quotes every hop against an x*y=k pool holding reserve
+// of each token, with no fee
+func constantProductQuote(reserve *big.Int) quoteFunc {
+	return func(amountIn *big.Int, path []common.Address) []*big.Int {
+		amounts := []*big.Int{new(big.Int).Set(amountIn)}
+		current := amountIn
+		for i := 1; i < len(path); i++ {
+			numerator := new(big.Int).Mul(current, reserve)
+			current = numerator.Div(numerator, new(big.Int).Add(reserve, current))
+			amounts = append(amounts, current)
+		}
+		return amounts
+	}
+}
+
+func TestGetPriceImpact(t *testing.T) {
+	router := common.HexToAddress(config.PancakeswapRouter)
+	wbnb, usdt, busd := common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT), common.HexToAddress(config.BUSD)
+
+	tests := []struct {
+		name     string
+		quote    quoteFunc
+		amountIn *big.Int
+		path     []common.Address
+		want     float64 // percent
+	}{
+		{
+			// A fixed rate has no impact however large the trade
+			name:     "fixed rate",
+			quote:    rateQuote(300, 1),
+			amountIn: wbnbAmount(100),
+			path:     []common.Address{wbnb, usdt},
+			want:     0,
+		},
+		{
+			// 100 into a 1000/1000 pool, against the 0.1 reference trade:
+			// 1 - (1000/1100)/(1000/1000.1) = 1 - 1000.1/1100
+			name:     "10% of the pool",
+			quote:    constantProductQuote(wbnbAmount(1000)),
+			amountIn: wbnbAmount(100),
+			path:     []common.Address{wbnb, usdt},
+			want:     100 * (1 - 1000.1/1100),
+		},
+		{
+			// Two equal hops behave like one pool of 1000 taking 2x the
+			// amount: 100 → 1000/12 and 0.1 → 0.1*1000/1000.2, so the
+			// impact is 1 - 1000.2/1200
+			name:     "two hops through equal pools",
+			quote:    constantProductQuote(wbnbAmount(1000)),
+			amountIn: wbnbAmount(100),
+			path:     []common.Address{wbnb, usdt, busd},
+			want:     100 * (1 - 1000.2/1200),
+		},
+		{
+			// Below 1000 wei the reference trade is floored at 1 wei
+			name:     "dust amount",
+			quote:    rateQuote(2, 1),
+			amountIn: big.NewInt(10),
+			path:     []common.Address{wbnb, usdt},
+			want:     0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.quotes[router] = tt.quote
+			service := newTestArbitrageService(t, backend).RouterService
+
+			got, err := service.GetPriceImpact(router, tt.amountIn, tt.path)
+			if err != nil {
+				t.Fatalf("GetPriceImpact returned error: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("GetPriceImpact = %.8f%%, want %.8f%%", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		backend := newMockBackend()
+		service := newTestArbitrageService(t, backend).RouterService
+
+		if _, err := service.GetPriceImpact(router, wbnbAmount(1), []common.Address{wbnb}); err == nil {
+			t.Error("GetPriceImpact accepted a single-token path")
+		}
+
+		backend.callErr = errors.New("execution reverted")
+		if _, err := service.GetPriceImpact(router, wbnbAmount(1), []common.Address{wbnb, usdt}); err == nil {
+			t.Error("GetPriceImpact ignored a failed quote")
+		}
+	})
+}
+
+func TestValidateSwapPath(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend()).RouterService
+	wbnb, usdt, busd, cake, doge := common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT),
+		common.HexToAddress(config.BUSD), common.HexToAddress(config.CAKE), common.HexToAddress(config.DOGE)
+
+	tests := []struct {
+		name    string
+		path    []common.Address
+		wantErr bool
+	}{
+		{"single hop", []common.Address{wbnb, usdt}, false},
+		{"four tokens", []common.Address{wbnb, usdt, busd, cake}, false},
+		{"one token", []common.Address{wbnb}, true},
+		{"five tokens", []common.Address{wbnb, usdt, busd, cake, doge}, true},
+		{"zero address", []common.Address{wbnb, {}}, true},
+		{"repeated token", []common.Address{wbnb, usdt, wbnb}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.ValidateSwapPath(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSwapPath error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLiquidity(t *testing.T) {
+	router := common.HexToAddress(config.PancakeswapRouter)
+	path := []common.Address{common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT)}
+
+	doge := []common.Address{common.HexToAddress(config.WBNB), common.HexToAddress(config.DOGE)}
+
+	tests := []struct {
+		name     string
+		quote    quoteFunc
+		amountIn *big.Int
+		path     []common.Address
+		wantErr  bool
+	}{
+		{"deep pool", rateQuote(300, 1), wbnbAmount(1), path, false},
+		{"dust output", rateQuote(1, 1), big.NewInt(1e9), path, true},
+		{"under the token's own floor", rateQuote(300, 1), big.NewInt(1e15), path, true},
+		{"8-decimal output sized in whole tokens", rateQuote(1, 1e10), wbnbAmount(1), doge, false},
+		{"no quote", nil, wbnbAmount(1), path, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.decimals[common.HexToAddress(config.DOGE)] = 8
+			if tt.quote != nil {
+				backend.quotes[router] = tt.quote
+			}
+			service := newTestArbitrageService(t, backend).RouterService
+			service.Config.MinOutputAmount = 0.000001
+			service.Config.MinOutputAmounts = map[string]float64{"USDT": 1}
+
+			if err := service.CheckLiquidity(router, tt.amountIn, tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("CheckLiquidity error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOptimalDirectPoolAmount(t *testing.T) {
+	const (
+		buyPool  = "0x00000000000000000000000000000000000000a1"
+		sellPool = "0x00000000000000000000000000000000000000b1"
+	)
+
+	// The worked example of TestOptimalDirectAmount, with the sell pool's
+	// tokens stored in the opposite order
+	backend := newMockBackend()
+	backend.listPool(config.PancakeswapFactory, buyPool, config.WBNB, config.USDT, wbnbAmount(1000))
+	backend.pools[common.HexToAddress(buyPool)].reserve1 = wbnbAmount(300000)
+	backend.listPool(config.BiswapFactory, sellPool, config.USDT, config.WBNB, wbnbAmount(280000))
+	backend.pools[common.HexToAddress(sellPool)].reserve1 = wbnbAmount(1000)
+
+	service := newTestArbitrageService(t, backend)
+	var pancake, biswap DEX
+	for _, dex := range service.DEXes {
+		switch dex.Name() {
+		case "PancakeSwap":
+			pancake = dex
+		case "BiSwap":
+			biswap = dex
+		}
+	}
+
+	wbnb, usdt := common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT)
+	got, err := service.RouterService.OptimalDirectPoolAmount(common.HexToAddress(buyPool), pancake, common.HexToAddress(sellPool), biswap, wbnb, usdt)
+	if err != nil {
+		t.Fatalf("OptimalDirectPoolAmount returned error: %v", err)
+	}
+	want, _ := new(big.Int).SetString("15875750634223086752", 10)
+	if got.Cmp(want) != 0 {
+		t.Errorf("OptimalDirectPoolAmount = %s, want %s", got, want)
+	}
+
+	// The reverse direction buys where USDT is dearer
+	got, err = service.RouterService.OptimalDirectPoolAmount(common.HexToAddress(sellPool), biswap, common.HexToAddress(buyPool), pancake, wbnb, usdt)
+	if err != nil || got.Sign() != 0 {
+		t.Errorf("reverse OptimalDirectPoolAmount = %v, %v, want 0", got, err)
+	}
+
+	// A pool of another pair is refused rather than misread
+	if _, err := service.RouterService.OptimalDirectPoolAmount(common.HexToAddress(buyPool), pancake, common.HexToAddress(sellPool), biswap, wbnb, common.HexToAddress(config.BUSD)); err == nil {
+		t.Error("expected an error for pools that don't hold the traded tokens")
+	}
+}
@@ -0,0 +1,216 @@
+// services/signer.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"arbitrage-bot/config"
+)
+
+// bscChainID is the BSC mainnet chain ID used for EIP-155 signing
+var bscChainID = big.NewInt(56)
+
+// Signer signs transactions for the bot's wallet
+type Signer interface {
+	SignTx(tx *types.Transaction) (*types.Transaction, error)
+	Address() common.Address
+}
+
+// NewSigner builds the signer selected by the configuration: an external
+// signer when SIGNER_URL is set, otherwise a local key from env or keystore
+func NewSigner(cfg *config.Config) (Signer, error) {
+	if cfg.SignerURL != "" {
+		return NewExternalSigner(cfg.SignerURL, cfg.SignerMethod, common.HexToAddress(cfg.SignerAddress), bscChainID)
+	}
+
+	privateKey, err := loadPrivateKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalSigner(privateKey, bscChainID)
+}
+
+// NewSigners builds every wallet executions rotate through: one local signer
+// per key when PRIVATE_KEY_1..N are set, otherwise the single signer from
+// NewSigner
+func NewSigners(cfg *config.Config) ([]Signer, error) {
+	if len(cfg.PrivateKeys) == 0 {
+		signer, err := NewSigner(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return []Signer{signer}, nil
+	}
+
+	var signers []Signer
+	for i, hexKey := range cfg.LocalPrivateKeys() {
+		privateKey, err := crypto.HexToECDSA(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key for wallet %d: %v", i+1, err)
+		}
+		signer, err := NewLocalSigner(privateKey, bscChainID)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// LocalSigner signs with a private key held in process memory
+type LocalSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+	signer  types.Signer
+}
+
+// NewLocalSigner creates a signer for a private key
+func NewLocalSigner(key *ecdsa.PrivateKey, chainID *big.Int) (*LocalSigner, error) {
+	publicKeyECDSA, ok := key.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("error casting public key to ECDSA")
+	}
+
+	return &LocalSigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(*publicKeyECDSA),
+		signer:  types.NewEIP155Signer(chainID),
+	}, nil
+}
+
+// SignTx signs the transaction with the local key
+func (s *LocalSigner) SignTx(tx *types.Transaction) (*types.Transaction, error) {
+	return types.SignTx(tx, s.signer, s.key)
+}
+
+// Address returns the address of the local key
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+// ExternalSigner asks a remote signer (clef or an HTTP JSON-RPC signer) to
+// sign transactions so the key never enters this process
+type ExternalSigner struct {
+	url     string
+	method  string
+	address common.Address
+	chainID *big.Int
+}
+
+// NewExternalSigner creates a signer that posts to the given URL
+func NewExternalSigner(url, method string, address common.Address, chainID *big.Int) (*ExternalSigner, error) {
+	if address == (common.Address{}) {
+		return nil, fmt.Errorf("external signer requires a wallet address")
+	}
+	if method == "" {
+		method = "account_signTransaction"
+	}
+
+	return &ExternalSigner{
+		url:     url,
+		method:  method,
+		address: address,
+		chainID: chainID,
+	}, nil
+}
+
+// signTxArgs is the transaction description sent to the external signer
+type signTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+	ChainID  *hexutil.Big    `json:"chainId"`
+}
+
+// signTxResult is the signer's response; only the raw encoding is needed
+type signTxResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// SignTx sends the transaction to the external signer and decodes the result
+func (s *ExternalSigner) SignTx(tx *types.Transaction) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := rpc.DialContext(ctx, s.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to external signer: %v", err)
+	}
+	defer client.Close()
+
+	args := signTxArgs{
+		From:     s.address,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+		ChainID:  (*hexutil.Big)(s.chainID),
+	}
+
+	var result signTxResult
+	if err := client.CallContext(ctx, &result, s.method, args); err != nil {
+		return nil, fmt.Errorf("external signer rejected transaction: %v", err)
+	}
+
+	signedTx := new(types.Transaction)
+	if err := signedTx.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("invalid signed transaction from external signer: %v", err)
+	}
+
+	// Make sure the signer signed what we asked for, from the right account
+	sender, err := types.Sender(types.LatestSignerForChainID(s.chainID), signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature from external signer: %v", err)
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("external signer signed as %s, not %s", sender.Hex(), s.address.Hex())
+	}
+	if field := mismatchedField(tx, signedTx); field != "" {
+		return nil, fmt.Errorf("external signer returned a transaction whose %s does not match the request", field)
+	}
+
+	return signedTx, nil
+}
+
+// mismatchedField names the first field the signed transaction changed from
+// the requested one, or returns "" if it signed exactly what was asked
+func mismatchedField(requested, signed *types.Transaction) string {
+	switch {
+	case (requested.To() == nil) != (signed.To() == nil) ||
+		requested.To() != nil && *requested.To() != *signed.To():
+		return "recipient"
+	case !bytes.Equal(requested.Data(), signed.Data()):
+		return "data"
+	case requested.Value().Cmp(signed.Value()) != 0:
+		return "value"
+	case requested.Gas() != signed.Gas():
+		return "gas limit"
+	case requested.GasPrice().Cmp(signed.GasPrice()) != 0:
+		return "gas price"
+	case requested.Nonce() != signed.Nonce():
+		return "nonce"
+	}
+	return ""
+}
+
+// Address returns the wallet address managed by the external signer
+func (s *ExternalSigner) Address() common.Address {
+	return s.address
+}
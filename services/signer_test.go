@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeExternalSigner answers account_signTransaction by signing with a local
+// key, after letting tamper change what it was asked to sign
+type fakeExternalSigner struct {
+	key    *ecdsa.PrivateKey
+	tamper func(args *signTxArgs)
+}
+
+func (f *fakeExternalSigner) SignTransaction(args signTxArgs) (*signTxResult, error) {
+	if f.tamper != nil {
+		f.tamper(&args)
+	}
+
+	tx := types.NewTransaction(uint64(args.Nonce), *args.To, (*big.Int)(args.Value), uint64(args.Gas),
+		(*big.Int)(args.GasPrice), args.Data)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer((*big.Int)(args.ChainID)), f.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &signTxResult{Raw: raw}, nil
+}
+
+func TestExternalSignerVerifiesSignedTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     *ecdsa.PrivateKey
+		tamper  func(args *signTxArgs)
+		wantErr string
+	}{
+		{name: "signed as requested", key: key},
+		{name: "wrong account", key: other, wantErr: "signed as"},
+		{
+			name:    "recipient changed",
+			key:     key,
+			tamper:  func(args *signTxArgs) { to := common.HexToAddress("0xbad"); args.To = &to },
+			wantErr: "recipient",
+		},
+		{name: "data changed", key: key, tamper: func(args *signTxArgs) { args.Data = hexutil.Bytes{0x01} }, wantErr: "data"},
+		{name: "value changed", key: key, tamper: func(args *signTxArgs) { args.Value = (*hexutil.Big)(big.NewInt(1)) }, wantErr: "value"},
+		{name: "gas limit changed", key: key, tamper: func(args *signTxArgs) { args.Gas++ }, wantErr: "gas limit"},
+		{
+			name:    "gas price changed",
+			key:     key,
+			tamper:  func(args *signTxArgs) { args.GasPrice = (*hexutil.Big)(big.NewInt(1e12)) },
+			wantErr: "gas price",
+		},
+		{name: "nonce changed", key: key, tamper: func(args *signTxArgs) { args.Nonce++ }, wantErr: "nonce"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rpc.NewServer()
+			if err := server.RegisterName("account", &fakeExternalSigner{key: tt.key, tamper: tt.tamper}); err != nil {
+				t.Fatalf("failed to register signer: %v", err)
+			}
+			httpServer := httptest.NewServer(server)
+			defer httpServer.Close()
+			defer server.Stop()
+
+			signer, err := NewExternalSigner(httpServer.URL, "", crypto.PubkeyToAddress(key.PublicKey), bscChainID)
+			if err != nil {
+				t.Fatalf("NewExternalSigner returned error: %v", err)
+			}
+
+			tx := types.NewTransaction(7, common.HexToAddress("0xf1"), big.NewInt(0), 600000, big.NewInt(5e9), []byte{0xde, 0xad})
+			signed, err := signer.SignTx(tx)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("SignTx returned error: %v", err)
+				}
+				if signed.Nonce() != tx.Nonce() || !bytes.Equal(signed.Data(), tx.Data()) {
+					t.Errorf("signed transaction = %+v, want the requested one", signed)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SignTx error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// services/strategy.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/models"
+)
+
+// Strategy finds arbitrage opportunities on a pair. The scanner runs each of
+// the service's strategies on every pair and executes the most profitable
+// opportunity any of them returns.
+type Strategy interface {
+	// Name identifies the strategy in STRATEGIES and in logs
+	Name() string
+	// Evaluate quotes the pair and returns the opportunities that clear its
+	// thresholds. It returns an error when the pair couldn't be quoted.
+	Evaluate(ctx context.Context, pair models.TokenPair) ([]models.Opportunity, error)
+}
+
+// NewStrategies builds the named strategies on top of service, skipping names
+// it doesn't know; ValidateConfig rejects those before startup
+func NewStrategies(service *ArbitrageService, names []string) []Strategy {
+	var strategies []Strategy
+	for _, name := range names {
+		switch name {
+		case config.StrategyTriangular:
+			strategies = append(strategies, &TriangularStrategy{Service: service})
+		case config.StrategyDirect:
+			strategies = append(strategies, &DirectStrategy{Service: service})
+		default:
+			slog.Warn("⚠️ Unknown strategy, ignoring it", "strategy", name)
+		}
+	}
+	return strategies
+}
+
+// TriangularStrategy trades the cycle WBNB -> B -> C -> WBNB with the outer
+// hops on one exchange and the middle hop on another
+type TriangularStrategy struct {
+	Service *ArbitrageService
+}
+
+// Name returns "triangular"
+func (t *TriangularStrategy) Name() string {
+	return config.StrategyTriangular
+}
+
+// Evaluate returns the pair's best triangular route, if one clears the pair's
+// thresholds
+func (t *TriangularStrategy) Evaluate(ctx context.Context, pair models.TokenPair) ([]models.Opportunity, error) {
+	routes, err := t.Service.Routes(pair)
+	if err != nil {
+		return nil, fmt.Errorf("%s has no routes: %w", pair.Name, err)
+	}
+	return evaluateStrategyRoutes(ctx, t.Service, t.Name(), pair, routes)
+}
+
+// DirectStrategy buys one of the pair's tokens with WBNB on one exchange and
+// sells it back on another. The flash contract only runs triangular routes,
+// so direct trades execute leg by leg and need ALLOW_MANUAL_ARBITRAGE.
+type DirectStrategy struct {
+	Service *ArbitrageService
+}
+
+// Name returns "direct"
+func (d *DirectStrategy) Name() string {
+	return config.StrategyDirect
+}
+
+// Evaluate returns the pair's best two-exchange round trip, if one clears the
+// pair's thresholds. Each round trip is quoted at the size that maximizes its
+// profit given its two pools, up to the pair's largest test amount; one whose
+// pools can't be read is quoted at the test amounts instead.
+func (d *DirectStrategy) Evaluate(ctx context.Context, pair models.TokenPair) ([]models.Opportunity, error) {
+	routes, err := d.Service.DirectRoutes(pair)
+	if err != nil {
+		return nil, fmt.Errorf("%s has no direct routes: %w", pair.Name, err)
+	}
+
+	var best []models.Opportunity
+	var lastErr error
+	failed, evaluated := 0, 0
+	for _, route := range routes {
+		sized := pair
+		amount, err := d.Service.directAmount(pair, route)
+		if err != nil {
+			slog.Debug("🧮 Direct sizing failed, using test amounts", "pair", pair.Name, "route", route.String(), "err", err)
+		} else if amount <= 0 {
+			continue // no profit left after fees at any size
+		} else {
+			sized.TestAmounts = []float64{amount}
+		}
+
+		evaluated++
+		opportunities, err := evaluateStrategyRoutes(ctx, d.Service, d.Name(), sized, []Route{route})
+		if errors.Is(err, ErrScanCancelled) {
+			return nil, err
+		}
+		if err != nil {
+			lastErr = err
+			failed++
+			continue
+		}
+		if len(opportunities) > 0 && (best == nil || opportunities[0].NetProfitWBNB > best[0].NetProfitWBNB) {
+			best = opportunities
+		}
+	}
+
+	if best == nil && evaluated > 0 && failed == evaluated {
+		return nil, lastErr
+	}
+	return best, nil
+}
+
+// directAmount returns the WBNB input that maximizes a direct route's profit,
+// capped at the pair's largest test amount, or 0 when the route's pools leave
+// no profit after fees
+func (s *ArbitrageService) directAmount(pair models.TokenPair, route Route) (float64, error) {
+	buy, sell := route.Hops[0], route.Hops[1]
+	buyPool := findPairAddress(buy.DEX.PairAddresses(&pair), buy.SymbolIn, buy.SymbolOut)
+	sellPool := findPairAddress(sell.DEX.PairAddresses(&pair), sell.SymbolIn, sell.SymbolOut)
+	if buyPool == "" || sellPool == "" {
+		return 0, fmt.Errorf("no pools configured for %s", route.String())
+	}
+
+	optimal, err := s.RouterService.OptimalDirectPoolAmount(
+		common.HexToAddress(buyPool), buy.DEX,
+		common.HexToAddress(sellPool), sell.DEX,
+		buy.TokenIn, buy.TokenOut,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	largest := 0.0
+	for _, amount := range pair.TestAmounts {
+		if amount > largest {
+			largest = amount
+		}
+	}
+	return math.Min(formatWBNB(optimal), largest), nil
+}
+
+// evaluateStrategyRoutes quotes a strategy's routes and tags the opportunity
+// found, if any, with the strategy's name
+func evaluateStrategyRoutes(ctx context.Context, service *ArbitrageService, name string, pair models.TokenPair, routes []Route) ([]models.Opportunity, error) {
+	if err := scanCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	opportunity, err := service.evaluateRoutes(pair, routes)
+	if err != nil || opportunity == nil {
+		return nil, err
+	}
+	opportunity.Strategy = name
+	return []models.Opportunity{*opportunity}, nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/models"
+)
+
+// stubStrategy returns fixed opportunities, or err
+type stubStrategy struct {
+	name          string
+	opportunities []models.Opportunity
+	err           error
+}
+
+func (s *stubStrategy) Name() string {
+	return s.name
+}
+
+func (s *stubStrategy) Evaluate(ctx context.Context, pair models.TokenPair) ([]models.Opportunity, error) {
+	return s.opportunities, s.err
+}
+
+func TestNewStrategiesFollowsConfiguredNames(t *testing.T) {
+	service := newTestArbitrageService(t, newMockBackend())
+
+	strategies := NewStrategies(service, []string{config.StrategyDirect, "unknown", config.StrategyTriangular})
+	if len(strategies) != 2 {
+		t.Fatalf("got %d strategies, want 2", len(strategies))
+	}
+	if strategies[0].Name() != config.StrategyDirect || strategies[1].Name() != config.StrategyTriangular {
+		t.Errorf("strategies = %s, %s, want direct, triangular", strategies[0].Name(), strategies[1].Name())
+	}
+}
+
+func TestFindEnhancedCandidateTakesBestAcrossStrategies(t *testing.T) {
+	quoteErr := errors.New("quote failed")
+	low := models.Opportunity{Strategy: "low", AdjustedProfit: 0.01}
+	high := models.Opportunity{Strategy: "high", AdjustedProfit: 0.02}
+
+	tests := []struct {
+		name       string
+		strategies []Strategy
+		want       string // strategy of the opportunity returned, "" for none
+		wantErr    error
+	}{
+		{
+			name: "most profitable wins regardless of order",
+			strategies: []Strategy{
+				&stubStrategy{name: "a", opportunities: []models.Opportunity{low}},
+				&stubStrategy{name: "b", opportunities: []models.Opportunity{high}},
+			},
+			want: "high",
+		},
+		{
+			name: "one strategy failing doesn't hide another's opportunity",
+			strategies: []Strategy{
+				&stubStrategy{name: "a", err: quoteErr},
+				&stubStrategy{name: "b", opportunities: []models.Opportunity{low}},
+			},
+			want: "low",
+		},
+		{
+			name: "failure is reported when nothing was found",
+			strategies: []Strategy{
+				&stubStrategy{name: "a"},
+				&stubStrategy{name: "b", err: quoteErr},
+			},
+			wantErr: quoteErr,
+		},
+		{
+			name: "no strategies finds nothing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestArbitrageService(t, newMockBackend())
+			service.Strategies = tt.strategies
+
+			got, err := service.findEnhancedCandidate(context.Background(), testPair())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("findEnhancedCandidate error = %v, want %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == "") || (got != nil && got.Strategy != tt.want) {
+				t.Errorf("findEnhancedCandidate = %+v, want the %q opportunity", got, tt.want)
+			}
+		})
+	}
+}
+
+// reserveQuote quotes a single hop from a fixed pool, the way a V2 router
+// would from its reserves
+func reserveQuote(service *RouterService, reserveIn, reserveOut *big.Int, feeBps int64) quoteFunc {
+	return func(amountIn *big.Int, path []common.Address) []*big.Int {
+		return []*big.Int{amountIn, service.GetAmountOutFromReserves(amountIn, reserveIn, reserveOut, feeBps)}
+	}
+}
+
+func TestDirectStrategySizesAtOptimalAmount(t *testing.T) {
+	const (
+		pancakePool = "0x00000000000000000000000000000000000000a1"
+		biswapPool  = "0x00000000000000000000000000000000000000b1"
+	)
+
+	// The worked example of TestOptimalDirectAmount: USDT is cheaper on
+	// PancakeSwap, so buying there and selling on BiSwap peaks near 15.88 WBNB
+	backend := newMockBackend()
+	backend.listPool(config.PancakeswapFactory, pancakePool, config.WBNB, config.USDT, wbnbAmount(1000))
+	backend.pools[common.HexToAddress(pancakePool)].reserve1 = wbnbAmount(300000)
+	backend.listPool(config.BiswapFactory, biswapPool, config.USDT, config.WBNB, wbnbAmount(280000))
+	backend.pools[common.HexToAddress(biswapPool)].reserve1 = wbnbAmount(1000)
+
+	service := newTestArbitrageService(t, backend)
+	service.Config.MaxPriceImpact = 1
+	backend.quotes[common.HexToAddress(config.PancakeswapRouter)] = reserveQuote(service.RouterService, wbnbAmount(1000), wbnbAmount(300000), 25)
+	backend.quotes[common.HexToAddress(config.BiswapRouter)] = reserveQuote(service.RouterService, wbnbAmount(280000), wbnbAmount(1000), 20)
+
+	pair := models.TokenPair{
+		Name:            "WBNB-USDT",
+		Tokens:          map[string]string{"WBNB": config.WBNB, "USDT": config.USDT},
+		PancakeswapPair: map[string]string{"WBNB-USDT": pancakePool},
+		BiswapPair:      map[string]string{"WBNB-USDT": biswapPool},
+		TestAmounts:     []float64{0.5, 50},
+	}
+
+	strategy := &DirectStrategy{Service: service}
+	opportunities, err := strategy.Evaluate(context.Background(), pair)
+	if err != nil {
+		t.Fatalf("Evaluate returned error: %v", err)
+	}
+	if len(opportunities) != 1 {
+		t.Fatalf("got %d opportunities, want 1", len(opportunities))
+	}
+	if got := opportunities[0].Route(); got != "PancakeSwap→BiSwap" {
+		t.Errorf("route = %s, want PancakeSwap→BiSwap", got)
+	}
+	if got := opportunities[0].AmountWBNB; math.Abs(got-15.8757) > 0.0001 {
+		t.Errorf("sized at %.4f WBNB, want the optimal 15.8757", got)
+	}
+
+	// The largest test amount still caps the trade
+	pair.TestAmounts = []float64{0.5, 5}
+	opportunities, err = strategy.Evaluate(context.Background(), pair)
+	if err != nil || len(opportunities) != 1 {
+		t.Fatalf("Evaluate = %v, %v, want one opportunity", opportunities, err)
+	}
+	if got := opportunities[0].AmountWBNB; got != 5 {
+		t.Errorf("sized at %.4f WBNB, want the 5 WBNB cap", got)
+	}
+}
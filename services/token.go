@@ -3,37 +3,60 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"math/big"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 
+	"arbitrage-bot/config"
 	"arbitrage-bot/contracts"
 )
 
+// ErrNotERC20 is returned when a token call comes back empty, e.g. because
+// the address is a wallet, a mis-pasted address or a self-destructed token
+var ErrNotERC20 = errors.New("not an ERC20 token")
+
+// defaultDecimals is assumed for tokens whose decimals() can't be read
+const defaultDecimals = uint8(18)
+
+// wbnbGasLimit covers WBNB deposit and withdraw, which cost well under 50k gas
+const wbnbGasLimit = uint64(60000)
+
 // TokenService handles operations related to ERC20 tokenas
 type TokenService struct {
-	Client *EthClient
+	Client  *EthClient
+	Backend ContractCaller
 }
 
 // NewTokenService creates a new TokenService
 func NewTokenService(client *EthClient) *TokenService {
 	return &TokenService{
-		Client: client,
+		Client:  client,
+		Backend: client,
 	}
 }
 
-// GetTokenDecimals returns the decimals of a token
+// GetTokenDecimals returns the decimals of a token. Tokens that return
+// decimals() as a uint256 are accepted, and tokens whose decimals() reverts
+// or can't be decoded are assumed to have defaultDecimals so one quirky meme
+// token doesn't fail its whole pair. Connection errors are still returned.
 func (s *TokenService) GetTokenDecimals(tokenAddress common.Address) (uint8, error) {
 	callData, err := contracts.ERC20ABI.Pack("decimals")
 	if err != nil {
 		return 0, err
 	}
 
-	result, err := s.Client.Client.CallContract(context.Background(),
+	result, err := callContract(context.Background(), s.Backend, "decimals",
 		ethereum.CallMsg{
 			To:   &tokenAddress,
 			Data: callData,
@@ -42,16 +65,71 @@ func (s *TokenService) GetTokenDecimals(tokenAddress common.Address) (uint8, err
 	)
 
 	if err != nil {
-		return 0, err
+		if !IsRevertError(err) {
+			return 0, err
+		}
+		slog.Warn("⚠️ Token decimals() reverted, assuming default", "token", tokenAddress.Hex(),
+			"decimals", defaultDecimals, "err", err)
+		return defaultDecimals, nil
+	}
+
+	// A contract without decimals() falls back below, but an address with no
+	// code at all is not a token
+	if len(result) < 32 {
+		if err := requireCode(s.Backend, tokenAddress); err != nil {
+			return 0, err
+		}
+	}
+
+	decimals, ok := decodeDecimals(result)
+	if !ok {
+		slog.Warn("⚠️ Token decimals() returned an unexpected value, assuming default", "token", tokenAddress.Hex(),
+			"decimals", defaultDecimals, "result", hexutil.Encode(result))
+		return defaultDecimals, nil
 	}
 
-	decimals := new(uint8)
-	err = contracts.ERC20ABI.UnpackIntoInterface(decimals, "decimals", result)
+	return decimals, nil
+}
+
+// requireCode returns ErrNotERC20 when there is no contract at address
+func requireCode(backend ContractCaller, address common.Address) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	code, err := backend.CodeAt(ctx, address, nil)
 	if err != nil {
-		return 0, err
+		return fmt.Errorf("failed to read code at %s: %v", address.Hex(), err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("%w: no code at address %s", ErrNotERC20, address.Hex())
+	}
+	return nil
+}
+
+// emptyResultError describes a token call that returned no data: either
+// nothing is deployed at the address or the contract lacks the method
+func emptyResultError(backend ContractCaller, token common.Address, method string) error {
+	if err := requireCode(backend, token); err != nil {
+		return err
 	}
+	return fmt.Errorf("%w: %s() on %s returned no data", ErrNotERC20, method, token.Hex())
+}
 
-	return *decimals, nil
+// decodeDecimals decodes a decimals() result. A uint8 and a uint256 are both
+// returned as one zero-padded 32-byte word with the value in the last byte,
+// but the ABI decoder reads a uint8 from the last byte alone, so the whole
+// word is decoded and range-checked instead: that accepts both declarations
+// without truncating a nonsense value. Words after the first are ignored.
+func decodeDecimals(result []byte) (uint8, bool) {
+	if len(result) < 32 {
+		return 0, false // no return data, e.g. decimals() isn't implemented
+	}
+
+	decimals := new(big.Int).SetBytes(result[:32])
+	if !decimals.IsUint64() || decimals.Uint64() > math.MaxUint8 {
+		return 0, false
+	}
+	return uint8(decimals.Uint64()), true
 }
 
 // GetTokenBalance returns the balance of a token for a specific address
@@ -61,7 +139,7 @@ func (s *TokenService) GetTokenBalance(tokenAddress, ownerAddress common.Address
 		return nil, err
 	}
 
-	result, err := s.Client.Client.CallContract(context.Background(),
+	result, err := s.Backend.CallContract(context.Background(),
 		ethereum.CallMsg{
 			To:   &tokenAddress,
 			Data: callData,
@@ -72,6 +150,9 @@ func (s *TokenService) GetTokenBalance(tokenAddress, ownerAddress common.Address
 	if err != nil {
 		return nil, err
 	}
+	if len(result) < 32 {
+		return nil, emptyResultError(s.Backend, tokenAddress, "balanceOf")
+	}
 
 	var balance *big.Int
 	err = contracts.ERC20ABI.UnpackIntoInterface(&balance, "balanceOf", result)
@@ -84,49 +165,38 @@ func (s *TokenService) GetTokenBalance(tokenAddress, ownerAddress common.Address
 
 // ApproveToken approves a spender to spend tokens
 func (s *TokenService) ApproveToken(tokenAddress, spenderAddress common.Address, amount *big.Int) (*common.Hash, error) {
-	nonce, err := s.Client.Client.PendingNonceAt(context.Background(), s.Client.Address)
+	nonce, err := s.Backend.PendingNonceAt(context.Background(), s.Client.Address)
 	if err != nil {
 		return nil, err
 	}
 
-	gasPrice, err := s.Client.Client.SuggestGasPrice(context.Background())
+	gasPrice, err := s.Backend.SuggestGasPrice(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(s.Client.PrivateKey, big.NewInt(56)) // BSC chain ID
-	if err != nil {
-		return nil, err
-	}
-
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // no value
-	auth.GasLimit = uint64(100000) // gas limit for approve
-	auth.GasPrice = gasPrice
-
 	callData, err := contracts.ERC20ABI.Pack("approve", spenderAddress, amount)
 	if err != nil {
 		return nil, err
 	}
 
 	tx := types.NewTransaction(
-		auth.Nonce.Uint64(),
+		nonce,
 		tokenAddress,
-		auth.Value,
-		auth.GasLimit,
-		auth.GasPrice,
+		big.NewInt(0),  // no value
+		uint64(100000), // gas limit for approve
+		gasPrice,
 		callData,
 	)
 
 	// Sign transaction
-	chainID := big.NewInt(56) // BSC chain ID
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), s.Client.PrivateKey)
+	signedTx, err := s.Client.Signer.SignTx(tx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Send transaction
-	err = s.Client.Client.SendTransaction(context.Background(), signedTx)
+	err = s.Backend.SendTransaction(context.Background(), signedTx)
 	if err != nil {
 		return nil, err
 	}
@@ -135,20 +205,213 @@ func (s *TokenService) ApproveToken(tokenAddress, spenderAddress common.Address,
 	return &hash, nil
 }
 
-// FormatTokenAmount formats a token amount with the correct number of decimals
+// GetAllowance returns how much of a token spender may still transfer from owner
+func (s *TokenService) GetAllowance(tokenAddress, ownerAddress, spenderAddress common.Address) (*big.Int, error) {
+	callData, err := contracts.ERC20ABI.Pack("allowance", ownerAddress, spenderAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := callContract(context.Background(), s.Backend, "allowance",
+		ethereum.CallMsg{
+			To:   &tokenAddress,
+			Data: callData,
+		},
+		nil, // latest block
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var allowance *big.Int
+	err = contracts.ERC20ABI.UnpackIntoInterface(&allowance, "allowance", result)
+	if err != nil {
+		return nil, err
+	}
+
+	return allowance, nil
+}
+
+// RevokeApproval sets a spender's allowance for a token back to zero
+func (s *TokenService) RevokeApproval(tokenAddress, spenderAddress common.Address) (*common.Hash, error) {
+	return s.ApproveToken(tokenAddress, spenderAddress, big.NewInt(0))
+}
+
+// Allowance is what one spender may transfer of one token from the wallet
+type Allowance struct {
+	Symbol         string
+	Token          common.Address
+	Spender        string
+	SpenderAddress common.Address
+	Amount         *big.Int
+}
+
+// Allowances looks up the wallet's allowance of every token for every
+// spender, returning the non-zero ones ordered by token symbol then spender.
+// tokens and spenders are keyed by a display name.
+func (s *TokenService) Allowances(tokens, spenders map[string]common.Address) ([]Allowance, error) {
+	var allowances []Allowance
+	for _, symbol := range sortedNames(tokens) {
+		for _, spender := range sortedNames(spenders) {
+			amount, err := s.GetAllowance(tokens[symbol], s.Client.Address, spenders[spender])
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s allowance for %s: %v", symbol, spender, err)
+			}
+			if amount.Sign() == 0 {
+				continue
+			}
+
+			allowances = append(allowances, Allowance{
+				Symbol:         symbol,
+				Token:          tokens[symbol],
+				Spender:        spender,
+				SpenderAddress: spenders[spender],
+				Amount:         amount,
+			})
+		}
+	}
+	return allowances, nil
+}
+
+// sortedNames returns the keys of an address map in order
+func sortedNames(m map[string]common.Address) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WrapBNB converts native BNB into WBNB by calling deposit() on the WBNB
+// contract with the amount attached as the transaction value
+func (s *TokenService) WrapBNB(amount *big.Int) (*common.Hash, error) {
+	tx, err := s.wrapTransaction(amount)
+	if err != nil {
+		return nil, err
+	}
+	return s.signAndSend(tx)
+}
+
+// wrapTransaction builds the unsigned deposit() transaction WrapBNB sends
+func (s *TokenService) wrapTransaction(amount *big.Int) (*types.Transaction, error) {
+	callData, err := contracts.WBNBABI.Pack("deposit")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack deposit: %v", err)
+	}
+
+	return s.wbnbTransaction(amount, amount, callData)
+}
+
+// UnwrapBNB converts WBNB back into native BNB by calling withdraw(amount)
+func (s *TokenService) UnwrapBNB(amount *big.Int) (*common.Hash, error) {
+	callData, err := contracts.WBNBABI.Pack("withdraw", amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack withdraw: %v", err)
+	}
+
+	tx, err := s.wbnbTransaction(amount, big.NewInt(0), callData)
+	if err != nil {
+		return nil, err
+	}
+	return s.signAndSend(tx)
+}
+
+// autoWrapAmount returns how much native BNB to wrap so the WBNB balance is
+// topped up to target once it drops below threshold. It never wraps into the
+// gas reserve and returns zero when no wrap is needed or possible.
+func autoWrapAmount(wbnbBalance, bnbBalance, threshold, target, gasReserve *big.Int) *big.Int {
+	if wbnbBalance.Cmp(threshold) >= 0 {
+		return big.NewInt(0)
+	}
+
+	amount := new(big.Int).Sub(target, wbnbBalance)
+	available := new(big.Int).Sub(bnbBalance, gasReserve)
+	if available.Cmp(amount) < 0 {
+		amount = available
+	}
+
+	if amount.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return amount
+}
+
+// wbnbTransaction builds an unsigned call to the WBNB contract. value is the
+// native BNB attached to the call and is only non-zero for deposits.
+func (s *TokenService) wbnbTransaction(amount, value *big.Int, callData []byte) (*types.Transaction, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount")
+	}
+
+	nonce, err := s.Backend.PendingNonceAt(context.Background(), s.Client.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %v", err)
+	}
+
+	gasPrice, err := s.Backend.SuggestGasPrice(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %v", err)
+	}
+
+	return types.NewTransaction(
+		nonce,
+		common.HexToAddress(config.WBNB),
+		value,
+		wbnbGasLimit,
+		gasPrice,
+		callData,
+	), nil
+}
+
+// signAndSend signs a transaction with the wallet's signer and sends it
+func (s *TokenService) signAndSend(tx *types.Transaction) (*common.Hash, error) {
+	signedTx, err := s.Client.Signer.SignTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+
+	err = s.Backend.SendTransaction(context.Background(), signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %v", err)
+	}
+
+	hash := signedTx.Hash()
+	return &hash, nil
+}
+
+// FormatTokenAmount formats a token amount with the correct number of decimals.
+// The amount is converted from its shortest decimal representation so that
+// values like 0.1 map to exactly 10^(decimals-1) wei; fractional digits beyond
+// the token's precision are truncated.
 func (s *TokenService) FormatTokenAmount(amount float64, decimals uint8) *big.Int {
-	// Convert float to string with high precision
-	amountStr := strconv.FormatFloat(amount, 'f', int(decimals), 64)
+	// Shortest decimal string that round-trips to the same float64, e.g. "0.1"
+	amountStr := strconv.FormatFloat(amount, 'f', -1, 64)
 
-	// Create big.Float for precise conversion
-	amountFloat, _ := new(big.Float).SetString(amountStr)
+	negative := strings.HasPrefix(amountStr, "-")
+	amountStr = strings.TrimPrefix(amountStr, "-")
 
-	// Multiply by 10^decimals
-	multiplier := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
-	amountFloat.Mul(amountFloat, multiplier)
+	whole, fraction := amountStr, ""
+	if dot := strings.IndexByte(amountStr, '.'); dot >= 0 {
+		whole, fraction = amountStr[:dot], amountStr[dot+1:]
+	}
+
+	// Pad or truncate the fractional part to the token's decimals
+	if len(fraction) > int(decimals) {
+		fraction = fraction[:decimals]
+	} else {
+		fraction += strings.Repeat("0", int(decimals)-len(fraction))
+	}
 
-	// Convert to big.Int
-	amountInt, _ := amountFloat.Int(nil)
+	amountInt, ok := new(big.Int).SetString(whole+fraction, 10)
+	if !ok {
+		return big.NewInt(0)
+	}
+
+	if negative {
+		amountInt.Neg(amountInt)
+	}
 	return amountInt
 }
 
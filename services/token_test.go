@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+)
+
+func TestFormatTokenAmount(t *testing.T) {
+	service := &TokenService{}
+
+	tests := []struct {
+		name     string
+		amount   float64
+		decimals uint8
+		want     string
+	}{
+		{"18 decimals tenth", 0.1, 18, "100000000000000000"},
+		{"18 decimals small fraction", 0.000123, 18, "123000000000000"},
+		{"18 decimals whole and fraction", 12.345, 18, "12345000000000000000"},
+		{"18 decimals smallest unit", 0.000000000000000001, 18, "1"},
+		{"9 decimals", 1.5, 9, "1500000000"},
+		{"9 decimals truncates extra digits", 0.1234567899, 9, "123456789"},
+		{"6 decimals", 250.75, 6, "250750000"},
+		{"6 decimals truncates extra digits", 0.0000019, 6, "1"},
+		{"6 decimals below precision", 0.0000001, 6, "0"},
+		{"zero decimals", 42.9, 0, "42"},
+		{"zero amount", 0, 18, "0"},
+		{"negative amount", -0.25, 6, "-250000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := service.FormatTokenAmount(tt.amount, tt.decimals)
+			if got.String() != tt.want {
+				t.Errorf("FormatTokenAmount(%v, %d) = %s, want %s", tt.amount, tt.decimals, got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestTokenService(t *testing.T, backend *mockBackend) *TokenService {
+	t.Helper()
+
+	if err := contracts.Initialize(); err != nil {
+		t.Fatalf("failed to initialize ABIs: %v", err)
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, err := NewLocalSigner(key, bscChainID)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	client := &EthClient{Address: signer.Address(), Signer: signer}
+	return &TokenService{Client: client, Backend: backend}
+}
+
+func TestWrapBNBSendsValueBearingDeposit(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestTokenService(t, backend)
+
+	amount := big.NewInt(250000000000000000) // 0.25 BNB
+	if _, err := service.WrapBNB(amount); err != nil {
+		t.Fatalf("WrapBNB returned error: %v", err)
+	}
+
+	if len(backend.sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(backend.sent))
+	}
+	tx := backend.sent[0]
+
+	if *tx.To() != common.HexToAddress(config.WBNB) {
+		t.Errorf("deposit sent to %s, want WBNB", tx.To().Hex())
+	}
+	if tx.Value().Cmp(amount) != 0 {
+		t.Errorf("deposit value = %s, want %s", tx.Value(), amount)
+	}
+
+	method, err := contracts.WBNBABI.MethodById(tx.Data()[:4])
+	if err != nil || method.Name != "deposit" {
+		t.Errorf("deposit transaction calls %v, want deposit", method)
+	}
+}
+
+func TestUnwrapBNBSendsWithdrawWithoutValue(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestTokenService(t, backend)
+
+	amount := big.NewInt(100000000000000000) // 0.1 WBNB
+	if _, err := service.UnwrapBNB(amount); err != nil {
+		t.Fatalf("UnwrapBNB returned error: %v", err)
+	}
+
+	if len(backend.sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(backend.sent))
+	}
+	tx := backend.sent[0]
+
+	if tx.Value().Sign() != 0 {
+		t.Errorf("withdraw value = %s, want 0", tx.Value())
+	}
+
+	method, err := contracts.WBNBABI.MethodById(tx.Data()[:4])
+	if err != nil || method.Name != "withdraw" {
+		t.Fatalf("withdraw transaction calls %v, want withdraw", method)
+	}
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		t.Fatalf("failed to unpack withdraw: %v", err)
+	}
+	if args[0].(*big.Int).Cmp(amount) != 0 {
+		t.Errorf("withdraw amount = %s, want %s", args[0], amount)
+	}
+}
+
+func TestWrapBNBRejectsZeroAmount(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestTokenService(t, backend)
+
+	if _, err := service.WrapBNB(big.NewInt(0)); err == nil {
+		t.Error("expected error for zero amount")
+	}
+	if len(backend.sent) != 0 {
+		t.Errorf("sent %d transactions for a zero amount", len(backend.sent))
+	}
+}
+
+func TestAutoWrapAmount(t *testing.T) {
+	threshold := big.NewInt(100)
+	target := big.NewInt(500)
+	reserve := big.NewInt(10)
+
+	tests := []struct {
+		name string
+		wbnb int64
+		bnb  int64
+		want int64
+	}{
+		{"above threshold", 150, 1000, 0},
+		{"at threshold", 100, 1000, 0},
+		{"tops up to target", 50, 1000, 450},
+		{"limited by gas reserve", 50, 210, 200},
+		{"only gas reserve left", 50, 10, 0},
+		{"below gas reserve", 50, 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := autoWrapAmount(big.NewInt(tt.wbnb), big.NewInt(tt.bnb), threshold, target, reserve)
+			if got.Cmp(big.NewInt(tt.want)) != 0 {
+				t.Errorf("autoWrapAmount(wbnb=%d, bnb=%d) = %s, want %d", tt.wbnb, tt.bnb, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowancesAndRevoke(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestTokenService(t, backend)
+
+	cake := common.HexToAddress(config.CAKE)
+	usdt := common.HexToAddress(config.USDT)
+	pancake := common.HexToAddress(config.PancakeswapRouter)
+	biswap := common.HexToAddress(config.BiswapRouter)
+
+	unlimited := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	backend.allowances[cake] = map[common.Address]*big.Int{pancake: unlimited, biswap: big.NewInt(0)}
+	backend.allowances[usdt] = map[common.Address]*big.Int{biswap: big.NewInt(1000)}
+
+	allowances, err := service.Allowances(
+		map[string]common.Address{"CAKE": cake, "USDT": usdt},
+		map[string]common.Address{"PancakeSwap": pancake, "BiSwap": biswap},
+	)
+	if err != nil {
+		t.Fatalf("Allowances returned error: %v", err)
+	}
+
+	if len(allowances) != 2 {
+		t.Fatalf("got %d allowances, want 2: %+v", len(allowances), allowances)
+	}
+	if allowances[0].Symbol != "CAKE" || allowances[0].Spender != "PancakeSwap" || allowances[0].Amount.Cmp(unlimited) != 0 {
+		t.Errorf("first allowance = %+v, want unlimited CAKE for PancakeSwap", allowances[0])
+	}
+	if allowances[1].Symbol != "USDT" || allowances[1].SpenderAddress != biswap || allowances[1].Amount.Int64() != 1000 {
+		t.Errorf("second allowance = %+v, want 1000 USDT for BiSwap", allowances[1])
+	}
+
+	if _, err := service.RevokeApproval(cake, pancake); err != nil {
+		t.Fatalf("RevokeApproval returned error: %v", err)
+	}
+	if len(backend.sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(backend.sent))
+	}
+
+	tx := backend.sent[0]
+	if *tx.To() != cake {
+		t.Errorf("revoke sent to %s, want the token %s", tx.To().Hex(), cake.Hex())
+	}
+	args, err := contracts.ERC20ABI.Methods["approve"].Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		t.Fatalf("failed to unpack approve: %v", err)
+	}
+	if args[0].(common.Address) != pancake || args[1].(*big.Int).Sign() != 0 {
+		t.Errorf("approve(%v, %v), want approve(%s, 0)", args[0], args[1], pancake.Hex())
+	}
+}
+
+func TestGetTokenDecimalsToleratesNonStandardTokens(t *testing.T) {
+	token := common.HexToAddress(config.DOGE)
+
+	word := func(value *big.Int) []byte {
+		return common.LeftPadBytes(value.Bytes(), 32)
+	}
+
+	tests := []struct {
+		name    string
+		raw     []byte
+		callErr error
+		want    uint8
+		wantErr bool
+	}{
+		{name: "standard uint8", raw: word(big.NewInt(8)), want: 8},
+		{name: "zero-padded word", raw: common.FromHex("0x0000000000000000000000000000000000000000000000000000000000000012"), want: 18},
+		{name: "trailing data ignored", raw: append(word(big.NewInt(9)), make([]byte, 32)...), want: 9},
+		{name: "reverts", raw: nil, want: 18},
+		{name: "no return data", raw: []byte{}, want: 18},
+		{name: "out of range", raw: word(big.NewInt(264)), want: 18},
+		{name: "node unreachable", callErr: errors.New("dial tcp 1.2.3.4:443: connection refused"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.rawDecimals[token] = tt.raw
+			backend.callErr = tt.callErr
+			service := newTestTokenService(t, backend)
+
+			got, err := service.GetTokenDecimals(token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTokenDecimals error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetTokenDecimals = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenCallsRejectAddressesWithoutCode(t *testing.T) {
+	backend := newMockBackend()
+	service := newTestTokenService(t, backend)
+
+	token := common.HexToAddress(config.CAKE)
+	wallet := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	backend.balances[token] = big.NewInt(42)
+
+	balance, err := service.GetTokenBalance(token, service.Client.Address)
+	if err != nil || balance.Int64() != 42 {
+		t.Fatalf("GetTokenBalance = %v, %v; want 42", balance, err)
+	}
+
+	// A mis-pasted address, e.g. a wallet, answers every call with no data
+	backend.codeless[wallet] = true
+
+	if _, err := service.GetTokenBalance(wallet, service.Client.Address); !errors.Is(err, ErrNotERC20) {
+		t.Errorf("GetTokenBalance error = %v, want ErrNotERC20", err)
+	}
+	if _, err := service.GetTokenDecimals(wallet); !errors.Is(err, ErrNotERC20) {
+		t.Errorf("GetTokenDecimals error = %v, want ErrNotERC20", err)
+	}
+}
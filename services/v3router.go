@@ -0,0 +1,199 @@
+// services/v3router.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/config"
+	"arbitrage-bot/contracts"
+)
+
+// V3RouterService quotes PancakeSwap V3 concentrated-liquidity pools through
+// the QuoterV2 contract. V3 pools are keyed by fee tier as well as tokens, so
+// every quote has to say which tier it is for.
+type V3RouterService struct {
+	Backend   ContractCaller
+	Config    *config.Config
+	QuoterABI abi.ABI
+	Quoter    common.Address
+
+	// Block that quotes are pinned to; nil quotes the latest block
+	blockMu    sync.Mutex
+	quoteBlock *big.Int
+
+	// Context of the scan quotes belong to; nil quotes are never cancelled
+	quoteCtx context.Context
+}
+
+// NewV3RouterService creates a new V3RouterService
+func NewV3RouterService(client *EthClient, cfg *config.Config) *V3RouterService {
+	return &V3RouterService{
+		Backend:   client,
+		Config:    cfg,
+		QuoterABI: contracts.QuoterV2ABI,
+		Quoter:    common.HexToAddress(config.PancakeswapV3Quoter),
+	}
+}
+
+// SetQuoteBlock pins quotes to a block, or to the latest block when nil
+func (s *V3RouterService) SetQuoteBlock(block *big.Int) {
+	s.blockMu.Lock()
+	s.quoteBlock = block
+	s.blockMu.Unlock()
+}
+
+// SetQuoteContext ties quotes to a scan's context so they are abandoned when
+// it is cancelled, or detaches them when nil
+func (s *V3RouterService) SetQuoteContext(ctx context.Context) {
+	s.blockMu.Lock()
+	s.quoteCtx = ctx
+	s.blockMu.Unlock()
+}
+
+// quoteExactInputSingleParams mirrors IQuoterV2.QuoteExactInputSingleParams
+type quoteExactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	AmountIn          *big.Int
+	Fee               *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// QuoteExactInputSingle returns the output of swapping amountIn of tokenIn
+// for tokenOut in the pool with the given fee tier
+func (s *V3RouterService) QuoteExactInputSingle(tokenIn, tokenOut common.Address, fee uint32, amountIn *big.Int) (*big.Int, error) {
+	if amountIn == nil || amountIn.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid input amount")
+	}
+
+	callData, err := s.QuoterABI.Pack("quoteExactInputSingle", quoteExactInputSingleParams{
+		TokenIn:           tokenIn,
+		TokenOut:          tokenOut,
+		AmountIn:          amountIn,
+		Fee:               new(big.Int).SetUint64(uint64(fee)),
+		SqrtPriceLimitX96: big.NewInt(0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack quoteExactInputSingle: %v", err)
+	}
+
+	return s.callQuoter("quoteExactInputSingle", callData)
+}
+
+// QuoteExactInput returns the output of a multi-hop V3 swap, where fees[i] is
+// the fee tier of the pool between path[i] and path[i+1]
+func (s *V3RouterService) QuoteExactInput(path []common.Address, fees []uint32, amountIn *big.Int) (*big.Int, error) {
+	if amountIn == nil || amountIn.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid input amount")
+	}
+
+	encodedPath, err := encodeV3Path(path, fees)
+	if err != nil {
+		return nil, err
+	}
+
+	callData, err := s.QuoterABI.Pack("quoteExactInput", encodedPath, amountIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack quoteExactInput: %v", err)
+	}
+
+	return s.callQuoter("quoteExactInput", callData)
+}
+
+// BestQuote tries every configured fee tier for a single hop and returns the
+// largest output along with the tier that produced it. Tiers without a pool
+// simply revert and are skipped.
+func (s *V3RouterService) BestQuote(tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, uint32, error) {
+	var bestOut *big.Int
+	var bestFee uint32
+	var lastErr error
+
+	for _, fee := range s.Config.V3FeeTiers {
+		amountOut, err := s.QuoteExactInputSingle(tokenIn, tokenOut, fee, amountIn)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if bestOut == nil || amountOut.Cmp(bestOut) > 0 {
+			bestOut = amountOut
+			bestFee = fee
+		}
+	}
+
+	if bestOut == nil {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no fee tiers configured")
+		}
+		return nil, 0, fmt.Errorf("no V3 pool quoted %s -> %s: %v", tokenIn.Hex(), tokenOut.Hex(), lastErr)
+	}
+
+	return bestOut, bestFee, nil
+}
+
+// callQuoter calls a quote method and returns the amountOut output
+func (s *V3RouterService) callQuoter(method string, callData []byte) (*big.Int, error) {
+	s.blockMu.Lock()
+	block := s.quoteBlock
+	ctx := s.quoteCtx
+	s.blockMu.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result, err := callContract(ctx, s.Backend, method, ethereum.CallMsg{
+		To:   &s.Quoter,
+		Data: callData,
+	}, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s on quoter %s: %v", method, s.Quoter.Hex(), err)
+	}
+
+	outputs, err := s.QuoterABI.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s result: %v", method, err)
+	}
+
+	amountOut, ok := outputs[0].(*big.Int)
+	if !ok || amountOut.Sign() <= 0 {
+		return nil, fmt.Errorf("zero output amount from %s, possible liquidity issue", method)
+	}
+
+	return amountOut, nil
+}
+
+// FeeTierPercent converts a V3 fee tier to a percentage, e.g. 2500 -> 0.25
+func FeeTierPercent(fee uint32) float64 {
+	return float64(fee) / 10000
+}
+
+// encodeV3Path packs a V3 swap path as token (20 bytes), fee (3 bytes), token, ...
+func encodeV3Path(path []common.Address, fees []uint32) ([]byte, error) {
+	if len(path) < 2 {
+		return nil, fmt.Errorf("path must contain at least 2 tokens")
+	}
+	if len(fees) != len(path)-1 {
+		return nil, fmt.Errorf("path of %d tokens needs %d fee tiers, got %d", len(path), len(path)-1, len(fees))
+	}
+
+	encoded := make([]byte, 0, len(path)*common.AddressLength+len(fees)*3)
+	for i, token := range path {
+		encoded = append(encoded, token.Bytes()...)
+		if i < len(fees) {
+			fee := fees[i]
+			if fee >= 1<<24 {
+				return nil, fmt.Errorf("fee tier %d does not fit in uint24", fee)
+			}
+			encoded = append(encoded, byte(fee>>16), byte(fee>>8), byte(fee))
+		}
+	}
+
+	return encoded, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"arbitrage-bot/config"
+)
+
+func TestEncodeV3Path(t *testing.T) {
+	wbnb := common.HexToAddress(config.WBNB)
+	usdt := common.HexToAddress(config.USDT)
+	cake := common.HexToAddress(config.CAKE)
+
+	encoded, err := encodeV3Path([]common.Address{wbnb, usdt, cake}, []uint32{500, 2500})
+	if err != nil {
+		t.Fatalf("encodeV3Path returned error: %v", err)
+	}
+
+	var want []byte
+	want = append(want, wbnb.Bytes()...)
+	want = append(want, 0x00, 0x01, 0xf4) // 500
+	want = append(want, usdt.Bytes()...)
+	want = append(want, 0x00, 0x09, 0xc4) // 2500
+	want = append(want, cake.Bytes()...)
+
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("encodeV3Path = %x, want %x", encoded, want)
+	}
+
+	if _, err := encodeV3Path([]common.Address{wbnb, usdt}, []uint32{500, 2500}); err == nil {
+		t.Error("expected error for mismatched fee count")
+	}
+}
+
+func TestV3BestQuotePicksBestFeeTier(t *testing.T) {
+	backend := newMockBackend()
+	backend.v3Quotes[500] = rateQuote(99, 100)
+	backend.v3Quotes[2500] = rateQuote(101, 100)
+	// 100 and 10000 have no pool and revert
+
+	service := newTestArbitrageService(t, backend).V3Router
+	amountOut, fee, err := service.BestQuote(common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT), big.NewInt(1000))
+	if err != nil {
+		t.Fatalf("BestQuote returned error: %v", err)
+	}
+	if fee != 2500 {
+		t.Errorf("fee = %d, want 2500", fee)
+	}
+	if amountOut.Cmp(big.NewInt(1010)) != 0 {
+		t.Errorf("amountOut = %s, want 1010", amountOut)
+	}
+
+	backend.v3Quotes = make(map[uint32]quoteFunc)
+	if _, _, err := service.BestQuote(common.HexToAddress(config.WBNB), common.HexToAddress(config.USDT), big.NewInt(1000)); err == nil {
+		t.Error("expected error when no fee tier has a pool")
+	}
+}
@@ -0,0 +1,27 @@
+package utils
+
+import "time"
+
+// Clock tells the time, so time-of-day decisions such as peak hours can be
+// tested at a chosen instant
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the real wall clock
+type SystemClock struct{}
+
+// Now returns the current time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock always returns Time, which tests can move by assigning to it
+type FixedClock struct {
+	Time time.Time
+}
+
+// Now returns the fixed time
+func (c *FixedClock) Now() time.Time {
+	return c.Time
+}
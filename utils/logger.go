@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ParseLogLevel converts a LOG_LEVEL value (debug, info, warn, error) to a slog level
+func ParseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q", level)
+}
+
+// NewLogger builds a leveled logger writing to w. format is "json" for one
+// JSON object per line, or "console" for human-readable lines.
+func NewLogger(w io.Writer, level slog.Level, format string) (*slog.Logger, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})), nil
+	case "console", "":
+		return slog.New(NewConsoleHandler(w, level)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// ConsoleHandler writes records as "2006/01/02 15:04:05 INFO  message key=value ...",
+// which keeps terminal output readable while still carrying fields
+type ConsoleHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	level  slog.Leveler
+	attrs  []slog.Attr
+	prefix string // group prefix for attribute keys, e.g. "rpc."
+}
+
+// NewConsoleHandler creates a ConsoleHandler that drops records below level
+func NewConsoleHandler(w io.Writer, level slog.Leveler) *ConsoleHandler {
+	return &ConsoleHandler{w: w, mu: &sync.Mutex{}, level: level}
+}
+
+// Enabled reports whether records at the given level are written
+func (h *ConsoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle formats and writes a single record
+func (h *ConsoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+
+	if !r.Time.IsZero() {
+		b.WriteString(r.Time.Format("2006/01/02 15:04:05"))
+		b.WriteByte(' ')
+	}
+	fmt.Fprintf(&b, "%-5s %s", r.Level.String(), r.Message)
+
+	for _, attr := range h.attrs {
+		writeConsoleAttr(&b, "", attr)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		writeConsoleAttr(&b, h.prefix, attr)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// WithAttrs returns a handler that adds attrs to every record
+func (h *ConsoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, attr := range attrs {
+		attr.Key = h.prefix + attr.Key
+		clone.attrs = append(clone.attrs, attr)
+	}
+	return &clone
+}
+
+// WithGroup returns a handler that prefixes later attribute keys with name
+func (h *ConsoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// writeConsoleAttr appends " key=value", flattening groups into dotted keys
+func writeConsoleAttr(b *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "."
+		}
+		for _, inner := range attr.Value.Group() {
+			writeConsoleAttr(b, groupPrefix, inner)
+		}
+		return
+	}
+
+	value := attr.Value.String()
+	if attr.Value.Kind() == slog.KindDuration {
+		value = attr.Value.Duration().Round(time.Millisecond).String()
+	}
+	if strings.ContainsAny(value, " =\"") || value == "" {
+		value = fmt.Sprintf("%q", value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, attr.Key, value)
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", slog.LevelInfo, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLogLevel(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLogLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLogLevel(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestConsoleLoggerWritesFieldsAndFiltersLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, slog.LevelInfo, "console")
+	if err != nil {
+		t.Fatalf("NewLogger returned error: %v", err)
+	}
+
+	logger.Debug("hidden")
+	logger.With("rpc", "bsc-dataseed").Warn("Health check failed", "err", errors.New("timeout exceeded"))
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("debug record written at info level: %q", out)
+	}
+	for _, want := range []string{"WARN", "Health check failed", "rpc=bsc-dataseed", `err="timeout exceeded"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("console output %q missing %q", out, want)
+		}
+	}
+}
+
+func TestConsoleLoggerFlattensGroups(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, slog.LevelDebug, "console")
+	if err != nil {
+		t.Fatalf("NewLogger returned error: %v", err)
+	}
+
+	logger.WithGroup("trade").Info("Executed", "pair", "WBNB-CAKE-USDT", slog.Group("gas", "used", 210000))
+
+	out := buf.String()
+	for _, want := range []string{"trade.pair=WBNB-CAKE-USDT", "trade.gas.used=210000"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("console output %q missing %q", out, want)
+		}
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, slog.LevelDebug, "json")
+	if err != nil {
+		t.Fatalf("NewLogger returned error: %v", err)
+	}
+
+	logger.Info("Route quoted", "route", "PancakeSwap→BiSwap→PancakeSwap", "profit_pct", 0.42)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("output is not JSON: %v (%q)", err, buf.String())
+	}
+	if record["msg"] != "Route quoted" || record["level"] != "INFO" {
+		t.Errorf("unexpected record: %v", record)
+	}
+	if record["profit_pct"] != 0.42 {
+		t.Errorf("profit_pct = %v, want 0.42", record["profit_pct"])
+	}
+}
+
+func TestNewLoggerRejectsUnknownFormat(t *testing.T) {
+	if _, err := NewLogger(&bytes.Buffer{}, slog.LevelInfo, "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
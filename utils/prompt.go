@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	stdinOnce  sync.Once
+	stdinLines chan string
+)
+
+// stdin returns the lines typed on standard input. A single reader feeds every
+// prompt so an answer typed after a prompt timed out can't be picked up by a
+// reader left behind from that prompt.
+func stdin() <-chan string {
+	stdinOnce.Do(func() {
+		stdinLines = make(chan string)
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				stdinLines <- scanner.Text()
+			}
+			close(stdinLines)
+		}()
+	})
+	return stdinLines
+}
+
+// WaitForConfirmation prompts the user to confirm an action
+func WaitForConfirmation(prompt string) bool {
+	return confirm(stdin(), prompt, 0)
+}
+
+// WaitForConfirmationTimeout prompts the user to confirm an action, answering
+// no if nothing is entered within timeout so an unattended bot never blocks
+func WaitForConfirmationTimeout(prompt string, timeout time.Duration) bool {
+	return confirm(stdin(), prompt, timeout)
+}
+
+// confirm asks prompt and reads the answer from lines, waiting at most
+// timeout when it is positive. Input typed before the prompt is discarded,
+// and closed input, e.g. no terminal attached, is a no.
+func confirm(lines <-chan string, prompt string, timeout time.Duration) bool {
+	for drained := false; !drained; {
+		select {
+		case _, ok := <-lines:
+			if !ok {
+				return false
+			}
+		default:
+			drained = true
+		}
+	}
+
+	fmt.Print(prompt + " (y/n): ")
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case response, ok := <-lines:
+		response = strings.ToLower(strings.TrimSpace(response))
+		return ok && (response == "y" || response == "yes")
+	case <-expired:
+		fmt.Println()
+		fmt.Printf("⌛ No answer within %v, treating as no\n", timeout)
+		return false
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name  string
+		stale []string // typed before the prompt
+		reply string   // typed after the prompt, if any
+		want  bool
+	}{
+		{name: "yes", reply: "y", want: true},
+		{name: "full word", reply: " YES ", want: true},
+		{name: "no", reply: "n"},
+		{name: "no answer times out"},
+		{name: "earlier input is discarded", stale: []string{"y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make(chan string, len(tt.stale))
+			for _, line := range tt.stale {
+				lines <- line
+			}
+			if tt.reply != "" {
+				go func() {
+					time.Sleep(10 * time.Millisecond) // after the stale input is drained
+					lines <- tt.reply
+				}()
+			}
+
+			if got := confirm(lines, "Execute this trade?", 200*time.Millisecond); got != tt.want {
+				t.Errorf("confirm = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmClosedInput(t *testing.T) {
+	lines := make(chan string)
+	close(lines)
+
+	done := make(chan bool)
+	go func() { done <- confirm(lines, "Execute this trade?", 0) }()
+
+	select {
+	case got := <-done:
+		if got {
+			t.Error("confirm = true on closed input")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("confirm blocked on closed input")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -27,6 +28,58 @@ func FormatBigInt(value *big.Int, decimals uint8) string {
 	return fmt.Sprintf("%."+fmt.Sprintf("%d", decimals)+"f", floatValue)
 }
 
+// ParseTokenAmount parses a human-readable amount such as "1.25" into the
+// token's smallest unit without going through floating point
+func ParseTokenAmount(s string, decimals uint8) (*big.Int, error) {
+	amountStr := strings.TrimSpace(s)
+	if amountStr == "" {
+		return nil, fmt.Errorf("empty token amount")
+	}
+
+	negative := false
+	if strings.HasPrefix(amountStr, "-") || strings.HasPrefix(amountStr, "+") {
+		negative = amountStr[0] == '-'
+		amountStr = amountStr[1:]
+	}
+
+	whole, fraction := amountStr, ""
+	if dot := strings.IndexByte(amountStr, '.'); dot >= 0 {
+		whole, fraction = amountStr[:dot], amountStr[dot+1:]
+	}
+
+	if whole == "" && fraction == "" {
+		return nil, fmt.Errorf("invalid token amount %q", s)
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("invalid token amount %q", s)
+	}
+	if len(fraction) > int(decimals) {
+		return nil, fmt.Errorf("token amount %q has more than %d decimal places", s, decimals)
+	}
+
+	fraction += strings.Repeat("0", int(decimals)-len(fraction))
+
+	amount, ok := new(big.Int).SetString(whole+fraction, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid token amount %q", s)
+	}
+
+	if negative {
+		amount.Neg(amount)
+	}
+	return amount, nil
+}
+
+// isDigits reports whether s consists only of ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // AddressToChecksum converts an address to checksum format
 func AddressToChecksum(address string) string {
 	if !strings.HasPrefix(address, "0x") {
@@ -55,6 +108,13 @@ func CalculatePercentage(a, b *big.Int) float64 {
 	return result
 }
 
+// Jitter spreads d by up to percent in either direction. random is a value in
+// [0, 1) such as rand.Float64(): 0 shortens d the most, 0.5 leaves it as is.
+func Jitter(d time.Duration, percent int, random float64) time.Duration {
+	spread := float64(percent) / 100 * (2*random - 1)
+	return time.Duration(float64(d) * (1 + spread))
+}
+
 // StringSliceContains checks if a string slice contains a string
 func StringSliceContains(slice []string, str string) bool {
 	for _, item := range slice {
@@ -64,11 +124,3 @@ func StringSliceContains(slice []string, str string) bool {
 	}
 	return false
 }
-
-// WaitForConfirmation prompts the user to confirm an action
-func WaitForConfirmation(prompt string) bool {
-	var response string
-	fmt.Print(prompt + " (y/n): ")
-	fmt.Scanln(&response)
-	return strings.ToLower(response) == "y" || strings.ToLower(response) == "yes"
-}
\ No newline at end of file
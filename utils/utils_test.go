@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTokenAmount(t *testing.T) {
+	tests := []struct {
+		input    string
+		decimals uint8
+		want     string
+	}{
+		{"1", 18, "1000000000000000000"},
+		{"0.1", 18, "100000000000000000"},
+		{" 2.5 ", 6, "2500000"},
+		{".5", 9, "500000000"},
+		{"3.", 6, "3000000"},
+		{"0.000001", 6, "1"},
+		{"-1.5", 6, "-1500000"},
+		{"42", 0, "42"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTokenAmount(tt.input, tt.decimals)
+		if err != nil {
+			t.Errorf("ParseTokenAmount(%q, %d) returned error: %v", tt.input, tt.decimals, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseTokenAmount(%q, %d) = %s, want %s", tt.input, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParseTokenAmountRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		decimals uint8
+	}{
+		{"", 18},
+		{"   ", 18},
+		{".", 18},
+		{"abc", 18},
+		{"1.2.3", 18},
+		{"1e18", 18},
+		{"1,5", 6},
+		{"0.0000001", 6},
+		{"1.5", 0},
+	}
+
+	for _, tt := range tests {
+		if got, err := ParseTokenAmount(tt.input, tt.decimals); err == nil {
+			t.Errorf("ParseTokenAmount(%q, %d) = %s, want error", tt.input, tt.decimals, got)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	tests := []struct {
+		percent int
+		random  float64
+		want    time.Duration
+	}{
+		{20, 0, 24 * time.Second},
+		{20, 0.5, 30 * time.Second},
+		{20, 0.75, 33 * time.Second},
+		{0, 0.99, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := Jitter(30*time.Second, tt.percent, tt.random); got != tt.want {
+			t.Errorf("Jitter(30s, %d, %v) = %v, want %v", tt.percent, tt.random, got, tt.want)
+		}
+	}
+}